@@ -6,8 +6,10 @@ package dispatcher
 
 import (
 	"context"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"v2ray.com/core"
@@ -17,11 +19,16 @@ import (
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/signal"
+	"v2ray.com/core/features/dns"
+	"v2ray.com/core/features/health"
 	"v2ray.com/core/features/outbound"
 	"v2ray.com/core/features/policy"
+	"v2ray.com/core/features/record"
 	"v2ray.com/core/features/routing"
 	"v2ray.com/core/features/stats"
 	"v2ray.com/core/transport"
+	"v2ray.com/core/transport/internet"
 	"v2ray.com/core/transport/pipe"
 
 	tstats "github.com/eycorsican/go-tun2socks/common/stats"
@@ -32,6 +39,8 @@ var (
 	errSniffingTimeout = newError("timeout on sniffing")
 )
 
+const http403response = "HTTP/1.1 403 Forbidden\r\nConnection: close\r\nContent-Length: 0\r\n\r\n"
+
 type cachedReader struct {
 	sync.Mutex
 	reader *pipe.Reader
@@ -97,18 +106,21 @@ func (r *cachedReader) Interrupt() {
 
 // DefaultDispatcher is a default implementation of Dispatcher.
 type DefaultDispatcher struct {
-	ohm    outbound.Manager
-	router routing.Router
-	policy policy.Manager
-	stats  stats.Manager
-	stater tstats.SessionStater
+	ohm      outbound.Manager
+	router   routing.Router
+	policy   policy.Manager
+	stats    stats.Manager
+	health   health.Registry
+	fdns     dns.FakeDNSEngine
+	recorder record.Recorder
+	stater   *sessionStater
 }
 
 func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		d := new(DefaultDispatcher)
-		if err := core.RequireFeatures(ctx, func(om outbound.Manager, router routing.Router, pm policy.Manager, sm stats.Manager) error {
-			return d.Init(config.(*Config), om, router, pm, sm)
+		if err := core.RequireFeatures(ctx, func(om outbound.Manager, router routing.Router, pm policy.Manager, sm stats.Manager, hr health.Registry, fdns dns.FakeDNSEngine, r record.Recorder) error {
+			return d.Init(config.(*Config), om, router, pm, sm, hr, fdns, r)
 		}); err != nil {
 			return nil, err
 		}
@@ -117,12 +129,15 @@ func init() {
 }
 
 // Init initializes DefaultDispatcher.
-func (d *DefaultDispatcher) Init(config *Config, om outbound.Manager, router routing.Router, pm policy.Manager, sm stats.Manager) error {
+func (d *DefaultDispatcher) Init(config *Config, om outbound.Manager, router routing.Router, pm policy.Manager, sm stats.Manager, hr health.Registry, fdns dns.FakeDNSEngine, r record.Recorder) error {
 	d.ohm = om
 	d.router = router
 	d.policy = pm
 	d.stats = sm
-	d.stater = tsession.NewSimpleSessionStater()
+	d.health = hr
+	d.fdns = fdns
+	d.recorder = r
+	d.stater = newSessionStater(tsession.NewSimpleSessionStater())
 	return nil
 }
 
@@ -143,6 +158,11 @@ func (d *DefaultDispatcher) Close() error {
 	return nil
 }
 
+// ActiveSessions implements routing.SessionCounter.
+func (d *DefaultDispatcher) ActiveSessions(tag string) int {
+	return d.stater.ActiveSessions(tag)
+}
+
 func (d *DefaultDispatcher) getLink(ctx context.Context, destination net.Destination) (*transport.Link, *transport.Link, *tstats.Session) {
 	var uplinkReader, downlinkReader *pipe.Reader
 	var uplinkWriter, downlinkWriter *pipe.Writer
@@ -247,10 +267,38 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 		ob = new(session.Outbound)
 		ctx = session.ContextWithOutbound(ctx, ob)
 	}
+	restored, err := d.restoreFakeDomain(ctx, &destination)
+	if err != nil {
+		return nil, err
+	}
 	ob.Target = destination
 
+	var rec *session.ProxyRecord
+	if d.recording() && session.ProxyRecordFromContext(ctx) == nil {
+		// Records of DNS queries are inserted by the DNS server.
+		rec = &session.ProxyRecord{
+			Target:    destination.String(),
+			StartTime: time.Now().UnixNano(),
+		}
+		ctx = session.ContextWithProxyRecord(ctx, rec)
+	}
+
 	inbound, outbound, sess := d.getLink(ctx, destination)
+	if rec != nil {
+		inbound.Writer = &RecordSizeWriter{
+			Size:   &rec.UploadBytes,
+			Writer: inbound.Writer,
+		}
+		outbound.Writer = &RecordSizeWriter{
+			Size:   &rec.DownloadBytes,
+			Writer: outbound.Writer,
+		}
+	}
+	hw := d.watchHealth(ctx, inbound, outbound, destination)
 	ctx = session.ContextWithProxySession(ctx, sess)
+	if restored && sess != nil {
+		sess.RemoteAddr = destination.NetAddr()
+	}
 	content := session.ContentFromContext(ctx)
 	if content == nil {
 		content = new(session.Content)
@@ -258,7 +306,7 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 	}
 	sniffingRequest := content.SniffingRequest
 	if destination.Network != net.Network_TCP || !sniffingRequest.Enabled {
-		go d.routedDispatch(ctx, outbound, destination)
+		go d.routedDispatch(ctx, outbound, destination, hw, rec)
 	} else {
 		go func() {
 			cReader := &cachedReader{
@@ -281,12 +329,33 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 					sess.RemoteAddr = destination.NetAddr()
 				}
 			}
-			d.routedDispatch(ctx, outbound, destination)
+			d.routedDispatch(ctx, outbound, destination, hw, rec)
 		}()
 	}
 	return inbound, nil
 }
 
+// restoreFakeDomain replaces an address handed out by the fake DNS with its
+// domain, so that domain rules apply regardless of sniffing. Fake addresses
+// whose mappings are evicted lead nowhere, so the request is rejected then.
+func (d *DefaultDispatcher) restoreFakeDomain(ctx context.Context, destination *net.Destination) (bool, error) {
+	if d.fdns == nil || !destination.Address.Family().IsIP() || !d.fdns.IsIPInIPPool(destination.Address) {
+		return false, nil
+	}
+
+	domain := d.fdns.GetDomainFromFakeDNS(destination.Address)
+	if len(domain) == 0 {
+		return false, newError("fake IP ", destination.Address, " is not mapped to any domain").AtWarning()
+	}
+
+	newError("restored domain ", domain, " from fake IP ", destination.Address).WriteToLog(session.ExportIDToError(ctx))
+	destination.Address = net.DomainAddress(domain)
+	if record := session.ProxyRecordFromContext(ctx); record != nil {
+		record.Target = destination.String()
+	}
+	return true, nil
+}
+
 func sniffer(ctx context.Context, cReader *cachedReader) (SniffResult, error) {
 	payload := buf.New()
 	defer payload.Release()
@@ -320,15 +389,87 @@ func sniffer(ctx context.Context, cReader *cachedReader) (SniffResult, error) {
 	}
 }
 
-func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.Link, destination net.Destination) {
+func (d *DefaultDispatcher) pickRoute(ctx context.Context) (*routing.Route, error) {
+	if r, ok := d.router.(routing.ActionRouter); ok {
+		return r.PickRouteAction(ctx)
+	}
+	tag, err := d.router.PickRoute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &routing.Route{
+		OutboundTag: tag,
+	}, nil
+}
+
+// watchHealth watches the uplink and the downlink of the session, to report
+// the health of its outbound. Only TCP sessions are expected to answer within
+// the handshake timeout.
+func (d *DefaultDispatcher) watchHealth(ctx context.Context, inbound *transport.Link, outbound *transport.Link, destination net.Destination) *HealthWriter {
+	hw := &HealthWriter{
+		Writer: outbound.Writer,
+		Start:  time.Now(),
+	}
+	if destination.Network == net.Network_TCP {
+		var level uint32
+		if sessionInbound := session.InboundFromContext(ctx); sessionInbound != nil && sessionInbound.User != nil {
+			level = sessionInbound.User.Level
+		}
+		hw.Timeout = d.policy.ForLevel(level).Timeouts.Handshake
+	}
+	outbound.Writer = hw
+	inbound.Writer = hw.UplinkWriter(inbound.Writer)
+	return hw
+}
+
+// recording returns whether connections are recorded.
+func (d *DefaultDispatcher) recording() bool {
+	_, noop := d.recorder.(record.NoopRecorder)
+	return d.recorder != nil && !noop
+}
+
+// insertRecord passes the connection record to the recorder, when the connection is finished.
+func (d *DefaultDispatcher) insertRecord(rec *session.ProxyRecord) {
+	d.recorder.Insert(&session.ProxyRecord{
+		Target:        rec.Target,
+		Tag:           rec.Tag,
+		StartTime:     rec.StartTime,
+		EndTime:       time.Now().UnixNano(),
+		UploadBytes:   atomic.LoadInt32(&rec.UploadBytes),
+		DownloadBytes: atomic.LoadInt32(&rec.DownloadBytes),
+	})
+}
+
+// routedDispatch routes the request and hands it to the outbound. rec is the
+// record of the connection, which is inserted at the end, or nil.
+func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.Link, destination net.Destination, hw *HealthWriter, rec *session.ProxyRecord) {
+	if rec != nil {
+		defer d.insertRecord(rec)
+	}
+
 	var handler outbound.Handler
+	var rewrittenFrom net.Destination
 	if d.router != nil {
-		if tag, err := d.router.PickRoute(ctx); err == nil {
-			if h := d.ohm.GetHandler(tag); h != nil {
-				newError("taking detour [", tag, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
-				handler = h
+		if route, err := d.pickRoute(ctx); err == nil {
+			if route.Action != routing.ActionRoute {
+				d.handleAction(ctx, link, destination, route.Action)
+				return
+			}
+			if route.Target.IsValid() {
+				newError("rewriting destination [", destination, "] to [", route.Target, "]").WriteToLog(session.ExportIDToError(ctx))
+				rewrittenFrom = destination
+				destination = route.Target
+				d.rewrite(ctx, destination)
+			}
+			if tag := route.OutboundTag; len(tag) > 0 {
+				if h := d.ohm.GetHandler(tag); h != nil {
+					newError("taking detour [", tag, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
+					handler = h
+				} else {
+					newError("non existing tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+				}
 			} else {
-				newError("non existing tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+				newError("default route for ", destination).WriteToLog(session.ExportIDToError(ctx))
 			}
 		} else {
 			newError("default route for ", destination).WriteToLog(session.ExportIDToError(ctx))
@@ -350,16 +491,103 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 		record.Tag = handler.Tag()
 	}
 	if sess := session.ProxySessionFromContext(ctx); sess != nil {
-		sess.OutboundTag = handler.Tag()
+		d.stater.SetOutboundTag(sess, handler.Tag())
 	}
 
 	accessMessage := log.AccessMessageFromContext(ctx)
 	if accessMessage != nil {
 		accessMessage.OutboundTag = handler.Tag()
+		if rewrittenFrom.IsValid() {
+			accessMessage.To = destination
+			accessMessage.Reason = "rewritten from " + rewrittenFrom.String()
+		}
 		log.Record(accessMessage)
 	}
 
 	handler.Dispatch(ctx, link)
 
+	d.health.Report(handler.Tag(), hw.Observation())
+	d.stater.RemoveSession(link)
+}
+
+// rewrite changes the destination of the request in its context.
+func (d *DefaultDispatcher) rewrite(ctx context.Context, destination net.Destination) {
+	if ob := session.OutboundFromContext(ctx); ob != nil {
+		ob.Target = destination
+		ob.ResolvedIPs = nil
+	}
+	if record := session.ProxyRecordFromContext(ctx); record != nil {
+		record.Target = destination.String()
+	}
+	if sess := session.ProxySessionFromContext(ctx); sess != nil {
+		sess.RemoteAddr = destination.NetAddr()
+	}
+}
+
+// handleAction takes a rule action other than routing on the request.
+func (d *DefaultDispatcher) handleAction(ctx context.Context, link *transport.Link, destination net.Destination, action routing.Action) {
+	newError("taking action [", action, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
+
+	if record := session.ProxyRecordFromContext(ctx); record != nil {
+		record.Tag = action.String()
+	}
+	if sess := session.ProxySessionFromContext(ctx); sess != nil {
+		d.stater.SetOutboundTag(sess, action.String())
+	}
+	if accessMessage := log.AccessMessageFromContext(ctx); accessMessage != nil {
+		accessMessage.OutboundTag = action.String()
+		accessMessage.Status = log.AccessRejected
+		accessMessage.Reason = "rule action: " + action.String()
+		log.Record(accessMessage)
+	}
+
+	switch action {
+	case routing.ActionRejectHTTP:
+		// The response is only understood by HTTP/1 clients. Other connections are closed.
+		if content := session.ContentFromContext(ctx); content != nil && content.Protocol == "http1" {
+			b := buf.New()
+			common.Must2(b.WriteString(http403response))
+			if err := link.Writer.WriteMultiBuffer(buf.MultiBuffer{b}); err != nil {
+				newError("failed to write HTTP response").Base(err).WriteToLog(session.ExportIDToError(ctx))
+			}
+		}
+	case routing.ActionRejectRST:
+		if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.Conn != nil {
+			// The inbound connection may be wrapped by TLS or WebSocket, whose Close would write to the client.
+			if c, ok := internet.UnwrapRawConn(inbound.Conn).(*net.TCPConn); ok {
+				// With zero linger, closing the connection sends RST instead of FIN.
+				c.SetLinger(0) // nolint: errcheck
+				c.Close()
+			} else {
+				newError("unable to reset connection of type ", reflect.TypeOf(inbound.Conn), ", closing it instead").AtInfo().WriteToLog(session.ExportIDToError(ctx))
+			}
+			inbound.Conn.Close()
+		}
+	case routing.ActionDrop:
+		d.discard(ctx, link)
+	}
+
+	common.Close(link.Writer)
+	common.Interrupt(link.Reader)
 	d.stater.RemoveSession(link)
 }
+
+// discard reads and drops the request without responding, until the client closes the connection
+// or the connection is idle for the timeout of the user's policy.
+func (d *DefaultDispatcher) discard(ctx context.Context, link *transport.Link) {
+	var level uint32
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil {
+		level = inbound.User.Level
+	}
+	plcy := d.policy.ForLevel(level)
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
+	go func() {
+		<-ctx.Done()
+		common.Interrupt(link.Reader)
+	}()
+
+	buf.Copy(link.Reader, buf.Discard, buf.UpdateActivity(timer)) // nolint: errcheck
+	cancel()
+}
@@ -0,0 +1,248 @@
+package dispatcher_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	. "v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/dns/fakedns"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/features/health"
+	"v2ray.com/core/features/outbound"
+	"v2ray.com/core/features/policy"
+	"v2ray.com/core/features/record"
+	"v2ray.com/core/features/routing"
+	"v2ray.com/core/features/stats"
+	"v2ray.com/core/transport"
+)
+
+func TestDispatchEvictedFakeIP(t *testing.T) {
+	fdns, err := fakedns.NewHolder(context.Background(), &fakedns.Config{
+		Pools: []*fakedns.FakeDnsPool{
+			{
+				IpPool: "198.18.0.0/15",
+			},
+		},
+	})
+	common.Must(err)
+
+	d := new(DefaultDispatcher)
+	common.Must(d.Init(&Config{}, nil, nil, policy.DefaultManager{}, stats.NoopManager{}, health.NoopRegistry{}, fdns, record.NoopRecorder{}))
+
+	// No domain has been handed this address.
+	dest := net.TCPDestination(net.ParseAddress("198.18.0.100"), 443)
+	if _, err := d.Dispatch(context.Background(), dest); err == nil {
+		t.Error("expect dispatching to an unmapped fake IP to fail")
+	}
+}
+
+type pongHandler struct{}
+
+func (pongHandler) Tag() string { return "pong" }
+
+func (pongHandler) Start() error { return nil }
+
+func (pongHandler) Close() error { return nil }
+
+// Dispatch answers the first request with "pong".
+func (pongHandler) Dispatch(ctx context.Context, link *transport.Link) {
+	mb, err := link.Reader.ReadMultiBuffer()
+	buf.ReleaseMulti(mb)
+	if err == nil {
+		b := buf.New()
+		common.Must2(b.WriteString("pong"))
+		link.Writer.WriteMultiBuffer(buf.MultiBuffer{b}) // nolint: errcheck
+	}
+	common.Close(link.Writer)
+}
+
+type pongManager struct {
+	outbound.Manager
+}
+
+func (pongManager) GetDefaultHandler() outbound.Handler {
+	return pongHandler{}
+}
+
+type chanRecorder struct {
+	record.NoopRecorder
+	records chan *session.ProxyRecord
+}
+
+func (r *chanRecorder) Insert(rec *session.ProxyRecord) {
+	r.records <- rec
+}
+
+func TestDispatchRecord(t *testing.T) {
+	recorder := &chanRecorder{records: make(chan *session.ProxyRecord, 1)}
+	d := new(DefaultDispatcher)
+	common.Must(d.Init(&Config{}, pongManager{}, nil, policy.DefaultManager{}, stats.NoopManager{}, health.NoopRegistry{}, nil, recorder))
+
+	link, err := d.Dispatch(context.Background(), net.TCPDestination(net.DomainAddress("v2ray.com"), 443))
+	common.Must(err)
+	b := buf.New()
+	common.Must2(b.WriteString("ping"))
+	common.Must(link.Writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+	mb, err := link.Reader.ReadMultiBuffer()
+	common.Must(err)
+	buf.ReleaseMulti(mb)
+
+	select {
+	case rec := <-recorder.records:
+		if r := cmp.Diff(rec, &session.ProxyRecord{
+			Target:        "tcp:v2ray.com:443",
+			Tag:           "pong",
+			StartTime:     rec.StartTime,
+			EndTime:       rec.EndTime,
+			UploadBytes:   4,
+			DownloadBytes: 4,
+		}); r != "" {
+			t.Error(r)
+		}
+		if rec.EndTime < rec.StartTime {
+			t.Error("expect the record to end after it starts")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expect the connection to be recorded")
+	}
+}
+
+type holdHandler struct {
+	pongHandler
+	started chan struct{}
+}
+
+// Dispatch holds the session until the client closes its side.
+func (h holdHandler) Dispatch(ctx context.Context, link *transport.Link) {
+	close(h.started)
+	for {
+		mb, err := link.Reader.ReadMultiBuffer()
+		buf.ReleaseMulti(mb)
+		if err != nil {
+			break
+		}
+	}
+	common.Close(link.Writer)
+}
+
+type holdManager struct {
+	outbound.Manager
+	handler holdHandler
+}
+
+func (m holdManager) GetDefaultHandler() outbound.Handler {
+	return m.handler
+}
+
+func TestDispatchActiveSessions(t *testing.T) {
+	handler := holdHandler{started: make(chan struct{})}
+	d := new(DefaultDispatcher)
+	common.Must(d.Init(&Config{}, holdManager{handler: handler}, nil, policy.DefaultManager{}, stats.NoopManager{}, health.NoopRegistry{}, nil, record.NoopRecorder{}))
+	common.Must(d.Start())
+	defer d.Close()
+
+	ctx := session.ContextWithContent(context.Background(), &session.Content{})
+	link, err := d.Dispatch(ctx, net.TCPDestination(net.DomainAddress("v2ray.com"), 443))
+	common.Must(err)
+
+	select {
+	case <-handler.started:
+	case <-time.After(time.Second * 5):
+		t.Fatal("expect the request to be dispatched")
+	}
+	if n := d.ActiveSessions("pong"); n != 1 {
+		t.Error("expect 1 active session, but got ", n)
+	}
+
+	common.Close(link.Writer)
+	for i := 0; i < 50 && d.ActiveSessions("pong") != 0; i++ {
+		time.Sleep(time.Millisecond * 100)
+	}
+	if n := d.ActiveSessions("pong"); n != 0 {
+		t.Error("expect no active session, but got ", n)
+	}
+}
+
+type actionRouter struct {
+	routing.DefaultRouter
+	action routing.Action
+}
+
+func (r actionRouter) PickRouteAction(ctx context.Context) (*routing.Route, error) {
+	return &routing.Route{Action: r.action}, nil
+}
+
+func TestDispatchDrop(t *testing.T) {
+	d := new(DefaultDispatcher)
+	common.Must(d.Init(&Config{}, nil, actionRouter{action: routing.ActionDrop}, policy.DefaultManager{}, stats.NoopManager{}, health.NoopRegistry{}, nil, record.NoopRecorder{}))
+
+	link, err := d.Dispatch(context.Background(), net.TCPDestination(net.DomainAddress("v2ray.com"), 443))
+	common.Must(err)
+	b := buf.New()
+	common.Must2(b.WriteString("ping"))
+	common.Must(link.Writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+
+	// Nothing is sent back, and the connection stays open while the client is active.
+	done := make(chan error, 1)
+	go func() {
+		mb, err := link.Reader.ReadMultiBuffer()
+		buf.ReleaseMulti(mb)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatal("expect the dropped connection to stay open, but got ", err)
+	case <-time.After(time.Second):
+	}
+
+	common.Close(link.Writer)
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expect no response from a dropped connection")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expect the dropped connection to be closed after the client closes")
+	}
+}
+
+func TestDispatchRejectHTTP(t *testing.T) {
+	d := new(DefaultDispatcher)
+	common.Must(d.Init(&Config{}, nil, actionRouter{action: routing.ActionRejectHTTP}, policy.DefaultManager{}, stats.NoopManager{}, health.NoopRegistry{}, nil, record.NoopRecorder{}))
+
+	dispatch := func(payload string) string {
+		ctx := session.ContextWithContent(context.Background(), &session.Content{
+			SniffingRequest: session.SniffingRequest{Enabled: true},
+		})
+		link, err := d.Dispatch(ctx, net.TCPDestination(net.DomainAddress("v2ray.com"), 80))
+		common.Must(err)
+		b := buf.New()
+		common.Must2(b.WriteString(payload))
+		common.Must(link.Writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+
+		var response buf.MultiBuffer
+		for {
+			mb, err := link.Reader.ReadMultiBuffer()
+			response = append(response, mb...)
+			if err != nil {
+				break
+			}
+		}
+		defer buf.ReleaseMulti(response)
+		return response.String()
+	}
+
+	if r := dispatch("GET / HTTP/1.1\r\nHost: v2ray.com\r\n\r\n"); !strings.HasPrefix(r, "HTTP/1.1 403 ") {
+		t.Error("expect HTTP 403, but got ", r)
+	}
+	if r := dispatch("\x16\x03\x01\x00\x05hello"); r != "" {
+		t.Error("expect no response to a request other than HTTP, but got ", r)
+	}
+}
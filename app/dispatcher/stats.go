@@ -4,10 +4,14 @@ package dispatcher
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/features/health"
 	"v2ray.com/core/features/stats"
 
 	tdns "github.com/eycorsican/go-tun2socks/common/dns"
@@ -88,3 +92,176 @@ func (w *SizeStatWriter) Close() error {
 func (w *SizeStatWriter) Interrupt() {
 	common.Interrupt(w.Writer)
 }
+
+// RecordSizeWriter adds the size of data written to the link to a byte count of a record.
+type RecordSizeWriter struct {
+	Size   *int32
+	Writer buf.LinkWriter
+}
+
+func (w *RecordSizeWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	atomic.AddInt32(w.Size, mb.Len())
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func (w *RecordSizeWriter) WritePacket(b *buf.Buffer, addr *net.UDPAddr) error {
+	atomic.AddInt32(w.Size, b.Len())
+	return w.Writer.WritePacket(b, addr)
+}
+
+func (w *RecordSizeWriter) Close() error {
+	return common.Close(w.Writer)
+}
+
+func (w *RecordSizeWriter) Interrupt() {
+	common.Interrupt(w.Writer)
+}
+
+// sessionStater keeps track of the sessions added to a SessionStater, so that the sessions
+// going through each outbound can be counted.
+type sessionStater struct {
+	tstats.SessionStater
+
+	access   sync.Mutex
+	sessions map[interface{}]*tstats.Session
+}
+
+func newSessionStater(stater tstats.SessionStater) *sessionStater {
+	return &sessionStater{
+		SessionStater: stater,
+		sessions:      make(map[interface{}]*tstats.Session),
+	}
+}
+
+// AddSession implements tstats.SessionStater.
+func (s *sessionStater) AddSession(key interface{}, sess *tstats.Session) {
+	s.access.Lock()
+	s.sessions[key] = sess
+	s.access.Unlock()
+	s.SessionStater.AddSession(key, sess)
+}
+
+// RemoveSession implements tstats.SessionStater.
+func (s *sessionStater) RemoveSession(key interface{}) {
+	s.access.Lock()
+	delete(s.sessions, key)
+	s.access.Unlock()
+	s.SessionStater.RemoveSession(key)
+}
+
+// SetOutboundTag sets the outbound of the session, which may be counted concurrently.
+func (s *sessionStater) SetOutboundTag(sess *tstats.Session, tag string) {
+	s.access.Lock()
+	sess.OutboundTag = tag
+	s.access.Unlock()
+}
+
+// ActiveSessions returns the number of sessions going through the outbound with the given tag.
+func (s *sessionStater) ActiveSessions(tag string) int {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	count := 0
+	for _, sess := range s.sessions {
+		if sess.OutboundTag == tag {
+			count++
+		}
+	}
+	return count
+}
+
+const (
+	endedByOutbound int32 = iota + 1
+	endedByInbound
+)
+
+// HealthWriter watches the downlink of an outbound to find out how a session went.
+type HealthWriter struct {
+	Writer buf.LinkWriter
+	Start  time.Time
+	// Timeout is how long the outbound may take to send back its first byte after the first uplink byte. Zero disables the check.
+	Timeout time.Duration
+
+	firstByte   int64
+	bytes       int64
+	firstUplink int64
+	uplink      int64
+	ended       int32
+}
+
+func (w *HealthWriter) record(n int64) {
+	if n > 0 && atomic.AddInt64(&w.bytes, n) == n {
+		atomic.StoreInt64(&w.firstByte, int64(time.Since(w.Start)))
+	}
+}
+
+func (w *HealthWriter) recordUplink(n int64) {
+	if n > 0 && atomic.AddInt64(&w.uplink, n) == n {
+		atomic.StoreInt64(&w.firstUplink, int64(time.Since(w.Start)))
+	}
+}
+
+func (w *HealthWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.record(int64(mb.Len()))
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func (w *HealthWriter) WritePacket(b *buf.Buffer, addr *net.UDPAddr) error {
+	w.record(int64(b.Len()))
+	return w.Writer.WritePacket(b, addr)
+}
+
+func (w *HealthWriter) Close() error {
+	return common.Close(w.Writer)
+}
+
+func (w *HealthWriter) Interrupt() {
+	atomic.CompareAndSwapInt32(&w.ended, 0, endedByOutbound)
+	common.Interrupt(w.Writer)
+}
+
+// UplinkWriter returns a writer of the uplink of the session, so that the session knows when the inbound sent data or gave up.
+func (w *HealthWriter) UplinkWriter(writer buf.LinkWriter) buf.LinkWriter {
+	return &healthUplinkWriter{
+		health: w,
+		Writer: writer,
+	}
+}
+
+// Observation returns what happened to the session so far. Outbound handlers interrupt the downlink when they fail, so a session interrupted by its outbound without any downlink data is considered a dial failure. Sessions given up by the inbound first tell nothing.
+func (w *HealthWriter) Observation() health.Observation {
+	bytes := atomic.LoadInt64(&w.bytes)
+	o := health.Observation{
+		DialFailed:    bytes == 0 && atomic.LoadInt32(&w.ended) == endedByOutbound,
+		FirstByte:     time.Duration(atomic.LoadInt64(&w.firstByte)),
+		DownlinkBytes: bytes,
+	}
+	if bytes == 0 && w.Timeout > 0 && atomic.LoadInt64(&w.uplink) > 0 {
+		o.FirstByteTimeout = time.Since(w.Start)-time.Duration(atomic.LoadInt64(&w.firstUplink)) >= w.Timeout
+	}
+	return o
+}
+
+type healthUplinkWriter struct {
+	health *HealthWriter
+	Writer buf.LinkWriter
+}
+
+func (w *healthUplinkWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.health.recordUplink(int64(mb.Len()))
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func (w *healthUplinkWriter) WritePacket(b *buf.Buffer, addr *net.UDPAddr) error {
+	w.health.recordUplink(int64(b.Len()))
+	return w.Writer.WritePacket(b, addr)
+}
+
+func (w *healthUplinkWriter) Close() error {
+	return common.Close(w.Writer)
+}
+
+func (w *healthUplinkWriter) Interrupt() {
+	atomic.CompareAndSwapInt32(&w.health.ended, 0, endedByInbound)
+	common.Interrupt(w.Writer)
+}
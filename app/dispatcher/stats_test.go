@@ -2,12 +2,15 @@ package dispatcher_test
 
 import (
 	"testing"
+	"time"
 
 	. "v2ray.com/core/app/dispatcher"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
 )
 
+var discard = buf.Discard.(buf.LinkWriter)
+
 type TestCounter int64
 
 func (c *TestCounter) Value() int64 {
@@ -29,7 +32,7 @@ func TestStatsWriter(t *testing.T) {
 	var c TestCounter
 	writer := &SizeStatWriter{
 		Counter: &c,
-		Writer:  buf.Discard,
+		Writer:  discard,
 	}
 
 	mb := buf.MergeBytes(nil, []byte("abcd"))
@@ -42,3 +45,41 @@ func TestStatsWriter(t *testing.T) {
 		t.Fatal("unexpected counter value. want 7, but got ", c.Value())
 	}
 }
+
+func TestHealthWriter(t *testing.T) {
+	// The outbound gives up first without any downlink.
+	hw := &HealthWriter{Writer: discard, Start: time.Now()}
+	hw.Interrupt()
+	hw.UplinkWriter(discard).(common.Interruptible).Interrupt()
+	if o := hw.Observation(); !o.DialFailed || !o.Failed() {
+		t.Error("expect dial failure, but got ", o)
+	}
+
+	// The inbound gives up first.
+	hw = &HealthWriter{Writer: discard, Start: time.Now()}
+	hw.UplinkWriter(discard).(common.Interruptible).Interrupt()
+	hw.Interrupt()
+	if o := hw.Observation(); o.Failed() || o.Succeeded() {
+		t.Error("expect no signal from cancelled session, but got ", o)
+	}
+
+	// Nothing comes back for the uplink within the timeout.
+	hw = &HealthWriter{Writer: discard, Start: time.Now(), Timeout: time.Millisecond * 10}
+	common.Must(hw.UplinkWriter(discard).WriteMultiBuffer(buf.MergeBytes(nil, []byte("request"))))
+	if o := hw.Observation(); o.Failed() {
+		t.Error("expect no failure before timeout, but got ", o)
+	}
+	time.Sleep(time.Millisecond * 20)
+	if o := hw.Observation(); !o.FirstByteTimeout {
+		t.Error("expect first byte timeout, but got ", o)
+	}
+
+	// Downlink data makes a success.
+	hw = &HealthWriter{Writer: discard, Start: time.Now(), Timeout: time.Millisecond * 10}
+	common.Must(hw.UplinkWriter(discard).WriteMultiBuffer(buf.MergeBytes(nil, []byte("request"))))
+	common.Must(hw.WriteMultiBuffer(buf.MergeBytes(nil, []byte("response"))))
+	time.Sleep(time.Millisecond * 20)
+	if o := hw.Observation(); !o.Succeeded() || o.DownlinkBytes != 8 {
+		t.Error("expect success, but got ", o)
+	}
+}
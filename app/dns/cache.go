@@ -0,0 +1,362 @@
+// +build !confonly
+
+package dns
+
+import (
+	"container/list"
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/task"
+	"v2ray.com/core/features/stats"
+)
+
+const (
+	// defaultCacheTTL is the TTL of the answers from clients that don't tell when their answers expire.
+	defaultCacheTTL     = time.Minute
+	defaultStaleTTL     = 86400
+	defaultPrefetchHits = 3
+	defaultMaxEntries   = 4096
+)
+
+type cacheState int
+
+const (
+	cacheMiss cacheState = iota
+	cacheFresh
+	cacheStale
+)
+
+type cacheEntry struct {
+	key        string
+	domain     string
+	option     IPOption
+	ips        []net.IP
+	server     string
+	expire     time.Time
+	ttl        time.Duration
+	hits       uint32
+	refreshing bool
+}
+
+// answerCache keeps the answers of all name servers of a Server. Expired
+// answers may be served while they are refreshed, and answers that are queried
+// often are refreshed before they expire. The least recently used answer is
+// evicted when the cache is full.
+type answerCache struct {
+	sync.Mutex
+	entries      map[string]*list.Element
+	order        *list.List // of *cacheEntry, the most recently used first
+	serveStale   bool
+	staleTTL     time.Duration
+	prefetch     bool
+	prefetchHits uint32
+	maxEntries   int
+	snapshotPath string
+	cleanup      *task.Periodic
+
+	hitCounter      stats.Counter
+	missCounter     stats.Counter
+	staleCounter    stats.Counter
+	prefetchCounter stats.Counter
+}
+
+func newAnswerCache(config *CacheConfig) *answerCache {
+	c := &answerCache{
+		entries:      make(map[string]*list.Element),
+		order:        list.New(),
+		staleTTL:     time.Second * defaultStaleTTL,
+		prefetchHits: defaultPrefetchHits,
+		maxEntries:   defaultMaxEntries,
+	}
+	if config != nil {
+		c.serveStale = config.ServeStale
+		c.prefetch = config.Prefetch
+		c.snapshotPath = config.SnapshotPath
+		if config.StaleTtl > 0 {
+			c.staleTTL = time.Second * time.Duration(config.StaleTtl)
+		}
+		if config.PrefetchHits > 0 {
+			c.prefetchHits = config.PrefetchHits
+		}
+		if config.MaxEntries > 0 {
+			c.maxEntries = int(config.MaxEntries)
+		}
+	}
+	c.cleanup = &task.Periodic{
+		Interval: time.Minute,
+		Execute:  c.Cleanup,
+	}
+	return c
+}
+
+// registerCounters registers the hit and miss counters of the cache to the stats manager.
+func (c *answerCache) registerCounters(m stats.Manager) {
+	register := func(name string) stats.Counter {
+		counter, _ := stats.GetOrRegisterCounter(m, "dns>>>cache>>>"+name)
+		return counter
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.hitCounter = register("hit")
+	c.missCounter = register("miss")
+	c.staleCounter = register("stale")
+	c.prefetchCounter = register("prefetch")
+}
+
+func incCounter(counter stats.Counter) {
+	if counter != nil {
+		counter.Add(1)
+	}
+}
+
+func cacheKey(domain string, option IPOption) string {
+	switch {
+	case option.IPv4Enable && option.IPv6Enable:
+		return domain + "/46"
+	case option.IPv6Enable:
+		return domain + "/6"
+	default:
+		return domain + "/4"
+	}
+}
+
+func (c *answerCache) staleLimit(e *cacheEntry) time.Time {
+	if !c.serveStale {
+		return e.expire
+	}
+	return e.expire.Add(c.staleTTL)
+}
+
+// get returns the cached answer of the domain, and the name server that
+// answered it. refresh is true if the caller should refresh the answer in
+// background, and call put or done afterwards.
+func (c *answerCache) get(domain string, option IPOption, now time.Time) (ips []net.IP, server string, state cacheState, refresh bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, found := c.entries[cacheKey(domain, option)]
+	if !found {
+		incCounter(c.missCounter)
+		return nil, "", cacheMiss, false
+	}
+	e := elem.Value.(*cacheEntry)
+	switch {
+	case !now.Before(c.staleLimit(e)):
+		incCounter(c.missCounter)
+		return nil, "", cacheMiss, false
+	case now.Before(e.expire):
+		e.hits++
+		incCounter(c.hitCounter)
+		if c.prefetch && !e.refreshing && e.hits >= c.prefetchHits && e.expire.Sub(now) <= e.ttl/10 {
+			e.refreshing = true
+			refresh = true
+			incCounter(c.prefetchCounter)
+		}
+		state = cacheFresh
+	default:
+		e.hits++
+		incCounter(c.hitCounter)
+		incCounter(c.staleCounter)
+		if !e.refreshing {
+			e.refreshing = true
+			refresh = true
+		}
+		state = cacheStale
+	}
+	c.order.MoveToFront(elem)
+
+	return append([]net.IP(nil), e.ips...), e.server, state, refresh
+}
+
+// put stores the answer of the domain. A zero expire means the answer must not
+// be cached.
+func (c *answerCache) put(domain string, option IPOption, a answer, now time.Time) {
+	if len(a.ips) == 0 || !a.expire.After(now) {
+		c.done(domain, option)
+		return
+	}
+
+	key := cacheKey(domain, option)
+	c.Lock()
+	var e *cacheEntry
+	if elem, found := c.entries[key]; found {
+		e = elem.Value.(*cacheEntry)
+		c.order.MoveToFront(elem)
+	} else {
+		for len(c.entries) >= c.maxEntries {
+			c.removeLocked(c.order.Back())
+		}
+		e = &cacheEntry{
+			key:    key,
+			domain: domain,
+			option: option,
+		}
+		c.entries[key] = c.order.PushFront(e)
+	}
+	e.ips = a.ips
+	e.server = a.server
+	e.expire = a.expire
+	e.ttl = a.expire.Sub(now)
+	e.refreshing = false
+	c.Unlock()
+
+	common.Must(c.cleanup.Start())
+}
+
+// done marks the end of a refresh that didn't get a new answer.
+func (c *answerCache) done(domain string, option IPOption) {
+	c.Lock()
+	defer c.Unlock()
+
+	if elem, found := c.entries[cacheKey(domain, option)]; found {
+		elem.Value.(*cacheEntry).refreshing = false
+	}
+}
+
+func (c *answerCache) removeLocked(elem *list.Element) {
+	e := c.order.Remove(elem).(*cacheEntry)
+	delete(c.entries, e.key)
+}
+
+// Cleanup removes the entries that can't be served any more.
+func (c *answerCache) Cleanup() error {
+	now := time.Now()
+	c.Lock()
+	defer c.Unlock()
+
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if !now.Before(c.staleLimit(elem.Value.(*cacheEntry))) {
+			c.removeLocked(elem)
+		}
+		elem = next
+	}
+
+	if len(c.entries) == 0 {
+		c.entries = make(map[string]*list.Element)
+		return newError("nothing to do. stopping...")
+	}
+
+	return nil
+}
+
+// load restores the entries from the snapshot file, if there is one.
+func (c *answerCache) load() error {
+	if len(c.snapshotPath) == 0 {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(c.snapshotPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return newError("failed to read DNS cache snapshot: ", c.snapshotPath).Base(err)
+	}
+	snapshot := new(CacheSnapshot)
+	if err := proto.Unmarshal(b, snapshot); err != nil {
+		return newError("invalid DNS cache snapshot: ", c.snapshotPath).Base(err)
+	}
+
+	now := time.Now()
+	c.Lock()
+	for _, se := range snapshot.Entry {
+		e := &cacheEntry{
+			domain: se.Domain,
+			option: IPOption{
+				IPv4Enable: se.Ipv4,
+				IPv6Enable: se.Ipv6,
+			},
+			server: se.Server,
+			expire: time.Unix(se.Expire, 0),
+			ttl:    time.Second * time.Duration(se.Ttl),
+		}
+		for _, ip := range se.Ip {
+			if len(ip) == net.IPv4len || len(ip) == net.IPv6len {
+				e.ips = append(e.ips, net.IP(ip))
+			}
+		}
+		e.key = cacheKey(e.domain, e.option)
+		if _, found := c.entries[e.key]; found || len(e.ips) == 0 || !now.Before(c.staleLimit(e)) || len(c.entries) >= c.maxEntries {
+			continue
+		}
+		// Snapshots are saved in the order of use, the most recently used first.
+		c.entries[e.key] = c.order.PushBack(e)
+	}
+	n := len(c.entries)
+	c.Unlock()
+
+	newError("loaded ", n, " DNS cache entries from ", c.snapshotPath).AtInfo().WriteToLog()
+	if n > 0 {
+		common.Must(c.cleanup.Start())
+	}
+	return nil
+}
+
+// save writes the entries to the snapshot file, if it is configured.
+func (c *answerCache) save() error {
+	if len(c.snapshotPath) == 0 {
+		return nil
+	}
+
+	snapshot := new(CacheSnapshot)
+	c.Lock()
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*cacheEntry)
+		se := &CacheSnapshot_Entry{
+			Domain: e.domain,
+			Ipv4:   e.option.IPv4Enable,
+			Ipv6:   e.option.IPv6Enable,
+			Expire: e.expire.Unix(),
+			Ttl:    uint32(e.ttl / time.Second),
+			Server: e.server,
+		}
+		for _, ip := range e.ips {
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			se.Ip = append(se.Ip, []byte(ip))
+		}
+		snapshot.Entry = append(snapshot.Entry, se)
+	}
+	c.Unlock()
+
+	b, err := proto.Marshal(snapshot)
+	if err != nil {
+		return newError("failed to encode DNS cache snapshot").Base(err)
+	}
+	// Write to a temporary file first, so that a broken write doesn't destroy the last snapshot.
+	tmp := c.snapshotPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return newError("failed to write DNS cache snapshot: ", tmp).Base(err)
+	}
+	if err := os.Rename(tmp, c.snapshotPath); err != nil {
+		return newError("failed to write DNS cache snapshot: ", c.snapshotPath).Base(err)
+	}
+	return nil
+}
+
+func (c *answerCache) close() error {
+	common.Close(c.cleanup) // nolint: errcheck
+	return c.save()
+}
+
+type refreshKey struct{}
+
+// contextWithRefresh marks the query as a refresh of the cached answer, so
+// that clients don't answer it from their own caches.
+func contextWithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+func isRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+	return refresh
+}
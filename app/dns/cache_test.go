@@ -0,0 +1,207 @@
+package dns_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/dispatcher"
+	. "v2ray.com/core/app/dns"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/app/stats"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	feature_dns "v2ray.com/core/features/dns"
+	feature_stats "v2ray.com/core/features/stats"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+)
+
+// countingHandler answers A queries with 10.0.0.n, where n is the number of
+// A queries so far, and a TTL of 2 seconds.
+type countingHandler struct {
+	queries int32
+}
+
+func (h *countingHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	ans := new(dns.Msg)
+	ans.SetReply(r)
+	for _, q := range r.Question {
+		if q.Qtype == dns.TypeA {
+			n := atomic.AddInt32(&h.queries, 1)
+			rr, err := dns.NewRR(q.Name + " 2 IN A 10.0.0." + net.Port(n).String())
+			common.Must(err)
+			ans.Answer = append(ans.Answer, rr)
+		}
+	}
+	w.WriteMsg(ans)
+}
+
+func TestServeStaleCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "v2ray-dns-cache")
+	common.Must(err)
+	defer os.RemoveAll(dir)
+
+	handler := &countingHandler{}
+	port := tcp.PickPort()
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "tcp",
+		Handler: handler,
+	}
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.DomainAddress("tcp://127.0.0.1:" + port.String())),
+						},
+					},
+				},
+				Cache: &CacheConfig{
+					ServeStale:   true,
+					SnapshotPath: filepath.Join(dir, "dns.cache"),
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+			serial.ToTypedMessage(&stats.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.IPv4Lookup)
+	lookup := func(expected net.IP) {
+		t.Helper()
+		ips, err := client.LookupIPv4("v2ray.com")
+		if err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+		if r := cmp.Diff(ips, []net.IP{expected}); r != "" {
+			t.Fatal(r)
+		}
+	}
+
+	lookup(net.IP{10, 0, 0, 1})
+	lookup(net.IP{10, 0, 0, 1})
+	if n := atomic.LoadInt32(&handler.queries); n != 1 {
+		t.Error("expect the second lookup to be cached, but got ", n, " queries")
+	}
+
+	// The expired answer is served, while it is refreshed in background.
+	time.Sleep(2500 * time.Millisecond)
+	lookup(net.IP{10, 0, 0, 1})
+	time.Sleep(500 * time.Millisecond)
+	if n := atomic.LoadInt32(&handler.queries); n != 2 {
+		t.Error("expect the stale answer to be refreshed, but got ", n, " queries")
+	}
+	lookup(net.IP{10, 0, 0, 2})
+
+	sm := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	if c := sm.GetCounter("dns>>>cache>>>hit"); c == nil || c.Value() != 3 {
+		t.Error("expect 3 cache hits, but got ", c)
+	}
+	if c := sm.GetCounter("dns>>>cache>>>miss"); c == nil || c.Value() != 1 {
+		t.Error("expect 1 cache miss, but got ", c)
+	}
+	if c := sm.GetCounter("dns>>>cache>>>stale"); c == nil || c.Value() != 1 {
+		t.Error("expect 1 stale answer, but got ", c)
+	}
+
+	// The cache is saved on shutdown, and loaded by the next instance.
+	common.Must(v.Close())
+	common.Must(dnsServer.Shutdown())
+
+	v, err = core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	client = v.GetFeature(feature_dns.ClientType()).(feature_dns.IPv4Lookup)
+	lookup(net.IP{10, 0, 0, 2})
+	if n := atomic.LoadInt32(&handler.queries); n != 2 {
+		t.Error("expect the answer from the snapshot, but got ", n, " queries")
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	handler := &countingHandler{}
+	port := tcp.PickPort()
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "tcp",
+		Handler: handler,
+	}
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.DomainAddress("tcp://127.0.0.1:" + port.String())),
+						},
+					},
+				},
+				Cache: &CacheConfig{
+					MaxEntries: 2,
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+			serial.ToTypedMessage(&stats.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.IPv4Lookup)
+	for _, domain := range []string{"a.v2ray.com", "b.v2ray.com", "a.v2ray.com", "c.v2ray.com", "a.v2ray.com", "b.v2ray.com"} {
+		if _, err := client.LookupIPv4(domain); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+	}
+	// b.v2ray.com is the least recently used when c.v2ray.com is added, so it misses the cache again.
+	sm := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	if c := sm.GetCounter("dns>>>cache>>>miss"); c == nil || c.Value() != 4 {
+		t.Error("expect 4 cache misses, but got ", c)
+	}
+	if c := sm.GetCounter("dns>>>cache>>>hit"); c == nil || c.Value() != 2 {
+		t.Error("expect 2 cache hits, but got ", c)
+	}
+
+	common.Must(dnsServer.Shutdown())
+}
@@ -0,0 +1,93 @@
+// +build !confonly
+
+package command
+
+//go:generate errorgen
+
+import (
+	"context"
+	"time"
+
+	grpc "google.golang.org/grpc"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/dns"
+	"v2ray.com/core/common"
+	feature_dns "v2ray.com/core/features/dns"
+)
+
+// dnsServer is an implementation of DNSService.
+type dnsServer struct {
+	client feature_dns.Client
+}
+
+func NewDNSServer(client feature_dns.Client) DNSServiceServer {
+	return &dnsServer{client: client}
+}
+
+func (s *dnsServer) SubscribeQueries(request *SubscribeQueriesRequest, stream DNSService_SubscribeQueriesServer) error {
+	server, ok := s.client.(*dns.Server)
+	if !ok {
+		return newError("SubscribeQueries only works with its own dns.Server.")
+	}
+
+	sub := server.SubscribeQueries()
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg := <-sub.Wait():
+			if err := stream.Send(toQueryEvent(msg.(*dns.QueryEvent))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toQueryEvent(e *dns.QueryEvent) *QueryEvent {
+	event := &QueryEvent{
+		Domain:  e.Domain,
+		Server:  e.Server,
+		Cached:  e.Cached,
+		Stale:   e.Stale,
+		Time:    e.Time.UnixNano() / int64(time.Millisecond),
+		Latency: int64(e.Latency / time.Millisecond),
+	}
+	switch {
+	case e.Option.IPv4Enable && e.Option.IPv6Enable:
+		event.QueryType = QueryEvent_A_AAAA
+	case e.Option.IPv6Enable:
+		event.QueryType = QueryEvent_AAAA
+	default:
+		event.QueryType = QueryEvent_A
+	}
+	for _, ip := range e.IPs {
+		event.Ip = append(event.Ip, ip.String())
+	}
+	if e.Err != nil {
+		event.Error = e.Err.Error()
+	}
+	return event
+}
+
+type service struct {
+	client feature_dns.Client
+}
+
+func (s *service) Register(server *grpc.Server) {
+	RegisterDNSServiceServer(server, NewDNSServer(s.client))
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := new(service)
+
+		core.RequireFeatures(ctx, func(c feature_dns.Client) {
+			s.client = c
+		})
+
+		return s, nil
+	}))
+}
@@ -0,0 +1,362 @@
+package command
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type QueryEvent_QueryType int32
+
+const (
+	QueryEvent_A    QueryEvent_QueryType = 0
+	QueryEvent_AAAA QueryEvent_QueryType = 1
+	// Both A and AAAA records.
+	QueryEvent_A_AAAA QueryEvent_QueryType = 2
+)
+
+var QueryEvent_QueryType_name = map[int32]string{
+	0: "A",
+	1: "AAAA",
+	2: "A_AAAA",
+}
+
+var QueryEvent_QueryType_value = map[string]int32{
+	"A":      0,
+	"AAAA":   1,
+	"A_AAAA": 2,
+}
+
+func (x QueryEvent_QueryType) String() string {
+	return proto.EnumName(QueryEvent_QueryType_name, int32(x))
+}
+
+func (QueryEvent_QueryType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_92ceadb32c442546, []int{1, 0}
+}
+
+type SubscribeQueriesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SubscribeQueriesRequest) Reset()         { *m = SubscribeQueriesRequest{} }
+func (m *SubscribeQueriesRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeQueriesRequest) ProtoMessage()    {}
+func (*SubscribeQueriesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_92ceadb32c442546, []int{0}
+}
+
+func (m *SubscribeQueriesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubscribeQueriesRequest.Unmarshal(m, b)
+}
+func (m *SubscribeQueriesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubscribeQueriesRequest.Marshal(b, m, deterministic)
+}
+func (m *SubscribeQueriesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeQueriesRequest.Merge(m, src)
+}
+func (m *SubscribeQueriesRequest) XXX_Size() int {
+	return xxx_messageInfo_SubscribeQueriesRequest.Size(m)
+}
+func (m *SubscribeQueriesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeQueriesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscribeQueriesRequest proto.InternalMessageInfo
+
+type QueryEvent struct {
+	Domain    string               `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	QueryType QueryEvent_QueryType `protobuf:"varint,2,opt,name=query_type,json=queryType,proto3,enum=v2ray.core.app.dns.command.QueryEvent_QueryType" json:"query_type,omitempty"`
+	// Name of the name server that answered, such as "udp:8.8.8.8:53". Empty if
+	// no name server answered.
+	Server string `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	// Whether the answer is from the cache, and whether it has expired.
+	Cached bool     `protobuf:"varint,4,opt,name=cached,proto3" json:"cached,omitempty"`
+	Stale  bool     `protobuf:"varint,5,opt,name=stale,proto3" json:"stale,omitempty"`
+	Ip     []string `protobuf:"bytes,6,rep,name=ip,proto3" json:"ip,omitempty"`
+	// Error of the lookup, if it failed.
+	Error string `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	// Unix time in milliseconds when the lookup started.
+	Time int64 `protobuf:"varint,8,opt,name=time,proto3" json:"time,omitempty"`
+	// Latency in milliseconds.
+	Latency              int64    `protobuf:"varint,9,opt,name=latency,proto3" json:"latency,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QueryEvent) Reset()         { *m = QueryEvent{} }
+func (m *QueryEvent) String() string { return proto.CompactTextString(m) }
+func (*QueryEvent) ProtoMessage()    {}
+func (*QueryEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_92ceadb32c442546, []int{1}
+}
+
+func (m *QueryEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueryEvent.Unmarshal(m, b)
+}
+func (m *QueryEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueryEvent.Marshal(b, m, deterministic)
+}
+func (m *QueryEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryEvent.Merge(m, src)
+}
+func (m *QueryEvent) XXX_Size() int {
+	return xxx_messageInfo_QueryEvent.Size(m)
+}
+func (m *QueryEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryEvent proto.InternalMessageInfo
+
+func (m *QueryEvent) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *QueryEvent) GetQueryType() QueryEvent_QueryType {
+	if m != nil {
+		return m.QueryType
+	}
+	return QueryEvent_A
+}
+
+func (m *QueryEvent) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *QueryEvent) GetCached() bool {
+	if m != nil {
+		return m.Cached
+	}
+	return false
+}
+
+func (m *QueryEvent) GetStale() bool {
+	if m != nil {
+		return m.Stale
+	}
+	return false
+}
+
+func (m *QueryEvent) GetIp() []string {
+	if m != nil {
+		return m.Ip
+	}
+	return nil
+}
+
+func (m *QueryEvent) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *QueryEvent) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *QueryEvent) GetLatency() int64 {
+	if m != nil {
+		return m.Latency
+	}
+	return 0
+}
+
+type Config struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Config) Reset()         { *m = Config{} }
+func (m *Config) String() string { return proto.CompactTextString(m) }
+func (*Config) ProtoMessage()    {}
+func (*Config) Descriptor() ([]byte, []int) {
+	return fileDescriptor_92ceadb32c442546, []int{2}
+}
+
+func (m *Config) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Config.Unmarshal(m, b)
+}
+func (m *Config) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Config.Marshal(b, m, deterministic)
+}
+func (m *Config) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Config.Merge(m, src)
+}
+func (m *Config) XXX_Size() int {
+	return xxx_messageInfo_Config.Size(m)
+}
+func (m *Config) XXX_DiscardUnknown() {
+	xxx_messageInfo_Config.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Config proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterEnum("v2ray.core.app.dns.command.QueryEvent_QueryType", QueryEvent_QueryType_name, QueryEvent_QueryType_value)
+	proto.RegisterType((*SubscribeQueriesRequest)(nil), "v2ray.core.app.dns.command.SubscribeQueriesRequest")
+	proto.RegisterType((*QueryEvent)(nil), "v2ray.core.app.dns.command.QueryEvent")
+	proto.RegisterType((*Config)(nil), "v2ray.core.app.dns.command.Config")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/app/dns/command/command.proto", fileDescriptor_92ceadb32c442546)
+}
+
+var fileDescriptor_92ceadb32c442546 = []byte{
+	// 375 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0xcd, 0x8e, 0xd3, 0x30,
+	0x14, 0x85, 0xc7, 0x69, 0x9b, 0x36, 0x77, 0x31, 0xaa, 0x2c, 0x04, 0xa6, 0x0b, 0x14, 0x65, 0x81,
+	0xb2, 0x40, 0xce, 0x28, 0xf3, 0x04, 0xa1, 0x83, 0xc4, 0x8a, 0x9f, 0x14, 0xb1, 0x60, 0x33, 0x72,
+	0x9d, 0x0b, 0x58, 0x9a, 0xd8, 0xae, 0x9d, 0x46, 0xca, 0x92, 0xd7, 0xe1, 0x0d, 0x78, 0x3b, 0x94,
+	0x3f, 0x90, 0x90, 0xa6, 0x62, 0xe5, 0xf3, 0x1d, 0x1f, 0xdf, 0x6b, 0x5d, 0x1b, 0x5e, 0xb5, 0xb9,
+	0x13, 0x1d, 0x97, 0xa6, 0xce, 0xa4, 0x71, 0x98, 0x09, 0x6b, 0xb3, 0x4a, 0xfb, 0x4c, 0x9a, 0xba,
+	0x16, 0xba, 0x9a, 0x57, 0x6e, 0x9d, 0x69, 0x0c, 0xdd, 0xcd, 0x69, 0x87, 0x5c, 0x58, 0xcb, 0x2b,
+	0xed, 0xf9, 0x94, 0x48, 0x9e, 0xc3, 0xb3, 0xc3, 0xf9, 0xe8, 0xa5, 0x53, 0x47, 0xfc, 0x78, 0x46,
+	0xa7, 0xd0, 0x97, 0x78, 0x3a, 0xa3, 0x6f, 0x92, 0x5f, 0x01, 0x40, 0x6f, 0x75, 0x6f, 0x5a, 0xd4,
+	0x0d, 0x7d, 0x0a, 0x61, 0x65, 0x6a, 0xa1, 0x34, 0x23, 0x31, 0x49, 0xa3, 0x72, 0x22, 0xfa, 0x1e,
+	0xe0, 0xd4, 0xa7, 0xee, 0x9b, 0xce, 0x22, 0x0b, 0x62, 0x92, 0x5e, 0xe7, 0x37, 0xfc, 0xf1, 0x96,
+	0xfc, 0x6f, 0xcd, 0x51, 0x7e, 0xea, 0x2c, 0x96, 0xd1, 0x69, 0x96, 0x7d, 0x23, 0x8f, 0xae, 0x45,
+	0xc7, 0x16, 0x63, 0xa3, 0x91, 0x7a, 0x5f, 0x0a, 0xf9, 0x1d, 0x2b, 0xb6, 0x8c, 0x49, 0xba, 0x29,
+	0x27, 0xa2, 0x4f, 0x60, 0xe5, 0x1b, 0xf1, 0x80, 0x6c, 0x35, 0xd8, 0x23, 0xd0, 0x6b, 0x08, 0x94,
+	0x65, 0x61, 0xbc, 0x48, 0xa3, 0x32, 0x50, 0xb6, 0x4f, 0xa1, 0x73, 0xc6, 0xb1, 0xf5, 0x50, 0x74,
+	0x04, 0x4a, 0x61, 0xd9, 0xa8, 0x1a, 0xd9, 0x26, 0x26, 0xe9, 0xa2, 0x1c, 0x34, 0x65, 0xb0, 0x7e,
+	0x10, 0x0d, 0x6a, 0xd9, 0xb1, 0x68, 0xb0, 0x67, 0x4c, 0x52, 0x88, 0xfe, 0xdc, 0x98, 0xae, 0x80,
+	0x14, 0xdb, 0x2b, 0xba, 0x81, 0x65, 0x51, 0x14, 0xc5, 0x96, 0x50, 0x80, 0xb0, 0xb8, 0x1f, 0x74,
+	0x90, 0x6c, 0x20, 0xdc, 0x1b, 0xfd, 0x55, 0x7d, 0xcb, 0x7f, 0x10, 0x80, 0xbb, 0x77, 0x87, 0x03,
+	0xba, 0x56, 0x49, 0xa4, 0x1e, 0xb6, 0xff, 0xce, 0x9b, 0xde, 0x5e, 0x9a, 0xd6, 0x23, 0xaf, 0xb3,
+	0x7b, 0xf9, 0x7f, 0x23, 0x4e, 0xae, 0x6e, 0xc8, 0xeb, 0xb7, 0xf0, 0x42, 0x9a, 0xfa, 0xc2, 0x81,
+	0x0f, 0xe4, 0xcb, 0x7a, 0x92, 0x3f, 0x83, 0xdd, 0xe7, 0xbc, 0x14, 0x1d, 0xdf, 0xf7, 0xb9, 0xc2,
+	0x5a, 0x7e, 0xa7, 0x3d, 0xdf, 0x8f, 0x9b, 0xc7, 0x70, 0xf8, 0x51, 0xb7, 0xbf, 0x07, 0x00, 0x7f,
+	0x5a, 0x12, 0x61, 0x81, 0x02, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// DNSServiceClient is the client API for DNSService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type DNSServiceClient interface {
+	// Streams the lookups of the DNS server as they are answered. Lookups
+	// answered by static hosts are not included.
+	SubscribeQueries(ctx context.Context, in *SubscribeQueriesRequest, opts ...grpc.CallOption) (DNSService_SubscribeQueriesClient, error)
+}
+
+type dNSServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewDNSServiceClient(cc *grpc.ClientConn) DNSServiceClient {
+	return &dNSServiceClient{cc}
+}
+
+func (c *dNSServiceClient) SubscribeQueries(ctx context.Context, in *SubscribeQueriesRequest, opts ...grpc.CallOption) (DNSService_SubscribeQueriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DNSService_serviceDesc.Streams[0], "/v2ray.core.app.dns.command.DNSService/SubscribeQueries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dNSServiceSubscribeQueriesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DNSService_SubscribeQueriesClient interface {
+	Recv() (*QueryEvent, error)
+	grpc.ClientStream
+}
+
+type dNSServiceSubscribeQueriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *dNSServiceSubscribeQueriesClient) Recv() (*QueryEvent, error) {
+	m := new(QueryEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DNSServiceServer is the server API for DNSService service.
+type DNSServiceServer interface {
+	// Streams the lookups of the DNS server as they are answered. Lookups
+	// answered by static hosts are not included.
+	SubscribeQueries(*SubscribeQueriesRequest, DNSService_SubscribeQueriesServer) error
+}
+
+func RegisterDNSServiceServer(s *grpc.Server, srv DNSServiceServer) {
+	s.RegisterService(&_DNSService_serviceDesc, srv)
+}
+
+func _DNSService_SubscribeQueries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeQueriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DNSServiceServer).SubscribeQueries(m, &dNSServiceSubscribeQueriesServer{stream})
+}
+
+type DNSService_SubscribeQueriesServer interface {
+	Send(*QueryEvent) error
+	grpc.ServerStream
+}
+
+type dNSServiceSubscribeQueriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *dNSServiceSubscribeQueriesServer) Send(m *QueryEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _DNSService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "v2ray.core.app.dns.command.DNSService",
+	HandlerType: (*DNSServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeQueries",
+			Handler:       _DNSService_SubscribeQueries_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "v2ray.com/core/app/dns/command/command.proto",
+}
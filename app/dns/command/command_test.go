@@ -0,0 +1,150 @@
+package command_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"google.golang.org/grpc"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/dispatcher"
+	dnsapp "v2ray.com/core/app/dns"
+	. "v2ray.com/core/app/dns/command"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/app/stats"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	feature_dns "v2ray.com/core/features/dns"
+	feature_stats "v2ray.com/core/features/stats"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	_ "v2ray.com/core/transport/internet/tcp"
+)
+
+type staticHandler struct{}
+
+func (*staticHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	ans := new(dns.Msg)
+	ans.SetReply(r)
+	for _, q := range r.Question {
+		if q.Name == "v2ray.com." && q.Qtype == dns.TypeA {
+			rr, err := dns.NewRR("v2ray.com. 60 IN A 8.8.8.8")
+			common.Must(err)
+			ans.Answer = append(ans.Answer, rr)
+		}
+	}
+	w.WriteMsg(ans)
+}
+
+type eventStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *QueryEvent
+}
+
+func (s *eventStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *eventStream) Send(e *QueryEvent) error {
+	s.events <- e
+	return nil
+}
+
+func TestSubscribeQueries(t *testing.T) {
+	port := tcp.PickPort()
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "tcp",
+		Handler: &staticHandler{},
+	}
+	defer dnsServer.Shutdown()
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dnsapp.Config{
+				NameServer: []*dnsapp.NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.DomainAddress("tcp://127.0.0.1:" + port.String())),
+						},
+					},
+				},
+				Cache: &dnsapp.CacheConfig{},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+			serial.ToTypedMessage(&stats.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &eventStream{
+		ctx:    ctx,
+		events: make(chan *QueryEvent, 4),
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- NewDNSServer(client).SubscribeQueries(&SubscribeQueriesRequest{}, stream)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	lookup := client.(feature_dns.IPv4Lookup)
+	for i := 0; i < 2; i++ {
+		if _, err := lookup.LookupIPv4("v2ray.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := "tcp://127.0.0.1:" + port.String()
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-stream.events:
+			if e.Domain != "v2ray.com" || e.QueryType != QueryEvent_A || e.Server != server || len(e.Ip) != 1 || e.Ip[0] != "8.8.8.8" {
+				t.Error("unexpected event: ", e)
+			}
+			if e.Cached != (i == 1) {
+				t.Error("expect only the second lookup to be cached, but got ", e)
+			}
+		case <-time.After(time.Second * 2):
+			t.Fatal("timeout waiting for query event")
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error("unexpected error: ", err)
+	}
+
+	sm := v.GetFeature(feature_stats.ManagerType()).(feature_stats.Manager)
+	for name, value := range map[string]int64{
+		"query":         1,
+		"failure":       0,
+		"cache_hit":     1,
+		"latency>>>1s":  1,
+		"latency>>>inf": 1,
+	} {
+		if c := sm.GetCounter("dns>>>server>>>" + server + ">>>" + name); c == nil || c.Value() != value {
+			t.Error("expect ", value, " for counter ", name, ", but got ", c)
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
 	math "math"
+	router "v2ray.com/core/app/router"
 	net "v2ray.com/core/common/net"
+	tls "v2ray.com/core/transport/internet/tls"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -49,12 +51,56 @@ func (DomainMatchingType) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_ed5695198e3def8f, []int{0}
 }
 
+type QueryStrategy int32
+
+const (
+	// Name servers are queried one after another, until one of them answers.
+	QueryStrategy_Sequential QueryStrategy = 0
+	// All name servers are queried at the same time, and the first answer is returned.
+	QueryStrategy_Parallel QueryStrategy = 1
+	// Name servers are queried one after another, the ones with the shortest round trip time first.
+	QueryStrategy_Fastest QueryStrategy = 2
+)
+
+var QueryStrategy_name = map[int32]string{
+	0: "Sequential",
+	1: "Parallel",
+	2: "Fastest",
+}
+
+var QueryStrategy_value = map[string]int32{
+	"Sequential": 0,
+	"Parallel":   1,
+	"Fastest":    2,
+}
+
+func (x QueryStrategy) String() string {
+	return proto.EnumName(QueryStrategy_name, int32(x))
+}
+
+func (QueryStrategy) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ed5695198e3def8f, []int{1}
+}
+
 type NameServer struct {
-	Address              *net.Endpoint                `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
-	PrioritizedDomain    []*NameServer_PriorityDomain `protobuf:"bytes,2,rep,name=prioritized_domain,json=prioritizedDomain,proto3" json:"prioritized_domain,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                     `json:"-"`
-	XXX_unrecognized     []byte                       `json:"-"`
-	XXX_sizecache        int32                        `json:"-"`
+	Address           *net.Endpoint                `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	PrioritizedDomain []*NameServer_PriorityDomain `protobuf:"bytes,2,rep,name=prioritized_domain,json=prioritizedDomain,proto3" json:"prioritized_domain,omitempty"`
+	// TLS settings to verify the server certificate of a DNS over TLS or DNS
+	// over HTTPS server.
+	TlsSettings *tls.Config `protobuf:"bytes,3,opt,name=tls_settings,json=tlsSettings,proto3" json:"tls_settings,omitempty"`
+	// If not empty, IPs in answers from this server must be in these sets.
+	// Answers without such IPs are discarded, and the next server is queried.
+	ExpectedIps []*router.GeoIP `protobuf:"bytes,4,rep,name=expected_ips,json=expectedIps,proto3" json:"expected_ips,omitempty"`
+	// Same as expected_ips, but in countries of these codes by geo.mmdb.
+	ExpectedCustomGeoip []string `protobuf:"bytes,5,rep,name=expected_custom_geoip,json=expectedCustomGeoip,proto3" json:"expected_custom_geoip,omitempty"`
+	// IPs in these sets are removed from answers from this server.
+	// Answers without other IPs are discarded, and the next server is queried.
+	RejectedIps []*router.GeoIP `protobuf:"bytes,6,rep,name=rejected_ips,json=rejectedIps,proto3" json:"rejected_ips,omitempty"`
+	// Same as rejected_ips, but in countries of these codes by geo.mmdb.
+	RejectedCustomGeoip  []string `protobuf:"bytes,7,rep,name=rejected_custom_geoip,json=rejectedCustomGeoip,proto3" json:"rejected_custom_geoip,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *NameServer) Reset()         { *m = NameServer{} }
@@ -96,6 +142,41 @@ func (m *NameServer) GetPrioritizedDomain() []*NameServer_PriorityDomain {
 	return nil
 }
 
+func (m *NameServer) GetTlsSettings() *tls.Config {
+	if m != nil {
+		return m.TlsSettings
+	}
+	return nil
+}
+
+func (m *NameServer) GetExpectedIps() []*router.GeoIP {
+	if m != nil {
+		return m.ExpectedIps
+	}
+	return nil
+}
+
+func (m *NameServer) GetExpectedCustomGeoip() []string {
+	if m != nil {
+		return m.ExpectedCustomGeoip
+	}
+	return nil
+}
+
+func (m *NameServer) GetRejectedIps() []*router.GeoIP {
+	if m != nil {
+		return m.RejectedIps
+	}
+	return nil
+}
+
+func (m *NameServer) GetRejectedCustomGeoip() []string {
+	if m != nil {
+		return m.RejectedCustomGeoip
+	}
+	return nil
+}
+
 type NameServer_PriorityDomain struct {
 	Type                 DomainMatchingType `protobuf:"varint,1,opt,name=type,proto3,enum=v2ray.core.app.dns.DomainMatchingType" json:"type,omitempty"`
 	Domain               string             `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
@@ -144,8 +225,11 @@ func (m *NameServer_PriorityDomain) GetDomain() string {
 }
 
 type Config struct {
-	// Nameservers used by this DNS. Only traditional UDP servers are support at the moment.
+	// Nameservers used by this DNS. UDP, TCP, DNS over TLS and DNS over HTTPS servers are supported.
 	// A special value 'localhost' as a domain address can be set to use DNS on local system.
+	// A special value 'fakedns' as a domain address can be set to answer with addresses from the fake DNS pools.
+	// A DNS over HTTPS URL, such as 'https://dns.google/dns-query', as a domain address sets a DoH server.
+	// 'tcp://host:port' and 'tls://host:port' as domain addresses set DNS over TCP and DNS over TLS servers.
 	NameServers []*net.Endpoint `protobuf:"bytes,1,rep,name=NameServers,proto3" json:"NameServers,omitempty"` // Deprecated: Do not use.
 	// NameServer list used by this DNS client.
 	NameServer []*NameServer `protobuf:"bytes,5,rep,name=name_server,json=nameServer,proto3" json:"name_server,omitempty"`
@@ -156,10 +240,15 @@ type Config struct {
 	ClientIp    []byte                `protobuf:"bytes,3,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"`
 	StaticHosts []*Config_HostMapping `protobuf:"bytes,4,rep,name=static_hosts,json=staticHosts,proto3" json:"static_hosts,omitempty"`
 	// Tag is the inbound tag of DNS client.
-	Tag                  string   `protobuf:"bytes,6,opt,name=tag,proto3" json:"tag,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Tag string `protobuf:"bytes,6,opt,name=tag,proto3" json:"tag,omitempty"`
+	// Cache of the answers from all name servers. The cache is disabled if not
+	// set, and each name server only keeps its own answers then.
+	Cache *CacheConfig `protobuf:"bytes,7,opt,name=cache,proto3" json:"cache,omitempty"`
+	// How the name servers are queried.
+	QueryStrategy        QueryStrategy `protobuf:"varint,8,opt,name=query_strategy,json=queryStrategy,proto3,enum=v2ray.core.app.dns.QueryStrategy" json:"query_strategy,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *Config) Reset()         { *m = Config{} }
@@ -231,6 +320,20 @@ func (m *Config) GetTag() string {
 	return ""
 }
 
+func (m *Config) GetCache() *CacheConfig {
+	if m != nil {
+		return m.Cache
+	}
+	return nil
+}
+
+func (m *Config) GetQueryStrategy() QueryStrategy {
+	if m != nil {
+		return m.QueryStrategy
+	}
+	return QueryStrategy_Sequential
+}
+
 type Config_HostMapping struct {
 	Type   DomainMatchingType `protobuf:"varint,1,opt,name=type,proto3,enum=v2ray.core.app.dns.DomainMatchingType" json:"type,omitempty"`
 	Domain string             `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
@@ -296,13 +399,232 @@ func (m *Config_HostMapping) GetProxiedDomain() string {
 	return ""
 }
 
+type CacheConfig struct {
+	// Serve expired answers while they are refreshed in background.
+	ServeStale bool `protobuf:"varint,1,opt,name=serve_stale,json=serveStale,proto3" json:"serve_stale,omitempty"`
+	// Seconds after expiration during which stale answers are served. Default to 86400.
+	StaleTtl uint32 `protobuf:"varint,2,opt,name=stale_ttl,json=staleTtl,proto3" json:"stale_ttl,omitempty"`
+	// Refresh answers that are queried often before they expire.
+	Prefetch bool `protobuf:"varint,3,opt,name=prefetch,proto3" json:"prefetch,omitempty"`
+	// Minimum number of hits for an answer to be prefetched. Default to 3.
+	PrefetchHits uint32 `protobuf:"varint,4,opt,name=prefetch_hits,json=prefetchHits,proto3" json:"prefetch_hits,omitempty"`
+	// Maximum number of cached answers. Default to 4096.
+	MaxEntries uint32 `protobuf:"varint,5,opt,name=max_entries,json=maxEntries,proto3" json:"max_entries,omitempty"`
+	// Path of the file that the cache is saved to on shutdown, and loaded from on start.
+	SnapshotPath         string   `protobuf:"bytes,6,opt,name=snapshot_path,json=snapshotPath,proto3" json:"snapshot_path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CacheConfig) Reset()         { *m = CacheConfig{} }
+func (m *CacheConfig) String() string { return proto.CompactTextString(m) }
+func (*CacheConfig) ProtoMessage()    {}
+func (*CacheConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ed5695198e3def8f, []int{2}
+}
+
+func (m *CacheConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CacheConfig.Unmarshal(m, b)
+}
+func (m *CacheConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CacheConfig.Marshal(b, m, deterministic)
+}
+func (m *CacheConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CacheConfig.Merge(m, src)
+}
+func (m *CacheConfig) XXX_Size() int {
+	return xxx_messageInfo_CacheConfig.Size(m)
+}
+func (m *CacheConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_CacheConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CacheConfig proto.InternalMessageInfo
+
+func (m *CacheConfig) GetServeStale() bool {
+	if m != nil {
+		return m.ServeStale
+	}
+	return false
+}
+
+func (m *CacheConfig) GetStaleTtl() uint32 {
+	if m != nil {
+		return m.StaleTtl
+	}
+	return 0
+}
+
+func (m *CacheConfig) GetPrefetch() bool {
+	if m != nil {
+		return m.Prefetch
+	}
+	return false
+}
+
+func (m *CacheConfig) GetPrefetchHits() uint32 {
+	if m != nil {
+		return m.PrefetchHits
+	}
+	return 0
+}
+
+func (m *CacheConfig) GetMaxEntries() uint32 {
+	if m != nil {
+		return m.MaxEntries
+	}
+	return 0
+}
+
+func (m *CacheConfig) GetSnapshotPath() string {
+	if m != nil {
+		return m.SnapshotPath
+	}
+	return ""
+}
+
+// CacheSnapshot is the content of the cache snapshot file.
+type CacheSnapshot struct {
+	Entry                []*CacheSnapshot_Entry `protobuf:"bytes,1,rep,name=entry,proto3" json:"entry,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *CacheSnapshot) Reset()         { *m = CacheSnapshot{} }
+func (m *CacheSnapshot) String() string { return proto.CompactTextString(m) }
+func (*CacheSnapshot) ProtoMessage()    {}
+func (*CacheSnapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ed5695198e3def8f, []int{3}
+}
+
+func (m *CacheSnapshot) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CacheSnapshot.Unmarshal(m, b)
+}
+func (m *CacheSnapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CacheSnapshot.Marshal(b, m, deterministic)
+}
+func (m *CacheSnapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CacheSnapshot.Merge(m, src)
+}
+func (m *CacheSnapshot) XXX_Size() int {
+	return xxx_messageInfo_CacheSnapshot.Size(m)
+}
+func (m *CacheSnapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_CacheSnapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CacheSnapshot proto.InternalMessageInfo
+
+func (m *CacheSnapshot) GetEntry() []*CacheSnapshot_Entry {
+	if m != nil {
+		return m.Entry
+	}
+	return nil
+}
+
+type CacheSnapshot_Entry struct {
+	Domain string   `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Ipv4   bool     `protobuf:"varint,2,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
+	Ipv6   bool     `protobuf:"varint,3,opt,name=ipv6,proto3" json:"ipv6,omitempty"`
+	Ip     [][]byte `protobuf:"bytes,4,rep,name=ip,proto3" json:"ip,omitempty"`
+	// Unix time in seconds when the answer expires.
+	Expire int64 `protobuf:"varint,5,opt,name=expire,proto3" json:"expire,omitempty"`
+	// TTL of the answer in seconds.
+	Ttl uint32 `protobuf:"varint,6,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// Name of the name server that answered.
+	Server               string   `protobuf:"bytes,7,opt,name=server,proto3" json:"server,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CacheSnapshot_Entry) Reset()         { *m = CacheSnapshot_Entry{} }
+func (m *CacheSnapshot_Entry) String() string { return proto.CompactTextString(m) }
+func (*CacheSnapshot_Entry) ProtoMessage()    {}
+func (*CacheSnapshot_Entry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ed5695198e3def8f, []int{3, 0}
+}
+
+func (m *CacheSnapshot_Entry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CacheSnapshot_Entry.Unmarshal(m, b)
+}
+func (m *CacheSnapshot_Entry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CacheSnapshot_Entry.Marshal(b, m, deterministic)
+}
+func (m *CacheSnapshot_Entry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CacheSnapshot_Entry.Merge(m, src)
+}
+func (m *CacheSnapshot_Entry) XXX_Size() int {
+	return xxx_messageInfo_CacheSnapshot_Entry.Size(m)
+}
+func (m *CacheSnapshot_Entry) XXX_DiscardUnknown() {
+	xxx_messageInfo_CacheSnapshot_Entry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CacheSnapshot_Entry proto.InternalMessageInfo
+
+func (m *CacheSnapshot_Entry) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *CacheSnapshot_Entry) GetIpv4() bool {
+	if m != nil {
+		return m.Ipv4
+	}
+	return false
+}
+
+func (m *CacheSnapshot_Entry) GetIpv6() bool {
+	if m != nil {
+		return m.Ipv6
+	}
+	return false
+}
+
+func (m *CacheSnapshot_Entry) GetIp() [][]byte {
+	if m != nil {
+		return m.Ip
+	}
+	return nil
+}
+
+func (m *CacheSnapshot_Entry) GetExpire() int64 {
+	if m != nil {
+		return m.Expire
+	}
+	return 0
+}
+
+func (m *CacheSnapshot_Entry) GetTtl() uint32 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+func (m *CacheSnapshot_Entry) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterEnum("v2ray.core.app.dns.DomainMatchingType", DomainMatchingType_name, DomainMatchingType_value)
+	proto.RegisterEnum("v2ray.core.app.dns.QueryStrategy", QueryStrategy_name, QueryStrategy_value)
 	proto.RegisterType((*NameServer)(nil), "v2ray.core.app.dns.NameServer")
 	proto.RegisterType((*NameServer_PriorityDomain)(nil), "v2ray.core.app.dns.NameServer.PriorityDomain")
 	proto.RegisterType((*Config)(nil), "v2ray.core.app.dns.Config")
 	proto.RegisterMapType((map[string]*net.IPOrDomain)(nil), "v2ray.core.app.dns.Config.HostsEntry")
 	proto.RegisterType((*Config_HostMapping)(nil), "v2ray.core.app.dns.Config.HostMapping")
+	proto.RegisterType((*CacheConfig)(nil), "v2ray.core.app.dns.CacheConfig")
+	proto.RegisterType((*CacheSnapshot)(nil), "v2ray.core.app.dns.CacheSnapshot")
+	proto.RegisterType((*CacheSnapshot_Entry)(nil), "v2ray.core.app.dns.CacheSnapshot.Entry")
 }
 
 func init() {
@@ -310,40 +632,67 @@ func init() {
 }
 
 var fileDescriptor_ed5695198e3def8f = []byte{
-	// 552 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x53, 0xd1, 0x6e, 0xd3, 0x30,
-	0x14, 0x25, 0x49, 0xdb, 0xad, 0x37, 0x5d, 0x55, 0xfc, 0x30, 0x45, 0x45, 0x82, 0x32, 0xb4, 0x51,
-	0x81, 0x70, 0xa4, 0x80, 0x04, 0xec, 0x65, 0x62, 0x5b, 0x11, 0x15, 0x1a, 0x54, 0x1e, 0xe2, 0x01,
-	0x90, 0x2a, 0x2f, 0x31, 0x9d, 0x45, 0x63, 0x5b, 0x8e, 0x5b, 0x16, 0x7e, 0x81, 0x1f, 0xe0, 0x1b,
-	0xf8, 0x0d, 0x7e, 0x0c, 0xd5, 0xee, 0x68, 0x61, 0x1d, 0xf0, 0xb2, 0xb7, 0xf8, 0xfa, 0x9c, 0x7b,
-	0x8e, 0xcf, 0xbd, 0x81, 0x3b, 0xd3, 0x44, 0xd3, 0x12, 0xa7, 0x32, 0x8f, 0x53, 0xa9, 0x59, 0x4c,
-	0x95, 0x8a, 0x33, 0x51, 0xc4, 0xa9, 0x14, 0x1f, 0xf9, 0x08, 0x2b, 0x2d, 0x8d, 0x44, 0xe8, 0x1c,
-	0xa4, 0x19, 0xa6, 0x4a, 0xe1, 0x4c, 0x14, 0xed, 0xbb, 0x7f, 0x10, 0x53, 0x99, 0xe7, 0x52, 0xc4,
-	0x82, 0x99, 0x98, 0x66, 0x99, 0x66, 0x45, 0xe1, 0xc8, 0xed, 0xfb, 0x97, 0x03, 0x33, 0x56, 0x18,
-	0x2e, 0xa8, 0xe1, 0x52, 0x38, 0xf0, 0xd6, 0x57, 0x1f, 0xe0, 0x15, 0xcd, 0xd9, 0x31, 0xd3, 0x53,
-	0xa6, 0xd1, 0x53, 0x58, 0x9b, 0x37, 0x8b, 0xbc, 0x8e, 0xd7, 0x0d, 0x93, 0x5b, 0x78, 0xc9, 0x8a,
-	0xeb, 0x84, 0x05, 0x33, 0xb8, 0x27, 0x32, 0x25, 0xb9, 0x30, 0xe4, 0x1c, 0x8f, 0x3e, 0x00, 0x52,
-	0x9a, 0x4b, 0xcd, 0x0d, 0xff, 0xc2, 0xb2, 0x61, 0x26, 0x73, 0xca, 0x45, 0xe4, 0x77, 0x82, 0x6e,
-	0x98, 0x3c, 0xc0, 0x17, 0x1f, 0x84, 0x17, 0xb2, 0x78, 0xe0, 0x88, 0xe5, 0xa1, 0x25, 0x91, 0xeb,
-	0x4b, 0x8d, 0x5c, 0xa9, 0x9d, 0x41, 0xf3, 0x77, 0x10, 0xda, 0x85, 0x8a, 0x29, 0x15, 0xb3, 0x3e,
-	0x9b, 0xc9, 0xce, 0x2a, 0x05, 0x87, 0x3c, 0xa2, 0x26, 0x3d, 0xe5, 0x62, 0xf4, 0xa6, 0x54, 0x8c,
-	0x58, 0x0e, 0xda, 0x84, 0xda, 0x2f, 0x7f, 0x5e, 0xb7, 0x4e, 0xe6, 0xa7, 0xad, 0x1f, 0x15, 0xa8,
-	0x1d, 0xd8, 0x41, 0xa0, 0x1e, 0x84, 0x0b, 0x83, 0xb3, 0x34, 0x82, 0xff, 0x48, 0x63, 0xdf, 0x8f,
-	0x3c, 0xb2, 0xcc, 0x43, 0x7b, 0x10, 0x0a, 0x9a, 0xb3, 0x61, 0x61, 0xcf, 0x51, 0xd5, 0xb6, 0xb9,
-	0xf9, 0xf7, 0x38, 0x08, 0x88, 0xc5, 0x44, 0xf6, 0xa0, 0xfa, 0x42, 0x16, 0xa6, 0x98, 0x27, 0xb9,
-	0xbd, 0x8a, 0xea, 0x2c, 0x63, 0x8b, 0xeb, 0x09, 0xa3, 0x4b, 0xeb, 0xc3, 0xf1, 0xd0, 0x0d, 0xa8,
-	0xa7, 0x63, 0xce, 0x84, 0x19, 0x72, 0x15, 0x05, 0x1d, 0xaf, 0xdb, 0x20, 0xeb, 0xae, 0xd0, 0x57,
-	0xa8, 0x0f, 0x8d, 0xc2, 0x50, 0xc3, 0xd3, 0xe1, 0xa9, 0x15, 0xa9, 0x58, 0x91, 0x9d, 0x7f, 0x88,
-	0x1c, 0x51, 0xa5, 0xb8, 0x18, 0x91, 0xd0, 0x71, 0x9d, 0x4e, 0x0b, 0x02, 0x43, 0x47, 0x51, 0xcd,
-	0x06, 0x3a, 0xfb, 0x6c, 0xbf, 0x07, 0x58, 0x58, 0x9a, 0xdd, 0x7f, 0x62, 0xa5, 0x1d, 0x57, 0x9d,
-	0xcc, 0x3e, 0xd1, 0x63, 0xa8, 0x4e, 0xe9, 0x78, 0xc2, 0xec, 0x10, 0xc2, 0xe4, 0xf6, 0x25, 0xe1,
-	0xf6, 0x07, 0xaf, 0xf5, 0x7c, 0x31, 0x1c, 0x7e, 0xd7, 0x7f, 0xe2, 0xb5, 0xbf, 0x79, 0x10, 0x2e,
-	0x79, 0xb9, 0x8a, 0x75, 0x40, 0x4d, 0xf0, 0x6d, 0x66, 0x41, 0xb7, 0x41, 0x7c, 0xae, 0xd0, 0x36,
-	0x34, 0x95, 0x96, 0x67, 0x7c, 0xb1, 0xde, 0x15, 0x8b, 0xdf, 0x98, 0x57, 0x9d, 0xc0, 0xbd, 0x1e,
-	0xa0, 0x8b, 0x52, 0x68, 0x1d, 0x2a, 0xcf, 0x27, 0xe3, 0x71, 0xeb, 0x1a, 0xda, 0x80, 0xfa, 0xf1,
-	0xe4, 0xc4, 0x75, 0x68, 0x79, 0x28, 0x84, 0xb5, 0x97, 0xac, 0xfc, 0x2c, 0x75, 0xd6, 0xf2, 0x51,
-	0x1d, 0xaa, 0x84, 0x8d, 0xd8, 0x59, 0x2b, 0xd8, 0x7f, 0x04, 0x9b, 0xa9, 0xcc, 0x57, 0x3c, 0x64,
-	0xe0, 0xbd, 0x0b, 0x32, 0x51, 0x7c, 0xf7, 0xd1, 0xdb, 0x84, 0xd0, 0x12, 0x1f, 0xcc, 0xee, 0x9e,
-	0x29, 0x85, 0x0f, 0x45, 0x71, 0x52, 0xb3, 0xff, 0xf5, 0xc3, 0x9f, 0x01, 0x00, 0x00, 0xff, 0xff,
-	0x15, 0xed, 0x7b, 0x41, 0x68, 0x04, 0x00, 0x00,
+	// 984 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xed, 0x6e, 0x1b, 0x45,
+	0x14, 0x65, 0xfd, 0x15, 0xfb, 0xae, 0x6d, 0x99, 0x41, 0x54, 0x96, 0x41, 0x34, 0x4d, 0xd5, 0x34,
+	0x14, 0xb1, 0x96, 0x4c, 0xf9, 0x8a, 0x84, 0x22, 0x9a, 0xa6, 0x4d, 0x04, 0x05, 0x33, 0xae, 0xf8,
+	0x01, 0x48, 0xab, 0xe9, 0xee, 0xd4, 0x1e, 0x58, 0xcf, 0x4e, 0x66, 0xae, 0x83, 0x97, 0x27, 0x81,
+	0x57, 0xe0, 0x35, 0xe0, 0x09, 0x78, 0x0c, 0x9e, 0x02, 0xed, 0xcc, 0xae, 0x63, 0x27, 0x0e, 0xf4,
+	0x0f, 0xff, 0x66, 0xee, 0x9c, 0x73, 0xef, 0x9d, 0x73, 0xcf, 0xec, 0xc2, 0xdd, 0x8b, 0x91, 0x66,
+	0x59, 0x10, 0xa5, 0xf3, 0x61, 0x94, 0x6a, 0x3e, 0x64, 0x4a, 0x0d, 0x63, 0x69, 0x86, 0x51, 0x2a,
+	0x5f, 0x8a, 0x69, 0xa0, 0x74, 0x8a, 0x29, 0x21, 0x25, 0x48, 0xf3, 0x80, 0x29, 0x15, 0xc4, 0xd2,
+	0x0c, 0xee, 0x5f, 0x21, 0x46, 0xe9, 0x7c, 0x9e, 0xca, 0xa1, 0xe4, 0x38, 0x64, 0x71, 0xac, 0xb9,
+	0x31, 0x8e, 0x3c, 0x78, 0xef, 0x66, 0x60, 0xcc, 0x0d, 0x0a, 0xc9, 0x50, 0xa4, 0xb2, 0x00, 0x8f,
+	0xae, 0x80, 0x51, 0x33, 0x69, 0x54, 0xaa, 0x71, 0x28, 0x24, 0x72, 0x9d, 0x93, 0x30, 0xd9, 0xec,
+	0x6e, 0xb0, 0xbf, 0xe5, 0x0a, 0x3a, 0x5d, 0x20, 0xd7, 0x1b, 0xb8, 0xbd, 0x3f, 0x6a, 0x00, 0x5f,
+	0xb1, 0x39, 0x9f, 0x70, 0x7d, 0xc1, 0x35, 0xf9, 0x14, 0x76, 0x8a, 0x46, 0xfb, 0xde, 0xae, 0x77,
+	0xe0, 0x8f, 0x6e, 0x07, 0x6b, 0xd7, 0x74, 0x5d, 0x06, 0x92, 0x63, 0x70, 0x22, 0x63, 0x95, 0x0a,
+	0x89, 0xb4, 0xc4, 0x93, 0x1f, 0x80, 0x28, 0x2d, 0x52, 0x2d, 0x50, 0xfc, 0xc2, 0xe3, 0x30, 0x4e,
+	0xe7, 0x4c, 0xc8, 0x7e, 0x65, 0xb7, 0x7a, 0xe0, 0x8f, 0xde, 0x0f, 0xae, 0x8b, 0x15, 0x5c, 0x96,
+	0x0d, 0xc6, 0x8e, 0x98, 0x3d, 0xb6, 0x24, 0xfa, 0xfa, 0x5a, 0x22, 0x17, 0x22, 0x5f, 0x42, 0x1b,
+	0x13, 0x13, 0x1a, 0x8e, 0x28, 0xe4, 0xd4, 0xf4, 0xab, 0xb6, 0xbb, 0x77, 0xd7, 0xf3, 0xae, 0x64,
+	0x09, 0x4a, 0x59, 0x02, 0x4c, 0x4c, 0x70, 0x6c, 0xaf, 0x4b, 0x7d, 0x4c, 0xcc, 0xa4, 0x60, 0x93,
+	0x23, 0x68, 0xf3, 0xa5, 0xe2, 0x11, 0xf2, 0x38, 0x14, 0xca, 0xf4, 0x6b, 0xb6, 0xcb, 0xb7, 0xaf,
+	0x76, 0xe9, 0x04, 0x0b, 0x9e, 0xf2, 0xf4, 0x6c, 0x4c, 0xfd, 0x92, 0x71, 0xa6, 0x0c, 0x19, 0xc1,
+	0x9b, 0xab, 0x04, 0xd1, 0xc2, 0x60, 0x3a, 0x0f, 0xa7, 0x3c, 0x15, 0xaa, 0x5f, 0xdf, 0xad, 0x1e,
+	0xb4, 0xe8, 0x1b, 0xe5, 0xe1, 0xb1, 0x3d, 0x7b, 0x9a, 0x1f, 0xe5, 0x45, 0x35, 0xff, 0xf1, 0xb2,
+	0x68, 0xe3, 0x55, 0x8a, 0x96, 0x8c, 0xa2, 0xe8, 0x2a, 0xc1, 0x46, 0xd1, 0x1d, 0x57, 0xb4, 0x3c,
+	0x5c, 0x2b, 0x3a, 0x88, 0xa1, 0xbb, 0x29, 0x2e, 0x39, 0x84, 0x1a, 0x66, 0x8a, 0xdb, 0xf9, 0x76,
+	0x47, 0xfb, 0xdb, 0x26, 0xe3, 0x90, 0xcf, 0x18, 0x46, 0x33, 0x21, 0xa7, 0xcf, 0x33, 0xc5, 0xa9,
+	0xe5, 0x90, 0x5b, 0xd0, 0x58, 0xcd, 0xd5, 0x3b, 0x68, 0xd1, 0x62, 0xb7, 0xf7, 0x67, 0x1d, 0x1a,
+	0x4e, 0x67, 0x72, 0x02, 0xfe, 0xe5, 0x60, 0x73, 0x17, 0x55, 0x5f, 0xc1, 0x45, 0x8f, 0x2a, 0x7d,
+	0x8f, 0xae, 0xf3, 0xc8, 0x11, 0xf8, 0x92, 0xcd, 0x79, 0x68, 0xec, 0xde, 0xca, 0xea, 0x8f, 0xde,
+	0xf9, 0x77, 0x1b, 0x51, 0x90, 0xab, 0x35, 0x39, 0x82, 0xfa, 0x69, 0x6a, 0xd0, 0x14, 0x0e, 0xbc,
+	0xb7, 0x8d, 0xea, 0x5a, 0x0e, 0x2c, 0xee, 0x44, 0xa2, 0xce, 0x6c, 0x1f, 0x8e, 0x47, 0xde, 0x82,
+	0x56, 0x94, 0x08, 0x2e, 0x31, 0x14, 0xca, 0xda, 0xad, 0x4d, 0x9b, 0x2e, 0x70, 0xa6, 0xc8, 0x19,
+	0xb4, 0x0d, 0x32, 0x14, 0x51, 0x38, 0xb3, 0x45, 0x9c, 0x81, 0xf6, 0xff, 0xa3, 0xc8, 0x33, 0xa6,
+	0x94, 0x90, 0x53, 0xea, 0x3b, 0xae, 0xab, 0xd3, 0x83, 0x2a, 0xb2, 0x69, 0xbf, 0x61, 0x05, 0xcd,
+	0x97, 0xe4, 0x43, 0xa8, 0x47, 0x2c, 0x9a, 0xf1, 0xfe, 0xce, 0xf5, 0x27, 0xb8, 0xca, 0x9a, 0x03,
+	0x0a, 0x6b, 0x3b, 0x34, 0x39, 0x85, 0xee, 0xf9, 0x82, 0xeb, 0x2c, 0x34, 0xa8, 0x19, 0xf2, 0x69,
+	0xd6, 0x6f, 0xda, 0x11, 0xdf, 0xd9, 0xc6, 0xff, 0x26, 0x47, 0x4e, 0x0a, 0x20, 0xed, 0x9c, 0xaf,
+	0x6f, 0x07, 0xdf, 0x03, 0x5c, 0x6a, 0x92, 0x37, 0xf8, 0x13, 0xcf, 0xac, 0x5f, 0x5a, 0x34, 0x5f,
+	0x92, 0x8f, 0xa1, 0x7e, 0xc1, 0x92, 0x05, 0xb7, 0x2e, 0xf0, 0x47, 0x77, 0x6e, 0x98, 0xee, 0xd9,
+	0xf8, 0x6b, 0x5d, 0xbc, 0x68, 0x87, 0x3f, 0xac, 0x7c, 0xe2, 0x0d, 0x7e, 0xf5, 0xc0, 0x5f, 0x13,
+	0xe3, 0xff, 0xf0, 0x23, 0xe9, 0x42, 0xc5, 0x0e, 0xad, 0x7a, 0xd0, 0xa6, 0x15, 0xa1, 0xc8, 0x3d,
+	0xe8, 0x2a, 0x9d, 0x2e, 0xc5, 0xe5, 0x77, 0xa9, 0x66, 0xf1, 0x9d, 0x22, 0xea, 0x0a, 0xec, 0xfd,
+	0xe5, 0x81, 0xbf, 0x26, 0x2c, 0xb9, 0x0d, 0xbe, 0xf5, 0x5f, 0x68, 0x90, 0x25, 0xae, 0xc3, 0x26,
+	0x05, 0x1b, 0x9a, 0xe4, 0x91, 0xdc, 0x23, 0xf6, 0x28, 0x44, 0x4c, 0x6c, 0x0b, 0x1d, 0xda, 0xb4,
+	0x81, 0xe7, 0x98, 0x90, 0x01, 0x34, 0x95, 0xe6, 0x2f, 0x39, 0x46, 0x33, 0xeb, 0x9f, 0x26, 0x5d,
+	0xed, 0xc9, 0x5d, 0xe8, 0x94, 0xeb, 0x70, 0x26, 0xac, 0x81, 0x72, 0x72, 0xbb, 0x0c, 0x9e, 0x0a,
+	0x34, 0x79, 0xf9, 0x39, 0x5b, 0x86, 0x5c, 0xa2, 0x16, 0xdc, 0xf4, 0xeb, 0x16, 0x02, 0x73, 0xb6,
+	0x3c, 0x71, 0x91, 0x3c, 0x8b, 0x91, 0x4c, 0x99, 0x59, 0x8a, 0xa1, 0x62, 0x38, 0x2b, 0x4c, 0xd4,
+	0x2e, 0x83, 0x63, 0x86, 0xb3, 0xbd, 0xbf, 0x3d, 0xe8, 0xd8, 0x4b, 0x4d, 0x8a, 0x28, 0xf9, 0x0c,
+	0xea, 0x79, 0xce, 0xac, 0x78, 0x9c, 0xf7, 0x6f, 0xf4, 0x57, 0xc9, 0x08, 0xac, 0x11, 0xa8, 0x63,
+	0x0d, 0x7e, 0xf3, 0xa0, 0x6e, 0x03, 0x6b, 0xf2, 0x7b, 0x1b, 0xf2, 0x13, 0xa8, 0x09, 0x75, 0xf1,
+	0xd0, 0x2a, 0xd2, 0xa4, 0x76, 0x5d, 0xc4, 0x3e, 0x2a, 0x94, 0xb0, 0xeb, 0x62, 0x4c, 0xb5, 0xd5,
+	0x98, 0x6e, 0x41, 0x83, 0x2f, 0x95, 0xd0, 0xdc, 0xde, 0xb5, 0x4a, 0x8b, 0x9d, 0x7d, 0x22, 0x98,
+	0xd8, 0xdb, 0x75, 0x68, 0xbe, 0xcc, 0x91, 0xc5, 0x97, 0x61, 0xc7, 0x55, 0x76, 0xbb, 0x07, 0x27,
+	0x40, 0xae, 0x9b, 0x85, 0x34, 0xa1, 0xf6, 0x64, 0x91, 0x24, 0xbd, 0xd7, 0x48, 0x07, 0x5a, 0x93,
+	0xc5, 0x0b, 0xd7, 0x66, 0xcf, 0x23, 0x3e, 0xec, 0x7c, 0xc1, 0xb3, 0x9f, 0x53, 0x1d, 0xf7, 0x2a,
+	0xa4, 0x05, 0x75, 0xca, 0xa7, 0x7c, 0xd9, 0xab, 0x3e, 0x38, 0x84, 0xce, 0xc6, 0x03, 0x21, 0x5d,
+	0x80, 0x09, 0x3f, 0x5f, 0x70, 0x89, 0x82, 0xe5, 0x79, 0xda, 0xd0, 0x1c, 0x33, 0xcd, 0x92, 0x84,
+	0x27, 0x2e, 0xcd, 0x13, 0x66, 0x90, 0x1b, 0xec, 0x55, 0x1e, 0x3d, 0x84, 0x5b, 0x51, 0x3a, 0xdf,
+	0xa2, 0xe9, 0xd8, 0xfb, 0xae, 0x1a, 0x4b, 0xf3, 0x7b, 0x85, 0x7c, 0x3b, 0xa2, 0x2c, 0x0b, 0x8e,
+	0xf3, 0xb3, 0xcf, 0x95, 0x0a, 0x1e, 0x4b, 0xf3, 0xa2, 0x61, 0x7f, 0xc7, 0x1f, 0xfc, 0x33, 0x00,
+	0x82, 0x40, 0x2e, 0x2f, 0x7b, 0x08, 0x00, 0x00,
 }
@@ -0,0 +1,362 @@
+// +build !confonly
+
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/errors"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/signal/pubsub"
+	dns_feature "v2ray.com/core/features/dns"
+)
+
+type record struct {
+	A    *IPRecord
+	AAAA *IPRecord
+}
+
+type IPRecord struct {
+	IP     []net.Address
+	Expire time.Time
+	RCode  dnsmessage.RCode
+}
+
+func (r *IPRecord) getIPs() ([]net.Address, error) {
+	if r == nil || r.Expire.Before(time.Now()) {
+		return nil, errRecordNotFound
+	}
+	if r.RCode != dnsmessage.RCodeSuccess {
+		return nil, dns_feature.RCodeError(r.RCode)
+	}
+	return r.IP, nil
+}
+
+var (
+	errRecordNotFound = errors.New("record not found")
+)
+
+func isNewer(baseRec *IPRecord, newRec *IPRecord) bool {
+	if newRec == nil {
+		return false
+	}
+	if baseRec == nil {
+		return true
+	}
+	return baseRec.Expire.Before(newRec.Expire)
+}
+
+// ipCache keeps the answers of a name server until their TTL expires.
+type ipCache struct {
+	sync.RWMutex
+	ips map[string]record
+	pub *pubsub.Service
+}
+
+func newIPCache() *ipCache {
+	return &ipCache{
+		ips: make(map[string]record),
+		pub: pubsub.NewService(),
+	}
+}
+
+// update stores the new records of the domain if they expire later than the
+// cached ones, and wakes up the queries waiting for the domain.
+func (c *ipCache) update(domain string, newRec record) {
+	c.Lock()
+	defer c.Unlock()
+
+	newError("updating IP records for domain:", domain).AtDebug().WriteToLog()
+	rec := c.ips[domain]
+
+	updated := false
+	if isNewer(rec.A, newRec.A) {
+		rec.A = newRec.A
+		updated = true
+	}
+	if isNewer(rec.AAAA, newRec.AAAA) {
+		rec.AAAA = newRec.AAAA
+		updated = true
+	}
+
+	if updated {
+		c.ips[domain] = rec
+		c.pub.Publish(domain, nil)
+	}
+}
+
+// cleanup removes expired records, and returns the number of domains left.
+func (c *ipCache) cleanup(now time.Time) int {
+	c.Lock()
+	defer c.Unlock()
+
+	for domain, record := range c.ips {
+		if record.A != nil && record.A.Expire.Before(now) {
+			record.A = nil
+		}
+		if record.AAAA != nil && record.AAAA.Expire.Before(now) {
+			record.AAAA = nil
+		}
+
+		if record.A == nil && record.AAAA == nil {
+			delete(c.ips, domain)
+		} else {
+			c.ips[domain] = record
+		}
+	}
+
+	if len(c.ips) == 0 {
+		c.ips = make(map[string]record)
+	}
+
+	return len(c.ips)
+}
+
+// invalidate removes the records of the domain, so that they are queried again.
+func (c *ipCache) invalidate(domain string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.ips, domain)
+}
+
+// find returns the cached IPs of the domain, and the time when the earliest of
+// their records expires.
+func (c *ipCache) find(domain string, option IPOption) ([]net.IP, time.Time, error) {
+	c.RLock()
+	record, found := c.ips[domain]
+	c.RUnlock()
+
+	if !found {
+		return nil, time.Time{}, errRecordNotFound
+	}
+
+	var ips []net.Address
+	var expire time.Time
+	var lastErr error
+	collect := func(r *IPRecord) {
+		addrs, err := r.getIPs()
+		if err != nil {
+			lastErr = err
+			return
+		}
+		if len(addrs) > 0 && (expire.IsZero() || r.Expire.Before(expire)) {
+			expire = r.Expire
+		}
+		ips = append(ips, addrs...)
+	}
+	if option.IPv4Enable {
+		collect(record.A)
+	}
+	if option.IPv6Enable {
+		collect(record.AAAA)
+	}
+
+	if len(ips) > 0 {
+		return toNetIP(ips), expire, nil
+	}
+
+	if lastErr != nil {
+		return nil, time.Time{}, lastErr
+	}
+
+	return nil, time.Time{}, dns_feature.ErrEmptyResponse
+}
+
+// cachedRecordTag tags the query records of answers that didn't go over the network.
+const cachedRecordTag = "cached"
+
+// lookup returns the cached IPs of the domain and when they expire. If they are
+// not cached, or ctx is a refresh, it calls query and waits until the answer
+// arrives or ctx is done.
+func (c *ipCache) lookup(ctx context.Context, domain string, option IPOption, query func()) ([]net.IP, time.Time, error) {
+	if isRefresh(ctx) {
+		c.invalidate(domain)
+	}
+
+	ips, expire, err := c.find(domain, option)
+	if err != errRecordNotFound {
+		if record := session.ProxyRecordFromContext(ctx); record != nil {
+			record.Tag = cachedRecordTag
+		}
+		return ips, expire, err
+	}
+
+	sub := c.pub.Subscribe(domain)
+	defer sub.Close()
+
+	query()
+
+	for {
+		ips, expire, err := c.find(domain, option)
+		if err != errRecordNotFound {
+			return ips, expire, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, time.Time{}, ctx.Err()
+		case <-sub.Wait():
+		}
+	}
+}
+
+// genEDNS0Options returns the EDNS0 client subnet option for the client IP, or
+// nil if the client IP is not set.
+func genEDNS0Options(clientIP net.IP) *dnsmessage.Resource {
+	if len(clientIP) == 0 {
+		return nil
+	}
+
+	var netmask int
+	var family uint16
+
+	if len(clientIP) == 4 {
+		family = 1
+		netmask = 24 // 24 for IPV4, 96 for IPv6
+	} else {
+		family = 2
+		netmask = 96
+	}
+
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint16(b[0:], family)
+	b[2] = byte(netmask)
+	b[3] = 0
+	switch family {
+	case 1:
+		ip := clientIP.To4().Mask(net.CIDRMask(netmask, net.IPv4len*8))
+		needLength := (netmask + 8 - 1) / 8 // division rounding up
+		b = append(b, ip[:needLength]...)
+	case 2:
+		ip := clientIP.Mask(net.CIDRMask(netmask, net.IPv6len*8))
+		needLength := (netmask + 8 - 1) / 8 // division rounding up
+		b = append(b, ip[:needLength]...)
+	}
+
+	const EDNS0SUBNET = 0x08
+
+	opt := new(dnsmessage.Resource)
+	common.Must(opt.Header.SetEDNS0(1350, 0xfe00, true))
+
+	opt.Body = &dnsmessage.OPTResource{
+		Options: []dnsmessage.Option{
+			{
+				Code: EDNS0SUBNET,
+				Data: b,
+			},
+		},
+	}
+
+	return opt
+}
+
+// buildReqMsgs builds the A and AAAA queries for the domain as enabled in option.
+// reqIDGen is called with the type of each query to assign its ID.
+func buildReqMsgs(domain string, option IPOption, reqIDGen func(dnsmessage.Type) uint16, reqOpts *dnsmessage.Resource) []*dnsmessage.Message {
+	var msgs []*dnsmessage.Message
+
+	addMsg := func(recType dnsmessage.Type) {
+		msg := new(dnsmessage.Message)
+		msg.Header.ID = reqIDGen(recType)
+		msg.Header.RecursionDesired = true
+		msg.Questions = []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(domain),
+			Type:  recType,
+			Class: dnsmessage.ClassINET,
+		}}
+		if reqOpts != nil {
+			msg.Additionals = append(msg.Additionals, *reqOpts)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if option.IPv4Enable {
+		addMsg(dnsmessage.TypeA)
+	}
+	if option.IPv6Enable {
+		addMsg(dnsmessage.TypeAAAA)
+	}
+
+	return msgs
+}
+
+// parseResponse parses a DNS response. Only answers of recType are collected
+// into the returned IPRecord, and its expiration is the smallest TTL among all
+// answers.
+func parseResponse(parser *dnsmessage.Parser, header dnsmessage.Header, domain string, recType dnsmessage.Type) *IPRecord {
+	now := time.Now()
+	ipRecord := &IPRecord{
+		RCode:  header.RCode,
+		Expire: now.Add(time.Second * 600),
+	}
+
+L:
+	for {
+		header, err := parser.AnswerHeader()
+		if err != nil {
+			if err != dnsmessage.ErrSectionDone {
+				newError("failed to parse answer section for domain: ", domain).Base(err).WriteToLog()
+			}
+			break
+		}
+		ttl := header.TTL
+		if ttl == 0 {
+			ttl = 600
+		}
+		expire := now.Add(time.Duration(ttl) * time.Second)
+		if ipRecord.Expire.After(expire) {
+			ipRecord.Expire = expire
+		}
+
+		if header.Type != recType {
+			if err := parser.SkipAnswer(); err != nil {
+				newError("failed to skip answer").Base(err).WriteToLog()
+				break L
+			}
+			continue
+		}
+
+		switch header.Type {
+		case dnsmessage.TypeA:
+			ans, err := parser.AResource()
+			if err != nil {
+				newError("failed to parse A record for domain: ", domain).Base(err).WriteToLog()
+				break L
+			}
+			ipRecord.IP = append(ipRecord.IP, net.IPAddress(ans.A[:]))
+		case dnsmessage.TypeAAAA:
+			ans, err := parser.AAAAResource()
+			if err != nil {
+				newError("failed to parse A record for domain: ", domain).Base(err).WriteToLog()
+				break L
+			}
+			ipRecord.IP = append(ipRecord.IP, net.IPAddress(ans.AAAA[:]))
+		default:
+			if err := parser.SkipAnswer(); err != nil {
+				newError("failed to skip answer").Base(err).WriteToLog()
+				break L
+			}
+		}
+	}
+
+	return ipRecord
+}
+
+// recordOf puts the IPRecord into the field for its type.
+func recordOf(recType dnsmessage.Type, ipRecord *IPRecord) record {
+	var rec record
+	switch recType {
+	case dnsmessage.TypeA:
+		rec.A = ipRecord
+	case dnsmessage.TypeAAAA:
+		rec.AAAA = ipRecord
+	}
+	return rec
+}
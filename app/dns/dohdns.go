@@ -0,0 +1,197 @@
+// +build !confonly
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol/dns"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/task"
+	"v2ray.com/core/features/routing"
+	"v2ray.com/core/transport/internet/tls"
+)
+
+// DoHNameServer implements DNS over HTTPS (RFC 8484) with the wire format.
+// Queries are sent over HTTP/2 connections that are dispatched by the
+// routing.Dispatcher, so they follow the routing rules like other traffic.
+type DoHNameServer struct {
+	url        *url.URL
+	dispatcher routing.Dispatcher
+	cache      *ipCache
+	cleanup    *task.Periodic
+	clientIP   net.IP
+	httpClient *http.Client
+}
+
+// NewDoHNameServer creates a DoHNameServer that queries the given https URL.
+// The server certificate is verified against the system roots, unless tlsConfig says otherwise.
+func NewDoHNameServer(u *url.URL, dispatcher routing.Dispatcher, tlsConfig *tls.Config, clientIP net.IP) *DoHNameServer {
+	s := &DoHNameServer{
+		url:        u,
+		dispatcher: dispatcher,
+		cache:      newIPCache(),
+		clientIP:   clientIP,
+	}
+	s.cleanup = &task.Periodic{
+		Interval: time.Minute,
+		Execute:  s.Cleanup,
+	}
+	transport := &http.Transport{
+		MaxIdleConns:        30,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 30 * time.Second,
+		ForceAttemptHTTP2:   true,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return s.dial(ctx, network, addr)
+		},
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig.GetTLSConfig(tls.WithNextProto("h2"))
+	}
+	s.httpClient = &http.Client{
+		Transport: transport,
+	}
+	return s
+}
+
+// Name implements Client.
+func (s *DoHNameServer) Name() string {
+	return s.url.String()
+}
+
+// Cleanup removes expired records from the cache.
+func (s *DoHNameServer) Cleanup() error {
+	if s.cache.cleanup(time.Now()) == 0 {
+		return newError("nothing to do. stopping...")
+	}
+	return nil
+}
+
+// dial opens a connection to the DoH server through the dispatcher. The
+// connection is shared by later queries, so it must not be bound to the
+// context of the query that opens it.
+func (s *DoHNameServer) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	dest, err := net.ParseDestination(network + ":" + addr)
+	if err != nil {
+		return nil, err
+	}
+
+	dnsCtx := context.Background()
+	inbound := &session.Inbound{
+		NoSource: true,
+	}
+	if original := session.InboundFromContext(ctx); original != nil {
+		inbound.Tag = original.Tag
+	}
+	dnsCtx = session.ContextWithInbound(dnsCtx, inbound)
+	dnsCtx = session.ContextWithContent(dnsCtx, &session.Content{
+		Protocol:    "tls",
+		Application: []string{"[Internal DNS]"},
+		Network:     "tcp",
+		Extra:       s.url.String(),
+	})
+
+	link, err := s.dispatcher.Dispatch(dnsCtx, dest)
+	if err != nil {
+		return nil, err
+	}
+	return net.NewConnection(
+		net.ConnectionInputMulti(link.Writer),
+		net.ConnectionOutputMulti(link.Reader),
+	), nil
+}
+
+func (s *DoHNameServer) sendQuery(ctx context.Context, domain string, option IPOption) {
+	newError("querying DNS for: ", domain, " at ", s.url).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+
+	// The ID is always 0 as recommended by RFC 8484, to be friendly to HTTP caches.
+	msgs := buildReqMsgs(domain, option, func(dnsmessage.Type) uint16 {
+		return 0
+	}, genEDNS0Options(s.clientIP))
+
+	for _, msg := range msgs {
+		go func(msg *dnsmessage.Message) {
+			// Keep the inbound tag for dispatching, but not the deadline of the caller,
+			// so that the answer is cached even if the caller gives up.
+			dnsCtx := context.Background()
+			if inbound := session.InboundFromContext(ctx); inbound != nil {
+				dnsCtx = session.ContextWithInbound(dnsCtx, inbound)
+			}
+			dnsCtx, cancel := context.WithTimeout(dnsCtx, 8*time.Second)
+			defer cancel()
+
+			recType := msg.Questions[0].Type
+			b, err := dns.PackMessage(msg)
+			if err != nil {
+				newError("failed to pack DNS query").Base(err).AtError().WriteToLog()
+				return
+			}
+			resp, err := s.post(dnsCtx, b.Bytes())
+			b.Release()
+			if err != nil {
+				newError("failed to query ", recType, " records of ", domain, " at ", s.url).Base(err).AtWarning().WriteToLog()
+				return
+			}
+
+			var parser dnsmessage.Parser
+			header, err := parser.Start(resp)
+			if err != nil {
+				newError("failed to parse DNS response").Base(err).AtWarning().WriteToLog()
+				return
+			}
+			if err := parser.SkipAllQuestions(); err != nil {
+				newError("failed to skip questions in DNS response").Base(err).AtWarning().WriteToLog()
+				return
+			}
+
+			ipRecord := parseResponse(&parser, header, domain, recType)
+			s.cache.update(domain, recordOf(recType, ipRecord))
+			common.Must(s.cleanup.Start())
+		}(msg)
+	}
+}
+
+func (s *DoHNameServer) post(ctx context.Context, b []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", s.url.String(), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/dns-message")
+	req.Header.Set("Content-Type", "application/dns-message")
+
+	resp, err := s.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, resp.Body) // nolint: errcheck
+		return nil, newError("DoH server returned status ", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// QueryIP implements Client.
+func (s *DoHNameServer) QueryIP(ctx context.Context, domain string, option IPOption) ([]net.IP, error) {
+	ips, _, err := s.QueryIPWithExpire(ctx, domain, option)
+	return ips, err
+}
+
+// QueryIPWithExpire implements ExpiringClient.
+func (s *DoHNameServer) QueryIPWithExpire(ctx context.Context, domain string, option IPOption) ([]net.IP, time.Time, error) {
+	fqdn := Fqdn(domain)
+	return s.cache.lookup(ctx, fqdn, option, func() {
+		s.sendQuery(ctx, fqdn, option)
+	})
+}
@@ -0,0 +1,147 @@
+package dns_test
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/dispatcher"
+	. "v2ray.com/core/app/dns"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	feature_dns "v2ray.com/core/features/dns"
+	"v2ray.com/core/proxy/freedom"
+	_ "v2ray.com/core/transport/internet/tcp"
+	"v2ray.com/core/transport/internet/tls"
+)
+
+type dohHandler struct {
+	t *testing.T
+}
+
+func (h *dohHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" || r.Header.Get("Content-Type") != "application/dns-message" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if r.ProtoMajor != 2 {
+		h.t.Error("expect HTTP/2, but got ", r.Proto)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	common.Must(err)
+	req := new(dns.Msg)
+	common.Must(req.Unpack(body))
+	if req.Id != 0 {
+		h.t.Error("expect query ID 0, but got ", req.Id)
+	}
+
+	ans := new(dns.Msg)
+	ans.SetReply(req)
+	for _, q := range req.Question {
+		if q.Name == "google.com." && q.Qtype == dns.TypeA {
+			rr, err := dns.NewRR("google.com. 300 IN A 8.8.8.8")
+			common.Must(err)
+			ans.Answer = append(ans.Answer, rr)
+		} else if q.Name == "ipv6.google.com." && q.Qtype == dns.TypeAAAA {
+			rr, err := dns.NewRR("ipv6.google.com. 300 IN AAAA 2001:4860:4860::8888")
+			common.Must(err)
+			ans.Answer = append(ans.Answer, rr)
+		} else if q.Name == "notexist.google.com." {
+			ans.Rcode = dns.RcodeNameError
+		}
+	}
+	b, err := ans.Pack()
+	common.Must(err)
+	w.Header().Set("Content-Type", "application/dns-message")
+	w.Write(b) // nolint: errcheck
+}
+
+func TestDoHNameServer(t *testing.T) {
+	server := httptest.NewUnstartedServer(&dohHandler{t: t})
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: net.NewIPOrDomain(net.DomainAddress(server.URL + "/dns-query")),
+							Port:    53,
+						},
+						// Trust the certificate of the test server, which is valid for 127.0.0.1.
+						TlsSettings: &tls.Config{
+							DisableSystemRoot: true,
+							Certificate: []*tls.Certificate{
+								{
+									Certificate: pem.EncodeToMemory(&pem.Block{
+										Type:  "CERTIFICATE",
+										Bytes: server.Certificate().Raw,
+									}),
+									Usage: tls.Certificate_AUTHORITY_VERIFY,
+								},
+							},
+						},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+
+	{
+		ips, err := client.LookupIP("google.com")
+		if err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+		if r := cmp.Diff(ips, []net.IP{{8, 8, 8, 8}}); r != "" {
+			t.Fatal(r)
+		}
+	}
+
+	{
+		ips, err := client.LookupIP("ipv6.google.com")
+		if err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+		if r := cmp.Diff(ips, []net.IP{{0x20, 0x01, 0x48, 0x60, 0x48, 0x60, 0, 0, 0, 0, 0, 0, 0, 0, 0x88, 0x88}}); r != "" {
+			t.Fatal(r)
+		}
+	}
+
+	{
+		_, err := client.LookupIP("notexist.google.com")
+		if err == nil {
+			t.Fatal("nil error")
+		}
+		if r := feature_dns.RCodeFromError(err); r != uint16(dns.RcodeNameError) {
+			t.Fatal("expected NameError, but got ", r)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package fakedns
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type FakeDnsPool struct {
+	// Reserved IP range in CIDR notation, such as 198.18.0.0/15 or fc00::/18.
+	IpPool string `protobuf:"bytes,1,opt,name=ip_pool,json=ipPool,proto3" json:"ip_pool,omitempty"`
+	// Number of domains to keep mappings for. When it is reached, the least
+	// recently used mapping is dropped. Default to 65535.
+	LruSize              int64    `protobuf:"varint,2,opt,name=lru_size,json=lruSize,proto3" json:"lru_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FakeDnsPool) Reset()         { *m = FakeDnsPool{} }
+func (m *FakeDnsPool) String() string { return proto.CompactTextString(m) }
+func (*FakeDnsPool) ProtoMessage()    {}
+func (*FakeDnsPool) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aa68e44a1dafb913, []int{0}
+}
+
+func (m *FakeDnsPool) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FakeDnsPool.Unmarshal(m, b)
+}
+func (m *FakeDnsPool) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FakeDnsPool.Marshal(b, m, deterministic)
+}
+func (m *FakeDnsPool) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FakeDnsPool.Merge(m, src)
+}
+func (m *FakeDnsPool) XXX_Size() int {
+	return xxx_messageInfo_FakeDnsPool.Size(m)
+}
+func (m *FakeDnsPool) XXX_DiscardUnknown() {
+	xxx_messageInfo_FakeDnsPool.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FakeDnsPool proto.InternalMessageInfo
+
+func (m *FakeDnsPool) GetIpPool() string {
+	if m != nil {
+		return m.IpPool
+	}
+	return ""
+}
+
+func (m *FakeDnsPool) GetLruSize() int64 {
+	if m != nil {
+		return m.LruSize
+	}
+	return 0
+}
+
+type Config struct {
+	Pools                []*FakeDnsPool `protobuf:"bytes,1,rep,name=pools,proto3" json:"pools,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *Config) Reset()         { *m = Config{} }
+func (m *Config) String() string { return proto.CompactTextString(m) }
+func (*Config) ProtoMessage()    {}
+func (*Config) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aa68e44a1dafb913, []int{1}
+}
+
+func (m *Config) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Config.Unmarshal(m, b)
+}
+func (m *Config) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Config.Marshal(b, m, deterministic)
+}
+func (m *Config) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Config.Merge(m, src)
+}
+func (m *Config) XXX_Size() int {
+	return xxx_messageInfo_Config.Size(m)
+}
+func (m *Config) XXX_DiscardUnknown() {
+	xxx_messageInfo_Config.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Config proto.InternalMessageInfo
+
+func (m *Config) GetPools() []*FakeDnsPool {
+	if m != nil {
+		return m.Pools
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*FakeDnsPool)(nil), "v2ray.core.app.dns.fakedns.FakeDnsPool")
+	proto.RegisterType((*Config)(nil), "v2ray.core.app.dns.fakedns.Config")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/app/dns/fakedns/config.proto", fileDescriptor_aa68e44a1dafb913)
+}
+
+var fileDescriptor_aa68e44a1dafb913 = []byte{
+	// 219 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xd2, 0x2e, 0x33, 0x2a, 0x4a,
+	0xac, 0xd4, 0x4b, 0xce, 0xcf, 0xd5, 0x4f, 0xce, 0x2f, 0x4a, 0xd5, 0x4f, 0x2c, 0x28, 0xd0, 0x4f,
+	0xc9, 0x2b, 0xd6, 0x4f, 0x4b, 0xcc, 0x4e, 0x05, 0xd1, 0xc9, 0xf9, 0x79, 0x69, 0x99, 0xe9, 0x7a,
+	0x05, 0x45, 0xf9, 0x25, 0xf9, 0x42, 0x52, 0x30, 0xc5, 0x45, 0xa9, 0x7a, 0x89, 0x05, 0x05, 0x7a,
+	0x29, 0x79, 0xc5, 0x7a, 0x50, 0x85, 0x4a, 0x8e, 0x5c, 0xdc, 0x6e, 0x89, 0xd9, 0xa9, 0x2e, 0x79,
+	0xc5, 0x01, 0xf9, 0xf9, 0x39, 0x42, 0xe2, 0x5c, 0xec, 0x99, 0x05, 0xf1, 0x05, 0xf9, 0xf9, 0x39,
+	0x12, 0x8c, 0x0a, 0x8c, 0x1a, 0x9c, 0x41, 0x6c, 0x99, 0x05, 0x60, 0x09, 0x49, 0x2e, 0x8e, 0x9c,
+	0xa2, 0xd2, 0xf8, 0xe2, 0xcc, 0xaa, 0x54, 0x09, 0x26, 0x05, 0x46, 0x0d, 0xe6, 0x20, 0xf6, 0x9c,
+	0xa2, 0xd2, 0xe0, 0xcc, 0xaa, 0x54, 0x25, 0x77, 0x2e, 0x36, 0x67, 0xb0, 0x75, 0x42, 0xb6, 0x5c,
+	0xac, 0x20, 0xad, 0xc5, 0x12, 0x8c, 0x0a, 0xcc, 0x1a, 0xdc, 0x46, 0xea, 0x7a, 0xb8, 0x2d, 0xd6,
+	0x43, 0xb2, 0x35, 0x08, 0xa2, 0xcb, 0xc9, 0x83, 0x4b, 0x2e, 0x39, 0x3f, 0x17, 0x8f, 0xa6, 0x00,
+	0xc6, 0x28, 0x76, 0x28, 0x73, 0x15, 0x93, 0x54, 0x98, 0x51, 0x50, 0x62, 0xa5, 0x9e, 0x33, 0x48,
+	0x9d, 0x63, 0x41, 0x81, 0x9e, 0x0b, 0xd4, 0xd0, 0x94, 0xbc, 0xe2, 0x24, 0x36, 0xb0, 0xc7, 0x8d,
+	0x01, 0x03, 0x00, 0x5b, 0xe5, 0xfd, 0xcd, 0x27, 0x01, 0x00, 0x00,
+}
@@ -0,0 +1,218 @@
+// +build !confonly
+
+package fakedns
+
+//go:generate errorgen
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/features/dns"
+)
+
+const defaultLRUSize = 65535
+
+type mapping struct {
+	domain string
+	ip     net.Address
+}
+
+// pool hands out addresses of one IP range. The mappings are kept in an LRU
+// list, so an address is only reused after its domain is evicted.
+type pool struct {
+	ipRange *net.IPNet
+	base    *big.Int
+	size    uint64
+	next    uint64
+
+	capacity int
+	order    *list.List // of *mapping, the most recently used first
+	domains  map[string]*list.Element
+	ips      map[string]*list.Element
+}
+
+func newPool(config *FakeDnsPool) (*pool, error) {
+	_, ipRange, err := net.ParseCIDR(config.IpPool)
+	if err != nil {
+		return nil, newError("invalid fake DNS pool: ", config.IpPool).Base(err)
+	}
+	ones, bits := ipRange.Mask.Size()
+	size := uint64(math.MaxUint64)
+	if bits-ones < 64 {
+		size = uint64(1) << uint(bits-ones)
+	}
+	// The first address of the range is never handed out.
+	if size < 2 {
+		return nil, newError("fake DNS pool is too small: ", config.IpPool)
+	}
+
+	capacity := int(config.LruSize)
+	if capacity <= 0 {
+		capacity = defaultLRUSize
+	}
+	if uint64(capacity) > size-1 {
+		capacity = int(size - 1)
+	}
+
+	return &pool{
+		ipRange:  ipRange,
+		base:     new(big.Int).SetBytes(ipRange.IP),
+		size:     size,
+		capacity: capacity,
+		order:    list.New(),
+		domains:  make(map[string]*list.Element),
+		ips:      make(map[string]*list.Element),
+	}, nil
+}
+
+func (p *pool) addressAt(offset uint64) net.Address {
+	n := new(big.Int).Add(p.base, new(big.Int).SetUint64(offset))
+	b := n.Bytes()
+	ip := make([]byte, len(p.ipRange.IP))
+	copy(ip[len(ip)-len(b):], b)
+	return net.IPAddress(ip)
+}
+
+func (p *pool) get(domain string) net.Address {
+	if e, found := p.domains[domain]; found {
+		p.order.MoveToFront(e)
+		return e.Value.(*mapping).ip
+	}
+
+	if p.order.Len() >= p.capacity {
+		oldest := p.order.Back()
+		m := p.order.Remove(oldest).(*mapping)
+		delete(p.domains, m.domain)
+		delete(p.ips, m.ip.String())
+	}
+
+	// There is always a free address, as the capacity is less than the size of the pool.
+	var ip net.Address
+	for {
+		p.next++
+		offset := p.next % p.size
+		if offset == 0 {
+			continue
+		}
+		ip = p.addressAt(offset)
+		if _, used := p.ips[ip.String()]; !used {
+			break
+		}
+	}
+
+	e := p.order.PushFront(&mapping{domain: domain, ip: ip})
+	p.domains[domain] = e
+	p.ips[ip.String()] = e
+	return ip
+}
+
+func (p *pool) lookup(ip net.Address) string {
+	e, found := p.ips[ip.String()]
+	if !found {
+		return ""
+	}
+	p.order.MoveToFront(e)
+	return e.Value.(*mapping).domain
+}
+
+func (p *pool) contains(ip net.Address) bool {
+	return p.ipRange.Contains(ip.IP())
+}
+
+// Holder is an implementation of dns.FakeDNSEngine.
+type Holder struct {
+	access sync.Mutex
+	pools  []*pool
+}
+
+// NewHolder creates a Holder with the pools in the config.
+func NewHolder(ctx context.Context, config *Config) (*Holder, error) {
+	h := new(Holder)
+	for _, pc := range config.Pools {
+		p, err := newPool(pc)
+		if err != nil {
+			return nil, err
+		}
+		h.pools = append(h.pools, p)
+	}
+	if len(h.pools) == 0 {
+		return nil, newError("no fake DNS pool")
+	}
+	return h, nil
+}
+
+// Type implements common.HasType.
+func (*Holder) Type() interface{} {
+	return dns.FakeDNSEngineType()
+}
+
+// Start implements common.Runnable.
+func (*Holder) Start() error {
+	return nil
+}
+
+// Close implements common.Closable.
+func (*Holder) Close() error {
+	return nil
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(domain, "."))
+}
+
+// GetFakeIPForDomain implements dns.FakeDNSEngine.
+func (h *Holder) GetFakeIPForDomain(domain string) []net.Address {
+	domain = normalizeDomain(domain)
+
+	h.access.Lock()
+	defer h.access.Unlock()
+
+	ips := make([]net.Address, 0, len(h.pools))
+	for _, p := range h.pools {
+		ips = append(ips, p.get(domain))
+	}
+	return ips
+}
+
+// GetDomainFromFakeDNS implements dns.FakeDNSEngine.
+func (h *Holder) GetDomainFromFakeDNS(ip net.Address) string {
+	if !ip.Family().IsIP() {
+		return ""
+	}
+
+	h.access.Lock()
+	defer h.access.Unlock()
+
+	for _, p := range h.pools {
+		if p.contains(ip) {
+			return p.lookup(ip)
+		}
+	}
+	return ""
+}
+
+// IsIPInIPPool implements dns.FakeDNSEngine.
+func (h *Holder) IsIPInIPPool(ip net.Address) bool {
+	if !ip.Family().IsIP() {
+		return false
+	}
+	for _, p := range h.pools {
+		if p.contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return NewHolder(ctx, config.(*Config))
+	}))
+}
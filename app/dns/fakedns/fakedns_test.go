@@ -0,0 +1,96 @@
+package fakedns_test
+
+import (
+	"context"
+	"testing"
+
+	. "v2ray.com/core/app/dns/fakedns"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+)
+
+func TestFakeDNS(t *testing.T) {
+	h, err := NewHolder(context.Background(), &Config{
+		Pools: []*FakeDnsPool{
+			{
+				IpPool:  "198.18.0.0/15",
+				LruSize: 3,
+			},
+			{
+				IpPool:  "fc00::/18",
+				LruSize: 3,
+			},
+		},
+	})
+	common.Must(err)
+
+	ips := h.GetFakeIPForDomain("v2ray.com.")
+	if len(ips) != 2 {
+		t.Fatal("expect one IP from each pool, but got ", ips)
+	}
+	if ips[0].String() != "198.18.0.1" || !ips[1].Family().IsIPv6() {
+		t.Error("unexpected fake IPs: ", ips)
+	}
+	for _, ip := range ips {
+		if !h.IsIPInIPPool(ip) {
+			t.Error("expect ", ip, " in pool")
+		}
+		if domain := h.GetDomainFromFakeDNS(ip); domain != "v2ray.com" {
+			t.Error("expect v2ray.com, but got ", domain)
+		}
+	}
+
+	if again := h.GetFakeIPForDomain("V2Ray.com"); again[0] != ips[0] || again[1] != ips[1] {
+		t.Error("expect the same IPs for the same domain, but got ", again)
+	}
+
+	if h.IsIPInIPPool(net.ParseAddress("8.8.8.8")) {
+		t.Error("8.8.8.8 is not in pool")
+	}
+	if domain := h.GetDomainFromFakeDNS(net.ParseAddress("198.18.100.100")); domain != "" {
+		t.Error("expect unmapped IP, but got ", domain)
+	}
+
+	// The least recently used domain is dropped when the pool is full.
+	h.GetFakeIPForDomain("example.com")
+	h.GetDomainFromFakeDNS(ips[0])
+	h.GetFakeIPForDomain("example.org")
+	h.GetFakeIPForDomain("example.net")
+	if domain := h.GetDomainFromFakeDNS(ips[0]); domain != "v2ray.com" {
+		t.Error("expect recently used v2ray.com to be kept, but got ", domain)
+	}
+	if ip := h.GetFakeIPForDomain("example.com")[0]; ip.String() == "198.18.0.2" {
+		t.Error("expect example.com to be evicted and get a new IP")
+	}
+}
+
+func TestFakeDNSSmallPool(t *testing.T) {
+	h, err := NewHolder(context.Background(), &Config{
+		Pools: []*FakeDnsPool{
+			{
+				IpPool: "10.0.0.0/30",
+			},
+		},
+	})
+	common.Must(err)
+
+	seen := make(map[string]string)
+	for _, domain := range []string{"a.com", "b.com", "c.com", "d.com", "e.com"} {
+		ip := h.GetFakeIPForDomain(domain)[0]
+		if ip.String() == "10.0.0.0" {
+			t.Error("the network address must not be handed out")
+		}
+		seen[domain] = ip.String()
+	}
+	for _, domain := range []string{"c.com", "d.com", "e.com"} {
+		if got := h.GetDomainFromFakeDNS(net.ParseAddress(seen[domain])); got != domain {
+			t.Error("expect ", domain, " for ", seen[domain], ", but got ", got)
+		}
+	}
+
+	if _, err := NewHolder(context.Background(), &Config{
+		Pools: []*FakeDnsPool{{IpPool: "v2ray.com"}},
+	}); err == nil {
+		t.Error("expect error for invalid pool")
+	}
+}
@@ -0,0 +1,86 @@
+// +build !confonly
+
+package dns
+
+import (
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/common/errors"
+	"v2ray.com/core/common/net"
+)
+
+var errAnswerRejected = errors.New("all IPs in the answer are rejected")
+
+// ipSet is a set of IPs in GeoIP sets, or in countries by geo.mmdb.
+type ipSet struct {
+	geoip  *router.MultiGeoIPMatcher
+	custom *router.CustomGeoIPMatcher
+}
+
+func newIPSet(geoips []*router.GeoIP, codes []string) (*ipSet, error) {
+	if len(geoips) == 0 && len(codes) == 0 {
+		return nil, nil
+	}
+
+	s := new(ipSet)
+	if len(geoips) > 0 {
+		matcher, err := router.NewMultiGeoIPMatcher(geoips, false)
+		if err != nil {
+			return nil, err
+		}
+		s.geoip = matcher
+	}
+	if len(codes) > 0 {
+		s.custom = router.NewCustomGeoIPMatcher(codes, false)
+	}
+	return s, nil
+}
+
+func (s *ipSet) contains(ip net.IP) bool {
+	return (s.geoip != nil && s.geoip.MatchIP(ip)) || (s.custom != nil && s.custom.MatchIP(ip))
+}
+
+// ipFilter checks the answers of a name server against its expected and rejected IPs.
+type ipFilter struct {
+	expected *ipSet
+	rejected *ipSet
+}
+
+func newIPFilter(ns *NameServer) (*ipFilter, error) {
+	expected, err := newIPSet(ns.ExpectedIps, ns.ExpectedCustomGeoip)
+	if err != nil {
+		return nil, newError("failed to create expected IPs").Base(err)
+	}
+	rejected, err := newIPSet(ns.RejectedIps, ns.RejectedCustomGeoip)
+	if err != nil {
+		return nil, newError("failed to create rejected IPs").Base(err)
+	}
+	if expected == nil && rejected == nil {
+		return nil, nil
+	}
+	return &ipFilter{
+		expected: expected,
+		rejected: rejected,
+	}, nil
+}
+
+// apply returns the IPs that pass the filter, or errAnswerRejected if none does.
+func (f *ipFilter) apply(ips []net.IP) ([]net.IP, error) {
+	if len(ips) == 0 {
+		return ips, nil
+	}
+
+	filtered := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		if f.expected != nil && !f.expected.contains(ip) {
+			continue
+		}
+		if f.rejected != nil && f.rejected.contains(ip) {
+			continue
+		}
+		filtered = append(filtered, ip)
+	}
+	if len(filtered) == 0 {
+		return nil, errAnswerRejected
+	}
+	return filtered, nil
+}
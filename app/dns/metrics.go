@@ -0,0 +1,148 @@
+// +build !confonly
+
+package dns
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/signal/pubsub"
+	"v2ray.com/core/features/stats"
+)
+
+// latencyBuckets are the upper bounds of the latency histogram of each name server.
+var latencyBuckets = []time.Duration{
+	time.Millisecond * 10,
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 500,
+	time.Second,
+}
+
+const queryTopic = "query"
+
+// QueryEvent is a lookup of the Server, published to the subscribers of SubscribeQueries.
+type QueryEvent struct {
+	Domain string
+	Option IPOption
+	// Server is the name of the name server that answered, or empty if none did.
+	Server string
+	// Cached is true if the answer is from the cache, and Stale is true if it has expired.
+	Cached  bool
+	Stale   bool
+	IPs     []net.IP
+	Err     error
+	Time    time.Time
+	Latency time.Duration
+}
+
+// serverCounters are the counters of a name server. All of them may be nil.
+type serverCounters struct {
+	query      stats.Counter
+	failure    stats.Counter
+	cacheHit   stats.Counter
+	latencySum stats.Counter
+	// latency has a cumulative counter for each of latencyBuckets, and a last one for all queries.
+	latency []stats.Counter
+}
+
+// metrics keeps the counters of each name server, and publishes the lookups to subscribers.
+type metrics struct {
+	sync.Mutex
+	manager  stats.Manager
+	counters map[string]*serverCounters
+	events   *pubsub.Service
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		counters: make(map[string]*serverCounters),
+		events:   pubsub.NewService(),
+	}
+}
+
+func (m *metrics) setManager(manager stats.Manager) {
+	m.Lock()
+	defer m.Unlock()
+	m.manager = manager
+}
+
+// serverCounters returns the counters of the name server, which are registered
+// as "dns>>>server>>>[name]>>>[counter]" on first use.
+func (m *metrics) serverCounters(name string) *serverCounters {
+	m.Lock()
+	defer m.Unlock()
+
+	if c, found := m.counters[name]; found {
+		return c
+	}
+	if m.manager == nil {
+		return nil
+	}
+
+	register := func(counter string) stats.Counter {
+		c, _ := stats.GetOrRegisterCounter(m.manager, "dns>>>server>>>"+name+">>>"+counter)
+		return c
+	}
+	c := &serverCounters{
+		query:      register("query"),
+		failure:    register("failure"),
+		cacheHit:   register("cache_hit"),
+		latencySum: register("latency>>>sum"),
+	}
+	for _, bucket := range latencyBuckets {
+		c.latency = append(c.latency, register("latency>>>"+bucket.String()))
+	}
+	c.latency = append(c.latency, register("latency>>>inf"))
+	m.counters[name] = c
+	return c
+}
+
+// recordQuery counts a query to the name server. The latency of queries
+// canceled by the Server is not recorded, as it says nothing about the name server.
+func (m *metrics) recordQuery(name string, latency time.Duration, err error) {
+	c := m.serverCounters(name)
+	if c == nil {
+		return
+	}
+
+	incCounter(c.query)
+	if err == context.Canceled {
+		return
+	}
+	if err != nil {
+		incCounter(c.failure)
+	}
+	if c.latencySum != nil {
+		c.latencySum.Add(int64(latency / time.Millisecond))
+	}
+	for i, bucket := range latencyBuckets {
+		if latency <= bucket {
+			incCounter(c.latency[i])
+		}
+	}
+	incCounter(c.latency[len(latencyBuckets)])
+}
+
+// recordCacheHit counts a cached answer that was from the name server.
+func (m *metrics) recordCacheHit(name string) {
+	if len(name) == 0 {
+		return
+	}
+	if c := m.serverCounters(name); c != nil {
+		incCounter(c.cacheHit)
+	}
+}
+
+func (m *metrics) publish(e *QueryEvent) {
+	m.events.Publish(queryTopic, e)
+}
+
+// SubscribeQueries returns a subscriber that receives a *QueryEvent for each
+// lookup that isn't answered by static hosts. Events are dropped if the
+// subscriber doesn't keep up. The subscriber must be closed after use.
+func (s *Server) SubscribeQueries() *pubsub.Subscriber {
+	return s.metrics.events.Subscribe(queryTopic)
+}
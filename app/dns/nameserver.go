@@ -4,8 +4,10 @@ package dns
 
 import (
 	"context"
+	"time"
 
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/features/dns"
 	"v2ray.com/core/features/dns/localdns"
 )
 
@@ -24,6 +26,15 @@ type Client interface {
 	QueryIP(ctx context.Context, domain string, option IPOption) ([]net.IP, error)
 }
 
+// ExpiringClient is a Client that tells when its answers expire.
+type ExpiringClient interface {
+	Client
+
+	// QueryIPWithExpire is the same as QueryIP, and also returns the time when the answer expires.
+	// A zero time means the answer must not be cached.
+	QueryIPWithExpire(ctx context.Context, domain string, option IPOption) ([]net.IP, time.Time, error)
+}
+
 type localNameServer struct {
 	client *localdns.Client
 }
@@ -53,3 +64,47 @@ func NewLocalNameServer() *localNameServer {
 		client: localdns.New(),
 	}
 }
+
+// FakeNameServer answers queries with addresses from the pools of a dns.FakeDNSEngine.
+// It only answers DNS inbounds through Server.LookupFakeIP, and is never queried for
+// lookups of V2Ray itself.
+type FakeNameServer struct {
+	engine dns.FakeDNSEngine
+}
+
+func NewFakeNameServer(engine dns.FakeDNSEngine) *FakeNameServer {
+	return &FakeNameServer{
+		engine: engine,
+	}
+}
+
+// Name implements Client.
+func (*FakeNameServer) Name() string {
+	return "fakedns"
+}
+
+// QueryIP implements Client.
+func (s *FakeNameServer) QueryIP(ctx context.Context, domain string, option IPOption) ([]net.IP, error) {
+	ips, _, err := s.QueryIPWithExpire(ctx, domain, option)
+	return ips, err
+}
+
+// QueryIPWithExpire implements ExpiringClient. Fake addresses are never cached,
+// as they may be handed out to other domains once evicted from the pools.
+func (s *FakeNameServer) QueryIPWithExpire(ctx context.Context, domain string, option IPOption) ([]net.IP, time.Time, error) {
+	addrs := s.engine.GetFakeIPForDomain(domain)
+	if len(addrs) == 0 {
+		return nil, time.Time{}, newError("fake DNS is not configured")
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		if (addr.Family().IsIPv4() && option.IPv4Enable) || (addr.Family().IsIPv6() && option.IPv6Enable) {
+			ips = append(ips, addr.IP())
+		}
+	}
+	if len(ips) == 0 {
+		return nil, time.Time{}, dns.ErrEmptyResponse
+	}
+	return ips, time.Time{}, nil
+}
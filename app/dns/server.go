@@ -7,6 +7,7 @@ package dns
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -19,7 +20,10 @@ import (
 	"v2ray.com/core/common/uuid"
 	"v2ray.com/core/features"
 	"v2ray.com/core/features/dns"
+	record_feature "v2ray.com/core/features/record"
 	"v2ray.com/core/features/routing"
+	"v2ray.com/core/features/stats"
+	"v2ray.com/core/transport/internet/tls"
 )
 
 // Server is a DNS rely server.
@@ -27,12 +31,23 @@ type Server struct {
 	sync.Mutex
 	hosts          *StaticHosts
 	clients        []Client
+	fake           *FakeNameServer
+	fakeDomains    strmatcher.IndexMatcher
 	clientIP       net.IP
 	domainMatcher  strmatcher.IndexMatcher
 	domainIndexMap map[uint32]uint32
 	tag            string
+	cache          *answerCache
+	strategy       QueryStrategy
+	rtt            rttTracker
+	filters        []*ipFilter
+	metrics        *metrics
+	recorder       record_feature.Recorder
 }
 
+// queryTimeout is the time limit of querying one name server.
+const queryTimeout = time.Second * 4
+
 func generateRandomTag() string {
 	id := uuid.New()
 	return "v2ray.system." + id.String()
@@ -41,8 +56,11 @@ func generateRandomTag() string {
 // New creates a new DNS server with given configuration.
 func New(ctx context.Context, config *Config) (*Server, error) {
 	server := &Server{
-		clients: make([]Client, 0, len(config.NameServers)+len(config.NameServer)),
-		tag:     config.Tag,
+		clients:  make([]Client, 0, len(config.NameServers)+len(config.NameServer)),
+		tag:      config.Tag,
+		strategy: config.QueryStrategy,
+		metrics:  newMetrics(),
+		recorder: record_feature.NoopRecorder{},
 	}
 	if len(server.tag) == 0 {
 		server.tag = generateRandomTag()
@@ -60,25 +78,83 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 	}
 	server.hosts = hosts
 
-	addNameServer := func(endpoint *net.Endpoint) int {
-		address := endpoint.Address.AsAddress()
-		if address.Family().IsDomain() && address.Domain() == "localhost" {
+	addClient := func(create func(d routing.Dispatcher) Client) {
+		idx := len(server.clients)
+		server.clients = append(server.clients, nil)
+
+		common.Must(core.RequireFeatures(ctx, func(d routing.Dispatcher) {
+			server.clients[idx] = create(d)
+		}))
+	}
+
+	addNameServer := func(ns *NameServer) (int, error) {
+		address := ns.Address.Address.AsAddress()
+		switch {
+		case address.Family().IsDomain() && address.Domain() == "localhost":
 			server.clients = append(server.clients, NewLocalNameServer())
-		} else {
-			dest := endpoint.AsDestination()
+		case isFakeNameServer(ns):
+			// Fake addresses only answer DNS inbounds, so the fake DNS is kept out of the clients.
+			common.Must(core.RequireFeatures(ctx, func(fd dns.FakeDNSEngine) {
+				server.fake = NewFakeNameServer(fd)
+			}))
+			if len(ns.PrioritizedDomain) > 0 {
+				fakeDomains := &strmatcher.MatcherGroup{}
+				for _, domain := range ns.PrioritizedDomain {
+					matcher, err := toStrMatcher(domain.Type, domain.Domain)
+					if err != nil {
+						return -1, newError("failed to create prioritized domain").Base(err).AtWarning()
+					}
+					fakeDomains.Add(matcher)
+				}
+				server.fakeDomains = fakeDomains
+			}
+			return -1, nil
+		case address.Family().IsDomain() && strings.HasPrefix(address.Domain(), "https://"):
+			u, err := url.Parse(address.Domain())
+			if err != nil {
+				return -1, newError("invalid DNS over HTTPS URL: ", address.Domain()).Base(err)
+			}
+			if len(u.Host) == 0 {
+				return -1, newError("empty host in DNS over HTTPS URL: ", address.Domain())
+			}
+			addClient(func(d routing.Dispatcher) Client {
+				return NewDoHNameServer(u, d, ns.TlsSettings, server.clientIP)
+			})
+		case address.Family().IsDomain() && (strings.HasPrefix(address.Domain(), "tcp://") || strings.HasPrefix(address.Domain(), "tls://")):
+			dest, useTLS, err := parseStreamURL(address.Domain())
+			if err != nil {
+				return -1, err
+			}
+			var tlsConfig *tls.Config
+			if useTLS {
+				tlsConfig = ns.TlsSettings
+				if tlsConfig == nil {
+					tlsConfig = new(tls.Config)
+				}
+			}
+			addClient(func(d routing.Dispatcher) Client {
+				return NewTCPNameServer(dest, d, tlsConfig, server.clientIP)
+			})
+		default:
+			dest := ns.Address.AsDestination()
 			if dest.Network == net.Network_Unknown {
 				dest.Network = net.Network_UDP
 			}
-			if dest.Network == net.Network_UDP {
-				idx := len(server.clients)
-				server.clients = append(server.clients, nil)
-
-				common.Must(core.RequireFeatures(ctx, func(d routing.Dispatcher) {
-					server.clients[idx] = NewClassicNameServer(dest, d, server.clientIP)
-				}))
+			switch dest.Network {
+			case net.Network_UDP:
+				addClient(func(d routing.Dispatcher) Client {
+					return NewClassicNameServer(dest, d, server.clientIP)
+				})
+			case net.Network_TCP:
+				addClient(func(d routing.Dispatcher) Client {
+					return NewTCPNameServer(dest, d, ns.TlsSettings, server.clientIP)
+				})
+			default:
+				newError("unsupported name server ", dest, ", ignored").AtWarning().WriteToLog()
+				return -1, nil
 			}
 		}
-		return len(server.clients) - 1
+		return len(server.clients) - 1, nil
 	}
 
 	if len(config.NameServers) > 0 {
@@ -86,7 +162,9 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 	}
 
 	for _, destPB := range config.NameServers {
-		addNameServer(destPB)
+		if _, err := addNameServer(&NameServer{Address: destPB}); err != nil {
+			return nil, newError("failed to create name server").Base(err)
+		}
 	}
 
 	if len(config.NameServer) > 0 {
@@ -94,7 +172,24 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 		domainIndexMap := make(map[uint32]uint32)
 
 		for _, ns := range config.NameServer {
-			idx := addNameServer(ns.Address)
+			idx, err := addNameServer(ns)
+			if err != nil {
+				return nil, newError("failed to create name server").Base(err)
+			}
+			if idx < 0 {
+				continue
+			}
+
+			filter, err := newIPFilter(ns)
+			if err != nil {
+				return nil, newError("failed to create name server").Base(err)
+			}
+			if filter != nil {
+				for len(server.filters) <= idx {
+					server.filters = append(server.filters, nil)
+				}
+				server.filters[idx] = filter
+			}
 
 			for _, domain := range ns.PrioritizedDomain {
 				matcher, err := toStrMatcher(domain.Type, domain.Domain)
@@ -114,6 +209,20 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 		server.clients = append(server.clients, NewLocalNameServer())
 	}
 
+	if config.Cache != nil {
+		server.cache = newAnswerCache(config.Cache)
+	}
+
+	common.Must(core.RequireFeatures(ctx, func(sm stats.Manager) {
+		if server.cache != nil {
+			server.cache.registerCounters(sm)
+		}
+		server.metrics.setManager(sm)
+	}))
+	common.Must(core.RequireFeatures(ctx, func(r record_feature.Recorder) {
+		server.recorder = r
+	}))
+
 	return server, nil
 }
 
@@ -124,12 +233,21 @@ func (*Server) Type() interface{} {
 
 // Start implements common.Runnable.
 func (s *Server) Start() error {
+	if s.cache == nil {
+		return nil
+	}
+	if err := s.cache.load(); err != nil {
+		newError("failed to load DNS cache").Base(err).AtWarning().WriteToLog()
+	}
 	return nil
 }
 
 // Close implements common.Closable.
 func (s *Server) Close() error {
-	return nil
+	if s.cache == nil {
+		return nil
+	}
+	return s.cache.close()
 }
 
 func (s *Server) IsOwnLink(ctx context.Context) bool {
@@ -137,14 +255,7 @@ func (s *Server) IsOwnLink(ctx context.Context) bool {
 	return inbound != nil && inbound.Tag == s.tag
 }
 
-func (s *Server) queryIPTimeout(client Client, domain string, option IPOption) ([]net.IP, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
-	if len(s.tag) > 0 {
-		ctx = session.ContextWithInbound(ctx, &session.Inbound{
-			Tag: s.tag,
-		})
-	}
-
+func newQueryRecord(target string, domain string, option IPOption) *session.ProxyRecord {
 	var queryType int32 = 0
 	if option.IPv4Enable && !option.IPv6Enable {
 		queryType = 0
@@ -153,13 +264,10 @@ func (s *Server) queryIPTimeout(client Client, domain string, option IPOption) (
 	} else if option.IPv4Enable && option.IPv6Enable {
 		queryType = 2
 	}
-	host := strings.Replace(client.Name(), "udp:", "dns:", -1)
-	if client.Name() == "localhost" {
-		host = "dns:" + client.Name()
-	}
-	record := &session.ProxyRecord{Target: host, StartTime: time.Now().UnixNano(), UploadBytes: 0, DownloadBytes: 0, RecordType: 1, DNSQueryType: queryType, DNSRequest: domain}
-	ctx = session.ContextWithProxyRecord(ctx, record)
-	ips, err := client.QueryIP(ctx, domain, option)
+	return &session.ProxyRecord{Target: target, StartTime: time.Now().UnixNano(), UploadBytes: 0, DownloadBytes: 0, RecordType: 1, DNSQueryType: queryType, DNSRequest: domain}
+}
+
+func (s *Server) insertQueryRecord(record *session.ProxyRecord, ips []net.IP, err error) {
 	if err != nil {
 		record.DNSResponse = fmt.Sprintf("failed to query ips: %v", err)
 		record.DNSNumIPs = 0
@@ -172,9 +280,61 @@ func (s *Server) queryIPTimeout(client Client, domain string, option IPOption) (
 		record.DNSResponse = response
 		record.DNSNumIPs = int32(len(ips))
 	}
-	go session.InsertRecord(record)
-	cancel()
-	return ips, err
+	s.recorder.Insert(record)
+}
+
+// queryIPTimeout queries the client, and returns the answer and when it expires.
+// cached is true if the client answered from its own cache.
+func (s *Server) queryIPTimeout(ctx context.Context, client Client, domain string, option IPOption) (ips []net.IP, expire time.Time, cached bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	if len(s.tag) > 0 {
+		ctx = session.ContextWithInbound(ctx, &session.Inbound{
+			Tag: s.tag,
+		})
+	}
+
+	host := strings.Replace(client.Name(), "udp:", "dns:", -1)
+	if client.Name() == "localhost" {
+		host = "dns:" + client.Name()
+	}
+	record := newQueryRecord(host, domain, option)
+	ctx = session.ContextWithProxyRecord(ctx, record)
+
+	if c, ok := client.(ExpiringClient); ok {
+		ips, expire, err = c.QueryIPWithExpire(ctx, domain, option)
+	} else {
+		ips, err = client.QueryIP(ctx, domain, option)
+		expire = time.Now().Add(defaultCacheTTL)
+	}
+	s.insertQueryRecord(record, ips, err)
+	return ips, expire, record.Tag == cachedRecordTag, err
+}
+
+// LookupFakeIP implements dns.FakeDNSLookup. Static hosts take precedence over fake addresses.
+func (s *Server) LookupFakeIP(domain string, ipv4, ipv6 bool) ([]net.IP, bool) {
+	if s.fake == nil || len(domain) == 0 {
+		return nil, false
+	}
+	if domain[len(domain)-1] == '.' {
+		domain = domain[:len(domain)-1]
+	}
+	option := IPOption{
+		IPv4Enable: ipv4,
+		IPv6Enable: ipv6,
+	}
+	if s.fakeDomains != nil && s.fakeDomains.Match(domain) == 0 {
+		return nil, false
+	}
+	if s.lookupStatic(domain, option, 0) != nil {
+		return nil, false
+	}
+	ips, err := s.fake.QueryIP(context.Background(), domain, option)
+	if err != nil && err != dns.ErrEmptyResponse {
+		newError("failed to get fake IP for domain ", domain).Base(err).WriteToLog()
+		return nil, false
+	}
+	return ips, true
 }
 
 // LookupIP implements dns.Client.
@@ -246,18 +406,93 @@ func (s *Server) lookupIPInternal(domain string, option IPOption) ([]net.IP, err
 		domain = newdomain
 	}
 
+	return s.lookupCached(domain, option)
+}
+
+// lookupCached returns the cached answer of the domain if there is one, and
+// refreshes it in background when needed. Otherwise it queries the name servers.
+func (s *Server) lookupCached(domain string, option IPOption) ([]net.IP, error) {
+	start := time.Now()
+	if s.cache == nil {
+		return s.lookupUncached(domain, option, start)
+	}
+
+	ips, server, state, refresh := s.cache.get(domain, option, start)
+	if refresh {
+		go s.refresh(domain, option)
+	}
+	if state != cacheMiss {
+		newError("returning ", len(ips), " cached IPs for domain ", domain).AtDebug().WriteToLog()
+		record := newQueryRecord("dns:cache", domain, option)
+		record.Tag = cachedRecordTag
+		if state == cacheStale {
+			record.Tag = "stale"
+		}
+		s.insertQueryRecord(record, ips, nil)
+		s.metrics.recordCacheHit(server)
+		s.metrics.publish(&QueryEvent{
+			Domain:  domain,
+			Option:  option,
+			Server:  server,
+			Cached:  true,
+			Stale:   state == cacheStale,
+			IPs:     ips,
+			Time:    start,
+			Latency: time.Since(start),
+		})
+		return ips, nil
+	}
+
+	return s.lookupUncached(domain, option, start)
+}
+
+// lookupUncached queries the name servers for the domain, and caches the answer if the cache is enabled.
+func (s *Server) lookupUncached(domain string, option IPOption, start time.Time) ([]net.IP, error) {
+	ans, err := s.resolve(context.Background(), domain, option)
+	s.metrics.publish(&QueryEvent{
+		Domain:  domain,
+		Option:  option,
+		Server:  ans.server,
+		IPs:     ans.ips,
+		Err:     err,
+		Time:    start,
+		Latency: time.Since(start),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.cache != nil {
+		s.cache.put(domain, option, ans, time.Now())
+	}
+	return ans.ips, nil
+}
+
+func (s *Server) refresh(domain string, option IPOption) {
+	newError("refreshing cached IPs for domain ", domain).AtDebug().WriteToLog()
+	ans, err := s.resolve(contextWithRefresh(context.Background()), domain, option)
+	if err != nil {
+		newError("failed to refresh cached IPs for domain ", domain).Base(err).AtDebug().WriteToLog()
+		s.cache.done(domain, option)
+		return
+	}
+	s.cache.put(domain, option, ans, time.Now())
+}
+
+// resolve queries the name servers for the domain, and returns the first answer.
+func (s *Server) resolve(ctx context.Context, domain string, option IPOption) (answer, error) {
 	var lastErr error
 	if s.domainMatcher != nil {
 		idx := s.domainMatcher.Match(domain)
 		if idx > 0 {
-			ns := s.clients[s.domainIndexMap[idx]]
+			nsIdx := int(s.domainIndexMap[idx])
+			ns := s.clients[nsIdx]
 			newError("querying domain ", domain, " at ", ns.Name()).WriteToLog()
-			ips, err := s.queryIPTimeout(ns, domain, option)
+			ips, expire, err := s.queryClient(ctx, nsIdx, domain, option)
 			if len(ips) > 0 {
-				return ips, nil
+				return answer{ips: ips, expire: expire, server: ns.Name()}, nil
 			}
 			if err == dns.ErrEmptyResponse {
-				return nil, err
+				return answer{}, err
 			}
 			if err != nil {
 				newError("failed to lookup ip for domain ", domain, " at server ", ns.Name()).Base(err).WriteToLog()
@@ -266,21 +501,48 @@ func (s *Server) lookupIPInternal(domain string, option IPOption) ([]net.IP, err
 		}
 	}
 
-	for _, client := range s.clients {
-		ips, err := s.queryIPTimeout(client, domain, option)
-		if len(ips) > 0 {
-			return ips, nil
+	switch s.strategy {
+	case QueryStrategy_Parallel:
+		return s.resolveParallel(ctx, domain, option, lastErr)
+	case QueryStrategy_Fastest:
+		return s.resolveSequential(ctx, s.rtt.order(len(s.clients)), domain, option, lastErr)
+	default:
+		indices := make([]int, len(s.clients))
+		for i := range indices {
+			indices[i] = i
 		}
+		return s.resolveSequential(ctx, indices, domain, option, lastErr)
+	}
+}
+
+func isFakeNameServer(ns *NameServer) bool {
+	address := ns.Address.Address.AsAddress()
+	return address.Family().IsDomain() && address.Domain() == "fakedns"
+}
+
+// parseStreamURL parses the address of a DNS over TCP or DNS over TLS server,
+// such as "tcp://8.8.8.8" or "tls://dns.google:853".
+func parseStreamURL(s string) (net.Destination, bool, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return net.Destination{}, false, newError("invalid name server URL: ", s).Base(err)
+	}
+	if len(u.Hostname()) == 0 {
+		return net.Destination{}, false, newError("empty host in name server URL: ", s)
+	}
+
+	useTLS := u.Scheme == "tls"
+	port := net.Port(53)
+	if useTLS {
+		port = 853
+	}
+	if len(u.Port()) > 0 {
+		port, err = net.PortFromString(u.Port())
 		if err != nil {
-			newError("failed to lookup ip for domain ", domain, " at server ", client.Name()).Base(err).WriteToLog()
-			lastErr = err
-		}
-		if err != context.Canceled && err != context.DeadlineExceeded {
-			return nil, err
+			return net.Destination{}, false, newError("invalid port in name server URL: ", s).Base(err)
 		}
 	}
-
-	return nil, newError("returning nil for domain ", domain).Base(lastErr)
+	return net.TCPDestination(net.ParseAddress(u.Hostname()), port), useTLS, nil
 }
 
 func init() {
@@ -10,14 +10,17 @@ import (
 	"v2ray.com/core"
 	"v2ray.com/core/app/dispatcher"
 	. "v2ray.com/core/app/dns"
+	"v2ray.com/core/app/dns/fakedns"
 	"v2ray.com/core/app/policy"
 	"v2ray.com/core/app/proxyman"
 	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/app/router"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/serial"
 	feature_dns "v2ray.com/core/features/dns"
 	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
 	"v2ray.com/core/testing/servers/udp"
 )
 
@@ -305,6 +308,85 @@ func TestPrioritizedDomain(t *testing.T) {
 	}
 }
 
+func TestFakeDNS(t *testing.T) {
+	port := tcp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "tcp",
+		Handler: &staticHandler{},
+	}
+
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.DomainAddress("tcp://127.0.0.1:" + port.String())),
+						},
+					},
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.DomainAddress("fakedns")),
+						},
+						PrioritizedDomain: []*NameServer_PriorityDomain{
+							{
+								Type:   DomainMatchingType_Full,
+								Domain: "google.com",
+							},
+						},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&fakedns.Config{
+				Pools: []*fakedns.FakeDnsPool{
+					{
+						IpPool: "198.18.0.0/15",
+					},
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+	fakeLookup := client.(feature_dns.FakeDNSLookup)
+
+	// Lookups of V2Ray itself never get fake addresses.
+	ips, err := client.LookupIP("google.com")
+	common.Must(err)
+	if r := cmp.Diff(ips, []net.IP{{8, 8, 8, 8}}); r != "" {
+		t.Error(r)
+	}
+
+	ips, fake := fakeLookup.LookupFakeIP("google.com.", true, false)
+	if !fake || len(ips) != 1 || ips[0][0] != 198 || ips[0][1]&0xfe != 18 {
+		t.Error("expect a fake IPv4 address, but got ", ips)
+	}
+	if ips, fake := fakeLookup.LookupFakeIP("google.com", false, true); !fake || len(ips) != 0 {
+		t.Error("expect an empty fake answer for IPv6, but got ", ips)
+	}
+	if _, fake := fakeLookup.LookupFakeIP("facebook.com", true, true); fake {
+		t.Error("expect facebook.com to be resolved as usual")
+	}
+
+	common.Must(dnsServer.Shutdown())
+}
+
 func TestUDPServerIPv6(t *testing.T) {
 	port := udp.PickPort()
 
@@ -426,3 +508,93 @@ func TestStaticHostDomain(t *testing.T) {
 
 	dnsServer.Shutdown()
 }
+
+func TestIPFilter(t *testing.T) {
+	var addresses []*net.Endpoint
+	for _, handler := range []dns.Handler{
+		&staticHandler{},
+		&delayedHandler{ip: "10.0.0.2"},
+	} {
+		port := tcp.PickPort()
+		dnsServer := dns.Server{
+			Addr:    "127.0.0.1:" + port.String(),
+			Net:     "tcp",
+			Handler: handler,
+		}
+		go dnsServer.ListenAndServe()
+		defer dnsServer.Shutdown()
+
+		addresses = append(addresses, &net.Endpoint{
+			Address: net.NewIPOrDomain(net.DomainAddress("tcp://127.0.0.1:" + port.String())),
+		})
+	}
+	time.Sleep(time.Second)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: addresses[0],
+						ExpectedIps: []*router.GeoIP{
+							{
+								Cidr: []*router.CIDR{{Ip: []byte{8, 8, 8, 0}, Prefix: 24}},
+							},
+						},
+						RejectedIps: []*router.GeoIP{
+							{
+								Cidr: []*router.CIDR{{Ip: []byte{8, 8, 8, 7}, Prefix: 32}},
+							},
+						},
+					},
+					{
+						Address: addresses[1],
+					},
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.IPv4Lookup)
+
+	testCases := []struct {
+		domain   string
+		expected []net.IP
+	}{
+		{
+			domain:   "google.com",
+			expected: []net.IP{{8, 8, 8, 8}},
+		},
+		{
+			// Not in the expected IPs.
+			domain:   "facebook.com",
+			expected: []net.IP{{10, 0, 0, 2}},
+		},
+		{
+			// In the rejected IPs.
+			domain:   "ipv6.google.com",
+			expected: []net.IP{{10, 0, 0, 2}},
+		},
+	}
+
+	for _, test := range testCases {
+		ips, err := client.LookupIPv4(test.domain)
+		if err != nil {
+			t.Fatal(test.domain, ": unexpected error: ", err)
+		}
+		if r := cmp.Diff(ips, test.expected); r != "" {
+			t.Error(test.domain, ": ", r)
+		}
+	}
+}
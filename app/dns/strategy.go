@@ -0,0 +1,168 @@
+// +build !confonly
+
+package dns
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/features/dns"
+)
+
+// rttTracker keeps the smoothed round trip time of each client, the same way as
+// TCP does (RFC 6298).
+type rttTracker struct {
+	sync.Mutex
+	rtt []time.Duration
+}
+
+func (t *rttTracker) update(idx int, rtt time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+
+	for len(t.rtt) <= idx {
+		t.rtt = append(t.rtt, 0)
+	}
+	if t.rtt[idx] == 0 {
+		t.rtt[idx] = rtt
+	} else {
+		t.rtt[idx] += (rtt - t.rtt[idx]) / 8
+	}
+}
+
+// order returns the indices of n clients, the ones with the shortest round trip
+// time first. Clients that have never been queried come first, so that they
+// are measured.
+func (t *rttTracker) order(n int) []int {
+	t.Lock()
+	rtt := make([]time.Duration, n)
+	copy(rtt, t.rtt)
+	t.Unlock()
+
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return rtt[indices[i]] < rtt[indices[j]]
+	})
+	return indices
+}
+
+func isTimeout(err error) bool {
+	return err == context.Canceled || err == context.DeadlineExceeded
+}
+
+// isAnswer returns whether the error is an answer of the name server, rather than a failure to get one.
+func isAnswer(err error) bool {
+	return err == nil || err == dns.ErrEmptyResponse || dns.RCodeFromError(err) != 0
+}
+
+// queryClient queries the client at idx, and tracks its round trip time.
+// The answer is checked against the expected and rejected IPs of the client.
+func (s *Server) queryClient(ctx context.Context, idx int, domain string, option IPOption) ([]net.IP, time.Time, error) {
+	start := time.Now()
+	ips, expire, cached, err := s.queryIPTimeout(ctx, s.clients[idx], domain, option)
+	rtt := time.Since(start)
+	s.metrics.recordQuery(s.clients[idx].Name(), rtt, err)
+	switch {
+	case err == context.Canceled:
+		// Canceled by the caller, which says nothing about the name server.
+	case !isAnswer(err):
+		// Errors may come back fast, such as refused connections, but the name server is no good either way.
+		s.rtt.update(idx, queryTimeout)
+	case !cached:
+		// Only answers over the network tell the round trip time.
+		s.rtt.update(idx, rtt)
+	}
+
+	if len(ips) > 0 && idx < len(s.filters) && s.filters[idx] != nil {
+		ips, err = s.filters[idx].apply(ips)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	return ips, expire, err
+}
+
+// shouldFallThrough returns whether the next client should be queried after the error.
+func shouldFallThrough(err error) bool {
+	return isTimeout(err) || err == errAnswerRejected
+}
+
+// answer is the answer of a name server, and when it expires.
+type answer struct {
+	ips    []net.IP
+	expire time.Time
+	server string
+}
+
+// resolveSequential queries the clients in the given order, until one of them answers.
+func (s *Server) resolveSequential(ctx context.Context, indices []int, domain string, option IPOption, lastErr error) (answer, error) {
+	for _, idx := range indices {
+		client := s.clients[idx]
+		ips, expire, err := s.queryClient(ctx, idx, domain, option)
+		if len(ips) > 0 {
+			return answer{ips: ips, expire: expire, server: client.Name()}, nil
+		}
+		if err != nil {
+			newError("failed to lookup ip for domain ", domain, " at server ", client.Name()).Base(err).WriteToLog()
+			lastErr = err
+		}
+		if !shouldFallThrough(err) {
+			return answer{}, err
+		}
+	}
+
+	return answer{}, newError("returning nil for domain ", domain).Base(lastErr)
+}
+
+type queryResult struct {
+	ips    []net.IP
+	expire time.Time
+	err    error
+	client Client
+}
+
+// resolveParallel queries all clients at the same time, and returns the first
+// answer. The other queries are canceled then.
+func (s *Server) resolveParallel(ctx context.Context, domain string, option IPOption, lastErr error) (answer, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan queryResult, len(s.clients))
+	for idx := range s.clients {
+		go func(idx int) {
+			ips, expire, err := s.queryClient(ctx, idx, domain, option)
+			results <- queryResult{
+				ips:    ips,
+				expire: expire,
+				err:    err,
+				client: s.clients[idx],
+			}
+		}(idx)
+	}
+
+	var failure error
+	for range s.clients {
+		r := <-results
+		if len(r.ips) > 0 {
+			return answer{ips: r.ips, expire: r.expire, server: r.client.Name()}, nil
+		}
+		if r.err != nil {
+			newError("failed to lookup ip for domain ", domain, " at server ", r.client.Name()).Base(r.err).WriteToLog()
+			lastErr = r.err
+			if !shouldFallThrough(r.err) && failure == nil {
+				failure = r.err
+			}
+		}
+	}
+
+	if failure != nil {
+		return answer{}, failure
+	}
+	return answer{}, newError("returning nil for domain ", domain).Base(lastErr)
+}
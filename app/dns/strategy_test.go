@@ -0,0 +1,198 @@
+package dns_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/dispatcher"
+	. "v2ray.com/core/app/dns"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	feature_dns "v2ray.com/core/features/dns"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+)
+
+// delayedHandler answers A queries with the IP after the delay, or after the
+// delay of the queried domain in delays.
+type delayedHandler struct {
+	ip     string
+	delay  time.Duration
+	delays map[string]time.Duration
+}
+
+func (h *delayedHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	delay := h.delay
+	if len(r.Question) > 0 {
+		if d, found := h.delays[r.Question[0].Name]; found {
+			delay = d
+		}
+	}
+	time.Sleep(delay)
+	ans := new(dns.Msg)
+	ans.SetReply(r)
+	for _, q := range r.Question {
+		if q.Qtype == dns.TypeA {
+			rr, err := dns.NewRR(q.Name + " 300 IN A " + h.ip)
+			common.Must(err)
+			ans.Answer = append(ans.Answer, rr)
+		}
+	}
+	w.WriteMsg(ans)
+}
+
+func TestQueryStrategy(t *testing.T) {
+	var nameServers []*NameServer
+	for _, handler := range []*delayedHandler{
+		{ip: "10.0.0.1", delay: time.Second},
+		{ip: "10.0.0.2"},
+	} {
+		port := tcp.PickPort()
+		dnsServer := dns.Server{
+			Addr:    "127.0.0.1:" + port.String(),
+			Net:     "tcp",
+			Handler: handler,
+		}
+		go dnsServer.ListenAndServe()
+		defer dnsServer.Shutdown()
+
+		nameServers = append(nameServers, &NameServer{
+			Address: &net.Endpoint{
+				Address: net.NewIPOrDomain(net.DomainAddress("tcp://127.0.0.1:" + port.String())),
+			},
+		})
+	}
+	time.Sleep(time.Second)
+
+	testCases := []struct {
+		strategy QueryStrategy
+		expected []net.IP
+	}{
+		{
+			strategy: QueryStrategy_Sequential,
+			expected: []net.IP{{10, 0, 0, 1}, {10, 0, 0, 1}, {10, 0, 0, 1}},
+		},
+		{
+			strategy: QueryStrategy_Parallel,
+			expected: []net.IP{{10, 0, 0, 2}, {10, 0, 0, 2}, {10, 0, 0, 2}},
+		},
+		{
+			// The slow server is measured by the first query, and avoided afterwards.
+			strategy: QueryStrategy_Fastest,
+			expected: []net.IP{{10, 0, 0, 1}, {10, 0, 0, 2}, {10, 0, 0, 2}},
+		},
+	}
+
+	for _, test := range testCases {
+		config := &core.Config{
+			App: []*serial.TypedMessage{
+				serial.ToTypedMessage(&Config{
+					NameServer:    nameServers,
+					QueryStrategy: test.strategy,
+				}),
+				serial.ToTypedMessage(&dispatcher.Config{}),
+				serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+				serial.ToTypedMessage(&policy.Config{}),
+			},
+			Outbound: []*core.OutboundHandlerConfig{
+				{
+					ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+				},
+			},
+		}
+
+		v, err := core.New(config)
+		common.Must(err)
+		common.Must(v.Start())
+
+		client := v.GetFeature(feature_dns.ClientType()).(feature_dns.IPv4Lookup)
+		for i, domain := range []string{"a.v2ray.com", "b.v2ray.com", "c.v2ray.com"} {
+			ips, err := client.LookupIPv4(domain)
+			if err != nil {
+				t.Fatal(test.strategy, ": unexpected error: ", err)
+			}
+			if r := cmp.Diff(ips, []net.IP{test.expected[i]}); r != "" {
+				t.Error(test.strategy, ": ", r)
+			}
+		}
+		common.Close(v)
+	}
+}
+
+func TestFastestStrategyIgnoresCachedAnswers(t *testing.T) {
+	var nameServers []*NameServer
+	for _, handler := range []*delayedHandler{
+		{ip: "10.0.0.1", delay: 100 * time.Millisecond, delays: map[string]time.Duration{
+			"slow1.v2ray.com.": 2 * time.Second,
+			"slow2.v2ray.com.": 2 * time.Second,
+		}},
+		{ip: "10.0.0.2", delay: 400 * time.Millisecond},
+	} {
+		port := tcp.PickPort()
+		dnsServer := dns.Server{
+			Addr:    "127.0.0.1:" + port.String(),
+			Net:     "tcp",
+			Handler: handler,
+		}
+		go dnsServer.ListenAndServe()
+		defer dnsServer.Shutdown()
+
+		nameServers = append(nameServers, &NameServer{
+			Address: &net.Endpoint{
+				Address: net.NewIPOrDomain(net.DomainAddress("tcp://127.0.0.1:" + port.String())),
+			},
+		})
+	}
+	time.Sleep(time.Second)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer:    nameServers,
+				QueryStrategy: QueryStrategy_Fastest,
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.IPv4Lookup)
+	// Both servers are measured, then the first one slows down. Answers from
+	// its cache in between must not make it look fast again.
+	domains := []string{"a.v2ray.com", "b.v2ray.com", "slow1.v2ray.com"}
+	for i := 0; i < 8; i++ {
+		domains = append(domains, "a.v2ray.com")
+	}
+	domains = append(domains, "slow2.v2ray.com")
+	for _, domain := range domains {
+		if _, err := client.LookupIPv4(domain); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+	}
+
+	ips, err := client.LookupIPv4("c.v2ray.com")
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if r := cmp.Diff(ips, []net.IP{{10, 0, 0, 2}}); r != "" {
+		t.Error(r)
+	}
+}
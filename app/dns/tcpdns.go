@@ -0,0 +1,319 @@
+// +build !confonly
+
+package dns
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol/dns"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/task"
+	"v2ray.com/core/features/routing"
+	"v2ray.com/core/transport/internet/tls"
+)
+
+type tcpRequest struct {
+	pendingRequest
+	msg     []byte
+	stream  *tcpStream
+	retried bool
+}
+
+// tcpStream is a connection to the name server, on which queries are pipelined.
+type tcpStream struct {
+	access sync.Mutex
+	conn   net.Conn
+	reader *dns.TCPReader
+	writer *dns.TCPWriter
+}
+
+func (c *tcpStream) write(msg []byte) error {
+	b := buf.New()
+	if _, err := b.Write(msg); err != nil {
+		b.Release()
+		return err
+	}
+
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.writer.WriteMessage(b)
+}
+
+// TCPNameServer is a DNS client over TCP (RFC 7766), or over TLS (RFC 7858) if
+// TLS settings are given. Queries are pipelined on one persistent connection,
+// which is dispatched by the routing.Dispatcher. When the connection breaks,
+// a new one is made on demand, and unanswered queries are sent again once.
+type TCPNameServer struct {
+	sync.Mutex
+	destination net.Destination
+	dispatcher  routing.Dispatcher
+	tlsConfig   *tls.Config
+	cache       *ipCache
+	requests    map[uint16]*tcpRequest
+	stream      *tcpStream
+	cleanup     *task.Periodic
+	reqID       uint32
+	clientIP    net.IP
+}
+
+// NewTCPNameServer creates a TCPNameServer. The queries are sent over TLS if tlsConfig is not nil.
+func NewTCPNameServer(destination net.Destination, dispatcher routing.Dispatcher, tlsConfig *tls.Config, clientIP net.IP) *TCPNameServer {
+	s := &TCPNameServer{
+		destination: destination,
+		dispatcher:  dispatcher,
+		tlsConfig:   tlsConfig,
+		cache:       newIPCache(),
+		requests:    make(map[uint16]*tcpRequest),
+		clientIP:    clientIP,
+	}
+	s.cleanup = &task.Periodic{
+		Interval: time.Minute,
+		Execute:  s.Cleanup,
+	}
+	return s
+}
+
+// Name implements Client.
+func (s *TCPNameServer) Name() string {
+	if s.tlsConfig != nil {
+		return "tls://" + s.destination.NetAddr()
+	}
+	return "tcp://" + s.destination.NetAddr()
+}
+
+// Cleanup removes expired records and requests.
+func (s *TCPNameServer) Cleanup() error {
+	now := time.Now()
+	s.Lock()
+	defer s.Unlock()
+
+	if s.cache.cleanup(now) == 0 && len(s.requests) == 0 {
+		return newError("nothing to do. stopping...")
+	}
+
+	for id, req := range s.requests {
+		if req.expire.Before(now) {
+			delete(s.requests, id)
+		}
+	}
+
+	if len(s.requests) == 0 {
+		s.requests = make(map[uint16]*tcpRequest)
+	}
+
+	return nil
+}
+
+func (s *TCPNameServer) dial(ctx context.Context) (*tcpStream, error) {
+	dnsCtx := context.Background()
+	inbound := &session.Inbound{
+		NoSource: true,
+	}
+	if original := session.InboundFromContext(ctx); original != nil {
+		inbound.Tag = original.Tag
+	}
+	dnsCtx = session.ContextWithInbound(dnsCtx, inbound)
+	dnsCtx = session.ContextWithContent(dnsCtx, &session.Content{
+		Protocol:    "dns",
+		Application: []string{"[Internal DNS]"},
+		Network:     "tcp",
+		Extra:       s.Name(),
+	})
+
+	link, err := s.dispatcher.Dispatch(dnsCtx, s.destination)
+	if err != nil {
+		return nil, err
+	}
+	conn := net.NewConnection(
+		net.ConnectionInputMulti(link.Writer),
+		net.ConnectionOutputMulti(link.Reader),
+	)
+	if s.tlsConfig != nil {
+		config := s.tlsConfig.GetTLSConfig(tls.WithDestination(s.destination), tls.WithNextProto("dot"))
+		if len(config.ServerName) == 0 {
+			// Verify the IP address of the server against the certificate.
+			config.ServerName = s.destination.Address.String()
+		}
+		conn = tls.Client(conn, config)
+	}
+
+	return &tcpStream{
+		conn:   conn,
+		reader: dns.NewTCPReader(buf.NewReader(conn)),
+		writer: &dns.TCPWriter{Writer: buf.NewWriter(conn)},
+	}, nil
+}
+
+func (s *TCPNameServer) getStream(ctx context.Context) (*tcpStream, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.stream != nil {
+		return s.stream, nil
+	}
+
+	stream, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.stream = stream
+	go s.readResponses(stream)
+	return stream, nil
+}
+
+func (s *TCPNameServer) readResponses(stream *tcpStream) {
+	for {
+		b, err := stream.reader.ReadMessage()
+		if err != nil {
+			s.closeStream(stream, err)
+			return
+		}
+		s.handleResponse(b.Bytes())
+		b.Release()
+	}
+}
+
+// closeStream drops the broken stream, and sends the queries still waiting
+// for answers on it again over a new one.
+func (s *TCPNameServer) closeStream(stream *tcpStream, err error) {
+	s.Lock()
+	if s.stream == stream {
+		s.stream = nil
+		newError("connection to ", s.Name(), " closed").Base(err).AtDebug().WriteToLog()
+	}
+	now := time.Now()
+	var retries []uint16
+	for id, req := range s.requests {
+		if req.stream == stream && !req.retried && req.expire.After(now) {
+			req.retried = true
+			retries = append(retries, id)
+		}
+	}
+	s.Unlock()
+
+	stream.conn.Close() // nolint: errcheck
+
+	for _, id := range retries {
+		s.writeRequest(context.Background(), id)
+	}
+}
+
+func (s *TCPNameServer) writeRequest(ctx context.Context, id uint16) {
+	stream, err := s.getStream(ctx)
+	if err != nil {
+		newError("failed to connect to ", s.Name()).Base(err).AtWarning().WriteToLog()
+		return
+	}
+
+	s.Lock()
+	req, found := s.requests[id]
+	if found {
+		req.stream = stream
+	}
+	s.Unlock()
+	if !found {
+		return
+	}
+
+	if err := stream.write(req.msg); err != nil {
+		newError("failed to send query to ", s.Name()).Base(err).AtDebug().WriteToLog()
+		s.closeStream(stream, err)
+	}
+}
+
+func (s *TCPNameServer) handleResponse(payload []byte) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(payload)
+	if err != nil {
+		newError("failed to parse DNS response").Base(err).AtWarning().WriteToLog()
+		return
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		newError("failed to skip questions in DNS response").Base(err).AtWarning().WriteToLog()
+		return
+	}
+
+	s.Lock()
+	req, found := s.requests[header.ID]
+	if found {
+		delete(s.requests, header.ID)
+	}
+	s.Unlock()
+
+	if !found {
+		return
+	}
+
+	ipRecord := parseResponse(&parser, header, req.domain, req.recType)
+	s.cache.update(req.domain, recordOf(req.recType, ipRecord))
+	common.Must(s.cleanup.Start())
+}
+
+func (s *TCPNameServer) addPendingRequest(domain string, recType dnsmessage.Type) uint16 {
+	id := uint16(atomic.AddUint32(&s.reqID, 1))
+	s.Lock()
+	defer s.Unlock()
+
+	s.requests[id] = &tcpRequest{
+		pendingRequest: pendingRequest{
+			domain:  domain,
+			expire:  time.Now().Add(time.Second * 8),
+			recType: recType,
+		},
+	}
+
+	return id
+}
+
+func (s *TCPNameServer) sendQuery(ctx context.Context, domain string, option IPOption) {
+	newError("querying DNS for: ", domain, " at ", s.Name()).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+
+	msgs := buildReqMsgs(domain, option, func(recType dnsmessage.Type) uint16 {
+		return s.addPendingRequest(domain, recType)
+	}, genEDNS0Options(s.clientIP))
+
+	var ids []uint16
+	for _, msg := range msgs {
+		b, err := dns.PackMessage(msg)
+		if err != nil {
+			newError("failed to pack DNS query").Base(err).AtError().WriteToLog()
+			continue
+		}
+		s.Lock()
+		if req, found := s.requests[msg.Header.ID]; found {
+			req.msg = append([]byte(nil), b.Bytes()...)
+			ids = append(ids, msg.Header.ID)
+		}
+		s.Unlock()
+		b.Release()
+	}
+
+	// Connecting and the TLS handshake may take a while, so the queries are
+	// written in background.
+	go func() {
+		for _, id := range ids {
+			s.writeRequest(ctx, id)
+		}
+	}()
+}
+
+// QueryIP implements Client.
+func (s *TCPNameServer) QueryIP(ctx context.Context, domain string, option IPOption) ([]net.IP, error) {
+	ips, _, err := s.QueryIPWithExpire(ctx, domain, option)
+	return ips, err
+}
+
+// QueryIPWithExpire implements ExpiringClient.
+func (s *TCPNameServer) QueryIPWithExpire(ctx context.Context, domain string, option IPOption) ([]net.IP, time.Time, error) {
+	fqdn := Fqdn(domain)
+	return s.cache.lookup(ctx, fqdn, option, func() {
+		s.sendQuery(ctx, fqdn, option)
+	})
+}
@@ -0,0 +1,132 @@
+package dns_test
+
+import (
+	gotls "crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/dispatcher"
+	. "v2ray.com/core/app/dns"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol/tls/cert"
+	"v2ray.com/core/common/serial"
+	feature_dns "v2ray.com/core/features/dns"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/transport/internet/tls"
+)
+
+func TestTCPNameServer(t *testing.T) {
+	serverCert := cert.MustGenerate(nil, cert.DNSNames("dns.example"))
+	certPEM, keyPEM := serverCert.ToPEM()
+	keyPair, err := gotls.X509KeyPair(certPEM, keyPEM)
+	common.Must(err)
+
+	testCases := []struct {
+		net string
+		url string
+		tls *tls.Config
+	}{
+		{
+			net: "tcp",
+			url: "tcp://127.0.0.1:",
+		},
+		{
+			net: "tcp-tls",
+			url: "tls://127.0.0.1:",
+			tls: &tls.Config{
+				ServerName:        "dns.example",
+				DisableSystemRoot: true,
+				Certificate: []*tls.Certificate{
+					{
+						Certificate: certPEM,
+						Usage:       tls.Certificate_AUTHORITY_VERIFY,
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		port := tcp.PickPort()
+		dnsServer := dns.Server{
+			Addr:      "127.0.0.1:" + port.String(),
+			Net:       test.net,
+			Handler:   &staticHandler{},
+			TLSConfig: &gotls.Config{Certificates: []gotls.Certificate{keyPair}},
+			// Close idle connections quickly, so that reconnecting is tested.
+			IdleTimeout: func() time.Duration {
+				return 200 * time.Millisecond
+			},
+		}
+		go dnsServer.ListenAndServe()
+		time.Sleep(time.Second)
+
+		config := &core.Config{
+			App: []*serial.TypedMessage{
+				serial.ToTypedMessage(&Config{
+					NameServer: []*NameServer{
+						{
+							Address: &net.Endpoint{
+								Address: net.NewIPOrDomain(net.DomainAddress(test.url + port.String())),
+							},
+							TlsSettings: test.tls,
+						},
+					},
+				}),
+				serial.ToTypedMessage(&dispatcher.Config{}),
+				serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+				serial.ToTypedMessage(&policy.Config{}),
+			},
+			Outbound: []*core.OutboundHandlerConfig{
+				{
+					ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+				},
+			},
+		}
+
+		v, err := core.New(config)
+		common.Must(err)
+
+		client := v.GetFeature(feature_dns.ClientType()).(feature_dns.Client)
+
+		{
+			ips, err := client.LookupIP("google.com")
+			if err != nil {
+				t.Fatal(test.url, ": unexpected error: ", err)
+			}
+			if r := cmp.Diff(ips, []net.IP{{8, 8, 8, 8}}); r != "" {
+				t.Fatal(r)
+			}
+		}
+
+		// The server has closed the connection by now.
+		time.Sleep(500 * time.Millisecond)
+
+		{
+			ips, err := client.LookupIP("ipv6.google.com")
+			if err != nil {
+				t.Fatal(test.url, ": unexpected error: ", err)
+			}
+			if r := cmp.Diff(ips, []net.IP{{8, 8, 8, 7}, {0x20, 0x01, 0x48, 0x60, 0x48, 0x60, 0, 0, 0, 0, 0, 0, 0, 0, 0x88, 0x88}}); r != "" {
+				t.Fatal(r)
+			}
+		}
+
+		{
+			_, err := client.LookupIP("notexist.google.com")
+			if r := feature_dns.RCodeFromError(err); r != uint16(dns.RcodeNameError) {
+				t.Fatal(test.url, ": expected NameError, but got ", err)
+			}
+		}
+
+		common.Must(dnsServer.Shutdown())
+	}
+}
@@ -0,0 +1,15 @@
+// +build !confonly
+
+package health
+
+import (
+	"context"
+
+	"v2ray.com/core/common"
+)
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return NewRegistry(ctx, config.(*Config))
+	}))
+}
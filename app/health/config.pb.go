@@ -0,0 +1,91 @@
+package health
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Config struct {
+	// Number of consecutive failed sessions before an outbound is marked down.
+	FailureThreshold uint32 `protobuf:"varint,1,opt,name=failure_threshold,json=failureThreshold,proto3" json:"failure_threshold,omitempty"`
+	// Seconds an outbound stays down before real traffic is allowed through it again.
+	DownDuration         uint32   `protobuf:"varint,2,opt,name=down_duration,json=downDuration,proto3" json:"down_duration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Config) Reset()         { *m = Config{} }
+func (m *Config) String() string { return proto.CompactTextString(m) }
+func (*Config) ProtoMessage()    {}
+func (*Config) Descriptor() ([]byte, []int) {
+	return fileDescriptor_54f6140bcbc8fa03, []int{0}
+}
+
+func (m *Config) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Config.Unmarshal(m, b)
+}
+func (m *Config) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Config.Marshal(b, m, deterministic)
+}
+func (m *Config) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Config.Merge(m, src)
+}
+func (m *Config) XXX_Size() int {
+	return xxx_messageInfo_Config.Size(m)
+}
+func (m *Config) XXX_DiscardUnknown() {
+	xxx_messageInfo_Config.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Config proto.InternalMessageInfo
+
+func (m *Config) GetFailureThreshold() uint32 {
+	if m != nil {
+		return m.FailureThreshold
+	}
+	return 0
+}
+
+func (m *Config) GetDownDuration() uint32 {
+	if m != nil {
+		return m.DownDuration
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*Config)(nil), "v2ray.core.app.health.Config")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/app/health/config.proto", fileDescriptor_54f6140bcbc8fa03)
+}
+
+var fileDescriptor_54f6140bcbc8fa03 = []byte{
+	// 182 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0x2b, 0x33, 0x2a, 0x4a,
+	0xac, 0xd4, 0x4b, 0xce, 0xcf, 0xd5, 0x4f, 0xce, 0x2f, 0x4a, 0xd5, 0x4f, 0x2c, 0x28, 0xd0, 0xcf,
+	0x48, 0x4d, 0xcc, 0x29, 0xc9, 0xd0, 0x4f, 0xce, 0xcf, 0x4b, 0xcb, 0x4c, 0xd7, 0x2b, 0x28, 0xca,
+	0x2f, 0xc9, 0x17, 0x12, 0x85, 0xa9, 0x2b, 0x4a, 0xd5, 0x4b, 0x2c, 0x28, 0xd0, 0x83, 0xa8, 0x51,
+	0x8a, 0xe2, 0x62, 0x73, 0x06, 0x2b, 0x13, 0xd2, 0xe6, 0x12, 0x4c, 0x4b, 0xcc, 0xcc, 0x29, 0x2d,
+	0x4a, 0x8d, 0x2f, 0xc9, 0x28, 0x4a, 0x2d, 0xce, 0xc8, 0xcf, 0x49, 0x91, 0x60, 0x54, 0x60, 0xd4,
+	0xe0, 0x0d, 0x12, 0x80, 0x4a, 0x84, 0xc0, 0xc4, 0x85, 0x94, 0xb9, 0x78, 0x53, 0xf2, 0xcb, 0xf3,
+	0xe2, 0x53, 0x4a, 0x8b, 0x12, 0x4b, 0x32, 0xf3, 0xf3, 0x24, 0x98, 0xc0, 0x0a, 0x79, 0x40, 0x82,
+	0x2e, 0x50, 0x31, 0x27, 0x5b, 0x2e, 0xc9, 0xe4, 0xfc, 0x5c, 0x3d, 0xac, 0x16, 0x07, 0x30, 0x46,
+	0xb1, 0x41, 0x58, 0xab, 0x98, 0x44, 0xc3, 0x8c, 0x82, 0x12, 0x2b, 0xf5, 0x9c, 0x41, 0x2a, 0x1c,
+	0x0b, 0x0a, 0xf4, 0x3c, 0xc0, 0xe2, 0x49, 0x6c, 0x60, 0x87, 0x1b, 0x03, 0x06, 0x00, 0x58, 0x82,
+	0x19, 0x87, 0xe2, 0x00, 0x00, 0x00,
+}
@@ -0,0 +1,141 @@
+// +build !confonly
+
+package health
+
+//go:generate errorgen
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"v2ray.com/core/features/health"
+)
+
+type outboundState struct {
+	consecutiveFailures uint32
+	downSince           time.Time
+	sessions            uint64
+	failures            uint64
+	firstByte           time.Duration
+	lastFailure         time.Time
+}
+
+// Registry is an implementation of health.Registry.
+type Registry struct {
+	access           sync.RWMutex
+	failureThreshold uint32
+	downDuration     time.Duration
+	outbounds        map[string]*outboundState
+}
+
+// NewRegistry creates a new Registry based on the given config.
+func NewRegistry(ctx context.Context, config *Config) (*Registry, error) {
+	r := &Registry{
+		failureThreshold: config.FailureThreshold,
+		downDuration:     time.Duration(config.DownDuration) * time.Second,
+		outbounds:        make(map[string]*outboundState),
+	}
+	if r.failureThreshold == 0 {
+		r.failureThreshold = 3
+	}
+	if r.downDuration == 0 {
+		r.downDuration = 30 * time.Second
+	}
+	return r, nil
+}
+
+// Type implements common.HasType.
+func (*Registry) Type() interface{} {
+	return health.RegistryType()
+}
+
+// Report implements health.Registry.
+func (r *Registry) Report(tag string, o health.Observation) {
+	if len(tag) == 0 {
+		return
+	}
+
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	s, found := r.outbounds[tag]
+	if !found {
+		s = new(outboundState)
+		r.outbounds[tag] = s
+	}
+
+	s.sessions++
+	if o.Failed() {
+		s.failures++
+		s.consecutiveFailures++
+		s.lastFailure = time.Now()
+		if s.consecutiveFailures >= r.failureThreshold {
+			if s.downSince.IsZero() {
+				newError("outbound [", tag, "] marked down after ", s.consecutiveFailures, " failed sessions").AtWarning().WriteToLog()
+			}
+			s.downSince = s.lastFailure
+		}
+		return
+	}
+	if !o.Succeeded() {
+		return
+	}
+
+	if !s.downSince.IsZero() {
+		newError("outbound [", tag, "] is back up").AtInfo().WriteToLog()
+	}
+	s.consecutiveFailures = 0
+	s.downSince = time.Time{}
+	if s.firstByte == 0 {
+		s.firstByte = o.FirstByte
+	} else {
+		s.firstByte += (o.FirstByte - s.firstByte) / 8
+	}
+}
+
+func (r *Registry) isHealthy(s *outboundState) bool {
+	// A down outbound is given another chance after downDuration, so that real traffic can find out whether it recovered.
+	return s.downSince.IsZero() || time.Since(s.downSince) > r.downDuration
+}
+
+// IsHealthy implements health.Registry.
+func (r *Registry) IsHealthy(tag string) bool {
+	r.access.RLock()
+	defer r.access.RUnlock()
+
+	s, found := r.outbounds[tag]
+	if !found {
+		return true
+	}
+	return r.isHealthy(s)
+}
+
+// GetStatus implements health.Registry.
+func (r *Registry) GetStatus(tag string) (health.Status, bool) {
+	r.access.RLock()
+	defer r.access.RUnlock()
+
+	s, found := r.outbounds[tag]
+	if !found {
+		return health.Status{}, false
+	}
+	return health.Status{
+		Healthy:             r.isHealthy(s),
+		ConsecutiveFailures: s.consecutiveFailures,
+		Sessions:            s.sessions,
+		Failures:            s.failures,
+		AverageFirstByte:    s.firstByte,
+		LastFailure:         s.lastFailure,
+	}, true
+}
+
+// Start implements common.Runnable.
+func (*Registry) Start() error {
+	return nil
+}
+
+// Close implements common.Closable.
+func (*Registry) Close() error {
+	return nil
+}
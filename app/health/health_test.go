@@ -0,0 +1,64 @@
+package health_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "v2ray.com/core/app/health"
+	"v2ray.com/core/common"
+	"v2ray.com/core/features/health"
+)
+
+func TestInterface(t *testing.T) {
+	_ = (health.Registry)(new(Registry))
+}
+
+func TestRegistry(t *testing.T) {
+	raw, err := common.CreateObject(context.Background(), &Config{
+		FailureThreshold: 3,
+		DownDuration:     60,
+	})
+	common.Must(err)
+
+	r := raw.(health.Registry)
+	if !r.IsHealthy("proxy") {
+		t.Error("expect unknown outbound to be healthy")
+	}
+
+	r.Report("proxy", health.Observation{DialFailed: true})
+	if !r.IsHealthy("proxy") {
+		t.Error("expect outbound to be healthy before reaching failure threshold")
+	}
+
+	// A session that closes without any downlink data tells nothing.
+	r.Report("proxy", health.Observation{})
+	if status, _ := r.GetStatus("proxy"); status.ConsecutiveFailures != 1 {
+		t.Error("expect session without downlink to be ignored, but got ", status)
+	}
+
+	r.Report("proxy", health.Observation{FirstByteTimeout: true})
+	if !r.IsHealthy("proxy") {
+		t.Error("expect outbound to be healthy before reaching failure threshold")
+	}
+
+	r.Report("proxy", health.Observation{DialFailed: true})
+	if r.IsHealthy("proxy") {
+		t.Error("expect outbound to be down")
+	}
+
+	r.Report("proxy", health.Observation{FirstByte: 100 * time.Millisecond, DownlinkBytes: 1024})
+	status, found := r.GetStatus("proxy")
+	if !found {
+		t.Fatal("expect status of proxy")
+	}
+	if !status.Healthy || status.ConsecutiveFailures != 0 {
+		t.Error("expect outbound to be back up, but got ", status)
+	}
+	if status.Sessions != 5 || status.Failures != 3 {
+		t.Error("unexpected session counters: ", status)
+	}
+	if status.AverageFirstByte != 100*time.Millisecond {
+		t.Error("unexpected first byte latency: ", status.AverageFirstByte)
+	}
+}
@@ -0,0 +1,101 @@
+package measure
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"v2ray.com/core/common/net"
+)
+
+// ParseLegacyTarget converts a probe target in the legacy "proto:host:port" form, where proto is either tcp or tls, into a ProbeConfig.
+func ParseLegacyTarget(target string, content string) (*ProbeConfig, error) {
+	parts := strings.Split(target, ":")
+	if len(parts) != 3 {
+		return nil, newError("invalid probe target: ", target, ", expecting proto:host:port")
+	}
+
+	config := &ProbeConfig{
+		Content: []byte(content),
+	}
+	switch strings.ToLower(parts[0]) {
+	case "tcp":
+		config.Kind = ProbeConfig_TCP
+	case "tls":
+		config.Kind = ProbeConfig_TLS
+	default:
+		return nil, newError("unknown protocol in probe target: ", target)
+	}
+
+	if len(parts[1]) == 0 {
+		return nil, newError("empty host in probe target: ", target)
+	}
+	port, err := strconv.ParseUint(parts[2], 10, 16)
+	if err != nil {
+		return nil, newError("invalid port in probe target: ", target).Base(err)
+	}
+	config.Address = net.NewIPOrDomain(net.ParseAddress(parts[1]))
+	config.Port = uint32(port)
+
+	return config, nil
+}
+
+// Validate returns an error if the probe can't be sent as configured.
+func (c *ProbeConfig) Validate() error {
+	if c.Address == nil || c.Address.Address == nil {
+		return newError("probe address is not set")
+	}
+	if c.Port == 0 || c.Port > 65535 {
+		return newError("invalid probe port: ", c.Port)
+	}
+	if c.Timeout == 0 {
+		return newError("probe timeout is not set")
+	}
+	if c.Interval == 0 {
+		return newError("probe interval is not set")
+	}
+
+	switch c.Kind {
+	case ProbeConfig_TCP, ProbeConfig_TLS:
+	case ProbeConfig_HTTP:
+		if len(c.Path) > 0 && !strings.HasPrefix(c.Path, "/") {
+			return newError("HTTP probe path must start with '/': ", c.Path)
+		}
+		if c.ExpectedStatus != 0 && (c.ExpectedStatus < 100 || c.ExpectedStatus > 599) {
+			return newError("invalid expected HTTP status: ", c.ExpectedStatus)
+		}
+	case ProbeConfig_DNS:
+		if len(c.Domain) == 0 {
+			return newError("DNS probe domain is not set")
+		}
+	default:
+		return newError("unknown probe kind: ", c.Kind)
+	}
+
+	return nil
+}
+
+// Destination returns the destination that probes are sent to.
+func (c *ProbeConfig) Destination() net.Destination {
+	return net.TCPDestination(c.Address.AsAddress(), net.Port(c.Port))
+}
+
+// GetTimeoutValue returns the timeout of a single probe.
+func (c *ProbeConfig) GetTimeoutValue() time.Duration {
+	return time.Duration(c.GetTimeout()) * time.Second
+}
+
+// GetIntervalValue returns the time between two measurements.
+func (c *ProbeConfig) GetIntervalValue() time.Duration {
+	return time.Duration(c.GetInterval()) * time.Second
+}
+
+// GetDelayValue returns the time between two probes of one measurement.
+func (c *ProbeConfig) GetDelayValue() time.Duration {
+	return time.Duration(c.GetDelay()) * time.Second
+}
+
+// GetToleranceValue returns the latency difference tolerated before switching outbounds.
+func (c *ProbeConfig) GetToleranceValue() time.Duration {
+	return time.Duration(c.GetTolerance()) * time.Millisecond
+}
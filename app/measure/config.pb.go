@@ -0,0 +1,261 @@
+package measure
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+	net "v2ray.com/core/common/net"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ProbeConfig_Kind int32
+
+const (
+	// Connects to the target, sends content if any, and waits for the first byte of response.
+	ProbeConfig_TCP ProbeConfig_Kind = 0
+	// Completes a TLS handshake with the target. Content is sent afterwards if any.
+	ProbeConfig_TLS ProbeConfig_Kind = 1
+	// Sends an HTTP GET request and checks the response status code.
+	ProbeConfig_HTTP ProbeConfig_Kind = 2
+	// Sends a DNS query over TCP and waits for the answer.
+	ProbeConfig_DNS ProbeConfig_Kind = 3
+)
+
+var ProbeConfig_Kind_name = map[int32]string{
+	0: "TCP",
+	1: "TLS",
+	2: "HTTP",
+	3: "DNS",
+}
+
+var ProbeConfig_Kind_value = map[string]int32{
+	"TCP":  0,
+	"TLS":  1,
+	"HTTP": 2,
+	"DNS":  3,
+}
+
+func (x ProbeConfig_Kind) String() string {
+	return proto.EnumName(ProbeConfig_Kind_name, int32(x))
+}
+
+func (ProbeConfig_Kind) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_5c8d5120840d4917, []int{0, 0}
+}
+
+// ProbeConfig describes how outbounds are probed.
+type ProbeConfig struct {
+	Kind ProbeConfig_Kind `protobuf:"varint,1,opt,name=kind,proto3,enum=v2ray.core.app.measure.ProbeConfig_Kind" json:"kind,omitempty"`
+	// Address and port of the probe target.
+	Address *net.IPOrDomain `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Port    uint32          `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	// Raw content sent by TCP and TLS probes.
+	Content []byte `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	// Path requested by HTTP probes.
+	Path string `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
+	// Whether HTTP probes are sent over TLS.
+	Tls bool `protobuf:"varint,6,opt,name=tls,proto3" json:"tls,omitempty"`
+	// Status code expected by HTTP probes. 0 accepts any 2xx or 3xx status.
+	ExpectedStatus uint32 `protobuf:"varint,7,opt,name=expected_status,json=expectedStatus,proto3" json:"expected_status,omitempty"`
+	// Domain queried by DNS probes.
+	Domain string `protobuf:"bytes,8,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Number of probes averaged into one measurement.
+	TotalMeasures uint32 `protobuf:"varint,9,opt,name=total_measures,json=totalMeasures,proto3" json:"total_measures,omitempty"`
+	// Seconds between measurements.
+	Interval uint32 `protobuf:"varint,10,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Seconds between probes of one measurement.
+	Delay uint32 `protobuf:"varint,11,opt,name=delay,proto3" json:"delay,omitempty"`
+	// Seconds before a probe is considered failed.
+	Timeout uint32 `protobuf:"varint,12,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Milliseconds of latency difference tolerated before switching outbounds.
+	Tolerance uint32 `protobuf:"varint,13,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
+	// Number of consecutive failed probes before an outbound is marked down.
+	FailureThreshold uint32 `protobuf:"varint,14,opt,name=failure_threshold,json=failureThreshold,proto3" json:"failure_threshold,omitempty"`
+	// Number of consecutive successful probes before a down outbound is brought back.
+	RecoveryThreshold    uint32   `protobuf:"varint,15,opt,name=recovery_threshold,json=recoveryThreshold,proto3" json:"recovery_threshold,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProbeConfig) Reset()         { *m = ProbeConfig{} }
+func (m *ProbeConfig) String() string { return proto.CompactTextString(m) }
+func (*ProbeConfig) ProtoMessage()    {}
+func (*ProbeConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c8d5120840d4917, []int{0}
+}
+
+func (m *ProbeConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProbeConfig.Unmarshal(m, b)
+}
+func (m *ProbeConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProbeConfig.Marshal(b, m, deterministic)
+}
+func (m *ProbeConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProbeConfig.Merge(m, src)
+}
+func (m *ProbeConfig) XXX_Size() int {
+	return xxx_messageInfo_ProbeConfig.Size(m)
+}
+func (m *ProbeConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProbeConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProbeConfig proto.InternalMessageInfo
+
+func (m *ProbeConfig) GetKind() ProbeConfig_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return ProbeConfig_TCP
+}
+
+func (m *ProbeConfig) GetAddress() *net.IPOrDomain {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *ProbeConfig) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *ProbeConfig) GetContent() []byte {
+	if m != nil {
+		return m.Content
+	}
+	return nil
+}
+
+func (m *ProbeConfig) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *ProbeConfig) GetTls() bool {
+	if m != nil {
+		return m.Tls
+	}
+	return false
+}
+
+func (m *ProbeConfig) GetExpectedStatus() uint32 {
+	if m != nil {
+		return m.ExpectedStatus
+	}
+	return 0
+}
+
+func (m *ProbeConfig) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *ProbeConfig) GetTotalMeasures() uint32 {
+	if m != nil {
+		return m.TotalMeasures
+	}
+	return 0
+}
+
+func (m *ProbeConfig) GetInterval() uint32 {
+	if m != nil {
+		return m.Interval
+	}
+	return 0
+}
+
+func (m *ProbeConfig) GetDelay() uint32 {
+	if m != nil {
+		return m.Delay
+	}
+	return 0
+}
+
+func (m *ProbeConfig) GetTimeout() uint32 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *ProbeConfig) GetTolerance() uint32 {
+	if m != nil {
+		return m.Tolerance
+	}
+	return 0
+}
+
+func (m *ProbeConfig) GetFailureThreshold() uint32 {
+	if m != nil {
+		return m.FailureThreshold
+	}
+	return 0
+}
+
+func (m *ProbeConfig) GetRecoveryThreshold() uint32 {
+	if m != nil {
+		return m.RecoveryThreshold
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterEnum("v2ray.core.app.measure.ProbeConfig_Kind", ProbeConfig_Kind_name, ProbeConfig_Kind_value)
+	proto.RegisterType((*ProbeConfig)(nil), "v2ray.core.app.measure.ProbeConfig")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/app/measure/config.proto", fileDescriptor_5c8d5120840d4917)
+}
+
+var fileDescriptor_5c8d5120840d4917 = []byte{
+	// 457 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0xcf, 0x6e, 0xd3, 0x4c,
+	0x14, 0xc5, 0x3f, 0x27, 0x6e, 0x9c, 0xdc, 0x34, 0xa9, 0x3b, 0xfa, 0x14, 0x8d, 0x22, 0x16, 0xa6,
+	0x12, 0xaa, 0xa5, 0x8a, 0xb1, 0x14, 0x96, 0xb0, 0x00, 0xd2, 0x05, 0x88, 0x7f, 0x96, 0x13, 0xb1,
+	0x60, 0x13, 0x4d, 0xed, 0x5b, 0x62, 0x61, 0xcf, 0x58, 0xe3, 0x49, 0x44, 0x5e, 0x89, 0x17, 0xe0,
+	0xf5, 0x90, 0x6f, 0xec, 0x12, 0x21, 0xd8, 0x9d, 0x73, 0xee, 0xef, 0x1e, 0xcd, 0xd8, 0x03, 0xd7,
+	0xfb, 0x85, 0x91, 0x07, 0x91, 0xea, 0x32, 0x4a, 0xb5, 0xc1, 0x48, 0x56, 0x55, 0x54, 0xa2, 0xac,
+	0x77, 0x06, 0xa3, 0x54, 0xab, 0xfb, 0xfc, 0xab, 0xa8, 0x8c, 0xb6, 0x9a, 0xcd, 0x3a, 0xd0, 0xa0,
+	0x90, 0x55, 0x25, 0x5a, 0x68, 0xfe, 0x67, 0x41, 0xaa, 0xcb, 0x52, 0xab, 0x48, 0xa1, 0x8d, 0x64,
+	0x96, 0x19, 0xac, 0xeb, 0x63, 0xc1, 0xd5, 0x4f, 0x17, 0xc6, 0xb1, 0xd1, 0x77, 0xb8, 0xa4, 0x5a,
+	0xf6, 0x02, 0xdc, 0x6f, 0xb9, 0xca, 0xb8, 0x13, 0x38, 0xe1, 0x74, 0x11, 0x8a, 0xbf, 0xf7, 0x8b,
+	0x93, 0x15, 0xf1, 0x2e, 0x57, 0x59, 0x42, 0x5b, 0xec, 0x39, 0x78, 0x6d, 0x3d, 0xef, 0x05, 0x4e,
+	0x38, 0x5e, 0x3c, 0x3e, 0x2d, 0x38, 0x1e, 0x42, 0x28, 0xb4, 0xe2, 0x6d, 0xfc, 0xc9, 0xdc, 0xea,
+	0x52, 0xe6, 0x2a, 0xe9, 0x36, 0x18, 0x03, 0xb7, 0xd2, 0xc6, 0xf2, 0x7e, 0xe0, 0x84, 0x93, 0x84,
+	0x34, 0xe3, 0xe0, 0xa5, 0x5a, 0x59, 0x54, 0x96, 0xbb, 0x81, 0x13, 0x9e, 0x27, 0x9d, 0x25, 0x5a,
+	0xda, 0x2d, 0x3f, 0x0b, 0x9c, 0x70, 0x94, 0x90, 0x66, 0x3e, 0xf4, 0x6d, 0x51, 0xf3, 0x41, 0xe0,
+	0x84, 0xc3, 0xa4, 0x91, 0xec, 0x1a, 0x2e, 0xf0, 0x7b, 0x85, 0xa9, 0xc5, 0x6c, 0x53, 0x5b, 0x69,
+	0x77, 0x35, 0xf7, 0xa8, 0x7e, 0xda, 0xc5, 0x2b, 0x4a, 0xd9, 0x0c, 0x06, 0x19, 0x9d, 0x87, 0x0f,
+	0xa9, 0xb0, 0x75, 0xec, 0x09, 0x4c, 0xad, 0xb6, 0xb2, 0xd8, 0xb4, 0x37, 0xaf, 0xf9, 0x88, 0xf6,
+	0x27, 0x94, 0x7e, 0x68, 0x43, 0x36, 0x87, 0x61, 0xae, 0x2c, 0x9a, 0xbd, 0x2c, 0x38, 0x10, 0xf0,
+	0xe0, 0xd9, 0xff, 0x70, 0x96, 0x61, 0x21, 0x0f, 0x7c, 0x4c, 0x83, 0xa3, 0x69, 0x6e, 0x66, 0xf3,
+	0x12, 0xf5, 0xce, 0xf2, 0x73, 0xca, 0x3b, 0xcb, 0x1e, 0xc1, 0xc8, 0xea, 0x02, 0x8d, 0x54, 0x29,
+	0xf2, 0x09, 0xcd, 0x7e, 0x07, 0xec, 0x06, 0x2e, 0xef, 0x65, 0x5e, 0xec, 0x0c, 0x6e, 0xec, 0xd6,
+	0x60, 0xbd, 0xd5, 0x45, 0xc6, 0xa7, 0x44, 0xf9, 0xed, 0x60, 0xdd, 0xe5, 0xec, 0x29, 0x30, 0x83,
+	0xa9, 0xde, 0xa3, 0x39, 0x9c, 0xd0, 0x17, 0x44, 0x5f, 0x76, 0x93, 0x07, 0xfc, 0xea, 0x06, 0xdc,
+	0xe6, 0x67, 0x32, 0x0f, 0xfa, 0xeb, 0x65, 0xec, 0xff, 0x47, 0xe2, 0xfd, 0xca, 0x77, 0xd8, 0x10,
+	0xdc, 0x37, 0xeb, 0x75, 0xec, 0xf7, 0x9a, 0xe8, 0xf6, 0xe3, 0xca, 0xef, 0xbf, 0x7e, 0x09, 0xf3,
+	0x54, 0x97, 0xff, 0x78, 0x20, 0xb1, 0xf3, 0xc5, 0x6b, 0xe5, 0x8f, 0xde, 0xec, 0xf3, 0x22, 0x91,
+	0x07, 0xb1, 0x6c, 0x98, 0x57, 0x55, 0x25, 0xda, 0xaf, 0x76, 0x37, 0xa0, 0x27, 0xf8, 0xec, 0xd7,
+	0x00, 0x2b, 0x0f, 0xa7, 0x0a, 0xee, 0x02, 0x00, 0x00,
+}
@@ -0,0 +1,56 @@
+package measure_test
+
+import (
+	"testing"
+
+	. "v2ray.com/core/app/measure"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+)
+
+func TestParseLegacyTarget(t *testing.T) {
+	config, err := ParseLegacyTarget("tls:www.google.com:443", "HEAD / HTTP/1.1\r\n\r\n")
+	common.Must(err)
+	if config.Kind != ProbeConfig_TLS {
+		t.Error("expect TLS probe, but got ", config.Kind)
+	}
+	if dest := config.Destination(); dest != net.TCPDestination(net.DomainAddress("www.google.com"), 443) {
+		t.Error("unexpected destination: ", dest)
+	}
+
+	for _, target := range []string{"www.google.com:443", "udp:www.google.com:443", "tcp::443", "tcp:www.google.com:https"} {
+		if _, err := ParseLegacyTarget(target, ""); err == nil {
+			t.Error("expect error for target: ", target)
+		}
+	}
+}
+
+func TestProbeConfigValidate(t *testing.T) {
+	valid := func() *ProbeConfig {
+		return &ProbeConfig{
+			Kind:     ProbeConfig_DNS,
+			Address:  net.NewIPOrDomain(net.LocalHostIP),
+			Port:     53,
+			Domain:   "v2ray.com",
+			Interval: 60,
+			Timeout:  5,
+		}
+	}
+	common.Must(valid().Validate())
+
+	invalid := []func(*ProbeConfig){
+		func(c *ProbeConfig) { c.Address = nil },
+		func(c *ProbeConfig) { c.Port = 0 },
+		func(c *ProbeConfig) { c.Timeout = 0 },
+		func(c *ProbeConfig) { c.Domain = "" },
+		func(c *ProbeConfig) { c.Kind = ProbeConfig_HTTP; c.Path = "generate_204" },
+		func(c *ProbeConfig) { c.Kind = ProbeConfig_HTTP; c.ExpectedStatus = 1000 },
+	}
+	for i, modify := range invalid {
+		c := valid()
+		modify(c)
+		if err := c.Validate(); err == nil {
+			t.Error("expect error in case ", i)
+		}
+	}
+}
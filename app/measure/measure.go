@@ -5,13 +5,19 @@ package measure
 //go:generate errorgen
 
 import (
+	"bufio"
 	"context"
 	"crypto/tls"
-	"fmt"
+	"encoding/binary"
+	"io"
+	"net/http"
 	"strings"
 	"time"
 
+	"golang.org/x/net/dns/dnsmessage"
+
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/dice"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/session"
 	"v2ray.com/core/features/outbound"
@@ -19,98 +25,199 @@ import (
 	"v2ray.com/core/transport/pipe"
 )
 
+// MeasureLatency sends a probe to target, in "proto:host:port" form, through the handler. It returns timeout if the probe fails.
+//
+// Deprecated: Use Probe.
 func MeasureLatency(handler outbound.Handler, target, content string, timeout time.Duration) time.Duration {
-	var isTls bool = false
-	tgtParts := strings.Split(target, ":")
-	if len(tgtParts) != 3 {
-		panic("invalid target in latency balancer")
+	config, err := ParseLegacyTarget(target, content)
+	if err != nil {
+		newError("failed to measure latency").Base(err).AtWarning().WriteToLog()
+		return timeout
 	}
-	proto := tgtParts[0]
-	host := tgtParts[1]
-	port := tgtParts[2]
-	if proto == "tls" {
-		isTls = true
-		proto = "tcp"
+	config.Timeout = uint32(timeout / time.Second)
+
+	latency, err := probe(handler, config, timeout)
+	if err != nil {
+		newError("probe failed").Base(err).AtDebug().WriteToLog()
+		return timeout
 	}
+	return latency
+}
 
+// Probe sends a probe as described by config through the handler, and returns the time it took to complete.
+func Probe(handler outbound.Handler, config *ProbeConfig) (time.Duration, error) {
+	if err := config.Validate(); err != nil {
+		return 0, err
+	}
+	return probe(handler, config, config.GetTimeoutValue())
+}
+
+func probe(handler outbound.Handler, config *ProbeConfig, timeout time.Duration) (time.Duration, error) {
 	uplinkReader, uplinkWriter := pipe.New(pipe.WithoutSizeLimit())
 	downlinkReader, downlinkWriter := pipe.New(pipe.WithoutSizeLimit())
-	inbound := net.NewConnection(
+	conn := net.NewConnection(
 		net.ConnectionInputMulti(uplinkWriter),
 		net.ConnectionOutputMulti(downlinkReader),
 	)
-	if isTls {
-		inbound = tls.Client(inbound, &tls.Config{ServerName: host})
-	}
-	outbound := &transport.Link{
+	link := &transport.Link{
 		Reader: uplinkReader,
 		Writer: downlinkWriter,
 	}
 	defer func() {
 		common.Close(downlinkWriter)
 		common.Interrupt(uplinkReader)
-		inbound.Close()
+		conn.Close()
 	}()
 
-	destination, err := net.ParseDestination(fmt.Sprintf("%s:%s:%s", proto, host, port))
-	if err != nil {
-		panic(err)
-	}
-
-	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: destination})
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: config.Destination()})
 
-	go handler.Dispatch(ctx, outbound)
+	go handler.Dispatch(ctx, link)
 
-	errCh := make(chan struct{}, 1)
-	done := make(chan struct{}, 1)
+	errCh := make(chan error, 1)
 	start := time.Now()
 
-	go readResponse(inbound, errCh, done)
-	go writeRequest(inbound, content, errCh)
+	go func() {
+		errCh <- send(conn, config)
+	}()
 
 	select {
-	case <-done:
-	case <-errCh:
-		return timeout
+	case err := <-errCh:
+		if err != nil {
+			return 0, err
+		}
 	case <-time.After(timeout):
-		return timeout
+		return 0, newError("probe timed out after ", timeout)
 	}
 
-	elasped := time.Since(start)
+	return time.Since(start), nil
+}
 
-	return elasped
+func send(conn net.Conn, config *ProbeConfig) error {
+	switch config.Kind {
+	case ProbeConfig_TCP:
+		return sendRaw(conn, config.Content)
+	case ProbeConfig_TLS:
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: serverName(config)})
+		if err := tlsConn.Handshake(); err != nil {
+			return newError("failed to complete TLS handshake").Base(err)
+		}
+		if len(config.Content) == 0 {
+			return nil
+		}
+		return sendRaw(tlsConn, config.Content)
+	case ProbeConfig_HTTP:
+		if config.Tls {
+			conn = tls.Client(conn, &tls.Config{ServerName: serverName(config)})
+		}
+		return sendHTTP(conn, config)
+	case ProbeConfig_DNS:
+		return sendDNS(conn, config.Domain)
+	default:
+		return newError("unknown probe kind: ", config.Kind)
+	}
 }
 
-func writeRequest(conn net.Conn, content string, errCh chan struct{}) {
-	_, err := conn.Write([]byte(content))
-	if err != nil {
-		newError("failed to send probe content").Base(err).WriteToLog()
-		select {
-		case errCh <- struct{}{}:
-		default:
+func serverName(config *ProbeConfig) string {
+	addr := config.Address.AsAddress()
+	if addr.Family().IsDomain() {
+		return addr.Domain()
+	}
+	return addr.String()
+}
+
+func sendRaw(conn net.Conn, content []byte) error {
+	if len(content) > 0 {
+		if _, err := conn.Write(content); err != nil {
+			return newError("failed to send probe content").Base(err)
 		}
-		return
 	}
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return newError("failed to read probe response").Base(err)
+	}
+	return nil
 }
 
-func readResponse(conn net.Conn, errCh, done chan struct{}) {
-	buf := make([]byte, 1)
-	n, err := conn.Read(buf)
+func sendHTTP(conn net.Conn, config *ProbeConfig) error {
+	path := config.Path
+	if len(path) == 0 {
+		path = "/"
+	}
+	req, err := http.NewRequest("GET", path, nil)
 	if err != nil {
-		newError("failed to read probe response").Base(err).WriteToLog()
-		select {
-		case errCh <- struct{}{}:
-		default:
-		}
-		return
+		return newError("failed to create HTTP probe").Base(err)
 	}
-	if n == 0 {
-		newError("failed to read probe response: empty response").WriteToLog()
-		select {
-		case errCh <- struct{}{}:
-		default:
+	req.Host = serverName(config)
+	req.Close = true
+	if err := req.Write(conn); err != nil {
+		return newError("failed to send HTTP probe").Base(err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return newError("failed to read HTTP probe response").Base(err)
+	}
+	resp.Body.Close()
+
+	if config.ExpectedStatus != 0 {
+		if uint32(resp.StatusCode) != config.ExpectedStatus {
+			return newError("unexpected HTTP status ", resp.StatusCode, ", expecting ", config.ExpectedStatus)
 		}
-		return
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return newError("unexpected HTTP status ", resp.StatusCode)
+	}
+	return nil
+}
+
+func sendDNS(conn net.Conn, domain string) error {
+	if !strings.HasSuffix(domain, ".") {
+		domain += "."
+	}
+	name, err := dnsmessage.NewName(domain)
+	if err != nil {
+		return newError("invalid domain in DNS probe: ", domain).Base(err)
+	}
+	id := dice.RollUint16()
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return newError("failed to pack DNS probe").Base(err)
+	}
+
+	// DNS over TCP, every message is prefixed with its length.
+	b := make([]byte, 2+len(packed))
+	binary.BigEndian.PutUint16(b, uint16(len(packed)))
+	copy(b[2:], packed)
+	if _, err := conn.Write(b); err != nil {
+		return newError("failed to send DNS probe").Base(err)
+	}
+
+	var size uint16
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		return newError("failed to read DNS probe response").Base(err)
+	}
+	resp := make([]byte, size)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return newError("failed to read DNS probe response").Base(err)
+	}
+
+	var header dnsmessage.Header
+	var parser dnsmessage.Parser
+	if header, err = parser.Start(resp); err != nil {
+		return newError("failed to parse DNS probe response").Base(err)
+	}
+	if header.ID != id {
+		return newError("mismatched DNS response id")
+	}
+	if header.RCode != dnsmessage.RCodeSuccess {
+		return newError("DNS probe returned ", header.RCode)
 	}
-	done <- struct{}{}
+	return nil
 }
@@ -80,6 +80,7 @@ func (w *tcpWorker) callback(conn internet.Connection) {
 		Source:  net.DestinationFromAddr(conn.RemoteAddr()),
 		Gateway: net.TCPDestination(w.address, w.port),
 		Tag:     w.tag,
+		Conn:    conn,
 	})
 	content := new(session.Content)
 	if w.sniffingConfig != nil {
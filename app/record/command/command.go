@@ -0,0 +1,79 @@
+// +build !confonly
+
+package command
+
+//go:generate errorgen
+
+import (
+	"context"
+
+	grpc "google.golang.org/grpc"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/record"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/session"
+	feature_record "v2ray.com/core/features/record"
+)
+
+// recordServer is an implementation of RecordService.
+type recordServer struct {
+	recorder feature_record.Recorder
+}
+
+func NewRecordServer(recorder feature_record.Recorder) RecordServiceServer {
+	return &recordServer{recorder: recorder}
+}
+
+func (s *recordServer) ListRecords(ctx context.Context, request *ListRecordsRequest) (*ListRecordsResponse, error) {
+	r, ok := s.recorder.(*record.Recorder)
+	if !ok {
+		return nil, newError("ListRecords only works with its own record.Recorder.")
+	}
+
+	records, err := r.Recent(int(request.Limit))
+	if err != nil {
+		return nil, err
+	}
+	response := &ListRecordsResponse{}
+	for _, rec := range records {
+		response.Record = append(response.Record, toRecord(rec))
+	}
+	return response, nil
+}
+
+func toRecord(r *session.ProxyRecord) *Record {
+	return &Record{
+		Type:          Record_Type(r.RecordType),
+		Target:        r.Target,
+		Tag:           r.Tag,
+		StartTime:     r.StartTime,
+		EndTime:       r.EndTime,
+		UploadBytes:   int64(r.UploadBytes),
+		DownloadBytes: int64(r.DownloadBytes),
+		DnsQueryType:  r.DNSQueryType,
+		DnsRequest:    r.DNSRequest,
+		DnsResponse:   r.DNSResponse,
+		DnsNumIps:     r.DNSNumIPs,
+	}
+}
+
+type service struct {
+	recorder feature_record.Recorder
+}
+
+func (s *service) Register(server *grpc.Server) {
+	RegisterRecordServiceServer(server, NewRecordServer(s.recorder))
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := new(service)
+
+		core.RequireFeatures(ctx, func(r feature_record.Recorder) {
+			s.recorder = r
+		})
+
+		return s, nil
+	}))
+}
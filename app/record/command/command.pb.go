@@ -0,0 +1,397 @@
+package command
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Record_Type int32
+
+const (
+	Record_Connection Record_Type = 0
+	Record_DNS        Record_Type = 1
+)
+
+var Record_Type_name = map[int32]string{
+	0: "Connection",
+	1: "DNS",
+}
+
+var Record_Type_value = map[string]int32{
+	"Connection": 0,
+	"DNS":        1,
+}
+
+func (x Record_Type) String() string {
+	return proto.EnumName(Record_Type_name, int32(x))
+}
+
+func (Record_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_379b707de4993750, []int{0, 0}
+}
+
+type Record struct {
+	Type   Record_Type `protobuf:"varint,1,opt,name=type,proto3,enum=v2ray.core.app.record.command.Record_Type" json:"type,omitempty"`
+	Target string      `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	// Tag of the outbound, or how a DNS query was answered.
+	Tag string `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
+	// Unix time in nanoseconds.
+	StartTime     int64 `protobuf:"varint,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64 `protobuf:"varint,5,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	UploadBytes   int64 `protobuf:"varint,6,opt,name=upload_bytes,json=uploadBytes,proto3" json:"upload_bytes,omitempty"`
+	DownloadBytes int64 `protobuf:"varint,7,opt,name=download_bytes,json=downloadBytes,proto3" json:"download_bytes,omitempty"`
+	// 0 for A, 1 for AAAA, and 2 for both.
+	DnsQueryType         int32    `protobuf:"varint,8,opt,name=dns_query_type,json=dnsQueryType,proto3" json:"dns_query_type,omitempty"`
+	DnsRequest           string   `protobuf:"bytes,9,opt,name=dns_request,json=dnsRequest,proto3" json:"dns_request,omitempty"`
+	DnsResponse          string   `protobuf:"bytes,10,opt,name=dns_response,json=dnsResponse,proto3" json:"dns_response,omitempty"`
+	DnsNumIps            int32    `protobuf:"varint,11,opt,name=dns_num_ips,json=dnsNumIps,proto3" json:"dns_num_ips,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Record) Reset()         { *m = Record{} }
+func (m *Record) String() string { return proto.CompactTextString(m) }
+func (*Record) ProtoMessage()    {}
+func (*Record) Descriptor() ([]byte, []int) {
+	return fileDescriptor_379b707de4993750, []int{0}
+}
+
+func (m *Record) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Record.Unmarshal(m, b)
+}
+func (m *Record) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Record.Marshal(b, m, deterministic)
+}
+func (m *Record) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Record.Merge(m, src)
+}
+func (m *Record) XXX_Size() int {
+	return xxx_messageInfo_Record.Size(m)
+}
+func (m *Record) XXX_DiscardUnknown() {
+	xxx_messageInfo_Record.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Record proto.InternalMessageInfo
+
+func (m *Record) GetType() Record_Type {
+	if m != nil {
+		return m.Type
+	}
+	return Record_Connection
+}
+
+func (m *Record) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *Record) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *Record) GetStartTime() int64 {
+	if m != nil {
+		return m.StartTime
+	}
+	return 0
+}
+
+func (m *Record) GetEndTime() int64 {
+	if m != nil {
+		return m.EndTime
+	}
+	return 0
+}
+
+func (m *Record) GetUploadBytes() int64 {
+	if m != nil {
+		return m.UploadBytes
+	}
+	return 0
+}
+
+func (m *Record) GetDownloadBytes() int64 {
+	if m != nil {
+		return m.DownloadBytes
+	}
+	return 0
+}
+
+func (m *Record) GetDnsQueryType() int32 {
+	if m != nil {
+		return m.DnsQueryType
+	}
+	return 0
+}
+
+func (m *Record) GetDnsRequest() string {
+	if m != nil {
+		return m.DnsRequest
+	}
+	return ""
+}
+
+func (m *Record) GetDnsResponse() string {
+	if m != nil {
+		return m.DnsResponse
+	}
+	return ""
+}
+
+func (m *Record) GetDnsNumIps() int32 {
+	if m != nil {
+		return m.DnsNumIps
+	}
+	return 0
+}
+
+type ListRecordsRequest struct {
+	// Maximum number of records to return. All records are returned if 0.
+	Limit                uint32   `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListRecordsRequest) Reset()         { *m = ListRecordsRequest{} }
+func (m *ListRecordsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListRecordsRequest) ProtoMessage()    {}
+func (*ListRecordsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_379b707de4993750, []int{1}
+}
+
+func (m *ListRecordsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRecordsRequest.Unmarshal(m, b)
+}
+func (m *ListRecordsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRecordsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListRecordsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRecordsRequest.Merge(m, src)
+}
+func (m *ListRecordsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListRecordsRequest.Size(m)
+}
+func (m *ListRecordsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRecordsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRecordsRequest proto.InternalMessageInfo
+
+func (m *ListRecordsRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type ListRecordsResponse struct {
+	// The latest records, the latest first.
+	Record               []*Record `protobuf:"bytes,1,rep,name=record,proto3" json:"record,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ListRecordsResponse) Reset()         { *m = ListRecordsResponse{} }
+func (m *ListRecordsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListRecordsResponse) ProtoMessage()    {}
+func (*ListRecordsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_379b707de4993750, []int{2}
+}
+
+func (m *ListRecordsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRecordsResponse.Unmarshal(m, b)
+}
+func (m *ListRecordsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRecordsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListRecordsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRecordsResponse.Merge(m, src)
+}
+func (m *ListRecordsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListRecordsResponse.Size(m)
+}
+func (m *ListRecordsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRecordsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRecordsResponse proto.InternalMessageInfo
+
+func (m *ListRecordsResponse) GetRecord() []*Record {
+	if m != nil {
+		return m.Record
+	}
+	return nil
+}
+
+type Config struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Config) Reset()         { *m = Config{} }
+func (m *Config) String() string { return proto.CompactTextString(m) }
+func (*Config) ProtoMessage()    {}
+func (*Config) Descriptor() ([]byte, []int) {
+	return fileDescriptor_379b707de4993750, []int{3}
+}
+
+func (m *Config) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Config.Unmarshal(m, b)
+}
+func (m *Config) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Config.Marshal(b, m, deterministic)
+}
+func (m *Config) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Config.Merge(m, src)
+}
+func (m *Config) XXX_Size() int {
+	return xxx_messageInfo_Config.Size(m)
+}
+func (m *Config) XXX_DiscardUnknown() {
+	xxx_messageInfo_Config.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Config proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterEnum("v2ray.core.app.record.command.Record_Type", Record_Type_name, Record_Type_value)
+	proto.RegisterType((*Record)(nil), "v2ray.core.app.record.command.Record")
+	proto.RegisterType((*ListRecordsRequest)(nil), "v2ray.core.app.record.command.ListRecordsRequest")
+	proto.RegisterType((*ListRecordsResponse)(nil), "v2ray.core.app.record.command.ListRecordsResponse")
+	proto.RegisterType((*Config)(nil), "v2ray.core.app.record.command.Config")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/app/record/command/command.proto", fileDescriptor_379b707de4993750)
+}
+
+var fileDescriptor_379b707de4993750 = []byte{
+	// 463 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x93, 0xdf, 0x6a, 0xd4, 0x40,
+	0x14, 0xc6, 0x9b, 0x66, 0x37, 0xbb, 0x7b, 0xd2, 0x5d, 0x96, 0x51, 0x24, 0x0a, 0x6b, 0xd3, 0x60,
+	0x21, 0xf4, 0x22, 0xc1, 0x78, 0xad, 0x60, 0xd7, 0x1b, 0x41, 0x16, 0x4d, 0x17, 0x2f, 0xbc, 0x09,
+	0x69, 0x72, 0x5c, 0x02, 0x9d, 0x3f, 0x9d, 0x99, 0xac, 0xe4, 0x09, 0x7c, 0x0e, 0x6f, 0x7d, 0x4a,
+	0xc9, 0x4c, 0xb6, 0x54, 0x84, 0xaa, 0x57, 0x99, 0xf9, 0xbe, 0xdf, 0x37, 0x67, 0x4e, 0x4e, 0x02,
+	0xe9, 0x3e, 0x93, 0x65, 0x97, 0x54, 0x9c, 0xa6, 0x15, 0x97, 0x98, 0x96, 0x42, 0xa4, 0x12, 0x2b,
+	0x2e, 0xeb, 0xb4, 0xe2, 0x94, 0x96, 0xec, 0xee, 0x99, 0x08, 0xc9, 0x35, 0x27, 0xab, 0x43, 0x40,
+	0x62, 0x52, 0x0a, 0x91, 0x58, 0x38, 0x19, 0xa0, 0xe8, 0x87, 0x0b, 0x5e, 0x6e, 0x24, 0xf2, 0x06,
+	0x46, 0xba, 0x13, 0x18, 0x38, 0xa1, 0x13, 0x2f, 0xb2, 0x8b, 0xe4, 0xc1, 0x60, 0x62, 0x43, 0xc9,
+	0xb6, 0x13, 0x98, 0x9b, 0x1c, 0x79, 0x02, 0x9e, 0x2e, 0xe5, 0x0e, 0x75, 0x70, 0x1c, 0x3a, 0xf1,
+	0x2c, 0x1f, 0x76, 0x64, 0x09, 0xae, 0x2e, 0x77, 0x81, 0x6b, 0xc4, 0x7e, 0x49, 0x56, 0x00, 0x4a,
+	0x97, 0x52, 0x17, 0xba, 0xa1, 0x18, 0x8c, 0x42, 0x27, 0x76, 0xf3, 0x99, 0x51, 0xb6, 0x0d, 0x45,
+	0xf2, 0x14, 0xa6, 0xc8, 0x6a, 0x6b, 0x8e, 0x8d, 0x39, 0x41, 0x56, 0x1b, 0xeb, 0x0c, 0x4e, 0x5a,
+	0x71, 0xc3, 0xcb, 0xba, 0xb8, 0xee, 0x34, 0xaa, 0xc0, 0x33, 0xb6, 0x6f, 0xb5, 0xcb, 0x5e, 0x22,
+	0xe7, 0xb0, 0xa8, 0xf9, 0x37, 0x76, 0x0f, 0x9a, 0x18, 0x68, 0x7e, 0x50, 0x2d, 0xf6, 0x02, 0x16,
+	0x35, 0x53, 0xc5, 0x6d, 0x8b, 0xb2, 0x2b, 0x4c, 0xdf, 0xd3, 0xd0, 0x89, 0xc7, 0xf9, 0x49, 0xcd,
+	0xd4, 0xa7, 0x5e, 0xec, 0x3b, 0x23, 0xa7, 0xe0, 0xf7, 0x94, 0xc4, 0xdb, 0x16, 0x95, 0x0e, 0x66,
+	0xa6, 0x07, 0xa8, 0x99, 0xca, 0xad, 0xd2, 0x5f, 0xc8, 0x02, 0x4a, 0x70, 0xa6, 0x30, 0x00, 0x43,
+	0xf8, 0x86, 0xb0, 0x12, 0x79, 0x6e, 0xcf, 0x60, 0x2d, 0x2d, 0x1a, 0xa1, 0x02, 0xdf, 0x94, 0x99,
+	0xd5, 0x4c, 0x6d, 0x5a, 0xfa, 0x5e, 0xa8, 0xe8, 0x14, 0x46, 0xa6, 0xd6, 0x02, 0x60, 0xcd, 0x19,
+	0xc3, 0x4a, 0x37, 0x9c, 0x2d, 0x8f, 0xc8, 0x04, 0xdc, 0x77, 0x9b, 0xab, 0xa5, 0x13, 0x5d, 0x00,
+	0xf9, 0xd0, 0x28, 0x6d, 0xdf, 0xf8, 0x5d, 0xe5, 0xc7, 0x30, 0xbe, 0x69, 0x68, 0xa3, 0xcd, 0xbc,
+	0xe6, 0xb9, 0xdd, 0x44, 0x5b, 0x78, 0xf4, 0x1b, 0x3b, 0xdc, 0xe1, 0x35, 0x78, 0x76, 0x7e, 0x81,
+	0x13, 0xba, 0xb1, 0x9f, 0x9d, 0xff, 0xd3, 0x74, 0xf3, 0x21, 0x14, 0x4d, 0xc1, 0x5b, 0x73, 0xf6,
+	0xb5, 0xd9, 0x65, 0xdf, 0x1d, 0x98, 0x5b, 0xf3, 0x0a, 0xe5, 0xbe, 0xa9, 0x90, 0xec, 0xc1, 0xbf,
+	0x57, 0x91, 0xbc, 0xfc, 0xcb, 0xc9, 0x7f, 0x76, 0xf2, 0x2c, 0xfb, 0x9f, 0x88, 0x6d, 0x28, 0x3a,
+	0xba, 0xdc, 0xc0, 0x59, 0xc5, 0xe9, 0xc3, 0xd1, 0x8f, 0xce, 0x97, 0xc9, 0xb0, 0xfc, 0x79, 0xbc,
+	0xfa, 0x9c, 0xe5, 0x65, 0x97, 0xac, 0x7b, 0xf4, 0xad, 0x10, 0x87, 0x2f, 0x78, 0x6d, 0xfd, 0x6b,
+	0xcf, 0xfc, 0x2f, 0xaf, 0x7e, 0x0d, 0x00, 0x89, 0x1f, 0x09, 0x52, 0x62, 0x03, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// RecordServiceClient is the client API for RecordService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type RecordServiceClient interface {
+	// Lists the records kept by the memory sink of the recorder.
+	ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*ListRecordsResponse, error)
+}
+
+type recordServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewRecordServiceClient(cc *grpc.ClientConn) RecordServiceClient {
+	return &recordServiceClient{cc}
+}
+
+func (c *recordServiceClient) ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*ListRecordsResponse, error) {
+	out := new(ListRecordsResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.record.command.RecordService/ListRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RecordServiceServer is the server API for RecordService service.
+type RecordServiceServer interface {
+	// Lists the records kept by the memory sink of the recorder.
+	ListRecords(context.Context, *ListRecordsRequest) (*ListRecordsResponse, error)
+}
+
+func RegisterRecordServiceServer(s *grpc.Server, srv RecordServiceServer) {
+	s.RegisterService(&_RecordService_serviceDesc, srv)
+}
+
+func _RecordService_ListRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordServiceServer).ListRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.record.command.RecordService/ListRecords",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordServiceServer).ListRecords(ctx, req.(*ListRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _RecordService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "v2ray.core.app.record.command.RecordService",
+	HandlerType: (*RecordServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListRecords",
+			Handler:    _RecordService_ListRecords_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "v2ray.com/core/app/record/command/command.proto",
+}
@@ -0,0 +1,29 @@
+// +build !confonly
+
+package record
+
+import (
+	"context"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/features/record"
+)
+
+func newSink(config *SinkConfig) (record.Sink, error) {
+	switch s := config.Sink.(type) {
+	case *SinkConfig_File:
+		return newFileSink(s.File)
+	case *SinkConfig_Database:
+		return newDatabaseSink(s.Database)
+	case *SinkConfig_Memory:
+		return newMemorySink(s.Memory), nil
+	default:
+		return nil, newError("unknown record sink")
+	}
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return NewRecorder(ctx, config.(*Config))
+	}))
+}
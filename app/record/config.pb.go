@@ -0,0 +1,333 @@
+package record
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// Writes each record as a line of JSON.
+type FileSink struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FileSink) Reset()         { *m = FileSink{} }
+func (m *FileSink) String() string { return proto.CompactTextString(m) }
+func (*FileSink) ProtoMessage()    {}
+func (*FileSink) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5046d49051906d89, []int{0}
+}
+
+func (m *FileSink) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FileSink.Unmarshal(m, b)
+}
+func (m *FileSink) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FileSink.Marshal(b, m, deterministic)
+}
+func (m *FileSink) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FileSink.Merge(m, src)
+}
+func (m *FileSink) XXX_Size() int {
+	return xxx_messageInfo_FileSink.Size(m)
+}
+func (m *FileSink) XXX_DiscardUnknown() {
+	xxx_messageInfo_FileSink.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FileSink proto.InternalMessageInfo
+
+func (m *FileSink) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+// Writes records to the proxy_log table of a SQL database, such as a local
+// SQLite file. No driver is built in, so this sink is only for applications
+// that register a database/sql driver, and can't be set in the JSON config.
+type DatabaseSink struct {
+	// Name of the database/sql driver, such as "sqlite3". Required.
+	Driver string `protobuf:"bytes,1,opt,name=driver,proto3" json:"driver,omitempty"`
+	// Data source name, such as the path of the SQLite file.
+	Source               string   `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DatabaseSink) Reset()         { *m = DatabaseSink{} }
+func (m *DatabaseSink) String() string { return proto.CompactTextString(m) }
+func (*DatabaseSink) ProtoMessage()    {}
+func (*DatabaseSink) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5046d49051906d89, []int{1}
+}
+
+func (m *DatabaseSink) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DatabaseSink.Unmarshal(m, b)
+}
+func (m *DatabaseSink) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DatabaseSink.Marshal(b, m, deterministic)
+}
+func (m *DatabaseSink) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DatabaseSink.Merge(m, src)
+}
+func (m *DatabaseSink) XXX_Size() int {
+	return xxx_messageInfo_DatabaseSink.Size(m)
+}
+func (m *DatabaseSink) XXX_DiscardUnknown() {
+	xxx_messageInfo_DatabaseSink.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DatabaseSink proto.InternalMessageInfo
+
+func (m *DatabaseSink) GetDriver() string {
+	if m != nil {
+		return m.Driver
+	}
+	return ""
+}
+
+func (m *DatabaseSink) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+// Keeps the latest records in memory, which are queryable through the
+// RecordService API.
+type MemorySink struct {
+	// Number of records to keep. 1024 by default.
+	Capacity             uint32   `protobuf:"varint,1,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MemorySink) Reset()         { *m = MemorySink{} }
+func (m *MemorySink) String() string { return proto.CompactTextString(m) }
+func (*MemorySink) ProtoMessage()    {}
+func (*MemorySink) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5046d49051906d89, []int{2}
+}
+
+func (m *MemorySink) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MemorySink.Unmarshal(m, b)
+}
+func (m *MemorySink) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MemorySink.Marshal(b, m, deterministic)
+}
+func (m *MemorySink) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MemorySink.Merge(m, src)
+}
+func (m *MemorySink) XXX_Size() int {
+	return xxx_messageInfo_MemorySink.Size(m)
+}
+func (m *MemorySink) XXX_DiscardUnknown() {
+	xxx_messageInfo_MemorySink.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MemorySink proto.InternalMessageInfo
+
+func (m *MemorySink) GetCapacity() uint32 {
+	if m != nil {
+		return m.Capacity
+	}
+	return 0
+}
+
+type SinkConfig struct {
+	// Types that are valid to be assigned to Sink:
+	//	*SinkConfig_File
+	//	*SinkConfig_Database
+	//	*SinkConfig_Memory
+	Sink                 isSinkConfig_Sink `protobuf_oneof:"sink"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SinkConfig) Reset()         { *m = SinkConfig{} }
+func (m *SinkConfig) String() string { return proto.CompactTextString(m) }
+func (*SinkConfig) ProtoMessage()    {}
+func (*SinkConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5046d49051906d89, []int{3}
+}
+
+func (m *SinkConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SinkConfig.Unmarshal(m, b)
+}
+func (m *SinkConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SinkConfig.Marshal(b, m, deterministic)
+}
+func (m *SinkConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SinkConfig.Merge(m, src)
+}
+func (m *SinkConfig) XXX_Size() int {
+	return xxx_messageInfo_SinkConfig.Size(m)
+}
+func (m *SinkConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_SinkConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SinkConfig proto.InternalMessageInfo
+
+type isSinkConfig_Sink interface {
+	isSinkConfig_Sink()
+}
+
+type SinkConfig_File struct {
+	File *FileSink `protobuf:"bytes,1,opt,name=file,proto3,oneof"`
+}
+
+type SinkConfig_Database struct {
+	Database *DatabaseSink `protobuf:"bytes,2,opt,name=database,proto3,oneof"`
+}
+
+type SinkConfig_Memory struct {
+	Memory *MemorySink `protobuf:"bytes,3,opt,name=memory,proto3,oneof"`
+}
+
+func (*SinkConfig_File) isSinkConfig_Sink() {}
+
+func (*SinkConfig_Database) isSinkConfig_Sink() {}
+
+func (*SinkConfig_Memory) isSinkConfig_Sink() {}
+
+func (m *SinkConfig) GetSink() isSinkConfig_Sink {
+	if m != nil {
+		return m.Sink
+	}
+	return nil
+}
+
+func (m *SinkConfig) GetFile() *FileSink {
+	if x, ok := m.GetSink().(*SinkConfig_File); ok {
+		return x.File
+	}
+	return nil
+}
+
+func (m *SinkConfig) GetDatabase() *DatabaseSink {
+	if x, ok := m.GetSink().(*SinkConfig_Database); ok {
+		return x.Database
+	}
+	return nil
+}
+
+func (m *SinkConfig) GetMemory() *MemorySink {
+	if x, ok := m.GetSink().(*SinkConfig_Memory); ok {
+		return x.Memory
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*SinkConfig) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*SinkConfig_File)(nil),
+		(*SinkConfig_Database)(nil),
+		(*SinkConfig_Memory)(nil),
+	}
+}
+
+type Config struct {
+	Sink []*SinkConfig `protobuf:"bytes,1,rep,name=sink,proto3" json:"sink,omitempty"`
+	// Number of records queued for the sinks. Records are dropped when the
+	// queue is full. 1024 by default.
+	BufferSize           uint32   `protobuf:"varint,2,opt,name=buffer_size,json=bufferSize,proto3" json:"buffer_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Config) Reset()         { *m = Config{} }
+func (m *Config) String() string { return proto.CompactTextString(m) }
+func (*Config) ProtoMessage()    {}
+func (*Config) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5046d49051906d89, []int{4}
+}
+
+func (m *Config) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Config.Unmarshal(m, b)
+}
+func (m *Config) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Config.Marshal(b, m, deterministic)
+}
+func (m *Config) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Config.Merge(m, src)
+}
+func (m *Config) XXX_Size() int {
+	return xxx_messageInfo_Config.Size(m)
+}
+func (m *Config) XXX_DiscardUnknown() {
+	xxx_messageInfo_Config.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Config proto.InternalMessageInfo
+
+func (m *Config) GetSink() []*SinkConfig {
+	if m != nil {
+		return m.Sink
+	}
+	return nil
+}
+
+func (m *Config) GetBufferSize() uint32 {
+	if m != nil {
+		return m.BufferSize
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*FileSink)(nil), "v2ray.core.app.record.FileSink")
+	proto.RegisterType((*DatabaseSink)(nil), "v2ray.core.app.record.DatabaseSink")
+	proto.RegisterType((*MemorySink)(nil), "v2ray.core.app.record.MemorySink")
+	proto.RegisterType((*SinkConfig)(nil), "v2ray.core.app.record.SinkConfig")
+	proto.RegisterType((*Config)(nil), "v2ray.core.app.record.Config")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/app/record/config.proto", fileDescriptor_5046d49051906d89)
+}
+
+var fileDescriptor_5046d49051906d89 = []byte{
+	// 328 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xc1, 0x6b, 0xfa, 0x30,
+	0x1c, 0xc5, 0xad, 0x4a, 0xf0, 0xf7, 0xf5, 0xe7, 0x25, 0xe0, 0xe8, 0x76, 0x98, 0xae, 0x83, 0xe1,
+	0x29, 0x85, 0x8e, 0x9d, 0xc6, 0x06, 0xea, 0x18, 0x5e, 0x06, 0x23, 0xc2, 0x0e, 0xbb, 0x6c, 0x31,
+	0xa6, 0x5b, 0xd0, 0x9a, 0x90, 0x56, 0xa1, 0xfe, 0x49, 0xfb, 0x57, 0xf6, 0x4f, 0x8d, 0x26, 0xd5,
+	0xee, 0xa0, 0xbb, 0xe5, 0xfb, 0xf8, 0xbc, 0xc7, 0x7b, 0x10, 0xb8, 0xda, 0x44, 0x86, 0xe5, 0x84,
+	0xab, 0x24, 0xe4, 0xca, 0x88, 0x90, 0x69, 0x1d, 0x1a, 0xc1, 0x95, 0x99, 0x87, 0x5c, 0xad, 0x62,
+	0xf9, 0x41, 0xb4, 0x51, 0x99, 0xc2, 0xdd, 0x1d, 0x67, 0x04, 0x61, 0x5a, 0x13, 0xc7, 0x04, 0xe7,
+	0xd0, 0x7a, 0x94, 0x4b, 0x31, 0x95, 0xab, 0x05, 0xc6, 0xd0, 0xd4, 0x2c, 0xfb, 0xf4, 0xbd, 0xbe,
+	0x37, 0xf8, 0x47, 0xed, 0x3b, 0xb8, 0x87, 0xff, 0x0f, 0x2c, 0x63, 0x33, 0x96, 0x3a, 0xe6, 0x04,
+	0xd0, 0xdc, 0xc8, 0x8d, 0x30, 0x25, 0x55, 0x5e, 0x85, 0x9e, 0xaa, 0xb5, 0xe1, 0xc2, 0xaf, 0x3b,
+	0xdd, 0x5d, 0xc1, 0x00, 0xe0, 0x49, 0x24, 0xca, 0xe4, 0xd6, 0x7d, 0x06, 0x2d, 0xce, 0x34, 0xe3,
+	0x32, 0xcb, 0xad, 0xbf, 0x43, 0xf7, 0x77, 0xf0, 0xed, 0x01, 0x14, 0xd0, 0xd8, 0xb6, 0xc6, 0x37,
+	0xd0, 0x8c, 0xe5, 0x52, 0x58, 0xac, 0x1d, 0xf5, 0xc8, 0xc1, 0xfa, 0x64, 0xd7, 0x7d, 0x52, 0xa3,
+	0x16, 0xc7, 0x43, 0x68, 0xcd, 0xcb, 0xbe, 0xb6, 0x49, 0x3b, 0xba, 0x3c, 0x62, 0xfd, 0x3d, 0x6b,
+	0x52, 0xa3, 0x7b, 0x1b, 0xbe, 0x05, 0x94, 0xd8, 0xca, 0x7e, 0xc3, 0x06, 0x5c, 0x1c, 0x09, 0xa8,
+	0x76, 0x4d, 0x6a, 0xb4, 0xb4, 0x8c, 0x10, 0x34, 0x53, 0xb9, 0x5a, 0x04, 0xef, 0x80, 0xaa, 0x21,
+	0x85, 0xe2, 0x7b, 0xfd, 0xc6, 0x1f, 0x61, 0xd5, 0x72, 0x6a, 0x71, 0xdc, 0x83, 0xf6, 0x6c, 0x1d,
+	0xc7, 0xc2, 0xbc, 0xa5, 0x72, 0xeb, 0xb6, 0x74, 0x28, 0x38, 0x69, 0x2a, 0xb7, 0x62, 0x74, 0x07,
+	0xa7, 0x5c, 0x25, 0x87, 0xe3, 0x9e, 0xbd, 0x57, 0xe4, 0x5e, 0x5f, 0xf5, 0xee, 0x4b, 0x44, 0x59,
+	0x4e, 0xc6, 0x05, 0x31, 0xd4, 0x9a, 0x50, 0xab, 0xcf, 0x90, 0xfd, 0x16, 0xd7, 0x3f, 0x03, 0x00,
+	0x53, 0x16, 0xa6, 0x80, 0x40, 0x02, 0x00, 0x00,
+}
@@ -0,0 +1,100 @@
+// +build !confonly
+
+package record
+
+import (
+	"database/sql"
+
+	"v2ray.com/core/common/session"
+)
+
+const (
+	createTableSQL = `CREATE TABLE IF NOT EXISTS proxy_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	target TEXT NOT NULL,
+	tag TEXT NOT NULL,
+	start_time INTEGER NOT NULL,
+	end_time INTEGER NOT NULL,
+	upload_bytes INTEGER NOT NULL,
+	download_bytes INTEGER NOT NULL,
+	record_type INTEGER NOT NULL,
+	dns_query_type INTEGER NOT NULL,
+	dns_request TEXT NOT NULL,
+	dns_response TEXT NOT NULL,
+	dns_num_ips INTEGER NOT NULL
+)`
+
+	insertSQL = `INSERT INTO proxy_log (target, tag, start_time, end_time, upload_bytes, download_bytes, record_type, dns_query_type, dns_request, dns_response, dns_num_ips) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+)
+
+// databaseSink inserts the records to the proxy_log table, in a transaction for each batch.
+type databaseSink struct {
+	db *sql.DB
+}
+
+func newDatabaseSink(config *DatabaseSink) (*databaseSink, error) {
+	// No driver is linked in, so that the binary doesn't depend on cgo.
+	if len(config.Driver) == 0 {
+		return nil, newError("empty driver of record database")
+	}
+	if len(config.Source) == 0 {
+		return nil, newError("empty data source of record database")
+	}
+
+	db, err := sql.Open(config.Driver, config.Source)
+	if err != nil {
+		return nil, newError("failed to open record database: ", config.Source).Base(err)
+	}
+	// Embedded databases such as SQLite allow only one writer at a time.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(createTableSQL); err != nil {
+		db.Close()
+		return nil, newError("failed to create table in record database: ", config.Source).Base(err)
+	}
+	return &databaseSink{db: db}, nil
+}
+
+// Write implements record.Sink.
+func (s *databaseSink) Write(records []*session.ProxyRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(insertSQL)
+	if err != nil {
+		tx.Rollback() // nolint: errcheck
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if _, err := stmt.Exec(r.Target, r.Tag, r.StartTime, r.EndTime, r.UploadBytes, r.DownloadBytes, r.RecordType, r.DNSQueryType, r.DNSRequest, r.DNSResponse, r.DNSNumIPs); err != nil {
+			tx.Rollback() // nolint: errcheck
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close implements common.Closable.
+func (s *databaseSink) Close() error {
+	return s.db.Close()
+}
+
+// dbServiceSink writes the records to the legacy session.DBService.
+type dbServiceSink struct {
+	service session.DBService
+}
+
+// Write implements record.Sink.
+func (s *dbServiceSink) Write(records []*session.ProxyRecord) error {
+	for _, r := range records {
+		s.service.InsertProxyLog(r.Target, r.Tag, r.StartTime, r.EndTime, r.UploadBytes, r.DownloadBytes, r.RecordType, r.DNSQueryType, r.DNSRequest, r.DNSResponse, r.DNSNumIPs)
+	}
+	return nil
+}
+
+// Close implements common.Closable.
+func (*dbServiceSink) Close() error {
+	return nil
+}
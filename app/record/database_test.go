@@ -0,0 +1,116 @@
+package record_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	. "v2ray.com/core/app/record"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/session"
+)
+
+// memoryDriver is a database/sql driver that keeps the executed statements.
+type memoryDriver struct {
+	sync.Mutex
+	statements []string
+	rows       [][]driver.Value
+}
+
+func (d *memoryDriver) Open(name string) (driver.Conn, error) {
+	return &memoryConn{driver: d}, nil
+}
+
+type memoryConn struct {
+	driver *memoryDriver
+}
+
+func (c *memoryConn) Prepare(query string) (driver.Stmt, error) {
+	return &memoryStmt{conn: c, query: query}, nil
+}
+
+func (*memoryConn) Close() error {
+	return nil
+}
+
+func (c *memoryConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (*memoryConn) Commit() error {
+	return nil
+}
+
+func (*memoryConn) Rollback() error {
+	return nil
+}
+
+type memoryStmt struct {
+	conn  *memoryConn
+	query string
+}
+
+func (*memoryStmt) Close() error {
+	return nil
+}
+
+func (s *memoryStmt) NumInput() int {
+	return strings.Count(s.query, "?")
+}
+
+func (s *memoryStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.conn.driver
+	d.Lock()
+	defer d.Unlock()
+	d.statements = append(d.statements, s.query)
+	if len(args) > 0 {
+		d.rows = append(d.rows, args)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (*memoryStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, io.EOF
+}
+
+func TestDatabaseSink(t *testing.T) {
+	d := new(memoryDriver)
+	sql.Register("v2ray-record-test", d)
+
+	r, err := NewRecorder(context.Background(), &Config{
+		Sink: []*SinkConfig{
+			{
+				Sink: &SinkConfig_Database{
+					Database: &DatabaseSink{
+						Driver: "v2ray-record-test",
+						Source: "records.db",
+					},
+				},
+			},
+		},
+	})
+	common.Must(err)
+	common.Must(r.Start())
+
+	r.Insert(&session.ProxyRecord{
+		Target:     "v2ray.com",
+		Tag:        "cached",
+		RecordType: 1,
+		DNSRequest: "v2ray.com",
+		DNSNumIPs:  2,
+	})
+	common.Must(r.Close())
+
+	d.Lock()
+	defer d.Unlock()
+	if len(d.statements) != 2 || !strings.HasPrefix(d.statements[0], "CREATE TABLE IF NOT EXISTS proxy_log") || !strings.HasPrefix(d.statements[1], "INSERT INTO proxy_log") {
+		t.Fatal("unexpected statements: ", d.statements)
+	}
+	if row := d.rows[0]; len(row) != 11 || row[0] != "v2ray.com" || row[1] != "cached" || row[10] != int64(2) {
+		t.Error("unexpected row: ", row)
+	}
+}
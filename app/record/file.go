@@ -0,0 +1,98 @@
+// +build !confonly
+
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+
+	"v2ray.com/core/common/session"
+)
+
+// jsonRecord is the JSON form of a record, written by fileSink.
+type jsonRecord struct {
+	Type          string    `json:"type"`
+	Target        string    `json:"target"`
+	Tag           string    `json:"tag,omitempty"`
+	StartTime     time.Time `json:"start"`
+	EndTime       time.Time `json:"end"`
+	UploadBytes   int32     `json:"uplink"`
+	DownloadBytes int32     `json:"downlink"`
+	DNSQueryType  string    `json:"qtype,omitempty"`
+	DNSRequest    string    `json:"request,omitempty"`
+	DNSResponse   string    `json:"response,omitempty"`
+	DNSNumIPs     int32     `json:"ips,omitempty"`
+}
+
+func dnsQueryType(t int32) string {
+	switch t {
+	case 0:
+		return "A"
+	case 1:
+		return "AAAA"
+	default:
+		return "A+AAAA"
+	}
+}
+
+func toJSONRecord(r *session.ProxyRecord) *jsonRecord {
+	j := &jsonRecord{
+		Type:          "connection",
+		Target:        r.Target,
+		Tag:           r.Tag,
+		StartTime:     time.Unix(0, r.StartTime),
+		EndTime:       time.Unix(0, r.EndTime),
+		UploadBytes:   r.UploadBytes,
+		DownloadBytes: r.DownloadBytes,
+	}
+	if r.RecordType == 1 {
+		j.Type = "dns"
+		j.DNSQueryType = dnsQueryType(r.DNSQueryType)
+		j.DNSRequest = r.DNSRequest
+		j.DNSResponse = r.DNSResponse
+		j.DNSNumIPs = r.DNSNumIPs
+	}
+	return j
+}
+
+// fileSink appends the records to a file as JSON lines.
+type fileSink struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func newFileSink(config *FileSink) (*fileSink, error) {
+	if len(config.Path) == 0 {
+		return nil, newError("empty path of record file")
+	}
+	file, err := os.OpenFile(config.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, newError("failed to open record file: ", config.Path).Base(err)
+	}
+	return &fileSink{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// Write implements record.Sink.
+func (s *fileSink) Write(records []*session.ProxyRecord) error {
+	encoder := json.NewEncoder(s.writer)
+	for _, r := range records {
+		if err := encoder.Encode(toJSONRecord(r)); err != nil {
+			return err
+		}
+	}
+	return s.writer.Flush()
+}
+
+// Close implements common.Closable.
+func (s *fileSink) Close() error {
+	if err := s.writer.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,76 @@
+// +build !confonly
+
+package record
+
+import (
+	"sync"
+
+	"v2ray.com/core/common/session"
+)
+
+const defaultMemoryCapacity = 1024
+
+// memorySink keeps the latest records in a ring buffer.
+type memorySink struct {
+	sync.Mutex
+	records []*session.ProxyRecord
+	// next is the position of the next record in records.
+	next int
+	full bool
+}
+
+func newMemorySink(config *MemorySink) *memorySink {
+	capacity := int(config.Capacity)
+	if capacity == 0 {
+		capacity = defaultMemoryCapacity
+	}
+	return &memorySink{
+		records: make([]*session.ProxyRecord, capacity),
+	}
+}
+
+// Write implements record.Sink.
+func (s *memorySink) Write(records []*session.ProxyRecord) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, r := range records {
+		s.records[s.next] = r
+		s.next++
+		if s.next == len(s.records) {
+			s.next = 0
+			s.full = true
+		}
+	}
+	return nil
+}
+
+// recent returns at most limit latest records, the latest first. All records
+// are returned if limit is 0.
+func (s *memorySink) recent(limit int) []*session.ProxyRecord {
+	s.Lock()
+	defer s.Unlock()
+
+	n := s.next
+	if s.full {
+		n = len(s.records)
+	}
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	records := make([]*session.ProxyRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		idx := s.next - i
+		if idx < 0 {
+			idx += len(s.records)
+		}
+		records = append(records, s.records[idx])
+	}
+	return records
+}
+
+// Close implements common.Closable.
+func (*memorySink) Close() error {
+	return nil
+}
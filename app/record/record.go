@@ -0,0 +1,188 @@
+// +build !confonly
+
+package record
+
+//go:generate errorgen
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/signal/done"
+	"v2ray.com/core/features/record"
+)
+
+const (
+	defaultBufferSize = 1024
+	// maxBatchSize is the maximum number of records passed to a sink at once.
+	maxBatchSize = 64
+)
+
+// Recorder is an implementation of record.Recorder. Records are queued, and
+// written to the sinks by a single goroutine.
+type Recorder struct {
+	access sync.RWMutex
+	sinks  []record.Sink
+	queue  chan *session.ProxyRecord
+	done   *done.Instance
+	closed chan struct{}
+	start  sync.Once
+	// registered is whether session.InsertRecord forwards to this recorder.
+	registered bool
+}
+
+// NewRecorder creates a new Recorder based on the given config.
+func NewRecorder(ctx context.Context, config *Config) (*Recorder, error) {
+	size := int(config.BufferSize)
+	if size == 0 {
+		size = defaultBufferSize
+	}
+	r := &Recorder{
+		queue:  make(chan *session.ProxyRecord, size),
+		done:   done.New(),
+		closed: make(chan struct{}),
+	}
+	for _, sc := range config.Sink {
+		sink, err := newSink(sc)
+		if err != nil {
+			r.closeSinks()
+			return nil, err
+		}
+		r.sinks = append(r.sinks, sink)
+	}
+	return r, nil
+}
+
+// Type implements common.HasType.
+func (*Recorder) Type() interface{} {
+	return record.RecorderType()
+}
+
+// Insert implements record.Recorder.
+func (r *Recorder) Insert(rec *session.ProxyRecord) {
+	// Copy the record, as the caller may keep updating it.
+	c := *rec
+	if c.EndTime == 0 {
+		c.EndTime = time.Now().UnixNano()
+	}
+
+	select {
+	case r.queue <- &c:
+	default:
+		newError("record queue is full, dropping record of ", c.Target).AtDebug().WriteToLog()
+	}
+}
+
+// AddSink implements record.Recorder.
+func (r *Recorder) AddSink(sink record.Sink) error {
+	if r.done.Done() {
+		return newError("recorder closed")
+	}
+
+	r.access.Lock()
+	defer r.access.Unlock()
+	r.sinks = append(r.sinks, sink)
+	return nil
+}
+
+// Recent returns at most limit latest records, the latest first, from the
+// first memory sink. All records are returned if limit is 0.
+func (r *Recorder) Recent(limit int) ([]*session.ProxyRecord, error) {
+	r.access.RLock()
+	defer r.access.RUnlock()
+
+	for _, sink := range r.sinks {
+		if m, ok := sink.(*memorySink); ok {
+			return m.recent(limit), nil
+		}
+	}
+	return nil, newError("no memory sink")
+}
+
+func (r *Recorder) run() {
+	defer close(r.closed)
+
+	batch := make([]*session.ProxyRecord, 0, maxBatchSize)
+	for {
+		select {
+		case rec := <-r.queue:
+			batch = append(batch[:0], rec)
+		fill:
+			for len(batch) < maxBatchSize {
+				select {
+				case rec := <-r.queue:
+					batch = append(batch, rec)
+				default:
+					break fill
+				}
+			}
+			r.write(batch)
+		case <-r.done.Wait():
+			// Flush the records inserted before closing.
+			for {
+				select {
+				case rec := <-r.queue:
+					r.write([]*session.ProxyRecord{rec})
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *Recorder) write(batch []*session.ProxyRecord) {
+	r.access.RLock()
+	defer r.access.RUnlock()
+
+	for _, sink := range r.sinks {
+		if err := sink.Write(batch); err != nil {
+			newError("failed to write records").Base(err).AtWarning().WriteToLog()
+		}
+	}
+}
+
+func (r *Recorder) closeSinks() {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	for _, sink := range r.sinks {
+		if err := sink.Close(); err != nil {
+			newError("failed to close record sink").Base(err).AtWarning().WriteToLog()
+		}
+	}
+}
+
+// Start implements common.Runnable.
+func (r *Recorder) Start() error {
+	r.start.Do(func() {
+		if !r.done.Done() {
+			// Records passed to the legacy session.InsertRecord come here, and
+			// are written to session.DefaultDBService like to the other sinks.
+			if service := session.DefaultDBService; service != nil {
+				common.Must(r.AddSink(&dbServiceSink{service: service}))
+			}
+			session.RegisterRecordInserter(r.Insert)
+			r.registered = true
+		}
+		go r.run()
+	})
+	return nil
+}
+
+// Close implements common.Closable. Records queued before are written to the
+// sinks, and then the sinks are closed.
+func (r *Recorder) Close() error {
+	common.Must(r.done.Close())
+	// Run the goroutine even if the recorder has never started, so that it flushes the queue.
+	common.Must(r.Start())
+	if r.registered {
+		session.RegisterRecordInserter(nil)
+	}
+	<-r.closed
+	r.closeSinks()
+	return nil
+}
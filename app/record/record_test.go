@@ -0,0 +1,148 @@
+package record_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	. "v2ray.com/core/app/record"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/session"
+)
+
+type sliceSink struct {
+	records []*session.ProxyRecord
+	closed  bool
+}
+
+func (s *sliceSink) Write(records []*session.ProxyRecord) error {
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *sliceSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "v2ray-record")
+	common.Must(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "records.json")
+
+	r, err := NewRecorder(context.Background(), &Config{
+		Sink: []*SinkConfig{
+			{
+				Sink: &SinkConfig_File{
+					File: &FileSink{Path: path},
+				},
+			},
+			{
+				Sink: &SinkConfig_Memory{
+					Memory: &MemorySink{Capacity: 2},
+				},
+			},
+		},
+	})
+	common.Must(err)
+	custom := new(sliceSink)
+	common.Must(r.AddSink(custom))
+	common.Must(r.Start())
+
+	targets := []string{"tcp:v2ray.com:443", "tcp:google.com:443", "v2ray.com"}
+	for i, target := range targets {
+		record := &session.ProxyRecord{
+			Target:      target,
+			Tag:         "direct",
+			StartTime:   1,
+			UploadBytes: 100,
+		}
+		if i == 2 {
+			record.RecordType = 1
+			record.DNSRequest = target
+			record.DNSResponse = "1.2.3.4"
+			record.DNSNumIPs = 1
+		}
+		r.Insert(record)
+	}
+	common.Must(r.Close())
+
+	if !custom.closed || len(custom.records) != 3 {
+		t.Fatal("expect all records written to the custom sink before closing, but got ", len(custom.records))
+	}
+	for i, record := range custom.records {
+		if record.Target != targets[i] || record.EndTime == 0 {
+			t.Error("unexpected record: ", record)
+		}
+	}
+
+	recent, err := r.Recent(0)
+	common.Must(err)
+	if len(recent) != 2 || recent[0].Target != targets[2] || recent[1].Target != targets[1] {
+		t.Error("expect the latest 2 records in memory, but got ", recent)
+	}
+	if recent, _ := r.Recent(1); len(recent) != 1 || recent[0].Target != targets[2] {
+		t.Error("expect the latest record, but got ", recent)
+	}
+
+	f, err := os.Open(path)
+	common.Must(err)
+	defer f.Close()
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := make(map[string]interface{})
+		common.Must(json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	if len(lines) != 3 {
+		t.Fatal("expect 3 lines in record file, but got ", len(lines))
+	}
+	if lines[0]["type"] != "connection" || lines[0]["target"] != targets[0] || lines[0]["uplink"] != float64(100) {
+		t.Error("unexpected connection record: ", lines[0])
+	}
+	if lines[2]["type"] != "dns" || lines[2]["qtype"] != "A" || lines[2]["response"] != "1.2.3.4" {
+		t.Error("unexpected DNS record: ", lines[2])
+	}
+}
+
+type sliceDBService struct {
+	targets []string
+}
+
+func (s *sliceDBService) InsertProxyLog(target, tag string, startTime, endTime int64, uploadBytes, downloadBytes int32, recordType, dnsQueryType int32, dnsRequest, dnsResponse string, dnsNumIPs int32) {
+	s.targets = append(s.targets, target)
+}
+
+func TestInsertRecord(t *testing.T) {
+	service := new(sliceDBService)
+	session.DefaultDBService = service
+	defer func() {
+		session.DefaultDBService = nil
+	}()
+
+	// Without a recorder, records go to the service directly.
+	session.InsertRecord(&session.ProxyRecord{Target: "tcp:v2ray.com:443"})
+
+	r, err := NewRecorder(context.Background(), &Config{})
+	common.Must(err)
+	custom := new(sliceSink)
+	common.Must(r.AddSink(custom))
+	common.Must(r.Start())
+	session.InsertRecord(&session.ProxyRecord{Target: "tcp:google.com:443"})
+	common.Must(r.Close())
+
+	if r := cmp.Diff(service.targets, []string{"tcp:v2ray.com:443", "tcp:google.com:443"}); r != "" {
+		t.Error(r)
+	}
+	if len(custom.records) != 1 || custom.records[0].Target != "tcp:google.com:443" {
+		t.Error("expect the record to be passed to the recorder, but got ", custom.records)
+	}
+}
@@ -8,13 +8,32 @@ import (
 )
 
 type BalancingStrategy interface {
-	PickOutbound([]string) string
+	// PickOutbound picks one of the tags for the request described by the routing context. The context may be nil.
+	PickOutbound(*Context, []string) string
+}
+
+// peekingStrategy is implemented by strategies whose PickOutbound changes their state, such as counters.
+type peekingStrategy interface {
+	// PeekOutbound returns the tag that PickOutbound would pick, without changing any state.
+	PeekOutbound(*Context, []string) string
+}
+
+// selectingStrategy is implemented by strategies that stick to one outbound until something changes.
+type selectingStrategy interface {
+	// SelectedOutbound returns the outbound that is currently picked among the tags.
+	SelectedOutbound(tags []string) string
+}
+
+// measuringStrategy is implemented by strategies that measure the latency of outbounds.
+type measuringStrategy interface {
+	// Measurements returns the latest measurement of each outbound.
+	Measurements() []Server
 }
 
 type RandomStrategy struct {
 }
 
-func (s *RandomStrategy) PickOutbound(tags []string) string {
+func (s *RandomStrategy) PickOutbound(ctx *Context, tags []string) string {
 	n := len(tags)
 	if n == 0 {
 		panic("0 tags")
@@ -29,7 +48,7 @@ type Balancer struct {
 	ohm       outbound.Manager
 }
 
-func (b *Balancer) PickOutbound() (string, error) {
+func (b *Balancer) PickOutbound(ctx *Context) (string, error) {
 	hs, ok := b.ohm.(outbound.HandlerSelector)
 	if !ok {
 		return "", newError("outbound.Manager is not a HandlerSelector")
@@ -38,9 +57,53 @@ func (b *Balancer) PickOutbound() (string, error) {
 	if len(tags) == 0 {
 		return "", newError("no available outbounds selected")
 	}
-	tag := b.strategy.PickOutbound(tags)
+	tag := b.strategy.PickOutbound(ctx, tags)
 	if len(tag) == 0 {
 		return "", newError("balancing strategy returns empty tag")
 	}
 	return tag, nil
 }
+
+// PeekOutbound returns the outbound that PickOutbound would pick for the context, without
+// affecting later picks. It is used for explaining routing decisions.
+func (b *Balancer) PeekOutbound(ctx *Context) (string, error) {
+	s, ok := b.strategy.(peekingStrategy)
+	if !ok {
+		return b.PickOutbound(ctx)
+	}
+	hs, ok := b.ohm.(outbound.HandlerSelector)
+	if !ok {
+		return "", newError("outbound.Manager is not a HandlerSelector")
+	}
+	tags := hs.Select(b.selectors)
+	if len(tags) == 0 {
+		return "", newError("no available outbounds selected")
+	}
+	return s.PeekOutbound(ctx, tags), nil
+}
+
+// SelectedOutbound returns the outbound that the balancer currently routes to.
+// It returns empty if the strategy doesn't stick to one outbound.
+func (b *Balancer) SelectedOutbound() (string, error) {
+	s, ok := b.strategy.(selectingStrategy)
+	if !ok {
+		return "", nil
+	}
+	hs, ok := b.ohm.(outbound.HandlerSelector)
+	if !ok {
+		return "", newError("outbound.Manager is not a HandlerSelector")
+	}
+	tags := hs.Select(b.selectors)
+	if len(tags) == 0 {
+		return "", newError("no available outbounds selected")
+	}
+	return s.SelectedOutbound(tags), nil
+}
+
+// Measurements returns the latest latency measurement of each outbound, if the strategy measures latency.
+func (b *Balancer) Measurements() []Server {
+	if s, ok := b.strategy.(measuringStrategy); ok {
+		return s.Measurements()
+	}
+	return nil
+}
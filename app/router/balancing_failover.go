@@ -0,0 +1,191 @@
+// +build !confonly
+
+package router
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"v2ray.com/core/app/measure"
+	"v2ray.com/core/common/task"
+	"v2ray.com/core/features/health"
+	"v2ray.com/core/features/outbound"
+)
+
+type outboundHealth struct {
+	failures  uint32
+	successes uint32
+	down      bool
+}
+
+// FailoverStrategy routes to the first healthy outbound in selector order.
+// Outbounds are probed periodically in the background, and an outbound is
+// marked down after failureThreshold consecutive failures. It is brought back
+// after recoveryThreshold consecutive successes. Outbounds that the health
+// registry reports as down from real traffic are skipped as well.
+type FailoverStrategy struct {
+	sync.Mutex
+
+	ohm       outbound.Manager
+	hr        health.Registry
+	selectors []string
+
+	probe             *measure.ProbeConfig
+	failureThreshold  uint32
+	recoveryThreshold uint32
+
+	health  map[string]*outboundHealth
+	checker *task.Periodic
+}
+
+func NewFailoverStrategy(ohm outbound.Manager, hr health.Registry, selectors []string, probe *measure.ProbeConfig) *FailoverStrategy {
+	s := &FailoverStrategy{
+		ohm:               ohm,
+		hr:                hr,
+		selectors:         selectors,
+		probe:             probe,
+		failureThreshold:  probe.FailureThreshold,
+		recoveryThreshold: probe.RecoveryThreshold,
+		health:            make(map[string]*outboundHealth),
+	}
+	if s.failureThreshold == 0 {
+		s.failureThreshold = 1
+	}
+	if s.recoveryThreshold == 0 {
+		s.recoveryThreshold = 1
+	}
+	s.checker = &task.Periodic{
+		Interval: probe.GetIntervalValue(),
+		Execute:  s.probeAll,
+	}
+	return s
+}
+
+// Start implements common.Runnable.
+func (s *FailoverStrategy) Start() error {
+	go func() {
+		if err := s.checker.Start(); err != nil {
+			newError("failed to start failover health checker").Base(err).WriteToLog()
+		}
+	}()
+	return nil
+}
+
+// Close implements common.Closable.
+func (s *FailoverStrategy) Close() error {
+	return s.checker.Close()
+}
+
+// PickOutbound implements BalancingStrategy.
+func (s *FailoverStrategy) PickOutbound(ctx *Context, tags []string) string {
+	n := len(tags)
+	if n == 0 {
+		panic("0 tags")
+	}
+
+	ordered := s.order(tags)
+
+	s.Lock()
+	defer s.Unlock()
+
+	for _, tag := range ordered {
+		if h, found := s.health[tag]; found && h.down {
+			continue
+		}
+		if s.hr != nil && !s.hr.IsHealthy(tag) {
+			continue
+		}
+		return tag
+	}
+
+	// Every outbound is down, stick to the primary one.
+	return ordered[0]
+}
+
+// SelectedOutbound returns the first healthy outbound among the tags.
+func (s *FailoverStrategy) SelectedOutbound(tags []string) string {
+	return s.PickOutbound(nil, tags)
+}
+
+// Report records the result of a health check on the outbound with the given tag.
+func (s *FailoverStrategy) Report(tag string, ok bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	h, found := s.health[tag]
+	if !found {
+		h = new(outboundHealth)
+		s.health[tag] = h
+	}
+
+	if ok {
+		h.failures = 0
+		h.successes++
+		if h.down && h.successes >= s.recoveryThreshold {
+			h.down = false
+			newError("outbound ", tag, " is back up").AtInfo().WriteToLog()
+		}
+		return
+	}
+
+	h.successes = 0
+	h.failures++
+	if !h.down && h.failures >= s.failureThreshold {
+		h.down = true
+		newError("outbound ", tag, " is marked down after ", h.failures, " failures").AtWarning().WriteToLog()
+	}
+}
+
+// IsDown returns whether the outbound with the given tag is currently marked down.
+func (s *FailoverStrategy) IsDown(tag string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	h, found := s.health[tag]
+	return found && h.down
+}
+
+// order sorts tags by the index of the first selector they match, keeping
+// the order of outbound_selector in the configuration.
+func (s *FailoverStrategy) order(tags []string) []string {
+	ordered := make([]string, len(tags))
+	copy(ordered, tags)
+	rank := func(tag string) int {
+		for i, selector := range s.selectors {
+			if strings.HasPrefix(tag, selector) {
+				return i
+			}
+		}
+		return len(s.selectors)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, rj := rank(ordered[i]), rank(ordered[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return ordered[i] < ordered[j]
+	})
+	return ordered
+}
+
+func (s *FailoverStrategy) probeAll() error {
+	hs, ok := s.ohm.(outbound.HandlerSelector)
+	if !ok {
+		return newError("outbound.Manager is not a HandlerSelector")
+	}
+	for _, tag := range hs.Select(s.selectors) {
+		h := s.ohm.GetHandler(tag)
+		if h == nil {
+			continue
+		}
+		latency, err := measure.Probe(h, s.probe)
+		if err != nil {
+			newError("failed to probe ", tag).Base(err).AtDebug().WriteToLog()
+		} else {
+			newError("probed ", tag, ", target: ", s.probe.Destination(), ", latency: ", latency).AtDebug().WriteToLog()
+		}
+		s.Report(tag, err == nil)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// +build !confonly
+
+package router
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"v2ray.com/core/common/dice"
+)
+
+// virtualNodes is the number of points each outbound takes on the hash ring.
+const virtualNodes = 160
+
+type ringNode struct {
+	hash uint64
+	tag  string
+}
+
+// ConsistentHashStrategy picks outbounds from a consistent hash ring, so that
+// requests with the same key leave through the same outbound. When an outbound
+// is added or removed, only the keys on its share of the ring move.
+type ConsistentHashStrategy struct {
+	sync.Mutex
+
+	key BalancingRule_HashKey
+
+	members string
+	ring    []ringNode
+}
+
+func NewConsistentHashStrategy(key BalancingRule_HashKey) *ConsistentHashStrategy {
+	return &ConsistentHashStrategy{
+		key: key,
+	}
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s)) // nolint: errcheck
+	// FNV alone spreads similar keys poorly, so the result is mixed with the finalizer of MurmurHash3.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// PickOutbound implements BalancingStrategy.
+func (s *ConsistentHashStrategy) PickOutbound(ctx *Context, tags []string) string {
+	n := len(tags)
+	if n == 0 {
+		panic("0 tags")
+	}
+
+	key := s.hashKey(ctx)
+	if len(key) == 0 {
+		return tags[dice.Roll(n)]
+	}
+	h := hashString(key)
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.updateRing(tags)
+	idx := sort.Search(len(s.ring), func(i int) bool {
+		return s.ring[i].hash >= h
+	})
+	if idx == len(s.ring) {
+		idx = 0
+	}
+	return s.ring[idx].tag
+}
+
+func (s *ConsistentHashStrategy) hashKey(ctx *Context) string {
+	if ctx == nil {
+		return ""
+	}
+	switch s.key {
+	case BalancingRule_SourceIP:
+		if ctx.Inbound != nil && ctx.Inbound.Source.IsValid() {
+			return ctx.Inbound.Source.Address.String()
+		}
+	case BalancingRule_Domain:
+		if ctx.Outbound != nil && ctx.Outbound.Target.IsValid() {
+			return ctx.Outbound.Target.Address.String()
+		}
+	case BalancingRule_UserEmail:
+		if ctx.Inbound != nil && ctx.Inbound.User != nil {
+			return ctx.Inbound.User.Email
+		}
+	}
+	return ""
+}
+
+// updateRing rebuilds the ring if the set of outbounds changed since last time.
+func (s *ConsistentHashStrategy) updateRing(tags []string) {
+	sorted := make([]string, len(tags))
+	copy(sorted, tags)
+	sort.Strings(sorted)
+	members := strings.Join(sorted, "\n")
+	if members == s.members {
+		return
+	}
+
+	ring := make([]ringNode, 0, len(sorted)*virtualNodes)
+	for _, tag := range sorted {
+		for i := 0; i < virtualNodes; i++ {
+			ring = append(ring, ringNode{
+				hash: hashString(tag + "#" + strconv.Itoa(i)),
+				tag:  tag,
+			})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+
+	s.ring = ring
+	s.members = members
+}
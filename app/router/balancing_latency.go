@@ -20,6 +20,16 @@ type Server struct {
 	tag     string
 }
 
+// Tag returns the tag of the measured outbound.
+func (s Server) Tag() string {
+	return s.tag
+}
+
+// Latency returns the average latency of the outbound.
+func (s Server) Latency() time.Duration {
+	return s.latency
+}
+
 type By func(p1, p2 *Server) bool
 
 func (by By) Sort(servers []Server) {
@@ -52,14 +62,13 @@ type LatencyStrategy struct {
 
 	ohm       outbound.Manager
 	selectors []string
+	probe     *measure.ProbeConfig
 
-	totalMeasures int           // 2
-	interval      time.Duration // 120 * time.Second
-	delay         time.Duration // 0 * time.Second
-	timeout       time.Duration // 6 * time.Second
-	tolerance     time.Duration // 300 * time.Millisecond
-	target        string        // "tls:www.google.com:443"
-	content       string        // "HEAD / HTTP/1.1\r\n\r\n"
+	totalMeasures int
+	interval      time.Duration
+	delay         time.Duration
+	timeout       time.Duration
+	tolerance     time.Duration
 
 	lastMeasure time.Time
 
@@ -67,30 +76,32 @@ type LatencyStrategy struct {
 	selectedServer *Server
 }
 
-func NewLatencyStrategy(ohm outbound.Manager, selectors []string, totalMeasures int, interval, delay, timeout, tolerance time.Duration, target, content string) BalancingStrategy {
+func NewLatencyStrategy(ohm outbound.Manager, selectors []string, probe *measure.ProbeConfig) BalancingStrategy {
 	s := &LatencyStrategy{
 		ohm:            ohm,
 		selectors:      selectors,
-		totalMeasures:  totalMeasures,
-		interval:       interval,
-		delay:          delay,
-		timeout:        timeout,
-		tolerance:      tolerance,
-		target:         target,
-		content:        content,
+		probe:          probe,
+		totalMeasures:  int(probe.TotalMeasures),
+		interval:       probe.GetIntervalValue(),
+		delay:          probe.GetDelayValue(),
+		timeout:        probe.GetTimeoutValue(),
+		tolerance:      probe.GetToleranceValue(),
 		servers:        make([]Server, 0),
 		selectedServer: nil,
 	}
+	if s.totalMeasures == 0 {
+		s.totalMeasures = 1
+	}
 
 	go func() {
 		time.Sleep(4 * time.Second)
-		newError(fmt.Sprintf("new latency balancer with totalMeasures %v, interval %v, delay %v, timeout %v, tolerance: %v, probeTarget %v, probeContent %v", totalMeasures, interval, delay, timeout, tolerance, target, content)).WriteToLog()
+		newError(fmt.Sprintf("new latency balancer with probe: %v", probe)).WriteToLog()
 		s.measureOnce()
 	}()
 	return s
 }
 
-func (s *LatencyStrategy) PickOutbound(tags []string) string {
+func (s *LatencyStrategy) PickOutbound(ctx *Context, tags []string) string {
 	s.Lock()
 	defer s.Unlock()
 
@@ -111,6 +122,35 @@ func (s *LatencyStrategy) PickOutbound(tags []string) string {
 	return s.selectedServer.tag
 }
 
+// PeekOutbound implements peekingStrategy. Unlike PickOutbound, it never triggers a measurement.
+func (s *LatencyStrategy) PeekOutbound(ctx *Context, tags []string) string {
+	if selected := s.SelectedOutbound(tags); len(selected) > 0 {
+		return selected
+	}
+	return tags[dice.Roll(len(tags))]
+}
+
+// SelectedOutbound returns the fastest outbound from the last measurement.
+func (s *LatencyStrategy) SelectedOutbound(tags []string) string {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.selectedServer == nil {
+		return ""
+	}
+	return s.selectedServer.tag
+}
+
+// Measurements returns the outbounds from the last measurement, sorted by latency.
+func (s *LatencyStrategy) Measurements() []Server {
+	s.Lock()
+	defer s.Unlock()
+
+	servers := make([]Server, len(s.servers))
+	copy(servers, s.servers)
+	return servers
+}
+
 func (s *LatencyStrategy) measureOnce() {
 	servers := make([]Server, 0)
 
@@ -126,8 +166,12 @@ func (s *LatencyStrategy) measureOnce() {
 		h := s.ohm.GetHandler(tag)
 		var totalLatency int64 = 0
 		for i := 0; i < s.totalMeasures; i++ {
-			newError(fmt.Sprintf("measuring %v, target: %v", tag, s.target)).AtDebug().WriteToLog()
-			latency := measure.MeasureLatency(h, s.target, s.content, s.timeout)
+			newError(fmt.Sprintf("measuring %v, target: %v", tag, s.probe.Destination())).AtDebug().WriteToLog()
+			latency, err := measure.Probe(h, s.probe)
+			if err != nil {
+				newError("failed to probe ", tag).Base(err).AtDebug().WriteToLog()
+				latency = s.timeout
+			}
 			totalLatency += latency.Nanoseconds()
 			// Waits 1 second between each measure.
 			time.Sleep(s.delay)
@@ -144,7 +188,7 @@ func (s *LatencyStrategy) measureOnce() {
 	}
 	By(latency).Sort(servers)
 	for _, server := range servers {
-		newError(fmt.Sprintf("outbound: %v, target: %v, latency: %v", server.tag, s.target, server.latency.String())).WriteToLog()
+		newError(fmt.Sprintf("outbound: %v, target: %v, latency: %v", server.tag, s.probe.Destination(), server.latency.String())).WriteToLog()
 	}
 
 	s.Lock()
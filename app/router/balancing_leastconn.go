@@ -0,0 +1,43 @@
+// +build !confonly
+
+package router
+
+import (
+	"v2ray.com/core/common/dice"
+	"v2ray.com/core/features/routing"
+)
+
+// LeastConnectionsStrategy picks the outbound with the fewest active sessions,
+// as counted by the dispatcher. Ties are broken randomly.
+type LeastConnectionsStrategy struct {
+	counter routing.SessionCounter
+}
+
+func NewLeastConnectionsStrategy(sc routing.SessionCounter) *LeastConnectionsStrategy {
+	return &LeastConnectionsStrategy{
+		counter: sc,
+	}
+}
+
+// PickOutbound implements BalancingStrategy.
+func (s *LeastConnectionsStrategy) PickOutbound(ctx *Context, tags []string) string {
+	n := len(tags)
+	if n == 0 {
+		panic("0 tags")
+	}
+
+	least := -1
+	candidates := make([]string, 0, n)
+	for _, tag := range tags {
+		count := s.counter.ActiveSessions(tag)
+		switch {
+		case least < 0 || count < least:
+			least = count
+			candidates = append(candidates[:0], tag)
+		case count == least:
+			candidates = append(candidates, tag)
+		}
+	}
+
+	return candidates[dice.Roll(len(candidates))]
+}
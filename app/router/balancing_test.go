@@ -0,0 +1,196 @@
+package router_test
+
+import (
+	"context"
+	"testing"
+
+	apphealth "v2ray.com/core/app/health"
+	"v2ray.com/core/app/measure"
+	. "v2ray.com/core/app/router"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/features/health"
+)
+
+func TestFailoverStrategy(t *testing.T) {
+	s := NewFailoverStrategy(nil, nil, []string{"primary", "backup"}, &measure.ProbeConfig{
+		Address:           net.NewIPOrDomain(net.DomainAddress("v2ray.com")),
+		Port:              80,
+		Interval:          60,
+		Timeout:           1,
+		FailureThreshold:  2,
+		RecoveryThreshold: 2,
+	})
+	tags := []string{"backup", "primary"}
+
+	if tag := s.PickOutbound(nil, tags); tag != "primary" {
+		t.Error("expect primary, but got ", tag)
+	}
+
+	s.Report("primary", false)
+	if tag := s.PickOutbound(nil, tags); tag != "primary" {
+		t.Error("expect primary before reaching failure threshold, but got ", tag)
+	}
+
+	s.Report("primary", false)
+	if tag := s.PickOutbound(nil, tags); tag != "backup" {
+		t.Error("expect backup, but got ", tag)
+	}
+
+	s.Report("primary", true)
+	if tag := s.PickOutbound(nil, tags); tag != "backup" {
+		t.Error("expect backup before reaching recovery threshold, but got ", tag)
+	}
+
+	s.Report("primary", true)
+	if tag := s.PickOutbound(nil, tags); tag != "primary" {
+		t.Error("expect primary after recovery, but got ", tag)
+	}
+
+	s.Report("primary", false)
+	s.Report("primary", false)
+	s.Report("backup", false)
+	s.Report("backup", false)
+	if tag := s.PickOutbound(nil, tags); tag != "primary" {
+		t.Error("expect primary when all outbounds are down, but got ", tag)
+	}
+}
+
+func TestFailoverStrategyWithHealthRegistry(t *testing.T) {
+	hr, err := apphealth.NewRegistry(context.Background(), &apphealth.Config{FailureThreshold: 1})
+	common.Must(err)
+
+	s := NewFailoverStrategy(nil, hr, []string{"primary", "backup"}, &measure.ProbeConfig{
+		Address:           net.NewIPOrDomain(net.DomainAddress("v2ray.com")),
+		Port:              80,
+		Interval:          60,
+		Timeout:           1,
+		FailureThreshold:  2,
+		RecoveryThreshold: 2,
+	})
+	tags := []string{"backup", "primary"}
+
+	hr.Report("primary", health.Observation{DialFailed: true})
+	if tag := s.PickOutbound(nil, tags); tag != "backup" {
+		t.Error("expect backup when real traffic reports primary down, but got ", tag)
+	}
+}
+
+func TestConsistentHashStrategy(t *testing.T) {
+	s := NewConsistentHashStrategy(BalancingRule_SourceIP)
+	tags := []string{"a", "b", "c", "d"}
+
+	contextOf := func(i int) *Context {
+		return &Context{
+			Inbound: &session.Inbound{
+				Source: net.TCPDestination(net.IPAddress([]byte{10, 0, byte(i >> 8), byte(i)}), 1024),
+			},
+		}
+	}
+
+	const total = 4000
+	picked := make(map[int]string, total)
+	count := make(map[string]int)
+	for i := 0; i < total; i++ {
+		tag := s.PickOutbound(contextOf(i), tags)
+		if again := s.PickOutbound(contextOf(i), []string{"d", "c", "b", "a"}); again != tag {
+			t.Fatal("expect the same outbound for the same source, but got ", tag, " and ", again)
+		}
+		picked[i] = tag
+		count[tag]++
+	}
+	for _, tag := range tags {
+		if count[tag] < total/len(tags)/2 {
+			t.Error("outbound ", tag, " gets too few keys: ", count[tag])
+		}
+	}
+
+	// Removing an outbound only moves the keys that were on it.
+	for i := 0; i < total; i++ {
+		tag := s.PickOutbound(contextOf(i), []string{"a", "b", "c"})
+		if picked[i] != "d" && tag != picked[i] {
+			t.Fatal("key ", i, " moved from ", picked[i], " to ", tag)
+		}
+	}
+
+	// Adding an outbound only moves keys onto it.
+	for i := 0; i < total; i++ {
+		tag := s.PickOutbound(contextOf(i), []string{"a", "b", "c", "d", "e"})
+		if tag != "e" && tag != picked[i] {
+			t.Fatal("key ", i, " moved from ", picked[i], " to ", tag)
+		}
+	}
+}
+
+func TestWeightedRoundRobinStrategy(t *testing.T) {
+	s := NewWeightedRoundRobinStrategy(map[string]uint32{
+		"a": 5,
+		"b": 2,
+	})
+	tags := []string{"a", "b", "c"}
+
+	count := make(map[string]int)
+	longest := 0
+	run := 0
+	last := ""
+	for i := 0; i < 800; i++ {
+		peeked := s.PeekOutbound(nil, tags)
+		if s.PeekOutbound(nil, tags) != peeked {
+			t.Fatal("expect peeking to have no effect")
+		}
+		tag := s.PickOutbound(nil, tags)
+		if tag != peeked {
+			t.Fatal("expect ", peeked, " to be picked, but got ", tag)
+		}
+		count[tag]++
+		if tag == last {
+			run++
+		} else {
+			run = 1
+			last = tag
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	if count["a"] != 500 || count["b"] != 200 || count["c"] != 100 {
+		t.Error("expect picks in proportion to weights, but got ", count)
+	}
+	if longest > 3 {
+		t.Error("expect picks to be interleaved, but got ", longest, " picks of ", last, " in a row")
+	}
+}
+
+type sessionCounter map[string]int
+
+func (c sessionCounter) ActiveSessions(tag string) int {
+	return c[tag]
+}
+
+func TestLeastConnectionsStrategy(t *testing.T) {
+	sessions := sessionCounter{
+		"a": 3,
+		"b": 1,
+		"c": 1,
+	}
+	s := NewLeastConnectionsStrategy(sessions)
+	tags := []string{"a", "b", "c", "d"}
+
+	if tag := s.PickOutbound(nil, tags); tag != "d" {
+		t.Error("expect d, but got ", tag)
+	}
+
+	sessions["d"] = 2
+	count := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		count[s.PickOutbound(nil, tags)]++
+	}
+	if count["a"] != 0 || count["d"] != 0 {
+		t.Error("expect only the least loaded outbounds to be picked, but got ", count)
+	}
+	if count["b"] < 400 || count["c"] < 400 {
+		t.Error("expect ties to be broken evenly, but got ", count)
+	}
+}
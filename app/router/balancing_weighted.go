@@ -0,0 +1,91 @@
+// +build !confonly
+
+package router
+
+import (
+	"sync"
+)
+
+// WeightedRoundRobinStrategy picks outbounds in turn, so that each outbound
+// gets a share of requests in proportion to its weight. Picks of different
+// outbounds are interleaved smoothly, instead of sending a burst of requests
+// to the heaviest one.
+type WeightedRoundRobinStrategy struct {
+	sync.Mutex
+
+	weights map[string]uint32
+	current map[string]int64
+}
+
+func NewWeightedRoundRobinStrategy(weights map[string]uint32) *WeightedRoundRobinStrategy {
+	return &WeightedRoundRobinStrategy{
+		weights: weights,
+		current: make(map[string]int64),
+	}
+}
+
+func (s *WeightedRoundRobinStrategy) weight(tag string) int64 {
+	if w, found := s.weights[tag]; found && w > 0 {
+		return int64(w)
+	}
+	return 1
+}
+
+// PickOutbound implements BalancingStrategy.
+func (s *WeightedRoundRobinStrategy) PickOutbound(ctx *Context, tags []string) string {
+	n := len(tags)
+	if n == 0 {
+		panic("0 tags")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	var total int64
+	picked := ""
+	for _, tag := range tags {
+		w := s.weight(tag)
+		s.current[tag] += w
+		total += w
+		if len(picked) == 0 || s.current[tag] > s.current[picked] {
+			picked = tag
+		}
+	}
+	s.current[picked] -= total
+
+	// Forget outbounds that are no longer selected.
+	if len(s.current) > n {
+		selected := make(map[string]bool, n)
+		for _, tag := range tags {
+			selected[tag] = true
+		}
+		for tag := range s.current {
+			if !selected[tag] {
+				delete(s.current, tag)
+			}
+		}
+	}
+
+	return picked
+}
+
+// PeekOutbound implements peekingStrategy.
+func (s *WeightedRoundRobinStrategy) PeekOutbound(ctx *Context, tags []string) string {
+	if len(tags) == 0 {
+		panic("0 tags")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	picked := ""
+	var max int64
+	for _, tag := range tags {
+		current := s.current[tag] + s.weight(tag)
+		if len(picked) == 0 || current > max {
+			picked = tag
+			max = current
+		}
+	}
+	return picked
+}
@@ -0,0 +1,250 @@
+// +build !confonly
+
+package command
+
+//go:generate errorgen
+
+import (
+	"context"
+
+	grpc "google.golang.org/grpc"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/features/routing"
+)
+
+// routingServer is an implementation of RoutingService.
+type routingServer struct {
+	router routing.Router
+}
+
+func NewRoutingServer(r routing.Router) RoutingServiceServer {
+	return &routingServer{router: r}
+}
+
+func (s *routingServer) getRouter() (*router.Router, error) {
+	r, ok := s.router.(*router.Router)
+	if !ok {
+		return nil, newError("RoutingService only works with its own router.Router.")
+	}
+	return r, nil
+}
+
+func (s *routingServer) ListRules(ctx context.Context, request *ListRulesRequest) (*ListRulesResponse, error) {
+	r, err := s.getRouter()
+	if err != nil {
+		return nil, err
+	}
+	return &ListRulesResponse{
+		Rule:          r.ListRules(),
+		BalancingRule: r.ListBalancers(),
+	}, nil
+}
+
+func (s *routingServer) AddRule(ctx context.Context, request *AddRuleRequest) (*AddRuleResponse, error) {
+	r, err := s.getRouter()
+	if err != nil {
+		return nil, err
+	}
+	if request.Rule == nil {
+		return nil, newError("empty rule")
+	}
+	index := int(request.Index)
+	if request.Append {
+		index = -1
+	}
+	if err := r.AddRule(request.Rule, index); err != nil {
+		return nil, newError("failed to add rule").Base(err)
+	}
+	return &AddRuleResponse{}, nil
+}
+
+func (s *routingServer) RemoveRule(ctx context.Context, request *RemoveRuleRequest) (*RemoveRuleResponse, error) {
+	r, err := s.getRouter()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.RemoveRule(int(request.Index)); err != nil {
+		return nil, err
+	}
+	return &RemoveRuleResponse{}, nil
+}
+
+func (s *routingServer) ReplaceRules(ctx context.Context, request *ReplaceRulesRequest) (*ReplaceRulesResponse, error) {
+	r, err := s.getRouter()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ReplaceRules(request.Rule); err != nil {
+		return nil, newError("failed to replace rules").Base(err)
+	}
+	return &ReplaceRulesResponse{}, nil
+}
+
+func (s *routingServer) AddBalancer(ctx context.Context, request *AddBalancerRequest) (*AddBalancerResponse, error) {
+	r, err := s.getRouter()
+	if err != nil {
+		return nil, err
+	}
+	if request.Balancer == nil {
+		return nil, newError("empty balancer")
+	}
+	if err := r.AddBalancer(request.Balancer); err != nil {
+		return nil, newError("failed to add balancer ", request.Balancer.Tag).Base(err)
+	}
+	return &AddBalancerResponse{}, nil
+}
+
+func (s *routingServer) RemoveBalancer(ctx context.Context, request *RemoveBalancerRequest) (*RemoveBalancerResponse, error) {
+	r, err := s.getRouter()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.RemoveBalancer(request.Tag); err != nil {
+		return nil, err
+	}
+	return &RemoveBalancerResponse{}, nil
+}
+
+func (s *routingServer) GetBalancers(ctx context.Context, request *GetBalancersRequest) (*GetBalancersResponse, error) {
+	r, err := s.getRouter()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := request.Tag
+	if len(tags) == 0 {
+		for _, rule := range r.ListBalancers() {
+			tags = append(tags, rule.Tag)
+		}
+	}
+
+	response := &GetBalancersResponse{}
+	for _, tag := range tags {
+		b := r.GetBalancer(tag)
+		if b == nil {
+			return nil, newError("balancer ", tag, " not found.")
+		}
+		selected, err := b.SelectedOutbound()
+		if err != nil {
+			return nil, newError("failed to get selected outbound of balancer ", tag).Base(err)
+		}
+		status := &BalancerStatus{
+			Tag:      tag,
+			Selected: selected,
+		}
+		for _, server := range b.Measurements() {
+			status.Latency = append(status.Latency, &OutboundLatency{
+				Tag:     server.Tag(),
+				Latency: server.Latency().Nanoseconds() / 1e6,
+			})
+		}
+		response.Balancer = append(response.Balancer, status)
+	}
+
+	return response, nil
+}
+
+func (s *routingServer) TestRoute(ctx context.Context, request *TestRouteRequest) (*TestRouteResponse, error) {
+	r, err := s.getRouter()
+	if err != nil {
+		return nil, err
+	}
+	if len(request.Destination) == 0 {
+		return nil, newError("empty destination")
+	}
+
+	trace, err := r.TestRoute(newRoutingContext(request))
+	if err != nil {
+		return nil, newError("failed to route request").Base(err)
+	}
+
+	response := &TestRouteResponse{
+		RuleIndex:   int32(trace.RuleIndex),
+		BalancerTag: trace.BalancerTag,
+		OutboundTag: trace.OutboundTag,
+		Action:      trace.Action.String(),
+	}
+	if trace.Target.IsValid() {
+		response.Rewrite = trace.Target.String()
+	}
+	for _, rule := range trace.Rules {
+		result := &RuleResult{
+			Index:   int32(rule.Index),
+			Matched: rule.Matched,
+		}
+		for _, cond := range rule.Conditions {
+			result.Condition = append(result.Condition, &ConditionResult{
+				Name:    cond.Name,
+				Matched: cond.Matched,
+			})
+		}
+		response.Rule = append(response.Rule, result)
+	}
+	for _, ip := range trace.ResolvedIPs {
+		response.ResolvedIp = append(response.ResolvedIp, ip.String())
+	}
+	return response, nil
+}
+
+func newRoutingContext(request *TestRouteRequest) *router.Context {
+	network := request.Network
+	if network == net.Network_Unknown {
+		network = net.Network_TCP
+	}
+
+	inbound := &session.Inbound{
+		Tag: request.InboundTag,
+	}
+	if len(request.SourceIp) > 0 {
+		inbound.Source = net.Destination{
+			Network: network,
+			Address: net.ParseAddress(request.SourceIp),
+		}
+	}
+	if len(request.UserEmail) > 0 {
+		inbound.User = &protocol.MemoryUser{
+			Email: request.UserEmail,
+		}
+	}
+
+	return &router.Context{
+		Inbound: inbound,
+		Outbound: &session.Outbound{
+			Target: net.Destination{
+				Network: network,
+				Address: net.ParseAddress(request.Destination),
+				Port:    net.Port(request.Port),
+			},
+		},
+		Content: &session.Content{
+			Protocol:    request.Protocol,
+			Application: request.Application,
+		},
+	}
+}
+
+type service struct {
+	router routing.Router
+}
+
+func (s *service) Register(server *grpc.Server) {
+	RegisterRoutingServiceServer(server, NewRoutingServer(s.router))
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, cfg interface{}) (interface{}, error) {
+		s := new(service)
+
+		core.RequireFeatures(ctx, func(r routing.Router) {
+			s.router = r
+		})
+
+		return s, nil
+	}))
+}
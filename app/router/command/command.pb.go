@@ -0,0 +1,1374 @@
+package command
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+	router "v2ray.com/core/app/router"
+	net "v2ray.com/core/common/net"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ListRulesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListRulesRequest) Reset()         { *m = ListRulesRequest{} }
+func (m *ListRulesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListRulesRequest) ProtoMessage()    {}
+func (*ListRulesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{0}
+}
+
+func (m *ListRulesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRulesRequest.Unmarshal(m, b)
+}
+func (m *ListRulesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRulesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListRulesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRulesRequest.Merge(m, src)
+}
+func (m *ListRulesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListRulesRequest.Size(m)
+}
+func (m *ListRulesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRulesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRulesRequest proto.InternalMessageInfo
+
+type ListRulesResponse struct {
+	Rule                 []*router.RoutingRule   `protobuf:"bytes,1,rep,name=rule,proto3" json:"rule,omitempty"`
+	BalancingRule        []*router.BalancingRule `protobuf:"bytes,2,rep,name=balancing_rule,json=balancingRule,proto3" json:"balancing_rule,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *ListRulesResponse) Reset()         { *m = ListRulesResponse{} }
+func (m *ListRulesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListRulesResponse) ProtoMessage()    {}
+func (*ListRulesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{1}
+}
+
+func (m *ListRulesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRulesResponse.Unmarshal(m, b)
+}
+func (m *ListRulesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRulesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListRulesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRulesResponse.Merge(m, src)
+}
+func (m *ListRulesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListRulesResponse.Size(m)
+}
+func (m *ListRulesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRulesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRulesResponse proto.InternalMessageInfo
+
+func (m *ListRulesResponse) GetRule() []*router.RoutingRule {
+	if m != nil {
+		return m.Rule
+	}
+	return nil
+}
+
+func (m *ListRulesResponse) GetBalancingRule() []*router.BalancingRule {
+	if m != nil {
+		return m.BalancingRule
+	}
+	return nil
+}
+
+type AddRuleRequest struct {
+	Rule *router.RoutingRule `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	// Position of the new rule, counting from 0. The rule is appended if index
+	// is beyond the last rule. Ignored if append is set.
+	Index int32 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	// Whether to add the rule after all existing rules.
+	Append               bool     `protobuf:"varint,3,opt,name=append,proto3" json:"append,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddRuleRequest) Reset()         { *m = AddRuleRequest{} }
+func (m *AddRuleRequest) String() string { return proto.CompactTextString(m) }
+func (*AddRuleRequest) ProtoMessage()    {}
+func (*AddRuleRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{2}
+}
+
+func (m *AddRuleRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddRuleRequest.Unmarshal(m, b)
+}
+func (m *AddRuleRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddRuleRequest.Marshal(b, m, deterministic)
+}
+func (m *AddRuleRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddRuleRequest.Merge(m, src)
+}
+func (m *AddRuleRequest) XXX_Size() int {
+	return xxx_messageInfo_AddRuleRequest.Size(m)
+}
+func (m *AddRuleRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddRuleRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddRuleRequest proto.InternalMessageInfo
+
+func (m *AddRuleRequest) GetRule() *router.RoutingRule {
+	if m != nil {
+		return m.Rule
+	}
+	return nil
+}
+
+func (m *AddRuleRequest) GetIndex() int32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *AddRuleRequest) GetAppend() bool {
+	if m != nil {
+		return m.Append
+	}
+	return false
+}
+
+type AddRuleResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddRuleResponse) Reset()         { *m = AddRuleResponse{} }
+func (m *AddRuleResponse) String() string { return proto.CompactTextString(m) }
+func (*AddRuleResponse) ProtoMessage()    {}
+func (*AddRuleResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{3}
+}
+
+func (m *AddRuleResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddRuleResponse.Unmarshal(m, b)
+}
+func (m *AddRuleResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddRuleResponse.Marshal(b, m, deterministic)
+}
+func (m *AddRuleResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddRuleResponse.Merge(m, src)
+}
+func (m *AddRuleResponse) XXX_Size() int {
+	return xxx_messageInfo_AddRuleResponse.Size(m)
+}
+func (m *AddRuleResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddRuleResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddRuleResponse proto.InternalMessageInfo
+
+type RemoveRuleRequest struct {
+	Index                int32    `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveRuleRequest) Reset()         { *m = RemoveRuleRequest{} }
+func (m *RemoveRuleRequest) String() string { return proto.CompactTextString(m) }
+func (*RemoveRuleRequest) ProtoMessage()    {}
+func (*RemoveRuleRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{4}
+}
+
+func (m *RemoveRuleRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveRuleRequest.Unmarshal(m, b)
+}
+func (m *RemoveRuleRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveRuleRequest.Marshal(b, m, deterministic)
+}
+func (m *RemoveRuleRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveRuleRequest.Merge(m, src)
+}
+func (m *RemoveRuleRequest) XXX_Size() int {
+	return xxx_messageInfo_RemoveRuleRequest.Size(m)
+}
+func (m *RemoveRuleRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveRuleRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveRuleRequest proto.InternalMessageInfo
+
+func (m *RemoveRuleRequest) GetIndex() int32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+type RemoveRuleResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveRuleResponse) Reset()         { *m = RemoveRuleResponse{} }
+func (m *RemoveRuleResponse) String() string { return proto.CompactTextString(m) }
+func (*RemoveRuleResponse) ProtoMessage()    {}
+func (*RemoveRuleResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{5}
+}
+
+func (m *RemoveRuleResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveRuleResponse.Unmarshal(m, b)
+}
+func (m *RemoveRuleResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveRuleResponse.Marshal(b, m, deterministic)
+}
+func (m *RemoveRuleResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveRuleResponse.Merge(m, src)
+}
+func (m *RemoveRuleResponse) XXX_Size() int {
+	return xxx_messageInfo_RemoveRuleResponse.Size(m)
+}
+func (m *RemoveRuleResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveRuleResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveRuleResponse proto.InternalMessageInfo
+
+type ReplaceRulesRequest struct {
+	Rule                 []*router.RoutingRule `protobuf:"bytes,1,rep,name=rule,proto3" json:"rule,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *ReplaceRulesRequest) Reset()         { *m = ReplaceRulesRequest{} }
+func (m *ReplaceRulesRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplaceRulesRequest) ProtoMessage()    {}
+func (*ReplaceRulesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{6}
+}
+
+func (m *ReplaceRulesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplaceRulesRequest.Unmarshal(m, b)
+}
+func (m *ReplaceRulesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplaceRulesRequest.Marshal(b, m, deterministic)
+}
+func (m *ReplaceRulesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplaceRulesRequest.Merge(m, src)
+}
+func (m *ReplaceRulesRequest) XXX_Size() int {
+	return xxx_messageInfo_ReplaceRulesRequest.Size(m)
+}
+func (m *ReplaceRulesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplaceRulesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplaceRulesRequest proto.InternalMessageInfo
+
+func (m *ReplaceRulesRequest) GetRule() []*router.RoutingRule {
+	if m != nil {
+		return m.Rule
+	}
+	return nil
+}
+
+type ReplaceRulesResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplaceRulesResponse) Reset()         { *m = ReplaceRulesResponse{} }
+func (m *ReplaceRulesResponse) String() string { return proto.CompactTextString(m) }
+func (*ReplaceRulesResponse) ProtoMessage()    {}
+func (*ReplaceRulesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{7}
+}
+
+func (m *ReplaceRulesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplaceRulesResponse.Unmarshal(m, b)
+}
+func (m *ReplaceRulesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplaceRulesResponse.Marshal(b, m, deterministic)
+}
+func (m *ReplaceRulesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplaceRulesResponse.Merge(m, src)
+}
+func (m *ReplaceRulesResponse) XXX_Size() int {
+	return xxx_messageInfo_ReplaceRulesResponse.Size(m)
+}
+func (m *ReplaceRulesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplaceRulesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplaceRulesResponse proto.InternalMessageInfo
+
+type AddBalancerRequest struct {
+	Balancer             *router.BalancingRule `protobuf:"bytes,1,opt,name=balancer,proto3" json:"balancer,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *AddBalancerRequest) Reset()         { *m = AddBalancerRequest{} }
+func (m *AddBalancerRequest) String() string { return proto.CompactTextString(m) }
+func (*AddBalancerRequest) ProtoMessage()    {}
+func (*AddBalancerRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{8}
+}
+
+func (m *AddBalancerRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddBalancerRequest.Unmarshal(m, b)
+}
+func (m *AddBalancerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddBalancerRequest.Marshal(b, m, deterministic)
+}
+func (m *AddBalancerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddBalancerRequest.Merge(m, src)
+}
+func (m *AddBalancerRequest) XXX_Size() int {
+	return xxx_messageInfo_AddBalancerRequest.Size(m)
+}
+func (m *AddBalancerRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddBalancerRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddBalancerRequest proto.InternalMessageInfo
+
+func (m *AddBalancerRequest) GetBalancer() *router.BalancingRule {
+	if m != nil {
+		return m.Balancer
+	}
+	return nil
+}
+
+type AddBalancerResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddBalancerResponse) Reset()         { *m = AddBalancerResponse{} }
+func (m *AddBalancerResponse) String() string { return proto.CompactTextString(m) }
+func (*AddBalancerResponse) ProtoMessage()    {}
+func (*AddBalancerResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{9}
+}
+
+func (m *AddBalancerResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddBalancerResponse.Unmarshal(m, b)
+}
+func (m *AddBalancerResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddBalancerResponse.Marshal(b, m, deterministic)
+}
+func (m *AddBalancerResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddBalancerResponse.Merge(m, src)
+}
+func (m *AddBalancerResponse) XXX_Size() int {
+	return xxx_messageInfo_AddBalancerResponse.Size(m)
+}
+func (m *AddBalancerResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddBalancerResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddBalancerResponse proto.InternalMessageInfo
+
+type RemoveBalancerRequest struct {
+	Tag                  string   `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveBalancerRequest) Reset()         { *m = RemoveBalancerRequest{} }
+func (m *RemoveBalancerRequest) String() string { return proto.CompactTextString(m) }
+func (*RemoveBalancerRequest) ProtoMessage()    {}
+func (*RemoveBalancerRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{10}
+}
+
+func (m *RemoveBalancerRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveBalancerRequest.Unmarshal(m, b)
+}
+func (m *RemoveBalancerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveBalancerRequest.Marshal(b, m, deterministic)
+}
+func (m *RemoveBalancerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveBalancerRequest.Merge(m, src)
+}
+func (m *RemoveBalancerRequest) XXX_Size() int {
+	return xxx_messageInfo_RemoveBalancerRequest.Size(m)
+}
+func (m *RemoveBalancerRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveBalancerRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveBalancerRequest proto.InternalMessageInfo
+
+func (m *RemoveBalancerRequest) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+type RemoveBalancerResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveBalancerResponse) Reset()         { *m = RemoveBalancerResponse{} }
+func (m *RemoveBalancerResponse) String() string { return proto.CompactTextString(m) }
+func (*RemoveBalancerResponse) ProtoMessage()    {}
+func (*RemoveBalancerResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{11}
+}
+
+func (m *RemoveBalancerResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveBalancerResponse.Unmarshal(m, b)
+}
+func (m *RemoveBalancerResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveBalancerResponse.Marshal(b, m, deterministic)
+}
+func (m *RemoveBalancerResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveBalancerResponse.Merge(m, src)
+}
+func (m *RemoveBalancerResponse) XXX_Size() int {
+	return xxx_messageInfo_RemoveBalancerResponse.Size(m)
+}
+func (m *RemoveBalancerResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveBalancerResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveBalancerResponse proto.InternalMessageInfo
+
+type GetBalancersRequest struct {
+	// Tags of balancers to query. All balancers are returned if empty.
+	Tag                  []string `protobuf:"bytes,1,rep,name=tag,proto3" json:"tag,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBalancersRequest) Reset()         { *m = GetBalancersRequest{} }
+func (m *GetBalancersRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBalancersRequest) ProtoMessage()    {}
+func (*GetBalancersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{12}
+}
+
+func (m *GetBalancersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBalancersRequest.Unmarshal(m, b)
+}
+func (m *GetBalancersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBalancersRequest.Marshal(b, m, deterministic)
+}
+func (m *GetBalancersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBalancersRequest.Merge(m, src)
+}
+func (m *GetBalancersRequest) XXX_Size() int {
+	return xxx_messageInfo_GetBalancersRequest.Size(m)
+}
+func (m *GetBalancersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBalancersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBalancersRequest proto.InternalMessageInfo
+
+func (m *GetBalancersRequest) GetTag() []string {
+	if m != nil {
+		return m.Tag
+	}
+	return nil
+}
+
+type OutboundLatency struct {
+	Tag string `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	// Latency in milliseconds.
+	Latency              int64    `protobuf:"varint,2,opt,name=latency,proto3" json:"latency,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OutboundLatency) Reset()         { *m = OutboundLatency{} }
+func (m *OutboundLatency) String() string { return proto.CompactTextString(m) }
+func (*OutboundLatency) ProtoMessage()    {}
+func (*OutboundLatency) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{13}
+}
+
+func (m *OutboundLatency) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OutboundLatency.Unmarshal(m, b)
+}
+func (m *OutboundLatency) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OutboundLatency.Marshal(b, m, deterministic)
+}
+func (m *OutboundLatency) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OutboundLatency.Merge(m, src)
+}
+func (m *OutboundLatency) XXX_Size() int {
+	return xxx_messageInfo_OutboundLatency.Size(m)
+}
+func (m *OutboundLatency) XXX_DiscardUnknown() {
+	xxx_messageInfo_OutboundLatency.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OutboundLatency proto.InternalMessageInfo
+
+func (m *OutboundLatency) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *OutboundLatency) GetLatency() int64 {
+	if m != nil {
+		return m.Latency
+	}
+	return 0
+}
+
+type BalancerStatus struct {
+	Tag string `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	// Outbound that the balancer currently routes to. Empty if the strategy
+	// doesn't stick to one outbound.
+	Selected string `protobuf:"bytes,2,opt,name=selected,proto3" json:"selected,omitempty"`
+	// Latest measurements, for strategies that measure latency.
+	Latency              []*OutboundLatency `protobuf:"bytes,3,rep,name=latency,proto3" json:"latency,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *BalancerStatus) Reset()         { *m = BalancerStatus{} }
+func (m *BalancerStatus) String() string { return proto.CompactTextString(m) }
+func (*BalancerStatus) ProtoMessage()    {}
+func (*BalancerStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{14}
+}
+
+func (m *BalancerStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BalancerStatus.Unmarshal(m, b)
+}
+func (m *BalancerStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BalancerStatus.Marshal(b, m, deterministic)
+}
+func (m *BalancerStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BalancerStatus.Merge(m, src)
+}
+func (m *BalancerStatus) XXX_Size() int {
+	return xxx_messageInfo_BalancerStatus.Size(m)
+}
+func (m *BalancerStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_BalancerStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BalancerStatus proto.InternalMessageInfo
+
+func (m *BalancerStatus) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *BalancerStatus) GetSelected() string {
+	if m != nil {
+		return m.Selected
+	}
+	return ""
+}
+
+func (m *BalancerStatus) GetLatency() []*OutboundLatency {
+	if m != nil {
+		return m.Latency
+	}
+	return nil
+}
+
+type GetBalancersResponse struct {
+	Balancer             []*BalancerStatus `protobuf:"bytes,1,rep,name=balancer,proto3" json:"balancer,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *GetBalancersResponse) Reset()         { *m = GetBalancersResponse{} }
+func (m *GetBalancersResponse) String() string { return proto.CompactTextString(m) }
+func (*GetBalancersResponse) ProtoMessage()    {}
+func (*GetBalancersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{15}
+}
+
+func (m *GetBalancersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBalancersResponse.Unmarshal(m, b)
+}
+func (m *GetBalancersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBalancersResponse.Marshal(b, m, deterministic)
+}
+func (m *GetBalancersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBalancersResponse.Merge(m, src)
+}
+func (m *GetBalancersResponse) XXX_Size() int {
+	return xxx_messageInfo_GetBalancersResponse.Size(m)
+}
+func (m *GetBalancersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBalancersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBalancersResponse proto.InternalMessageInfo
+
+func (m *GetBalancersResponse) GetBalancer() []*BalancerStatus {
+	if m != nil {
+		return m.Balancer
+	}
+	return nil
+}
+
+// A synthetic request to be routed.
+type TestRouteRequest struct {
+	InboundTag string `protobuf:"bytes,1,opt,name=inbound_tag,json=inboundTag,proto3" json:"inbound_tag,omitempty"`
+	SourceIp   string `protobuf:"bytes,2,opt,name=source_ip,json=sourceIp,proto3" json:"source_ip,omitempty"`
+	UserEmail  string `protobuf:"bytes,3,opt,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`
+	// Destination domain or IP.
+	Destination string      `protobuf:"bytes,4,opt,name=destination,proto3" json:"destination,omitempty"`
+	Port        uint32      `protobuf:"varint,5,opt,name=port,proto3" json:"port,omitempty"`
+	Network     net.Network `protobuf:"varint,6,opt,name=network,proto3,enum=v2ray.core.common.net.Network" json:"network,omitempty"`
+	// Sniffed protocol, such as "http" or "tls".
+	Protocol             string   `protobuf:"bytes,7,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Application          []string `protobuf:"bytes,8,rep,name=application,proto3" json:"application,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TestRouteRequest) Reset()         { *m = TestRouteRequest{} }
+func (m *TestRouteRequest) String() string { return proto.CompactTextString(m) }
+func (*TestRouteRequest) ProtoMessage()    {}
+func (*TestRouteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{16}
+}
+
+func (m *TestRouteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TestRouteRequest.Unmarshal(m, b)
+}
+func (m *TestRouteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TestRouteRequest.Marshal(b, m, deterministic)
+}
+func (m *TestRouteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TestRouteRequest.Merge(m, src)
+}
+func (m *TestRouteRequest) XXX_Size() int {
+	return xxx_messageInfo_TestRouteRequest.Size(m)
+}
+func (m *TestRouteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TestRouteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TestRouteRequest proto.InternalMessageInfo
+
+func (m *TestRouteRequest) GetInboundTag() string {
+	if m != nil {
+		return m.InboundTag
+	}
+	return ""
+}
+
+func (m *TestRouteRequest) GetSourceIp() string {
+	if m != nil {
+		return m.SourceIp
+	}
+	return ""
+}
+
+func (m *TestRouteRequest) GetUserEmail() string {
+	if m != nil {
+		return m.UserEmail
+	}
+	return ""
+}
+
+func (m *TestRouteRequest) GetDestination() string {
+	if m != nil {
+		return m.Destination
+	}
+	return ""
+}
+
+func (m *TestRouteRequest) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *TestRouteRequest) GetNetwork() net.Network {
+	if m != nil {
+		return m.Network
+	}
+	return net.Network_Unknown
+}
+
+func (m *TestRouteRequest) GetProtocol() string {
+	if m != nil {
+		return m.Protocol
+	}
+	return ""
+}
+
+func (m *TestRouteRequest) GetApplication() []string {
+	if m != nil {
+		return m.Application
+	}
+	return nil
+}
+
+type ConditionResult struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Matched              bool     `protobuf:"varint,2,opt,name=matched,proto3" json:"matched,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ConditionResult) Reset()         { *m = ConditionResult{} }
+func (m *ConditionResult) String() string { return proto.CompactTextString(m) }
+func (*ConditionResult) ProtoMessage()    {}
+func (*ConditionResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{17}
+}
+
+func (m *ConditionResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConditionResult.Unmarshal(m, b)
+}
+func (m *ConditionResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConditionResult.Marshal(b, m, deterministic)
+}
+func (m *ConditionResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConditionResult.Merge(m, src)
+}
+func (m *ConditionResult) XXX_Size() int {
+	return xxx_messageInfo_ConditionResult.Size(m)
+}
+func (m *ConditionResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConditionResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConditionResult proto.InternalMessageInfo
+
+func (m *ConditionResult) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ConditionResult) GetMatched() bool {
+	if m != nil {
+		return m.Matched
+	}
+	return false
+}
+
+type RuleResult struct {
+	Index                int32              `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Matched              bool               `protobuf:"varint,2,opt,name=matched,proto3" json:"matched,omitempty"`
+	Condition            []*ConditionResult `protobuf:"bytes,3,rep,name=condition,proto3" json:"condition,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *RuleResult) Reset()         { *m = RuleResult{} }
+func (m *RuleResult) String() string { return proto.CompactTextString(m) }
+func (*RuleResult) ProtoMessage()    {}
+func (*RuleResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{18}
+}
+
+func (m *RuleResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RuleResult.Unmarshal(m, b)
+}
+func (m *RuleResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RuleResult.Marshal(b, m, deterministic)
+}
+func (m *RuleResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RuleResult.Merge(m, src)
+}
+func (m *RuleResult) XXX_Size() int {
+	return xxx_messageInfo_RuleResult.Size(m)
+}
+func (m *RuleResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_RuleResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RuleResult proto.InternalMessageInfo
+
+func (m *RuleResult) GetIndex() int32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *RuleResult) GetMatched() bool {
+	if m != nil {
+		return m.Matched
+	}
+	return false
+}
+
+func (m *RuleResult) GetCondition() []*ConditionResult {
+	if m != nil {
+		return m.Condition
+	}
+	return nil
+}
+
+type TestRouteResponse struct {
+	// Index of the matching rule, or -1 if no rule matches.
+	RuleIndex int32 `protobuf:"varint,1,opt,name=rule_index,json=ruleIndex,proto3" json:"rule_index,omitempty"`
+	// Results of the rules evaluated, up to and including the matching rule.
+	Rule []*RuleResult `protobuf:"bytes,2,rep,name=rule,proto3" json:"rule,omitempty"`
+	// IPs of the destination domain, if it was resolved during routing.
+	ResolvedIp  []string `protobuf:"bytes,3,rep,name=resolved_ip,json=resolvedIp,proto3" json:"resolved_ip,omitempty"`
+	BalancerTag string   `protobuf:"bytes,4,opt,name=balancer_tag,json=balancerTag,proto3" json:"balancer_tag,omitempty"`
+	// Outbound of the request, which is the default outbound if no rule matches.
+	OutboundTag string `protobuf:"bytes,5,opt,name=outbound_tag,json=outboundTag,proto3" json:"outbound_tag,omitempty"`
+	// Action of the matching rule, such as "route" or "reject-rst".
+	Action string `protobuf:"bytes,6,opt,name=action,proto3" json:"action,omitempty"`
+	// Rewritten destination of the request, if the matching rule rewrites it.
+	Rewrite              string   `protobuf:"bytes,7,opt,name=rewrite,proto3" json:"rewrite,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TestRouteResponse) Reset()         { *m = TestRouteResponse{} }
+func (m *TestRouteResponse) String() string { return proto.CompactTextString(m) }
+func (*TestRouteResponse) ProtoMessage()    {}
+func (*TestRouteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{19}
+}
+
+func (m *TestRouteResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TestRouteResponse.Unmarshal(m, b)
+}
+func (m *TestRouteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TestRouteResponse.Marshal(b, m, deterministic)
+}
+func (m *TestRouteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TestRouteResponse.Merge(m, src)
+}
+func (m *TestRouteResponse) XXX_Size() int {
+	return xxx_messageInfo_TestRouteResponse.Size(m)
+}
+func (m *TestRouteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TestRouteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TestRouteResponse proto.InternalMessageInfo
+
+func (m *TestRouteResponse) GetRuleIndex() int32 {
+	if m != nil {
+		return m.RuleIndex
+	}
+	return 0
+}
+
+func (m *TestRouteResponse) GetRule() []*RuleResult {
+	if m != nil {
+		return m.Rule
+	}
+	return nil
+}
+
+func (m *TestRouteResponse) GetResolvedIp() []string {
+	if m != nil {
+		return m.ResolvedIp
+	}
+	return nil
+}
+
+func (m *TestRouteResponse) GetBalancerTag() string {
+	if m != nil {
+		return m.BalancerTag
+	}
+	return ""
+}
+
+func (m *TestRouteResponse) GetOutboundTag() string {
+	if m != nil {
+		return m.OutboundTag
+	}
+	return ""
+}
+
+func (m *TestRouteResponse) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *TestRouteResponse) GetRewrite() string {
+	if m != nil {
+		return m.Rewrite
+	}
+	return ""
+}
+
+type Config struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Config) Reset()         { *m = Config{} }
+func (m *Config) String() string { return proto.CompactTextString(m) }
+func (*Config) ProtoMessage()    {}
+func (*Config) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59607e80b1106a93, []int{20}
+}
+
+func (m *Config) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Config.Unmarshal(m, b)
+}
+func (m *Config) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Config.Marshal(b, m, deterministic)
+}
+func (m *Config) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Config.Merge(m, src)
+}
+func (m *Config) XXX_Size() int {
+	return xxx_messageInfo_Config.Size(m)
+}
+func (m *Config) XXX_DiscardUnknown() {
+	xxx_messageInfo_Config.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Config proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*ListRulesRequest)(nil), "v2ray.core.app.router.command.ListRulesRequest")
+	proto.RegisterType((*ListRulesResponse)(nil), "v2ray.core.app.router.command.ListRulesResponse")
+	proto.RegisterType((*AddRuleRequest)(nil), "v2ray.core.app.router.command.AddRuleRequest")
+	proto.RegisterType((*AddRuleResponse)(nil), "v2ray.core.app.router.command.AddRuleResponse")
+	proto.RegisterType((*RemoveRuleRequest)(nil), "v2ray.core.app.router.command.RemoveRuleRequest")
+	proto.RegisterType((*RemoveRuleResponse)(nil), "v2ray.core.app.router.command.RemoveRuleResponse")
+	proto.RegisterType((*ReplaceRulesRequest)(nil), "v2ray.core.app.router.command.ReplaceRulesRequest")
+	proto.RegisterType((*ReplaceRulesResponse)(nil), "v2ray.core.app.router.command.ReplaceRulesResponse")
+	proto.RegisterType((*AddBalancerRequest)(nil), "v2ray.core.app.router.command.AddBalancerRequest")
+	proto.RegisterType((*AddBalancerResponse)(nil), "v2ray.core.app.router.command.AddBalancerResponse")
+	proto.RegisterType((*RemoveBalancerRequest)(nil), "v2ray.core.app.router.command.RemoveBalancerRequest")
+	proto.RegisterType((*RemoveBalancerResponse)(nil), "v2ray.core.app.router.command.RemoveBalancerResponse")
+	proto.RegisterType((*GetBalancersRequest)(nil), "v2ray.core.app.router.command.GetBalancersRequest")
+	proto.RegisterType((*OutboundLatency)(nil), "v2ray.core.app.router.command.OutboundLatency")
+	proto.RegisterType((*BalancerStatus)(nil), "v2ray.core.app.router.command.BalancerStatus")
+	proto.RegisterType((*GetBalancersResponse)(nil), "v2ray.core.app.router.command.GetBalancersResponse")
+	proto.RegisterType((*TestRouteRequest)(nil), "v2ray.core.app.router.command.TestRouteRequest")
+	proto.RegisterType((*ConditionResult)(nil), "v2ray.core.app.router.command.ConditionResult")
+	proto.RegisterType((*RuleResult)(nil), "v2ray.core.app.router.command.RuleResult")
+	proto.RegisterType((*TestRouteResponse)(nil), "v2ray.core.app.router.command.TestRouteResponse")
+	proto.RegisterType((*Config)(nil), "v2ray.core.app.router.command.Config")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/app/router/command/command.proto", fileDescriptor_59607e80b1106a93)
+}
+
+var fileDescriptor_59607e80b1106a93 = []byte{
+	// 968 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0x5f, 0x6f, 0xe3, 0x44,
+	0x10, 0xc7, 0x4d, 0x9b, 0x3f, 0x93, 0x5e, 0xda, 0x6e, 0x7b, 0x55, 0x64, 0x54, 0x2e, 0xb5, 0x10,
+	0x97, 0x7b, 0xc0, 0xb9, 0x4b, 0x01, 0xf1, 0x72, 0x82, 0x5e, 0x85, 0xa0, 0xa2, 0x1c, 0xc8, 0x77,
+	0xba, 0x07, 0x5e, 0xa2, 0x8d, 0x3d, 0x04, 0x83, 0xe3, 0x5d, 0xec, 0x75, 0x8e, 0x3e, 0xf1, 0x8a,
+	0xee, 0x13, 0xf0, 0xc2, 0x17, 0xe0, 0x73, 0xf0, 0xc1, 0xd0, 0xae, 0x77, 0x9d, 0x4d, 0xfa, 0x27,
+	0x09, 0x0f, 0x55, 0x3d, 0xb3, 0x33, 0xbf, 0xdf, 0xec, 0xcc, 0xec, 0x4c, 0x60, 0x30, 0x1b, 0x66,
+	0xf4, 0xda, 0x0f, 0xd9, 0x74, 0x10, 0xb2, 0x0c, 0x07, 0x94, 0xf3, 0x41, 0xc6, 0x0a, 0x81, 0xd9,
+	0x20, 0x64, 0xd3, 0x29, 0x4d, 0x23, 0xf3, 0xdf, 0xe7, 0x19, 0x13, 0x8c, 0x9c, 0x18, 0x87, 0x0c,
+	0x7d, 0xca, 0xb9, 0x5f, 0x1a, 0xfb, 0xda, 0xc8, 0xfd, 0xe8, 0x3e, 0xbc, 0xf4, 0xa7, 0x78, 0x52,
+	0xc2, 0xb8, 0x8f, 0x97, 0xec, 0xa4, 0x3f, 0x4b, 0x07, 0x29, 0x0a, 0xf9, 0xf7, 0x96, 0x65, 0xbf,
+	0x96, 0x86, 0x1e, 0x81, 0xfd, 0xab, 0x38, 0x17, 0x41, 0x91, 0x60, 0x1e, 0xe0, 0x6f, 0x05, 0xe6,
+	0xc2, 0xfb, 0xcb, 0x81, 0x03, 0x4b, 0x99, 0x73, 0x96, 0xe6, 0x48, 0x3e, 0x83, 0xed, 0xac, 0x48,
+	0xb0, 0xeb, 0xf4, 0x6a, 0xfd, 0xf6, 0xd0, 0xf3, 0x6f, 0x0f, 0x34, 0x60, 0x85, 0x88, 0xd3, 0x89,
+	0x74, 0x0d, 0x94, 0x3d, 0xf9, 0x16, 0x3a, 0x63, 0x9a, 0xd0, 0x34, 0x8c, 0xd3, 0xc9, 0x48, 0x21,
+	0x6c, 0x29, 0x84, 0x0f, 0xef, 0x40, 0x78, 0x61, 0x8c, 0x15, 0xc6, 0x83, 0xb1, 0x2d, 0x7a, 0x33,
+	0xe8, 0x9c, 0x47, 0x91, 0x3a, 0x29, 0x83, 0xb5, 0xc2, 0x72, 0x36, 0x0a, 0xeb, 0x08, 0x76, 0xe2,
+	0x34, 0xc2, 0xdf, 0xbb, 0x5b, 0x3d, 0xa7, 0xbf, 0x13, 0x94, 0x02, 0x39, 0x86, 0x3a, 0xe5, 0x1c,
+	0xd3, 0xa8, 0x5b, 0xeb, 0x39, 0xfd, 0x66, 0xa0, 0x25, 0xef, 0x00, 0xf6, 0x2a, 0xde, 0x32, 0x1f,
+	0xde, 0x13, 0x38, 0x08, 0x70, 0xca, 0x66, 0x68, 0x47, 0x53, 0xa1, 0x3a, 0x16, 0xaa, 0x77, 0x04,
+	0xc4, 0x36, 0xd5, 0x00, 0xdf, 0xc1, 0x61, 0x80, 0x3c, 0xa1, 0x21, 0xda, 0xd9, 0xff, 0xbf, 0x79,
+	0xf6, 0x8e, 0xe1, 0x68, 0x11, 0x4e, 0xd3, 0xbc, 0x01, 0x72, 0x1e, 0x45, 0x65, 0x56, 0x31, 0x33,
+	0x2c, 0x5f, 0x42, 0x73, 0xac, 0x55, 0x3a, 0x75, 0xeb, 0xd5, 0xa3, 0xf2, 0xf2, 0x1e, 0xc2, 0xe1,
+	0x02, 0x6e, 0x95, 0x96, 0x87, 0xe5, 0x5d, 0x97, 0x19, 0xf7, 0xa1, 0x26, 0xe8, 0x44, 0x91, 0xb5,
+	0x02, 0xf9, 0xe9, 0x75, 0xe1, 0x78, 0xd9, 0x54, 0x83, 0x3c, 0x86, 0xc3, 0xaf, 0x51, 0x18, 0x75,
+	0x7e, 0x03, 0xa2, 0x66, 0x20, 0x9e, 0xc3, 0xde, 0xf7, 0x85, 0x18, 0xb3, 0x22, 0x8d, 0xae, 0xa8,
+	0xc0, 0x34, 0xbc, 0xbe, 0xc9, 0x43, 0xba, 0xd0, 0x48, 0xca, 0x43, 0x55, 0xec, 0x5a, 0x60, 0x44,
+	0xef, 0x4f, 0x07, 0x3a, 0x86, 0xe5, 0x95, 0xa0, 0xa2, 0xc8, 0x6f, 0x71, 0x77, 0xa1, 0x99, 0x63,
+	0x82, 0xa1, 0xc0, 0x48, 0xf9, 0xb7, 0x82, 0x4a, 0x26, 0xdf, 0xcc, 0xa1, 0x6b, 0xaa, 0x5e, 0xbe,
+	0x7f, 0xef, 0x03, 0xf6, 0x97, 0xa2, 0x9d, 0x87, 0x42, 0xe1, 0x68, 0xf1, 0xca, 0xfa, 0xd9, 0x5d,
+	0x2e, 0x14, 0x4a, 0x52, 0x7c, 0xbc, 0x82, 0x62, 0xf1, 0x42, 0x56, 0xc5, 0xfe, 0xde, 0x82, 0xfd,
+	0xd7, 0x98, 0x0b, 0xd9, 0x3b, 0x55, 0xc7, 0x3e, 0x82, 0x76, 0x9c, 0xaa, 0x90, 0x46, 0xf3, 0x7b,
+	0x83, 0x56, 0xbd, 0xa6, 0x13, 0xf2, 0x3e, 0xb4, 0x72, 0x56, 0x64, 0x21, 0x8e, 0x62, 0x5e, 0xdd,
+	0x5f, 0x29, 0x2e, 0x39, 0x39, 0x01, 0x28, 0x72, 0xcc, 0x46, 0x38, 0xa5, 0x71, 0xa2, 0xde, 0x4c,
+	0x2b, 0x68, 0x49, 0xcd, 0x57, 0x52, 0x41, 0x7a, 0xd0, 0x8e, 0x30, 0x17, 0x71, 0x4a, 0x45, 0xcc,
+	0xd2, 0xee, 0xb6, 0x3a, 0xb7, 0x55, 0x84, 0xc0, 0x36, 0x67, 0x99, 0xe8, 0xee, 0xf4, 0x9c, 0xfe,
+	0x83, 0x40, 0x7d, 0x93, 0xcf, 0xa1, 0xa1, 0x87, 0x54, 0xb7, 0xde, 0x73, 0xfa, 0x9d, 0xe1, 0x07,
+	0xf6, 0x8d, 0xcb, 0x51, 0xe6, 0xa7, 0x28, 0xfc, 0x97, 0xa5, 0x55, 0x60, 0xcc, 0x65, 0xa9, 0xd4,
+	0x58, 0x0b, 0x59, 0xd2, 0x6d, 0x94, 0xa1, 0x1a, 0x59, 0xc6, 0x42, 0x39, 0x4f, 0xe2, 0xb0, 0x8c,
+	0xa5, 0xa9, 0x9a, 0xc8, 0x56, 0x79, 0x5f, 0xc0, 0xde, 0x05, 0x4b, 0xa3, 0x58, 0x0a, 0x01, 0xe6,
+	0x45, 0x22, 0x64, 0x78, 0x29, 0x9d, 0xa2, 0x4e, 0x8b, 0xfa, 0x96, 0xed, 0x34, 0xa5, 0x22, 0xfc,
+	0x59, 0xb7, 0x43, 0x33, 0x30, 0xa2, 0xf7, 0xce, 0x01, 0xd0, 0x4f, 0xbc, 0x48, 0xee, 0x18, 0x06,
+	0x77, 0xbb, 0x93, 0x2b, 0x68, 0x85, 0x86, 0x7f, 0xcd, 0x76, 0x5a, 0x8a, 0x37, 0x98, 0x03, 0x78,
+	0xef, 0xb6, 0xe0, 0xc0, 0xaa, 0xb6, 0x6e, 0xa7, 0x13, 0x00, 0x39, 0x2d, 0x46, 0x76, 0x60, 0x2d,
+	0xa9, 0xb9, 0x54, 0xc1, 0x3d, 0x87, 0x6d, 0x6b, 0x44, 0x3f, 0x59, 0xc1, 0x3e, 0xbf, 0xab, 0x1e,
+	0xaa, 0x8f, 0xa0, 0x9d, 0x61, 0xce, 0x92, 0x19, 0x46, 0xb2, 0x5b, 0x6a, 0x2a, 0xc7, 0x60, 0x54,
+	0x97, 0x9c, 0x9c, 0xc2, 0xae, 0x69, 0x47, 0xd5, 0x6e, 0xba, 0x23, 0x8c, 0x4e, 0xf6, 0xdb, 0x29,
+	0xec, 0x32, 0xfd, 0x48, 0x94, 0xc9, 0x4e, 0x69, 0x62, 0x74, 0xd2, 0x44, 0x4e, 0xe9, 0x50, 0x65,
+	0xa9, 0xae, 0x0e, 0xb5, 0x24, 0x53, 0x9b, 0xe1, 0xdb, 0x2c, 0x16, 0xa8, 0xab, 0x6f, 0x44, 0xaf,
+	0x09, 0xf5, 0x0b, 0xb5, 0x1f, 0x87, 0xff, 0x36, 0xa0, 0xa3, 0x87, 0xe7, 0x2b, 0xcc, 0x66, 0x71,
+	0x88, 0x84, 0x43, 0xab, 0x5a, 0x77, 0x64, 0xb0, 0xe2, 0xce, 0xcb, 0xdb, 0xd2, 0x7d, 0xba, 0xbe,
+	0x83, 0x9e, 0x6e, 0xef, 0x91, 0x5f, 0xa0, 0xa1, 0xd7, 0x09, 0x59, 0xf5, 0x9a, 0x17, 0xd7, 0x9d,
+	0xeb, 0xaf, 0x6b, 0x5e, 0x71, 0xe5, 0x00, 0xf3, 0xe5, 0x43, 0x56, 0x45, 0x7b, 0x63, 0xa5, 0xb9,
+	0xcf, 0x36, 0xf0, 0xa8, 0x48, 0xaf, 0x61, 0xd7, 0x5e, 0x46, 0x64, 0xb8, 0x12, 0xe4, 0xc6, 0x22,
+	0x74, 0xcf, 0x36, 0xf2, 0xa9, 0xa8, 0x67, 0xd0, 0xb6, 0xf6, 0x12, 0x79, 0xb6, 0x3a, 0x61, 0x4b,
+	0x9b, 0xca, 0x1d, 0x6e, 0xe2, 0x52, 0xf1, 0xfe, 0x01, 0x9d, 0xc5, 0x6d, 0x46, 0x3e, 0x59, 0x2b,
+	0x73, 0xcb, 0xec, 0x9f, 0x6e, 0xe8, 0x65, 0xe7, 0xdc, 0xde, 0x20, 0x2b, 0x73, 0x7e, 0xcb, 0x86,
+	0x75, 0xcf, 0x36, 0xf2, 0xa9, 0xa8, 0x39, 0xb4, 0xaa, 0x51, 0xb3, 0xf2, 0x05, 0x2d, 0xaf, 0x20,
+	0xf7, 0xe9, 0xfa, 0x0e, 0x86, 0xf1, 0xc5, 0x4b, 0x38, 0x0d, 0xd9, 0xf4, 0x7e, 0xc7, 0x1f, 0x9c,
+	0x1f, 0x1b, 0xfa, 0xf3, 0x9f, 0xad, 0x93, 0x37, 0xc3, 0x80, 0x5e, 0xfb, 0x17, 0xd2, 0xf4, 0x9c,
+	0x73, 0xf5, 0x03, 0x0a, 0x33, 0xff, 0xa2, 0x3c, 0x1f, 0xd7, 0xd5, 0x9e, 0x38, 0xfb, 0x6f, 0x00,
+	0x85, 0xda, 0x50, 0x25, 0xb1, 0x0b, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// RoutingServiceClient is the client API for RoutingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type RoutingServiceClient interface {
+	ListRules(ctx context.Context, in *ListRulesRequest, opts ...grpc.CallOption) (*ListRulesResponse, error)
+	AddRule(ctx context.Context, in *AddRuleRequest, opts ...grpc.CallOption) (*AddRuleResponse, error)
+	RemoveRule(ctx context.Context, in *RemoveRuleRequest, opts ...grpc.CallOption) (*RemoveRuleResponse, error)
+	ReplaceRules(ctx context.Context, in *ReplaceRulesRequest, opts ...grpc.CallOption) (*ReplaceRulesResponse, error)
+	AddBalancer(ctx context.Context, in *AddBalancerRequest, opts ...grpc.CallOption) (*AddBalancerResponse, error)
+	RemoveBalancer(ctx context.Context, in *RemoveBalancerRequest, opts ...grpc.CallOption) (*RemoveBalancerResponse, error)
+	GetBalancers(ctx context.Context, in *GetBalancersRequest, opts ...grpc.CallOption) (*GetBalancersResponse, error)
+	TestRoute(ctx context.Context, in *TestRouteRequest, opts ...grpc.CallOption) (*TestRouteResponse, error)
+}
+
+type routingServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewRoutingServiceClient(cc *grpc.ClientConn) RoutingServiceClient {
+	return &routingServiceClient{cc}
+}
+
+func (c *routingServiceClient) ListRules(ctx context.Context, in *ListRulesRequest, opts ...grpc.CallOption) (*ListRulesResponse, error) {
+	out := new(ListRulesResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/ListRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingServiceClient) AddRule(ctx context.Context, in *AddRuleRequest, opts ...grpc.CallOption) (*AddRuleResponse, error) {
+	out := new(AddRuleResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/AddRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingServiceClient) RemoveRule(ctx context.Context, in *RemoveRuleRequest, opts ...grpc.CallOption) (*RemoveRuleResponse, error) {
+	out := new(RemoveRuleResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/RemoveRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingServiceClient) ReplaceRules(ctx context.Context, in *ReplaceRulesRequest, opts ...grpc.CallOption) (*ReplaceRulesResponse, error) {
+	out := new(ReplaceRulesResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/ReplaceRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingServiceClient) AddBalancer(ctx context.Context, in *AddBalancerRequest, opts ...grpc.CallOption) (*AddBalancerResponse, error) {
+	out := new(AddBalancerResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/AddBalancer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingServiceClient) RemoveBalancer(ctx context.Context, in *RemoveBalancerRequest, opts ...grpc.CallOption) (*RemoveBalancerResponse, error) {
+	out := new(RemoveBalancerResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/RemoveBalancer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingServiceClient) GetBalancers(ctx context.Context, in *GetBalancersRequest, opts ...grpc.CallOption) (*GetBalancersResponse, error) {
+	out := new(GetBalancersResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/GetBalancers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingServiceClient) TestRoute(ctx context.Context, in *TestRouteRequest, opts ...grpc.CallOption) (*TestRouteResponse, error) {
+	out := new(TestRouteResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.router.command.RoutingService/TestRoute", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RoutingServiceServer is the server API for RoutingService service.
+type RoutingServiceServer interface {
+	ListRules(context.Context, *ListRulesRequest) (*ListRulesResponse, error)
+	AddRule(context.Context, *AddRuleRequest) (*AddRuleResponse, error)
+	RemoveRule(context.Context, *RemoveRuleRequest) (*RemoveRuleResponse, error)
+	ReplaceRules(context.Context, *ReplaceRulesRequest) (*ReplaceRulesResponse, error)
+	AddBalancer(context.Context, *AddBalancerRequest) (*AddBalancerResponse, error)
+	RemoveBalancer(context.Context, *RemoveBalancerRequest) (*RemoveBalancerResponse, error)
+	GetBalancers(context.Context, *GetBalancersRequest) (*GetBalancersResponse, error)
+	TestRoute(context.Context, *TestRouteRequest) (*TestRouteResponse, error)
+}
+
+func RegisterRoutingServiceServer(s *grpc.Server, srv RoutingServiceServer) {
+	s.RegisterService(&_RoutingService_serviceDesc, srv)
+}
+
+func _RoutingService_ListRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).ListRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/ListRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).ListRules(ctx, req.(*ListRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoutingService_AddRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).AddRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/AddRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).AddRule(ctx, req.(*AddRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoutingService_RemoveRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).RemoveRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/RemoveRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).RemoveRule(ctx, req.(*RemoveRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoutingService_ReplaceRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplaceRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).ReplaceRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/ReplaceRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).ReplaceRules(ctx, req.(*ReplaceRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoutingService_AddBalancer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBalancerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).AddBalancer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/AddBalancer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).AddBalancer(ctx, req.(*AddBalancerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoutingService_RemoveBalancer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveBalancerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).RemoveBalancer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/RemoveBalancer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).RemoveBalancer(ctx, req.(*RemoveBalancerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoutingService_GetBalancers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalancersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).GetBalancers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/GetBalancers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).GetBalancers(ctx, req.(*GetBalancersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoutingService_TestRoute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TestRouteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServiceServer).TestRoute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.router.command.RoutingService/TestRoute",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServiceServer).TestRoute(ctx, req.(*TestRouteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _RoutingService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "v2ray.core.app.router.command.RoutingService",
+	HandlerType: (*RoutingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListRules",
+			Handler:    _RoutingService_ListRules_Handler,
+		},
+		{
+			MethodName: "AddRule",
+			Handler:    _RoutingService_AddRule_Handler,
+		},
+		{
+			MethodName: "RemoveRule",
+			Handler:    _RoutingService_RemoveRule_Handler,
+		},
+		{
+			MethodName: "ReplaceRules",
+			Handler:    _RoutingService_ReplaceRules_Handler,
+		},
+		{
+			MethodName: "AddBalancer",
+			Handler:    _RoutingService_AddBalancer_Handler,
+		},
+		{
+			MethodName: "RemoveBalancer",
+			Handler:    _RoutingService_RemoveBalancer_Handler,
+		},
+		{
+			MethodName: "GetBalancers",
+			Handler:    _RoutingService_GetBalancers_Handler,
+		},
+		{
+			MethodName: "TestRoute",
+			Handler:    _RoutingService_TestRoute_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "v2ray.com/core/app/router/command/command.proto",
+}
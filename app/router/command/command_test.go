@@ -0,0 +1,211 @@
+package command_test
+
+import (
+	"context"
+	"testing"
+
+	"v2ray.com/core/app/router"
+	. "v2ray.com/core/app/router/command"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+)
+
+func TestRoutingService(t *testing.T) {
+	r := new(router.Router)
+	common.Must(r.Init(&router.Config{
+		Rule: []*router.RoutingRule{
+			{
+				TargetTag: &router.RoutingRule_Tag{
+					Tag: "direct",
+				},
+				Networks: []net.Network{net.Network_UDP},
+			},
+		},
+		BalancingRule: []*router.BalancingRule{
+			{
+				Tag:              "balancer",
+				OutboundSelector: []string{"proxy"},
+			},
+		},
+	}, nil, nil, nil, nil))
+
+	s := NewRoutingServer(r)
+	ctx := context.Background()
+
+	_, err := s.AddRule(ctx, &AddRuleRequest{
+		Rule: &router.RoutingRule{
+			TargetTag: &router.RoutingRule_BalancingTag{
+				BalancingTag: "balancer",
+			},
+			Networks: []net.Network{net.Network_TCP},
+		},
+		Append: true,
+	})
+	common.Must(err)
+
+	resp, err := s.ListRules(ctx, &ListRulesRequest{})
+	common.Must(err)
+	if len(resp.Rule) != 2 || resp.Rule[1].GetBalancingTag() != "balancer" {
+		t.Error("unexpected rules: ", resp.Rule)
+	}
+	if len(resp.BalancingRule) != 1 || resp.BalancingRule[0].Tag != "balancer" {
+		t.Error("unexpected balancers: ", resp.BalancingRule)
+	}
+	resp.BalancingRule[0] = nil
+	if b := r.ListBalancers(); b[0] == nil {
+		t.Error("expect listed balancers to be a copy")
+	}
+
+	if _, err := s.AddRule(ctx, &AddRuleRequest{
+		Rule: &router.RoutingRule{
+			TargetTag: &router.RoutingRule_BalancingTag{
+				BalancingTag: "not-exist",
+			},
+		},
+	}); err == nil {
+		t.Error("expect error when adding rule with unknown balancer")
+	}
+
+	_, err = s.RemoveRule(ctx, &RemoveRuleRequest{Index: 0})
+	common.Must(err)
+	resp, err = s.ListRules(ctx, &ListRulesRequest{})
+	common.Must(err)
+	if len(resp.Rule) != 1 || resp.Rule[0].GetBalancingTag() != "balancer" {
+		t.Error("unexpected rules after removal: ", resp.Rule)
+	}
+
+	_, err = s.ReplaceRules(ctx, &ReplaceRulesRequest{})
+	common.Must(err)
+	resp, err = s.ListRules(ctx, &ListRulesRequest{})
+	common.Must(err)
+	if len(resp.Rule) != 0 {
+		t.Error("expect no rules after replacement, but got ", resp.Rule)
+	}
+
+	balancers, err := s.GetBalancers(ctx, &GetBalancersRequest{})
+	common.Must(err)
+	if len(balancers.Balancer) != 1 || balancers.Balancer[0].Tag != "balancer" || balancers.Balancer[0].Selected != "" {
+		t.Error("unexpected balancers: ", balancers.Balancer)
+	}
+	if _, err := s.GetBalancers(ctx, &GetBalancersRequest{Tag: []string{"not-exist"}}); err == nil {
+		t.Error("expect error when querying unknown balancer")
+	}
+}
+
+func TestRoutingServiceBalancers(t *testing.T) {
+	r := new(router.Router)
+	common.Must(r.Init(&router.Config{
+		Rule: []*router.RoutingRule{
+			{
+				TargetTag: &router.RoutingRule_Tag{
+					Tag: "direct",
+				},
+				Networks: []net.Network{net.Network_UDP},
+			},
+		},
+	}, nil, nil, nil, nil))
+	common.Must(r.Start())
+	defer r.Close()
+
+	s := NewRoutingServer(r)
+	ctx := context.Background()
+
+	_, err := s.AddBalancer(ctx, &AddBalancerRequest{
+		Balancer: &router.BalancingRule{
+			Tag:              "balancer",
+			OutboundSelector: []string{"proxy"},
+		},
+	})
+	common.Must(err)
+	if _, err := s.AddBalancer(ctx, &AddBalancerRequest{
+		Balancer: &router.BalancingRule{
+			Tag: "balancer",
+		},
+	}); err == nil {
+		t.Error("expect error when adding duplicated balancer")
+	}
+
+	// Rules are inserted at the front unless they are appended explicitly.
+	_, err = s.AddRule(ctx, &AddRuleRequest{
+		Rule: &router.RoutingRule{
+			TargetTag: &router.RoutingRule_BalancingTag{
+				BalancingTag: "balancer",
+			},
+			Networks: []net.Network{net.Network_TCP},
+		},
+	})
+	common.Must(err)
+	resp, err := s.ListRules(ctx, &ListRulesRequest{})
+	common.Must(err)
+	if len(resp.Rule) != 2 || resp.Rule[0].GetBalancingTag() != "balancer" {
+		t.Error("unexpected rules: ", resp.Rule)
+	}
+	if len(resp.BalancingRule) != 1 || resp.BalancingRule[0].Tag != "balancer" {
+		t.Error("unexpected balancers: ", resp.BalancingRule)
+	}
+
+	if _, err := s.RemoveBalancer(ctx, &RemoveBalancerRequest{Tag: "balancer"}); err == nil {
+		t.Error("expect error when removing balancer in use")
+	}
+	_, err = s.RemoveRule(ctx, &RemoveRuleRequest{Index: 0})
+	common.Must(err)
+	_, err = s.RemoveBalancer(ctx, &RemoveBalancerRequest{Tag: "balancer"})
+	common.Must(err)
+	if _, err := s.RemoveBalancer(ctx, &RemoveBalancerRequest{Tag: "balancer"}); err == nil {
+		t.Error("expect error when removing unknown balancer")
+	}
+
+	balancers, err := s.GetBalancers(ctx, &GetBalancersRequest{})
+	common.Must(err)
+	if len(balancers.Balancer) != 0 {
+		t.Error("expect no balancers, but got ", balancers.Balancer)
+	}
+}
+
+func TestRoutingServiceTestRoute(t *testing.T) {
+	r := new(router.Router)
+	common.Must(r.Init(&router.Config{
+		Rule: []*router.RoutingRule{
+			{
+				TargetTag: &router.RoutingRule_Tag{
+					Tag: "dns",
+				},
+				Networks: []net.Network{net.Network_UDP},
+				PortList: &net.PortList{Range: []*net.PortRange{net.SinglePortRange(53)}},
+			},
+			{
+				TargetTag: &router.RoutingRule_Tag{
+					Tag: "user",
+				},
+				UserEmail: []string{"love@v2ray.com"},
+			},
+		},
+	}, nil, nil, nil, nil))
+
+	s := NewRoutingServer(r)
+
+	resp, err := s.TestRoute(context.Background(), &TestRouteRequest{
+		UserEmail:   "love@v2ray.com",
+		Destination: "8.8.8.8",
+		Port:        53,
+		Network:     net.Network_UDP,
+	})
+	common.Must(err)
+	if resp.RuleIndex != 0 || resp.OutboundTag != "dns" || len(resp.Rule) != 1 || len(resp.Rule[0].Condition) != 2 {
+		t.Error("unexpected route: ", resp)
+	}
+
+	resp, err = s.TestRoute(context.Background(), &TestRouteRequest{
+		UserEmail:   "love@v2ray.com",
+		Destination: "v2ray.com",
+		Port:        443,
+	})
+	common.Must(err)
+	if resp.RuleIndex != 1 || resp.OutboundTag != "user" || len(resp.Rule) != 2 || resp.Rule[0].Matched {
+		t.Error("unexpected route: ", resp)
+	}
+
+	if _, err := s.TestRoute(context.Background(), &TestRouteRequest{}); err == nil {
+		t.Error("expect error for empty destination")
+	}
+}
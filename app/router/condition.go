@@ -4,6 +4,7 @@ package router
 
 import (
 	"strings"
+	"time"
 
 	"go.starlark.net/starlark"
 	"go.starlark.net/syntax"
@@ -114,6 +115,7 @@ func getIPsFromTarget(ctx *Context) []net.IP {
 
 type MultiGeoIPMatcher struct {
 	matchers []*GeoIPMatcher
+	onSource bool
 	ipFunc   func(*Context) []net.IP
 }
 
@@ -129,6 +131,7 @@ func NewMultiGeoIPMatcher(geoips []*GeoIP, onSource bool) (*MultiGeoIPMatcher, e
 
 	matcher := &MultiGeoIPMatcher{
 		matchers: matchers,
+		onSource: onSource,
 	}
 
 	if onSource {
@@ -144,10 +147,18 @@ func (m *MultiGeoIPMatcher) Apply(ctx *Context) bool {
 	ips := m.ipFunc(ctx)
 
 	for _, ip := range ips {
-		for _, matcher := range m.matchers {
-			if matcher.Match(ip) {
-				return true
-			}
+		if m.MatchIP(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchIP returns whether the IP is in any of the GeoIP sets.
+func (m *MultiGeoIPMatcher) MatchIP(ip net.IP) bool {
+	for _, matcher := range m.matchers {
+		if matcher.Match(ip) {
+			return true
 		}
 	}
 	return false
@@ -286,12 +297,14 @@ func (m *ProtocolMatcher) Apply(ctx *Context) bool {
 
 type CustomGeoIPMatcher struct {
 	geoipCodes []string
+	onSource   bool
 	ipFunc     func(*Context) []net.IP
 }
 
 func NewCustomGeoIPMatcher(geoipCodes []string, onSource bool) *CustomGeoIPMatcher {
 	matcher := &CustomGeoIPMatcher{
 		geoipCodes: geoipCodes,
+		onSource:   onSource,
 	}
 
 	if onSource {
@@ -307,16 +320,24 @@ func (m *CustomGeoIPMatcher) Apply(ctx *Context) bool {
 	ips := m.ipFunc(ctx)
 
 	for _, ip := range ips {
-		code, err := lookupGeoIP(ip)
-		if err != nil {
-			newError("failed to lookup GeoIP").Base(err).WriteToLog()
-			return false
+		if m.MatchIP(ip) {
+			return true
 		}
+	}
+	return false
+}
 
-		for _, c := range m.geoipCodes {
-			if code == c {
-				return true
-			}
+// MatchIP returns whether the country of the IP in geo.mmdb is one of the GeoIP codes.
+func (m *CustomGeoIPMatcher) MatchIP(ip net.IP) bool {
+	code, err := lookupGeoIP(ip)
+	if err != nil {
+		newError("failed to lookup GeoIP").Base(err).WriteToLog()
+		return false
+	}
+
+	for _, c := range m.geoipCodes {
+		if code == c {
+			return true
 		}
 	}
 	return false
@@ -413,3 +434,85 @@ func (m *ApplicationMatcher) Apply(ctx *Context) bool {
 
 	return false
 }
+
+const secondsPerDay = 24 * 60 * 60
+
+type scheduleWindow struct {
+	weekdays uint8 // bit i is set for time.Weekday(i)
+	start    uint32
+	end      uint32
+	location *time.Location
+}
+
+func (w *scheduleWindow) hasWeekday(d time.Weekday) bool {
+	return w.weekdays&(1<<uint(d)) != 0
+}
+
+func (w *scheduleWindow) contains(t time.Time) bool {
+	t = t.In(w.location)
+	hour, min, sec := t.Clock()
+	now := uint32(hour*3600 + min*60 + sec)
+	weekday := t.Weekday()
+
+	if w.start < w.end {
+		return w.hasWeekday(weekday) && now >= w.start && now < w.end
+	}
+	// The window crosses midnight, and belongs to the day it starts.
+	if now >= w.start {
+		return w.hasWeekday(weekday)
+	}
+	return now < w.end && w.hasWeekday((weekday+6)%7)
+}
+
+// ScheduleMatcher matches the time a request is routed against weekly time windows.
+type ScheduleMatcher struct {
+	windows []scheduleWindow
+}
+
+func NewScheduleMatcher(schedules []*Schedule) (*ScheduleMatcher, error) {
+	m := &ScheduleMatcher{
+		windows: make([]scheduleWindow, 0, len(schedules)),
+	}
+	for _, s := range schedules {
+		if s.Start >= secondsPerDay || s.End > secondsPerDay {
+			return nil, newError("invalid schedule time: ", s.Start, "-", s.End)
+		}
+		w := scheduleWindow{
+			start:    s.Start,
+			end:      s.End,
+			location: time.Local,
+		}
+		if len(s.Timezone) > 0 {
+			loc, err := time.LoadLocation(s.Timezone)
+			if err != nil {
+				return nil, newError("unknown time zone: ", s.Timezone).Base(err)
+			}
+			w.location = loc
+		}
+		if len(s.Weekday) == 0 {
+			w.weekdays = 0x7f
+		}
+		for _, d := range s.Weekday {
+			if d > 6 {
+				return nil, newError("invalid weekday: ", d)
+			}
+			w.weekdays |= 1 << d
+		}
+		m.windows = append(m.windows, w)
+	}
+	return m, nil
+}
+
+// ApplyTime returns whether t is in any of the time windows.
+func (m *ScheduleMatcher) ApplyTime(t time.Time) bool {
+	for i := range m.windows {
+		if m.windows[i].contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *ScheduleMatcher) Apply(ctx *Context) bool {
+	return m.ApplyTime(time.Now())
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
 	proto "github.com/golang/protobuf/proto"
 
@@ -402,3 +403,53 @@ func BenchmarkMultiGeoIPMatcher(b *testing.B) {
 		_ = matcher.Apply(ctx)
 	}
 }
+
+func TestScheduleMatcher(t *testing.T) {
+	matcher, err := NewScheduleMatcher([]*Schedule{
+		{
+			// Mon-Fri 09:00-18:00 in Shanghai.
+			Weekday:  []uint32{1, 2, 3, 4, 5},
+			Start:    9 * 3600,
+			End:      18 * 3600,
+			Timezone: "Asia/Shanghai",
+		},
+		{
+			// Sat 22:00 till Sun 02:00 in UTC.
+			Weekday:  []uint32{6},
+			Start:    22 * 3600,
+			End:      2 * 3600,
+			Timezone: "UTC",
+		},
+	})
+	common.Must(err)
+
+	testCases := []struct {
+		Time   string
+		Output bool
+	}{
+		{"2020-03-02T09:00:00+08:00", true},  // Monday
+		{"2020-03-02T01:00:00Z", true},       // Monday 09:00 in Shanghai
+		{"2020-03-02T17:59:59+08:00", true},  // Monday
+		{"2020-03-02T18:00:00+08:00", false}, // Monday
+		{"2020-03-02T08:59:59+08:00", false}, // Monday
+		{"2020-03-01T12:00:00+08:00", false}, // Sunday
+		{"2020-02-29T23:00:00Z", true},       // Saturday night
+		{"2020-03-01T01:59:59Z", true},       // Sunday early morning, continuing Saturday
+		{"2020-03-01T02:00:00Z", false},      // Sunday
+		{"2020-03-01T23:00:00Z", false},      // Sunday night
+	}
+	for _, test := range testCases {
+		tm, err := time.Parse(time.RFC3339, test.Time)
+		common.Must(err)
+		if v := matcher.ApplyTime(tm); v != test.Output {
+			t.Error("unexpected output: ", v, " for time ", test.Time)
+		}
+	}
+
+	if _, err := NewScheduleMatcher([]*Schedule{{Timezone: "Mars/Olympus"}}); err == nil {
+		t.Error("expect error for unknown time zone")
+	}
+	if _, err := NewScheduleMatcher([]*Schedule{{Weekday: []uint32{7}}}); err == nil {
+		t.Error("expect error for invalid weekday")
+	}
+}
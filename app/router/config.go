@@ -3,10 +3,11 @@
 package router
 
 import (
-	"time"
-
+	"v2ray.com/core/app/measure"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/features/health"
 	"v2ray.com/core/features/outbound"
+	"v2ray.com/core/features/routing"
 )
 
 // CIDRList is an alias of []*CIDR to provide sort.Interface.
@@ -52,20 +53,53 @@ type Rule struct {
 	Tag       string
 	Balancer  *Balancer
 	Condition Condition
+	Action    routing.Action
+
+	rewriteAddress net.Address
+	rewritePort    net.Port
+
+	config *RoutingRule
 }
 
-func (r *Rule) GetTag() (string, error) {
+func (r *Rule) GetTag(ctx *Context) (string, error) {
 	if r.Balancer != nil {
-		return r.Balancer.PickOutbound()
+		return r.Balancer.PickOutbound(ctx)
 	}
 	return r.Tag, nil
 }
 
+// PeekTag returns the outbound tag like GetTag, without affecting the balancer's later picks.
+func (r *Rule) PeekTag(ctx *Context) (string, error) {
+	if r.Balancer != nil {
+		return r.Balancer.PeekOutbound(ctx)
+	}
+	return r.Tag, nil
+}
+
+// Rewrite returns the destination that requests matching this rule are sent to instead of dest.
+// It returns an invalid destination if the rule doesn't rewrite destinations.
+func (r *Rule) Rewrite(dest net.Destination) net.Destination {
+	if r.rewriteAddress == nil && r.rewritePort == 0 {
+		return net.Destination{}
+	}
+	if r.rewriteAddress != nil {
+		dest.Address = r.rewriteAddress
+	}
+	if r.rewritePort != 0 {
+		dest.Port = r.rewritePort
+	}
+	return dest
+}
+
 func (r *Rule) Apply(ctx *Context) bool {
 	return r.Condition.Apply(ctx)
 }
 
 func (rr *RoutingRule) BuildCondition() (Condition, error) {
+	return rr.buildCondition(nil)
+}
+
+func (rr *RoutingRule) buildCondition(providers map[string]*ProviderMatcher) (Condition, error) {
 	conds := NewConditionChan()
 
 	if len(rr.Domain) > 0 {
@@ -143,6 +177,26 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		conds.Add(NewApplicationMatcher(rr.Application))
 	}
 
+	if len(rr.Schedule) > 0 {
+		cond, err := NewScheduleMatcher(rr.Schedule)
+		if err != nil {
+			return nil, newError("failed to build schedule condition").Base(err)
+		}
+		conds.Add(cond)
+	}
+
+	if len(rr.RuleProvider) > 0 {
+		group := make(ProviderGroup, 0, len(rr.RuleProvider))
+		for _, tag := range rr.RuleProvider {
+			provider, found := providers[tag]
+			if !found {
+				return nil, newError("rule provider ", tag, " not found")
+			}
+			group = append(group, provider)
+		}
+		conds.Add(group)
+	}
+
 	if conds.Len() == 0 {
 		return nil, newError("this rule has no effective fields").AtWarning()
 	}
@@ -150,25 +204,69 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 	return conds, nil
 }
 
-func (br *BalancingRule) Build(ohm outbound.Manager) (*Balancer, error) {
+// BuildAction returns the action of the rule.
+func (rr *RoutingRule) BuildAction() (routing.Action, error) {
+	switch rr.Action {
+	case RoutingRule_Route:
+		return routing.ActionRoute, nil
+	case RoutingRule_RejectRST:
+		return routing.ActionRejectRST, nil
+	case RoutingRule_RejectHTTP:
+		return routing.ActionRejectHTTP, nil
+	case RoutingRule_Drop:
+		return routing.ActionDrop, nil
+	default:
+		return routing.ActionRoute, newError("unknown rule action: ", rr.Action)
+	}
+}
+
+// BuildProbeConfig returns the probe settings of the balancer. Deprecated flat fields are converted if probe is not set.
+func (br *BalancingRule) BuildProbeConfig() (*measure.ProbeConfig, error) {
+	if br.Probe != nil {
+		return br.Probe, nil
+	}
+
+	probe, err := measure.ParseLegacyTarget(br.ProbeTarget, br.ProbeContent)
+	if err != nil {
+		return nil, err
+	}
+	probe.TotalMeasures = br.TotalMeasures
+	probe.Interval = br.Interval
+	probe.Delay = br.Delay
+	probe.Timeout = br.Timeout
+	probe.Tolerance = br.Tolerance
+	return probe, nil
+}
+
+func (br *BalancingRule) Build(ohm outbound.Manager, hr health.Registry, sc routing.SessionCounter) (*Balancer, error) {
 	var strategy BalancingStrategy
-	if br.BalancingStrategy == BalancingRule_Random {
+	switch br.BalancingStrategy {
+	case BalancingRule_Random:
 		strategy = &RandomStrategy{}
-	}
-	if br.BalancingStrategy == BalancingRule_Latency {
-		if ls := NewLatencyStrategy(
-			ohm,
-			br.OutboundSelector,
-			int(br.TotalMeasures),
-			time.Duration(br.Interval)*time.Second,
-			time.Duration(br.Delay)*time.Second,
-			time.Duration(br.Timeout)*time.Second,
-			time.Duration(br.Tolerance)*time.Millisecond,
-			br.ProbeTarget,
-			br.ProbeContent,
-		); ls != nil {
-			strategy = ls
+	case BalancingRule_ConsistentHash:
+		strategy = NewConsistentHashStrategy(br.HashKey)
+	case BalancingRule_WeightedRoundRobin:
+		strategy = NewWeightedRoundRobinStrategy(br.Weight)
+	case BalancingRule_LeastConnections:
+		if sc == nil {
+			return nil, newError("balancer ", br.Tag, " requires a dispatcher that counts sessions")
+		}
+		strategy = NewLeastConnectionsStrategy(sc)
+	case BalancingRule_Latency, BalancingRule_Failover:
+		probe, err := br.BuildProbeConfig()
+		if err != nil {
+			return nil, newError("invalid probe settings in balancer ", br.Tag).Base(err)
+		}
+		if err := probe.Validate(); err != nil {
+			return nil, newError("invalid probe settings in balancer ", br.Tag).Base(err)
+		}
+		if br.BalancingStrategy == BalancingRule_Latency {
+			strategy = NewLatencyStrategy(ohm, br.OutboundSelector, probe)
+		} else {
+			strategy = NewFailoverStrategy(ohm, hr, br.OutboundSelector, probe)
 		}
+	default:
+		return nil, newError("unknown balancing strategy: ", br.BalancingStrategy)
 	}
 	return &Balancer{
 		selectors: br.OutboundSelector,
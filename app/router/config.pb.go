@@ -4,6 +4,7 @@ import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
 	math "math"
+	measure "v2ray.com/core/app/measure"
 	net "v2ray.com/core/common/net"
 )
 
@@ -54,21 +55,75 @@ func (Domain_Type) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_6b1608360690c5fc, []int{0, 0}
 }
 
+type RoutingRule_Action int32
+
+const (
+	// Sends the request to the outbound or balancer of this rule, or to the
+	// default outbound if neither is set.
+	RoutingRule_Route RoutingRule_Action = 0
+	// Closes the inbound connection with a TCP RST.
+	RoutingRule_RejectRST RoutingRule_Action = 1
+	// Responds with HTTP 403 to requests sniffed as HTTP/1, and closes the
+	// inbound connection. Other requests are closed without a response.
+	RoutingRule_RejectHTTP RoutingRule_Action = 2
+	// Discards the request without responding. The inbound connection is
+	// kept open until the client closes it or it idles out.
+	RoutingRule_Drop RoutingRule_Action = 3
+)
+
+var RoutingRule_Action_name = map[int32]string{
+	0: "Route",
+	1: "RejectRST",
+	2: "RejectHTTP",
+	3: "Drop",
+}
+
+var RoutingRule_Action_value = map[string]int32{
+	"Route":      0,
+	"RejectRST":  1,
+	"RejectHTTP": 2,
+	"Drop":       3,
+}
+
+func (x RoutingRule_Action) String() string {
+	return proto.EnumName(RoutingRule_Action_name, int32(x))
+}
+
+func (RoutingRule_Action) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6b1608360690c5fc, []int{6, 0}
+}
+
 type BalancingRule_BalancingStrategy int32
 
 const (
 	BalancingRule_Random  BalancingRule_BalancingStrategy = 0
 	BalancingRule_Latency BalancingRule_BalancingStrategy = 1
+	// Picks the first healthy outbound in selector order.
+	BalancingRule_Failover BalancingRule_BalancingStrategy = 2
+	// Picks the outbound from a consistent hash ring over hash_key.
+	BalancingRule_ConsistentHash BalancingRule_BalancingStrategy = 3
+	// Picks outbounds in turn, in proportion to their weights.
+	BalancingRule_WeightedRoundRobin BalancingRule_BalancingStrategy = 4
+	// Picks the outbound with the fewest active sessions.
+	BalancingRule_LeastConnections BalancingRule_BalancingStrategy = 5
 )
 
 var BalancingRule_BalancingStrategy_name = map[int32]string{
 	0: "Random",
 	1: "Latency",
+	2: "Failover",
+	3: "ConsistentHash",
+	4: "WeightedRoundRobin",
+	5: "LeastConnections",
 }
 
 var BalancingRule_BalancingStrategy_value = map[string]int32{
-	"Random":  0,
-	"Latency": 1,
+	"Random":             0,
+	"Latency":            1,
+	"Failover":           2,
+	"ConsistentHash":     3,
+	"WeightedRoundRobin": 4,
+	"LeastConnections":   5,
 }
 
 func (x BalancingRule_BalancingStrategy) String() string {
@@ -76,7 +131,105 @@ func (x BalancingRule_BalancingStrategy) String() string {
 }
 
 func (BalancingRule_BalancingStrategy) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_6b1608360690c5fc, []int{7, 0}
+	return fileDescriptor_6b1608360690c5fc, []int{8, 0}
+}
+
+type BalancingRule_HashKey int32
+
+const (
+	// Source IP of the inbound connection.
+	BalancingRule_SourceIP BalancingRule_HashKey = 0
+	// Destination domain, or destination IP if no domain is known.
+	BalancingRule_Domain BalancingRule_HashKey = 1
+	// Email of the inbound user.
+	BalancingRule_UserEmail BalancingRule_HashKey = 2
+)
+
+var BalancingRule_HashKey_name = map[int32]string{
+	0: "SourceIP",
+	1: "Domain",
+	2: "UserEmail",
+}
+
+var BalancingRule_HashKey_value = map[string]int32{
+	"SourceIP":  0,
+	"Domain":    1,
+	"UserEmail": 2,
+}
+
+func (x BalancingRule_HashKey) String() string {
+	return proto.EnumName(BalancingRule_HashKey_name, int32(x))
+}
+
+func (BalancingRule_HashKey) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6b1608360690c5fc, []int{8, 1}
+}
+
+type RuleProvider_Behavior int32
+
+const (
+	// Each line is a domain, in the form of "[type:]value" where type is one
+	// of "domain", "full", "keyword" and "regexp". The type defaults to
+	// "domain", which matches the domain and its subdomains.
+	RuleProvider_Domain RuleProvider_Behavior = 0
+	// Each line is an IP or a CIDR.
+	RuleProvider_IPCIDR RuleProvider_Behavior = 1
+	// Each line is a rule in the form of "TYPE,value", where TYPE is one of
+	// DOMAIN, DOMAIN-SUFFIX, DOMAIN-KEYWORD, DOMAIN-REGEX, IP-CIDR and
+	// IP-CIDR6.
+	RuleProvider_Classical RuleProvider_Behavior = 2
+)
+
+var RuleProvider_Behavior_name = map[int32]string{
+	0: "Domain",
+	1: "IPCIDR",
+	2: "Classical",
+}
+
+var RuleProvider_Behavior_value = map[string]int32{
+	"Domain":    0,
+	"IPCIDR":    1,
+	"Classical": 2,
+}
+
+func (x RuleProvider_Behavior) String() string {
+	return proto.EnumName(RuleProvider_Behavior_name, int32(x))
+}
+
+func (RuleProvider_Behavior) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6b1608360690c5fc, []int{9, 0}
+}
+
+type RuleProvider_Format int32
+
+const (
+	// One entry per line. Empty lines and lines starting with '#' are
+	// ignored.
+	RuleProvider_Text RuleProvider_Format = 0
+	// A YAML document with entries listed under "payload".
+	RuleProvider_YAML RuleProvider_Format = 1
+	// A serialized GeoSiteList, for Domain behavior only.
+	RuleProvider_GeoSiteList RuleProvider_Format = 2
+)
+
+var RuleProvider_Format_name = map[int32]string{
+	0: "Text",
+	1: "YAML",
+	2: "GeoSiteList",
+}
+
+var RuleProvider_Format_value = map[string]int32{
+	"Text":        0,
+	"YAML":        1,
+	"GeoSiteList": 2,
+}
+
+func (x RuleProvider_Format) String() string {
+	return proto.EnumName(RuleProvider_Format_name, int32(x))
+}
+
+func (RuleProvider_Format) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6b1608360690c5fc, []int{9, 1}
 }
 
 type Config_DomainStrategy int32
@@ -111,7 +264,7 @@ func (x Config_DomainStrategy) String() string {
 }
 
 func (Config_DomainStrategy) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_6b1608360690c5fc, []int{8, 0}
+	return fileDescriptor_6b1608360690c5fc, []int{10, 0}
 }
 
 // Domain for routing decision.
@@ -509,16 +662,27 @@ type RoutingRule struct {
 	// List of CIDRs for source IP address matching.
 	SourceCidr []*CIDR `protobuf:"bytes,6,rep,name=source_cidr,json=sourceCidr,proto3" json:"source_cidr,omitempty"` // Deprecated: Do not use.
 	// List of GeoIPs for source IP address matching. If this entry exists, the source_cidr above will have no effect.
-	SourceGeoip          []*GeoIP `protobuf:"bytes,11,rep,name=source_geoip,json=sourceGeoip,proto3" json:"source_geoip,omitempty"`
-	CustomGeoip          []string `protobuf:"bytes,50,rep,name=custom_geoip,json=customGeoip,proto3" json:"custom_geoip,omitempty"`
-	UserEmail            []string `protobuf:"bytes,7,rep,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`
-	InboundTag           []string `protobuf:"bytes,8,rep,name=inbound_tag,json=inboundTag,proto3" json:"inbound_tag,omitempty"`
-	Protocol             []string `protobuf:"bytes,9,rep,name=protocol,proto3" json:"protocol,omitempty"`
-	Application          []string `protobuf:"bytes,51,rep,name=application,proto3" json:"application,omitempty"`
-	Attributes           string   `protobuf:"bytes,15,opt,name=attributes,proto3" json:"attributes,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	SourceGeoip []*GeoIP `protobuf:"bytes,11,rep,name=source_geoip,json=sourceGeoip,proto3" json:"source_geoip,omitempty"`
+	CustomGeoip []string `protobuf:"bytes,50,rep,name=custom_geoip,json=customGeoip,proto3" json:"custom_geoip,omitempty"`
+	UserEmail   []string `protobuf:"bytes,7,rep,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`
+	InboundTag  []string `protobuf:"bytes,8,rep,name=inbound_tag,json=inboundTag,proto3" json:"inbound_tag,omitempty"`
+	Protocol    []string `protobuf:"bytes,9,rep,name=protocol,proto3" json:"protocol,omitempty"`
+	Application []string `protobuf:"bytes,51,rep,name=application,proto3" json:"application,omitempty"`
+	Attributes  string   `protobuf:"bytes,15,opt,name=attributes,proto3" json:"attributes,omitempty"`
+	// Time windows in which this rule takes effect. The rule takes effect in
+	// any of them. Always if empty.
+	Schedule []*Schedule `protobuf:"bytes,52,rep,name=schedule,proto3" json:"schedule,omitempty"`
+	// Tags of rule providers. The rule takes effect if the request matches any
+	// of them.
+	RuleProvider []string           `protobuf:"bytes,53,rep,name=rule_provider,json=ruleProvider,proto3" json:"rule_provider,omitempty"`
+	Action       RoutingRule_Action `protobuf:"varint,54,opt,name=action,proto3,enum=v2ray.core.app.router.RoutingRule_Action" json:"action,omitempty"`
+	// Address and port that requests are sent to instead of their original
+	// destination, for Route action. Unchanged if not set.
+	RewriteAddress       *net.IPOrDomain `protobuf:"bytes,55,opt,name=rewrite_address,json=rewriteAddress,proto3" json:"rewrite_address,omitempty"`
+	RewritePort          uint32          `protobuf:"varint,56,opt,name=rewrite_port,json=rewritePort,proto3" json:"rewrite_port,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
 }
 
 func (m *RoutingRule) Reset()         { *m = RoutingRule{} }
@@ -692,6 +856,41 @@ func (m *RoutingRule) GetAttributes() string {
 	return ""
 }
 
+func (m *RoutingRule) GetSchedule() []*Schedule {
+	if m != nil {
+		return m.Schedule
+	}
+	return nil
+}
+
+func (m *RoutingRule) GetRuleProvider() []string {
+	if m != nil {
+		return m.RuleProvider
+	}
+	return nil
+}
+
+func (m *RoutingRule) GetAction() RoutingRule_Action {
+	if m != nil {
+		return m.Action
+	}
+	return RoutingRule_Route
+}
+
+func (m *RoutingRule) GetRewriteAddress() *net.IPOrDomain {
+	if m != nil {
+		return m.RewriteAddress
+	}
+	return nil
+}
+
+func (m *RoutingRule) GetRewritePort() uint32 {
+	if m != nil {
+		return m.RewritePort
+	}
+	return 0
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*RoutingRule) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -700,18 +899,96 @@ func (*RoutingRule) XXX_OneofWrappers() []interface{} {
 	}
 }
 
+// A time window that recurs every week.
+type Schedule struct {
+	// Days of week of the window, 0 for Sunday. Every day if empty.
+	Weekday []uint32 `protobuf:"varint,1,rep,packed,name=weekday,proto3" json:"weekday,omitempty"`
+	// Start of the window, in seconds after midnight.
+	Start uint32 `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	// End of the window, in seconds after midnight. The window crosses
+	// midnight if end is not after start, and then belongs to the day it
+	// starts.
+	End uint32 `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	// IANA name of the time zone, such as "Asia/Shanghai". Local time of the
+	// system if empty.
+	Timezone             string   `protobuf:"bytes,4,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Schedule) Reset()         { *m = Schedule{} }
+func (m *Schedule) String() string { return proto.CompactTextString(m) }
+func (*Schedule) ProtoMessage()    {}
+func (*Schedule) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b1608360690c5fc, []int{7}
+}
+
+func (m *Schedule) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Schedule.Unmarshal(m, b)
+}
+func (m *Schedule) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Schedule.Marshal(b, m, deterministic)
+}
+func (m *Schedule) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Schedule.Merge(m, src)
+}
+func (m *Schedule) XXX_Size() int {
+	return xxx_messageInfo_Schedule.Size(m)
+}
+func (m *Schedule) XXX_DiscardUnknown() {
+	xxx_messageInfo_Schedule.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Schedule proto.InternalMessageInfo
+
+func (m *Schedule) GetWeekday() []uint32 {
+	if m != nil {
+		return m.Weekday
+	}
+	return nil
+}
+
+func (m *Schedule) GetStart() uint32 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *Schedule) GetEnd() uint32 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *Schedule) GetTimezone() string {
+	if m != nil {
+		return m.Timezone
+	}
+	return ""
+}
+
 type BalancingRule struct {
 	Tag               string                          `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
 	OutboundSelector  []string                        `protobuf:"bytes,2,rep,name=outbound_selector,json=outboundSelector,proto3" json:"outbound_selector,omitempty"`
 	BalancingStrategy BalancingRule_BalancingStrategy `protobuf:"varint,3,opt,name=balancing_strategy,json=balancingStrategy,proto3,enum=v2ray.core.app.router.BalancingRule_BalancingStrategy" json:"balancing_strategy,omitempty"`
-	// FIXME Better define in its own setting struct.
-	TotalMeasures        uint32   `protobuf:"varint,100,opt,name=total_measures,json=totalMeasures,proto3" json:"total_measures,omitempty"`
-	Interval             uint32   `protobuf:"varint,101,opt,name=interval,proto3" json:"interval,omitempty"`
-	Delay                uint32   `protobuf:"varint,102,opt,name=delay,proto3" json:"delay,omitempty"`
-	Timeout              uint32   `protobuf:"varint,103,opt,name=timeout,proto3" json:"timeout,omitempty"`
-	Tolerance            uint32   `protobuf:"varint,104,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
-	ProbeTarget          string   `protobuf:"bytes,105,opt,name=probe_target,json=probeTarget,proto3" json:"probe_target,omitempty"`
-	ProbeContent         string   `protobuf:"bytes,106,opt,name=probe_content,json=probeContent,proto3" json:"probe_content,omitempty"`
+	// Settings of probes sent by Latency and Failover strategies.
+	Probe *measure.ProbeConfig `protobuf:"bytes,4,opt,name=probe,proto3" json:"probe,omitempty"`
+	// Key of requests for ConsistentHash strategy.
+	HashKey BalancingRule_HashKey `protobuf:"varint,5,opt,name=hash_key,json=hashKey,proto3,enum=v2ray.core.app.router.BalancingRule_HashKey" json:"hash_key,omitempty"`
+	// Weights of outbounds by tag for WeightedRoundRobin strategy. Outbounds
+	// not listed have a weight of 1.
+	Weight map[string]uint32 `protobuf:"bytes,6,rep,name=weight,proto3" json:"weight,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// Deprecated. Use probe.
+	TotalMeasures        uint32   `protobuf:"varint,100,opt,name=total_measures,json=totalMeasures,proto3" json:"total_measures,omitempty"` // Deprecated: Do not use.
+	Interval             uint32   `protobuf:"varint,101,opt,name=interval,proto3" json:"interval,omitempty"`                                // Deprecated: Do not use.
+	Delay                uint32   `protobuf:"varint,102,opt,name=delay,proto3" json:"delay,omitempty"`                                      // Deprecated: Do not use.
+	Timeout              uint32   `protobuf:"varint,103,opt,name=timeout,proto3" json:"timeout,omitempty"`                                  // Deprecated: Do not use.
+	Tolerance            uint32   `protobuf:"varint,104,opt,name=tolerance,proto3" json:"tolerance,omitempty"`                              // Deprecated: Do not use.
+	ProbeTarget          string   `protobuf:"bytes,105,opt,name=probe_target,json=probeTarget,proto3" json:"probe_target,omitempty"`        // Deprecated: Do not use.
+	ProbeContent         string   `protobuf:"bytes,106,opt,name=probe_content,json=probeContent,proto3" json:"probe_content,omitempty"`     // Deprecated: Do not use.
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -721,7 +998,7 @@ func (m *BalancingRule) Reset()         { *m = BalancingRule{} }
 func (m *BalancingRule) String() string { return proto.CompactTextString(m) }
 func (*BalancingRule) ProtoMessage()    {}
 func (*BalancingRule) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6b1608360690c5fc, []int{7}
+	return fileDescriptor_6b1608360690c5fc, []int{8}
 }
 
 func (m *BalancingRule) XXX_Unmarshal(b []byte) error {
@@ -763,6 +1040,28 @@ func (m *BalancingRule) GetBalancingStrategy() BalancingRule_BalancingStrategy {
 	return BalancingRule_Random
 }
 
+func (m *BalancingRule) GetProbe() *measure.ProbeConfig {
+	if m != nil {
+		return m.Probe
+	}
+	return nil
+}
+
+func (m *BalancingRule) GetHashKey() BalancingRule_HashKey {
+	if m != nil {
+		return m.HashKey
+	}
+	return BalancingRule_SourceIP
+}
+
+func (m *BalancingRule) GetWeight() map[string]uint32 {
+	if m != nil {
+		return m.Weight
+	}
+	return nil
+}
+
+// Deprecated: Do not use.
 func (m *BalancingRule) GetTotalMeasures() uint32 {
 	if m != nil {
 		return m.TotalMeasures
@@ -770,6 +1069,7 @@ func (m *BalancingRule) GetTotalMeasures() uint32 {
 	return 0
 }
 
+// Deprecated: Do not use.
 func (m *BalancingRule) GetInterval() uint32 {
 	if m != nil {
 		return m.Interval
@@ -777,6 +1077,7 @@ func (m *BalancingRule) GetInterval() uint32 {
 	return 0
 }
 
+// Deprecated: Do not use.
 func (m *BalancingRule) GetDelay() uint32 {
 	if m != nil {
 		return m.Delay
@@ -784,6 +1085,7 @@ func (m *BalancingRule) GetDelay() uint32 {
 	return 0
 }
 
+// Deprecated: Do not use.
 func (m *BalancingRule) GetTimeout() uint32 {
 	if m != nil {
 		return m.Timeout
@@ -791,6 +1093,7 @@ func (m *BalancingRule) GetTimeout() uint32 {
 	return 0
 }
 
+// Deprecated: Do not use.
 func (m *BalancingRule) GetTolerance() uint32 {
 	if m != nil {
 		return m.Tolerance
@@ -798,6 +1101,7 @@ func (m *BalancingRule) GetTolerance() uint32 {
 	return 0
 }
 
+// Deprecated: Do not use.
 func (m *BalancingRule) GetProbeTarget() string {
 	if m != nil {
 		return m.ProbeTarget
@@ -805,6 +1109,7 @@ func (m *BalancingRule) GetProbeTarget() string {
 	return ""
 }
 
+// Deprecated: Do not use.
 func (m *BalancingRule) GetProbeContent() string {
 	if m != nil {
 		return m.ProbeContent
@@ -812,10 +1117,95 @@ func (m *BalancingRule) GetProbeContent() string {
 	return ""
 }
 
+// A list of rules loaded from a local file. The file is reloaded when it
+// changes.
+type RuleProvider struct {
+	Tag      string                `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Behavior RuleProvider_Behavior `protobuf:"varint,2,opt,name=behavior,proto3,enum=v2ray.core.app.router.RuleProvider_Behavior" json:"behavior,omitempty"`
+	Format   RuleProvider_Format   `protobuf:"varint,3,opt,name=format,proto3,enum=v2ray.core.app.router.RuleProvider_Format" json:"format,omitempty"`
+	Path     string                `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	// Seconds between checks for changes of the file. The file is never
+	// reloaded if 0.
+	Interval uint32 `protobuf:"varint,5,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Country code of the entry to load from a GeoSiteList.
+	Code                 string   `protobuf:"bytes,6,opt,name=code,proto3" json:"code,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RuleProvider) Reset()         { *m = RuleProvider{} }
+func (m *RuleProvider) String() string { return proto.CompactTextString(m) }
+func (*RuleProvider) ProtoMessage()    {}
+func (*RuleProvider) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b1608360690c5fc, []int{9}
+}
+
+func (m *RuleProvider) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RuleProvider.Unmarshal(m, b)
+}
+func (m *RuleProvider) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RuleProvider.Marshal(b, m, deterministic)
+}
+func (m *RuleProvider) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RuleProvider.Merge(m, src)
+}
+func (m *RuleProvider) XXX_Size() int {
+	return xxx_messageInfo_RuleProvider.Size(m)
+}
+func (m *RuleProvider) XXX_DiscardUnknown() {
+	xxx_messageInfo_RuleProvider.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RuleProvider proto.InternalMessageInfo
+
+func (m *RuleProvider) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *RuleProvider) GetBehavior() RuleProvider_Behavior {
+	if m != nil {
+		return m.Behavior
+	}
+	return RuleProvider_Domain
+}
+
+func (m *RuleProvider) GetFormat() RuleProvider_Format {
+	if m != nil {
+		return m.Format
+	}
+	return RuleProvider_Text
+}
+
+func (m *RuleProvider) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *RuleProvider) GetInterval() uint32 {
+	if m != nil {
+		return m.Interval
+	}
+	return 0
+}
+
+func (m *RuleProvider) GetCode() string {
+	if m != nil {
+		return m.Code
+	}
+	return ""
+}
+
 type Config struct {
 	DomainStrategy       Config_DomainStrategy `protobuf:"varint,1,opt,name=domain_strategy,json=domainStrategy,proto3,enum=v2ray.core.app.router.Config_DomainStrategy" json:"domain_strategy,omitempty"`
 	Rule                 []*RoutingRule        `protobuf:"bytes,2,rep,name=rule,proto3" json:"rule,omitempty"`
 	BalancingRule        []*BalancingRule      `protobuf:"bytes,3,rep,name=balancing_rule,json=balancingRule,proto3" json:"balancing_rule,omitempty"`
+	RuleProvider         []*RuleProvider       `protobuf:"bytes,4,rep,name=rule_provider,json=ruleProvider,proto3" json:"rule_provider,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
 	XXX_unrecognized     []byte                `json:"-"`
 	XXX_sizecache        int32                 `json:"-"`
@@ -825,7 +1215,7 @@ func (m *Config) Reset()         { *m = Config{} }
 func (m *Config) String() string { return proto.CompactTextString(m) }
 func (*Config) ProtoMessage()    {}
 func (*Config) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6b1608360690c5fc, []int{8}
+	return fileDescriptor_6b1608360690c5fc, []int{10}
 }
 
 func (m *Config) XXX_Unmarshal(b []byte) error {
@@ -867,9 +1257,20 @@ func (m *Config) GetBalancingRule() []*BalancingRule {
 	return nil
 }
 
+func (m *Config) GetRuleProvider() []*RuleProvider {
+	if m != nil {
+		return m.RuleProvider
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterEnum("v2ray.core.app.router.Domain_Type", Domain_Type_name, Domain_Type_value)
+	proto.RegisterEnum("v2ray.core.app.router.RoutingRule_Action", RoutingRule_Action_name, RoutingRule_Action_value)
 	proto.RegisterEnum("v2ray.core.app.router.BalancingRule_BalancingStrategy", BalancingRule_BalancingStrategy_name, BalancingRule_BalancingStrategy_value)
+	proto.RegisterEnum("v2ray.core.app.router.BalancingRule_HashKey", BalancingRule_HashKey_name, BalancingRule_HashKey_value)
+	proto.RegisterEnum("v2ray.core.app.router.RuleProvider_Behavior", RuleProvider_Behavior_name, RuleProvider_Behavior_value)
+	proto.RegisterEnum("v2ray.core.app.router.RuleProvider_Format", RuleProvider_Format_name, RuleProvider_Format_value)
 	proto.RegisterEnum("v2ray.core.app.router.Config_DomainStrategy", Config_DomainStrategy_name, Config_DomainStrategy_value)
 	proto.RegisterType((*Domain)(nil), "v2ray.core.app.router.Domain")
 	proto.RegisterType((*Domain_Attribute)(nil), "v2ray.core.app.router.Domain.Attribute")
@@ -879,7 +1280,10 @@ func init() {
 	proto.RegisterType((*GeoSite)(nil), "v2ray.core.app.router.GeoSite")
 	proto.RegisterType((*GeoSiteList)(nil), "v2ray.core.app.router.GeoSiteList")
 	proto.RegisterType((*RoutingRule)(nil), "v2ray.core.app.router.RoutingRule")
+	proto.RegisterType((*Schedule)(nil), "v2ray.core.app.router.Schedule")
 	proto.RegisterType((*BalancingRule)(nil), "v2ray.core.app.router.BalancingRule")
+	proto.RegisterMapType((map[string]uint32)(nil), "v2ray.core.app.router.BalancingRule.WeightEntry")
+	proto.RegisterType((*RuleProvider)(nil), "v2ray.core.app.router.RuleProvider")
 	proto.RegisterType((*Config)(nil), "v2ray.core.app.router.Config")
 }
 
@@ -888,74 +1292,108 @@ func init() {
 }
 
 var fileDescriptor_6b1608360690c5fc = []byte{
-	// 1097 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0x6f, 0x6f, 0xdb, 0xb6,
-	0x13, 0x8e, 0x2d, 0xc7, 0xb1, 0x4e, 0xb6, 0xab, 0x12, 0xbf, 0xfe, 0xa0, 0x65, 0xfd, 0xe3, 0x69,
-	0xed, 0x6a, 0x60, 0x85, 0x0d, 0xb8, 0x5b, 0x5f, 0x0c, 0x1b, 0xba, 0xc4, 0xe9, 0x12, 0x63, 0x6d,
-	0x17, 0x30, 0x69, 0x5f, 0x6c, 0x2f, 0x0c, 0x5a, 0xbe, 0xb8, 0x5c, 0x65, 0x52, 0xa0, 0xa8, 0xac,
-	0xfe, 0x4a, 0x03, 0xf6, 0x0d, 0x06, 0xec, 0xcd, 0x3e, 0xd8, 0x40, 0x52, 0xb6, 0x93, 0xb6, 0xce,
-	0x82, 0xbd, 0xd3, 0x3d, 0x7c, 0xee, 0xf8, 0xf0, 0x8e, 0x77, 0x14, 0x7c, 0x71, 0x3e, 0x50, 0x6c,
-	0xd1, 0x4b, 0xe4, 0xbc, 0x9f, 0x48, 0x85, 0x7d, 0x96, 0x65, 0x7d, 0x25, 0x0b, 0x8d, 0xaa, 0x9f,
-	0x48, 0x71, 0xc6, 0x67, 0xbd, 0x4c, 0x49, 0x2d, 0xc9, 0xad, 0x25, 0x4f, 0x61, 0x8f, 0x65, 0x59,
-	0xcf, 0x71, 0x76, 0xef, 0xbf, 0xe7, 0x9e, 0xc8, 0xf9, 0x5c, 0x8a, 0xbe, 0x40, 0xdd, 0xcf, 0xa4,
-	0xd2, 0xce, 0x79, 0xf7, 0xe1, 0x66, 0x96, 0x40, 0xfd, 0x9b, 0x54, 0x6f, 0x1d, 0x31, 0xfe, 0xab,
-	0x0a, 0xf5, 0x03, 0x39, 0x67, 0x5c, 0x90, 0x27, 0x50, 0xd3, 0x8b, 0x0c, 0xa3, 0x4a, 0xa7, 0xd2,
-	0x6d, 0x0f, 0xe2, 0xde, 0x47, 0xf7, 0xef, 0x39, 0x72, 0xef, 0x74, 0x91, 0x21, 0xb5, 0x7c, 0xf2,
-	0x3f, 0xd8, 0x3e, 0x67, 0x69, 0x81, 0x51, 0xb5, 0x53, 0xe9, 0xfa, 0xd4, 0x19, 0xe4, 0x19, 0xf8,
-	0x4c, 0x6b, 0xc5, 0x27, 0x85, 0xc6, 0xc8, 0xeb, 0x78, 0xdd, 0x60, 0xf0, 0xf0, 0xea, 0x90, 0x7b,
-	0x4b, 0x3a, 0x5d, 0x7b, 0xee, 0xa6, 0xe0, 0xaf, 0x70, 0x12, 0x82, 0xf7, 0x16, 0x17, 0x56, 0xa0,
-	0x4f, 0xcd, 0x27, 0xb9, 0x07, 0x30, 0x91, 0x32, 0x1d, 0xaf, 0x05, 0x34, 0x8e, 0xb6, 0xa8, 0x6f,
-	0xb0, 0xd7, 0x56, 0xc6, 0x1d, 0xf0, 0xb9, 0xd0, 0xe5, 0xba, 0xd7, 0xa9, 0x74, 0xbd, 0xa3, 0x2d,
-	0xda, 0xe0, 0x42, 0xdb, 0xe5, 0xfd, 0x16, 0x04, 0xe6, 0x0c, 0x53, 0x47, 0x88, 0x07, 0x50, 0x33,
-	0x07, 0x23, 0x3e, 0x6c, 0x1f, 0xa7, 0x8c, 0x8b, 0x70, 0xcb, 0x7c, 0x52, 0x9c, 0xe1, 0xbb, 0xb0,
-	0x42, 0x60, 0x99, 0xaa, 0xb0, 0x4a, 0x1a, 0x50, 0xfb, 0xa1, 0x48, 0xd3, 0xd0, 0x8b, 0x7b, 0x50,
-	0x1b, 0x8e, 0x0e, 0x28, 0x69, 0x43, 0x95, 0x67, 0x56, 0x5b, 0x93, 0x56, 0x79, 0x46, 0xfe, 0x0f,
-	0xf5, 0x4c, 0xe1, 0x19, 0x7f, 0x67, 0x65, 0xb5, 0x68, 0x69, 0xc5, 0xbf, 0xc0, 0xf6, 0x21, 0xca,
-	0xd1, 0x31, 0xf9, 0x0c, 0x9a, 0x89, 0x2c, 0x84, 0x56, 0x8b, 0x71, 0x22, 0xa7, 0x58, 0x1e, 0x2b,
-	0x28, 0xb1, 0xa1, 0x9c, 0x22, 0xe9, 0x43, 0x2d, 0xe1, 0x53, 0x15, 0x55, 0x6d, 0xfe, 0x3e, 0xdd,
-	0x90, 0x3f, 0xb3, 0x3d, 0xb5, 0xc4, 0xf8, 0x29, 0xf8, 0x36, 0xf8, 0x73, 0x9e, 0x6b, 0x32, 0x80,
-	0x6d, 0x34, 0xa1, 0xa2, 0x8a, 0x75, 0xbf, 0xbd, 0xc1, 0xdd, 0x3a, 0x50, 0x47, 0x8d, 0x13, 0xd8,
-	0x39, 0x44, 0x79, 0xc2, 0x35, 0x5e, 0x47, 0xdf, 0xd7, 0x50, 0x9f, 0xda, 0x8c, 0x94, 0x0a, 0xef,
-	0x5c, 0x59, 0x61, 0x5a, 0x92, 0xe3, 0x21, 0x04, 0xe5, 0x26, 0x56, 0xe7, 0x57, 0x97, 0x75, 0xde,
-	0xdd, 0xac, 0xd3, 0xb8, 0x2c, 0x95, 0xfe, 0x5d, 0x87, 0x80, 0xca, 0x42, 0x73, 0x31, 0xa3, 0x45,
-	0x8a, 0x84, 0x80, 0xa7, 0xd9, 0xcc, 0xa9, 0x3c, 0xda, 0xa2, 0xc6, 0x20, 0x0f, 0xa0, 0x35, 0x61,
-	0x29, 0x13, 0x09, 0x17, 0xb3, 0xb1, 0x59, 0x6d, 0x96, 0xab, 0xcd, 0x15, 0x7c, 0xca, 0x66, 0xff,
-	0xf1, 0x18, 0xe4, 0x71, 0x59, 0x1d, 0xef, 0x5f, 0xab, 0xb3, 0x5f, 0x8d, 0x2a, 0xae, 0x42, 0xa6,
-	0x28, 0x33, 0x94, 0x3c, 0x8b, 0xe0, 0x3a, 0x45, 0xb1, 0x54, 0x32, 0x04, 0x30, 0xbd, 0x3d, 0x56,
-	0x4c, 0xcc, 0x30, 0xaa, 0x75, 0x2a, 0xdd, 0x60, 0xd0, 0xb9, 0xe8, 0xe8, 0xda, 0xbb, 0x27, 0x50,
-	0xf7, 0x8e, 0xa5, 0xd2, 0xd4, 0xf0, 0xec, 0x9e, 0x7e, 0xb6, 0x34, 0xc9, 0xb7, 0x60, 0x8d, 0x71,
-	0xca, 0x73, 0x1d, 0xb5, 0x6d, 0x8c, 0x7b, 0x57, 0xc4, 0x30, 0x95, 0xa1, 0x8d, 0xac, 0xfc, 0x22,
-	0x23, 0x68, 0x96, 0x83, 0xc3, 0x05, 0xd8, 0xb6, 0x01, 0xe2, 0x0d, 0x01, 0x5e, 0x3a, 0xaa, 0xf1,
-	0xb4, 0x32, 0x02, 0xb1, 0x06, 0xc8, 0x37, 0xd0, 0x28, 0xcd, 0x3c, 0x6a, 0x75, 0xbc, 0x6e, 0xfb,
-	0x72, 0xc5, 0x3f, 0x0c, 0x43, 0x57, 0x7c, 0xf2, 0x3d, 0x04, 0xb9, 0x2c, 0x54, 0x82, 0x63, 0x9b,
-	0xf9, 0xfa, 0xf5, 0x32, 0x0f, 0xce, 0x67, 0x68, 0xf2, 0xff, 0x14, 0x9a, 0x65, 0x04, 0x57, 0x86,
-	0xe0, 0x1a, 0x65, 0x28, 0xf7, 0x3c, 0xb4, 0xc5, 0x30, 0x6d, 0x51, 0xe4, 0x5a, 0xce, 0xcb, 0x00,
-	0x83, 0x8e, 0x67, 0xdb, 0xc2, 0x62, 0x8e, 0x72, 0x07, 0xa0, 0xc8, 0x51, 0x8d, 0x71, 0xce, 0x78,
-	0x1a, 0xed, 0x58, 0x82, 0x6f, 0x90, 0x67, 0x06, 0x20, 0xf7, 0x20, 0xe0, 0x62, 0x22, 0x0b, 0x31,
-	0xb5, 0x77, 0xb2, 0x61, 0xd7, 0xa1, 0x84, 0xcc, 0x7d, 0xdc, 0x85, 0x86, 0x9d, 0xce, 0x89, 0x4c,
-	0x23, 0xdf, 0xae, 0xae, 0x6c, 0xd2, 0x81, 0x80, 0x65, 0x59, 0xca, 0x13, 0xa6, 0xb9, 0x14, 0xd1,
-	0x63, 0xb7, 0xfb, 0x05, 0x88, 0xdc, 0x05, 0x58, 0xcd, 0xcf, 0x3c, 0xba, 0x61, 0xbb, 0xf6, 0x02,
-	0xb2, 0xdf, 0x04, 0xd0, 0x4c, 0xcd, 0x50, 0x9b, 0xdd, 0xe3, 0x3f, 0x3d, 0x68, 0xed, 0x2f, 0x9b,
-	0xc1, 0x36, 0x52, 0x78, 0xa1, 0x91, 0x5c, 0x1b, 0x7d, 0x09, 0x37, 0x65, 0xa1, 0x9d, 0xe2, 0x1c,
-	0x53, 0x4c, 0xb4, 0x74, 0x33, 0xc9, 0xa7, 0xe1, 0x72, 0xe1, 0xa4, 0xc4, 0x09, 0x02, 0x59, 0xf7,
-	0x5c, 0xae, 0x15, 0xd3, 0x38, 0x5b, 0xd8, 0xd1, 0xdb, 0x1e, 0x3c, 0xd9, 0x90, 0xe6, 0x4b, 0x02,
-	0xd6, 0xd6, 0x49, 0xe9, 0x4d, 0x6f, 0x4e, 0xde, 0x87, 0xc8, 0x03, 0x68, 0x6b, 0xa9, 0x59, 0x3a,
-	0x9e, 0x23, 0xcb, 0x0b, 0x85, 0x79, 0x34, 0xb5, 0x63, 0xb6, 0x65, 0xd1, 0x17, 0x25, 0x68, 0x52,
-	0xc9, 0x85, 0x46, 0x75, 0xce, 0xd2, 0x08, 0x2d, 0x61, 0x65, 0x9b, 0x87, 0x6b, 0x8a, 0x29, 0x5b,
-	0x44, 0x67, 0x76, 0xc1, 0x19, 0x24, 0x82, 0x1d, 0xcd, 0xe7, 0x28, 0x0b, 0x1d, 0xcd, 0x2c, 0xbe,
-	0x34, 0xc9, 0x6d, 0xf0, 0xb5, 0x4c, 0x51, 0x31, 0x91, 0x60, 0xf4, 0xc6, 0xae, 0xad, 0x01, 0x73,
-	0x2f, 0x32, 0x25, 0x27, 0x38, 0x76, 0xc9, 0x8d, 0xb8, 0x1b, 0x97, 0x16, 0x3b, 0xb5, 0x10, 0xf9,
-	0x1c, 0x5a, 0x8e, 0x92, 0x48, 0xa1, 0x51, 0xe8, 0xe8, 0x57, 0xcb, 0x71, 0x7e, 0x43, 0x87, 0xc5,
-	0x8f, 0xe0, 0xe6, 0x07, 0x09, 0x30, 0x4f, 0x0f, 0x65, 0x62, 0x2a, 0xe7, 0xe1, 0x16, 0x09, 0x60,
-	0xe7, 0x39, 0xd3, 0x28, 0x92, 0x45, 0x58, 0x89, 0xff, 0xa8, 0x42, 0x7d, 0x68, 0x7f, 0x1b, 0xc8,
-	0x2b, 0xb8, 0xe1, 0x06, 0xd3, 0x3a, 0xeb, 0xee, 0x29, 0x7f, 0xb4, 0xa9, 0x3f, 0xdc, 0xef, 0x86,
-	0x9b, 0x6a, 0xab, 0x5c, 0xb7, 0xa7, 0x97, 0x6c, 0xf3, 0x5b, 0xa0, 0x8a, 0x14, 0xcb, 0xd1, 0xb8,
-	0xe9, 0xb7, 0xe0, 0xc2, 0x24, 0xa6, 0x96, 0x4f, 0x7e, 0x84, 0xf6, 0xfa, 0x1e, 0xd8, 0x08, 0x6e,
-	0x4e, 0xde, 0xbf, 0xce, 0x1d, 0xa0, 0xeb, 0xb9, 0x6d, 0xcc, 0xf8, 0x10, 0xda, 0x97, 0x65, 0x9a,
-	0x07, 0x78, 0x2f, 0x1f, 0xe5, 0xee, 0x85, 0x7e, 0x95, 0xe3, 0x28, 0x0b, 0x2b, 0x24, 0x84, 0xe6,
-	0x28, 0x1b, 0x9d, 0xbd, 0x94, 0xe2, 0x05, 0xd3, 0xc9, 0x9b, 0xb0, 0x4a, 0xda, 0x00, 0xa3, 0xec,
-	0x27, 0x71, 0x80, 0x73, 0x26, 0xa6, 0xa1, 0xb7, 0xff, 0x1d, 0x7c, 0x92, 0xc8, 0xf9, 0xc7, 0x25,
-	0x1c, 0x57, 0x7e, 0xae, 0xbb, 0xaf, 0xdf, 0xab, 0xb7, 0x5e, 0x0f, 0x28, 0x5b, 0xf4, 0x86, 0x86,
-	0xb1, 0x97, 0x65, 0xf6, 0x7c, 0xa8, 0x26, 0x75, 0xdb, 0x87, 0x8f, 0xff, 0x09, 0x00, 0x00, 0xff,
-	0xff, 0xdf, 0x90, 0xb6, 0x7a, 0xc5, 0x09, 0x00, 0x00,
+	// 1639 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x57, 0xdb, 0x72, 0x1b, 0xc7,
+	0x11, 0x25, 0xae, 0x04, 0x1a, 0x17, 0xad, 0xa6, 0x6c, 0xd7, 0x86, 0xb1, 0x24, 0x78, 0x6d, 0x47,
+	0x74, 0x2e, 0x60, 0x02, 0xd9, 0x8a, 0xed, 0x38, 0xe5, 0x90, 0x90, 0x44, 0x42, 0x96, 0x6c, 0xd4,
+	0x90, 0x72, 0x2a, 0xc9, 0x03, 0x6a, 0xb0, 0xdb, 0x04, 0xd6, 0x5a, 0xcc, 0x6c, 0xcd, 0xce, 0x92,
+	0x42, 0x2a, 0xdf, 0x91, 0x8f, 0xc8, 0x4f, 0xe4, 0x27, 0xf2, 0x23, 0x79, 0xca, 0x6b, 0x6a, 0x2e,
+	0x58, 0x82, 0x34, 0x41, 0xb1, 0xfc, 0x36, 0xdd, 0xd3, 0xdd, 0x38, 0xdb, 0xdd, 0x73, 0xba, 0x01,
+	0xbf, 0x38, 0x1b, 0x48, 0xb6, 0xec, 0x87, 0x62, 0xb1, 0x17, 0x0a, 0x89, 0x7b, 0x2c, 0x4d, 0xf7,
+	0xa4, 0xc8, 0x15, 0xca, 0xbd, 0x50, 0xf0, 0xd3, 0x78, 0xd6, 0x4f, 0xa5, 0x50, 0x82, 0xbc, 0xbb,
+	0xb2, 0x93, 0xd8, 0x67, 0x69, 0xda, 0xb7, 0x36, 0x3b, 0x0f, 0xaf, 0xb8, 0x87, 0x62, 0xb1, 0x10,
+	0x7c, 0x8f, 0xa3, 0xda, 0x63, 0x51, 0x24, 0x31, 0xcb, 0xac, 0xff, 0xce, 0x47, 0x9b, 0x0d, 0x53,
+	0x21, 0x95, 0xb3, 0xba, 0x21, 0x1c, 0x47, 0x75, 0x2e, 0xe4, 0xeb, 0x0d, 0x86, 0x1a, 0xf6, 0x02,
+	0x59, 0x96, 0x4b, 0xbc, 0x84, 0x3b, 0xf8, 0x77, 0x19, 0xea, 0x4f, 0xc4, 0x82, 0xc5, 0x9c, 0x3c,
+	0x86, 0xaa, 0x5a, 0xa6, 0xe8, 0x97, 0x7a, 0xa5, 0xdd, 0xee, 0x20, 0xe8, 0x5f, 0xfb, 0x45, 0x7d,
+	0x6b, 0xdc, 0x3f, 0x59, 0xa6, 0x48, 0x8d, 0x3d, 0x79, 0x07, 0x6a, 0x67, 0x2c, 0xc9, 0xd1, 0x2f,
+	0xf7, 0x4a, 0xbb, 0x4d, 0x6a, 0x05, 0xf2, 0x14, 0x9a, 0x4c, 0x29, 0x19, 0x4f, 0x73, 0x85, 0x7e,
+	0xa5, 0x57, 0xd9, 0x6d, 0x0d, 0x1e, 0xde, 0x1c, 0x72, 0x7f, 0x65, 0x4e, 0x2f, 0x3c, 0x77, 0x12,
+	0x68, 0x16, 0x7a, 0xe2, 0x41, 0xe5, 0x35, 0x2e, 0x0d, 0xc0, 0x26, 0xd5, 0x47, 0xf2, 0x00, 0x60,
+	0x2a, 0x44, 0x32, 0xb9, 0x00, 0xd0, 0x38, 0xda, 0xa2, 0x4d, 0xad, 0xfb, 0xde, 0xc0, 0xb8, 0x07,
+	0xcd, 0x98, 0x2b, 0x77, 0x5f, 0xe9, 0x95, 0x76, 0x2b, 0x47, 0x5b, 0xb4, 0x11, 0x73, 0x65, 0xae,
+	0x0f, 0x3a, 0xd0, 0xd2, 0xdf, 0x10, 0x59, 0x83, 0x60, 0x00, 0x55, 0xfd, 0x61, 0xa4, 0x09, 0xb5,
+	0x71, 0xc2, 0x62, 0xee, 0x6d, 0xe9, 0x23, 0xc5, 0x19, 0xbe, 0xf1, 0x4a, 0x04, 0x56, 0xa9, 0xf2,
+	0xca, 0xa4, 0x01, 0xd5, 0x67, 0x79, 0x92, 0x78, 0x95, 0xa0, 0x0f, 0xd5, 0xe1, 0xe8, 0x09, 0x25,
+	0x5d, 0x28, 0xc7, 0xa9, 0xc1, 0xd6, 0xa6, 0xe5, 0x38, 0x25, 0xef, 0x41, 0x3d, 0x95, 0x78, 0x1a,
+	0xbf, 0x31, 0xb0, 0x3a, 0xd4, 0x49, 0xc1, 0xdf, 0xa0, 0x76, 0x88, 0x62, 0x34, 0x26, 0x1f, 0x40,
+	0x3b, 0x14, 0x39, 0x57, 0x72, 0x39, 0x09, 0x45, 0x84, 0xee, 0xb3, 0x5a, 0x4e, 0x37, 0x14, 0x11,
+	0x92, 0x3d, 0xa8, 0x86, 0x71, 0x24, 0xfd, 0xb2, 0xc9, 0xdf, 0xcf, 0x37, 0xe4, 0x4f, 0xff, 0x3c,
+	0x35, 0x86, 0xc1, 0xd7, 0xd0, 0x34, 0xc1, 0x5f, 0xc4, 0x99, 0x22, 0x03, 0xa8, 0xa1, 0x0e, 0xe5,
+	0x97, 0x8c, 0xfb, 0xfb, 0x1b, 0xdc, 0x8d, 0x03, 0xb5, 0xa6, 0x41, 0x08, 0xdb, 0x87, 0x28, 0x8e,
+	0x63, 0x85, 0xb7, 0xc1, 0xf7, 0x19, 0xd4, 0x23, 0x93, 0x11, 0x87, 0xf0, 0xde, 0x8d, 0x15, 0xa6,
+	0xce, 0x38, 0x18, 0x42, 0xcb, 0xfd, 0x88, 0xc1, 0xf9, 0xe9, 0x65, 0x9c, 0xf7, 0x37, 0xe3, 0xd4,
+	0x2e, 0x2b, 0xa4, 0xff, 0x6c, 0x42, 0x8b, 0x8a, 0x5c, 0xc5, 0x7c, 0x46, 0xf3, 0x04, 0x09, 0x81,
+	0x8a, 0x62, 0x33, 0x8b, 0xf2, 0x68, 0x8b, 0x6a, 0x81, 0x7c, 0x0c, 0x9d, 0x29, 0x4b, 0x18, 0x0f,
+	0x63, 0x3e, 0x9b, 0xe8, 0xdb, 0xb6, 0xbb, 0x6d, 0x17, 0xea, 0x13, 0x36, 0xfb, 0x89, 0x9f, 0x41,
+	0x1e, 0xb9, 0xea, 0x54, 0xde, 0x5a, 0x9d, 0x83, 0xb2, 0x5f, 0xb2, 0x15, 0xd2, 0x45, 0x99, 0xa1,
+	0x88, 0x53, 0x1f, 0x6e, 0x53, 0x14, 0x63, 0x4a, 0x86, 0x00, 0x9a, 0x04, 0x26, 0x92, 0xf1, 0x19,
+	0xfa, 0xd5, 0x5e, 0x69, 0xb7, 0x35, 0xe8, 0xad, 0x3b, 0x5a, 0x1e, 0xe8, 0x73, 0x54, 0xfd, 0xb1,
+	0x90, 0x8a, 0x6a, 0x3b, 0xf3, 0x9b, 0xcd, 0x74, 0x25, 0x92, 0xaf, 0xc0, 0x08, 0x93, 0x24, 0xce,
+	0x94, 0xdf, 0x35, 0x31, 0x1e, 0xdc, 0x10, 0x43, 0x57, 0x86, 0x36, 0x52, 0x77, 0x22, 0x23, 0x68,
+	0x3b, 0x86, 0xb1, 0x01, 0x6a, 0x26, 0x40, 0xb0, 0x21, 0xc0, 0xb7, 0xd6, 0x54, 0x7b, 0x1a, 0x18,
+	0x2d, 0x7e, 0xa1, 0x20, 0x5f, 0x42, 0xc3, 0x89, 0x99, 0xdf, 0xe9, 0x55, 0x76, 0xbb, 0x83, 0xfb,
+	0x37, 0x87, 0xa1, 0x85, 0x3d, 0xf9, 0x13, 0xb4, 0x32, 0x91, 0xcb, 0x10, 0x27, 0x26, 0xf3, 0xf5,
+	0xdb, 0x65, 0x1e, 0xac, 0xcf, 0x50, 0xe7, 0xff, 0x6b, 0x68, 0xbb, 0x08, 0xb6, 0x0c, 0xad, 0x5b,
+	0x94, 0xc1, 0xfd, 0xe6, 0xa1, 0x29, 0x86, 0x7e, 0x16, 0x79, 0xa6, 0xc4, 0xc2, 0x05, 0x18, 0xf4,
+	0x2a, 0xe6, 0x59, 0x18, 0x9d, 0x35, 0xb9, 0x07, 0x90, 0x67, 0x28, 0x27, 0xb8, 0x60, 0x71, 0xe2,
+	0x6f, 0x1b, 0x83, 0xa6, 0xd6, 0x3c, 0xd5, 0x0a, 0xf2, 0x00, 0x5a, 0x31, 0x9f, 0x8a, 0x9c, 0x47,
+	0xa6, 0x27, 0x1b, 0xe6, 0x1e, 0x9c, 0x4a, 0xf7, 0xe3, 0x0e, 0x34, 0x0c, 0x3b, 0x87, 0x22, 0xf1,
+	0x9b, 0xe6, 0xb6, 0x90, 0x49, 0x0f, 0x5a, 0x2c, 0x4d, 0x93, 0x38, 0x64, 0x2a, 0x16, 0xdc, 0x7f,
+	0x64, 0x7f, 0x7d, 0x4d, 0x45, 0xee, 0x03, 0x14, 0xfc, 0x99, 0xf9, 0x77, 0xcc, 0xab, 0x5d, 0xd3,
+	0x90, 0x3f, 0x40, 0x23, 0x0b, 0xe7, 0x18, 0xe5, 0x09, 0xfa, 0x9f, 0xf6, 0x2a, 0x57, 0xfb, 0x60,
+	0xed, 0xeb, 0x8f, 0x9d, 0x19, 0x2d, 0x1c, 0xc8, 0x87, 0xd0, 0x91, 0x79, 0x82, 0x93, 0x54, 0x8a,
+	0xb3, 0x38, 0x42, 0xe9, 0x7f, 0x66, 0x00, 0xb4, 0xb5, 0x72, 0xec, 0x74, 0x64, 0x1f, 0xea, 0x2c,
+	0x34, 0xf0, 0x1e, 0x9b, 0x59, 0xf2, 0xc9, 0x86, 0xf8, 0x6b, 0xcf, 0xb7, 0xbf, 0x6f, 0x1c, 0xa8,
+	0x73, 0x24, 0xcf, 0xe1, 0x8e, 0xc4, 0x73, 0x19, 0x2b, 0x9c, 0xb8, 0x41, 0xe9, 0xff, 0xde, 0xb4,
+	0xdc, 0x07, 0x1b, 0x7a, 0x65, 0x34, 0xfe, 0x4e, 0xba, 0xf7, 0xd9, 0x75, 0x9e, 0xfb, 0xd6, 0x51,
+	0x57, 0x6c, 0x15, 0x4b, 0xf7, 0xb3, 0xff, 0xb9, 0xe1, 0xe3, 0x96, 0xd3, 0xe9, 0x66, 0x0f, 0xbe,
+	0x82, 0xba, 0x05, 0x60, 0xf8, 0x5e, 0xa3, 0xf3, 0xb6, 0x48, 0x07, 0x9a, 0x14, 0x7f, 0xc0, 0x50,
+	0xd1, 0xe3, 0x13, 0xaf, 0x44, 0xba, 0x00, 0x56, 0x3c, 0x3a, 0x39, 0x19, 0xdb, 0x11, 0xf0, 0x44,
+	0x8a, 0xd4, 0xab, 0x1c, 0xb4, 0x01, 0x14, 0x93, 0x33, 0x54, 0xba, 0x9e, 0xc1, 0x1c, 0x1a, 0xab,
+	0xc4, 0x11, 0x1f, 0xb6, 0xcf, 0x11, 0x5f, 0x47, 0xcc, 0x92, 0x5b, 0x87, 0xae, 0x44, 0x3d, 0x35,
+	0x33, 0xc5, 0xa4, 0x72, 0xd3, 0xc1, 0x0a, 0x7a, 0xc2, 0x21, 0x8f, 0xcc, 0xa0, 0xea, 0x50, 0x7d,
+	0xd4, 0xbd, 0xa0, 0xe2, 0x05, 0xfe, 0x5d, 0x70, 0xfb, 0xf2, 0x9b, 0xb4, 0x90, 0x83, 0xff, 0xd6,
+	0xa1, 0x73, 0xb0, 0x22, 0x32, 0x43, 0x82, 0xde, 0x1a, 0x09, 0x5a, 0x0a, 0xfc, 0x15, 0xdc, 0x15,
+	0xb9, 0xb2, 0xdd, 0x96, 0x61, 0x82, 0xa1, 0x12, 0x76, 0x9e, 0x34, 0xa9, 0xb7, 0xba, 0x38, 0x76,
+	0x7a, 0x82, 0x40, 0x2e, 0xf8, 0x32, 0x53, 0x92, 0x29, 0x9c, 0x2d, 0x0d, 0x9a, 0xee, 0xe0, 0xf1,
+	0x86, 0x22, 0x5e, 0x02, 0x70, 0x21, 0x1d, 0x3b, 0x6f, 0x7a, 0x77, 0x7a, 0x55, 0x45, 0xbe, 0x80,
+	0x5a, 0x2a, 0xc5, 0x74, 0x45, 0x65, 0x1f, 0x5e, 0x8d, 0xec, 0x36, 0x95, 0xfe, 0x58, 0x1b, 0x0d,
+	0xcd, 0xba, 0x42, 0xad, 0x07, 0x39, 0x84, 0xc6, 0x9c, 0x65, 0xf3, 0x89, 0xde, 0x03, 0x6a, 0x06,
+	0xd7, 0xaf, 0x6f, 0x85, 0xeb, 0x88, 0x65, 0xf3, 0x6f, 0x70, 0x49, 0xb7, 0xe7, 0xf6, 0x40, 0x8e,
+	0xa0, 0x7e, 0x8e, 0xf1, 0x6c, 0xae, 0x1c, 0x89, 0xfc, 0xf6, 0x56, 0x61, 0xfe, 0x6c, 0x5c, 0x9e,
+	0xea, 0x01, 0x44, 0x9d, 0x3f, 0xf9, 0x04, 0xba, 0x4a, 0x28, 0x96, 0x4c, 0x1c, 0xec, 0xcc, 0x8f,
+	0x74, 0xf9, 0x0c, 0xf3, 0x74, 0xcc, 0xcd, 0x4b, 0x77, 0x41, 0xee, 0x83, 0x5e, 0x3d, 0x50, 0x9e,
+	0xb1, 0xc4, 0xc7, 0xc2, 0xa8, 0xd0, 0x11, 0x1f, 0x6a, 0x11, 0x26, 0x6c, 0xe9, 0x9f, 0x16, 0x97,
+	0x56, 0x41, 0xde, 0x87, 0x6d, 0x5d, 0x76, 0x91, 0x2b, 0x7f, 0x56, 0xdc, 0xad, 0x54, 0xa4, 0x07,
+	0x4d, 0x25, 0x12, 0x94, 0x8c, 0x87, 0xe8, 0xcf, 0x8b, 0xfb, 0x0b, 0x25, 0xf9, 0x18, 0xda, 0x26,
+	0x81, 0x13, 0xdb, 0xa8, 0x7e, 0xac, 0x3b, 0xc4, 0x72, 0xb3, 0xd1, 0x9f, 0x18, 0x35, 0x79, 0x08,
+	0x1d, 0x6b, 0x16, 0x0a, 0xae, 0x90, 0x2b, 0xff, 0x87, 0xc2, 0xce, 0xfa, 0x0f, 0xad, 0x7e, 0xe7,
+	0x0b, 0x68, 0xad, 0xe5, 0xe2, 0x9a, 0xcd, 0xec, 0xd2, 0x56, 0xd8, 0x71, 0x5b, 0xe1, 0x97, 0xe5,
+	0xcf, 0x4b, 0xc1, 0x3f, 0xe0, 0xee, 0x8f, 0xba, 0x44, 0xef, 0x56, 0x94, 0xf1, 0x48, 0x2c, 0xbc,
+	0x2d, 0xd2, 0x82, 0xed, 0x17, 0x4c, 0x21, 0x0f, 0x97, 0x5e, 0x89, 0xb4, 0xa1, 0xf1, 0x8c, 0xc5,
+	0x89, 0x38, 0x43, 0xe9, 0x95, 0x09, 0x81, 0xee, 0x50, 0xf0, 0x2c, 0xce, 0x34, 0x08, 0x5d, 0x53,
+	0xaf, 0x42, 0xde, 0x03, 0x62, 0xa1, 0x60, 0x44, 0x75, 0x37, 0x53, 0x31, 0x8d, 0xb9, 0x57, 0x25,
+	0xef, 0x80, 0xf7, 0x02, 0x59, 0xa6, 0x86, 0x82, 0x73, 0x34, 0x8f, 0x3b, 0xf3, 0x6a, 0xc1, 0x00,
+	0xb6, 0x5d, 0x2f, 0xe8, 0xd0, 0xc7, 0x86, 0xd8, 0x47, 0x63, 0x6f, 0x6b, 0x6d, 0xbb, 0x2b, 0xe9,
+	0x97, 0xff, 0x6a, 0x45, 0xd7, 0x5e, 0xf9, 0x79, 0xb5, 0xf1, 0xda, 0x4b, 0x9e, 0x57, 0x1b, 0x89,
+	0xb7, 0x08, 0xfe, 0x53, 0x86, 0x36, 0x5d, 0x27, 0xbb, 0x1f, 0x3f, 0xb9, 0x23, 0x68, 0x4c, 0x71,
+	0xce, 0xce, 0x62, 0xf3, 0xd2, 0x6e, 0xea, 0xd1, 0xf5, 0x40, 0xfd, 0x03, 0xe7, 0x43, 0x0b, 0x6f,
+	0x72, 0x00, 0xf5, 0x53, 0x21, 0x17, 0x4c, 0xb9, 0x37, 0xf8, 0xcb, 0xdb, 0xc4, 0x79, 0x66, 0x3c,
+	0xa8, 0xf3, 0x24, 0x04, 0xaa, 0x29, 0x53, 0x73, 0x47, 0x1e, 0xe6, 0xac, 0x49, 0xa5, 0xe8, 0xc3,
+	0x9a, 0xa9, 0x4f, 0x21, 0x6b, 0x7b, 0xb3, 0xee, 0xd5, 0xad, 0xbd, 0x3e, 0x07, 0xbf, 0x83, 0xc6,
+	0x0a, 0xdd, 0x5a, 0x9e, 0x4c, 0xce, 0x46, 0x63, 0x3d, 0x66, 0x6d, 0xce, 0x86, 0x09, 0xcb, 0xb2,
+	0x38, 0x64, 0x89, 0x57, 0x0e, 0x7e, 0x03, 0x75, 0x0b, 0x44, 0xf3, 0xe4, 0x09, 0xbe, 0x51, 0xde,
+	0x96, 0x3e, 0xfd, 0x65, 0xff, 0xe5, 0x0b, 0xaf, 0x44, 0xee, 0x5c, 0xda, 0x00, 0xbd, 0x72, 0xf0,
+	0xbf, 0x32, 0xd4, 0xed, 0x4b, 0x27, 0xaf, 0xe0, 0x8e, 0x5d, 0xb0, 0x2e, 0x18, 0xa8, 0x74, 0x63,
+	0x16, 0xad, 0x9f, 0xdb, 0xce, 0x0a, 0xde, 0xe9, 0x46, 0x97, 0x64, 0xfd, 0xf7, 0x46, 0x0f, 0x29,
+	0xb7, 0xe2, 0x05, 0x6f, 0x1f, 0x49, 0xd4, 0xd8, 0x93, 0x6f, 0xa0, 0x7b, 0xc1, 0x89, 0x26, 0x82,
+	0xdd, 0xf7, 0x3e, 0xba, 0x0d, 0x61, 0xd0, 0xce, 0x74, 0x5d, 0x24, 0x47, 0x57, 0xc7, 0x67, 0xb5,
+	0x57, 0xb9, 0x8e, 0x01, 0xaf, 0xa9, 0xeb, 0xe5, 0x19, 0x1b, 0x1c, 0x42, 0xf7, 0xf2, 0x07, 0xeb,
+	0xec, 0xee, 0x67, 0xa3, 0xcc, 0xfe, 0x67, 0x79, 0x95, 0xe1, 0x28, 0xf5, 0x4a, 0xc4, 0x83, 0xf6,
+	0x28, 0x1d, 0x9d, 0x7e, 0x2b, 0xf8, 0x4b, 0xa6, 0xc2, 0xb9, 0x57, 0xd6, 0x63, 0x6c, 0x94, 0x7e,
+	0xc7, 0x9f, 0xe0, 0x82, 0xf1, 0xc8, 0xab, 0x1c, 0xfc, 0x11, 0x7e, 0x16, 0x8a, 0xc5, 0xf5, 0x00,
+	0xc6, 0xa5, 0xbf, 0xd6, 0xed, 0xe9, 0x5f, 0xe5, 0x77, 0xbf, 0x1f, 0x50, 0xb6, 0xec, 0x0f, 0xb5,
+	0xc5, 0x7e, 0x9a, 0x9a, 0x4c, 0xa1, 0x9c, 0xd6, 0xcd, 0x66, 0xf2, 0xe8, 0xff, 0x03, 0x00, 0xfc,
+	0x76, 0x87, 0x75, 0x29, 0x0f, 0x00, 0x00,
 }
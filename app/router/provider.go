@@ -0,0 +1,328 @@
+// +build !confonly
+
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"gopkg.in/yaml.v2"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/platform/filesystem"
+	"v2ray.com/core/common/task"
+)
+
+// providerRules are the matchers built from one version of a provider file.
+type providerRules struct {
+	domains *DomainMatcher
+	ips     *GeoIPMatcher
+}
+
+func (r *providerRules) Apply(ctx *Context) bool {
+	if r.domains != nil && r.domains.Apply(ctx) {
+		return true
+	}
+	if r.ips != nil {
+		for _, ip := range ctx.GetTargetIPs() {
+			if r.ips.Match(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ProviderMatcher matches requests against rules loaded from a file. The file
+// is checked for changes periodically, and the rules are swapped atomically
+// when it is reloaded, so that matching never blocks.
+type ProviderMatcher struct {
+	config *RuleProvider
+	rules  atomic.Value // *providerRules
+
+	modTime time.Time
+	size    int64
+	checker *task.Periodic
+}
+
+func NewProviderMatcher(config *RuleProvider) (*ProviderMatcher, error) {
+	if len(config.Tag) == 0 {
+		return nil, newError("empty rule provider tag")
+	}
+	if len(config.Path) == 0 {
+		return nil, newError("empty path of rule provider ", config.Tag)
+	}
+	if config.Format == RuleProvider_GeoSiteList && config.Behavior != RuleProvider_Domain {
+		return nil, newError("GeoSiteList only contains domains, in rule provider ", config.Tag)
+	}
+
+	m := &ProviderMatcher{
+		config: config,
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	if config.Interval > 0 {
+		m.checker = &task.Periodic{
+			Interval: time.Duration(config.Interval) * time.Second,
+			Execute:  m.reloadIfChanged,
+		}
+	}
+	return m, nil
+}
+
+// Start implements common.Runnable.
+func (m *ProviderMatcher) Start() error {
+	if m.checker == nil {
+		return nil
+	}
+	return m.checker.Start()
+}
+
+// Close implements common.Closable.
+func (m *ProviderMatcher) Close() error {
+	if m.checker == nil {
+		return nil
+	}
+	return m.checker.Close()
+}
+
+func (m *ProviderMatcher) Apply(ctx *Context) bool {
+	return m.rules.Load().(*providerRules).Apply(ctx)
+}
+
+func (m *ProviderMatcher) reloadIfChanged() error {
+	info, err := os.Stat(m.config.Path)
+	if err != nil {
+		newError("failed to check rule provider ", m.config.Tag).Base(err).AtWarning().WriteToLog()
+		return nil
+	}
+	if info.ModTime().Equal(m.modTime) && info.Size() == m.size {
+		return nil
+	}
+	if err := m.load(); err != nil {
+		newError("failed to reload rule provider ", m.config.Tag, ", keeping the previous rules").Base(err).AtWarning().WriteToLog()
+		return nil
+	}
+	newError("rule provider ", m.config.Tag, " reloaded").AtInfo().WriteToLog()
+	return nil
+}
+
+func (m *ProviderMatcher) load() error {
+	info, err := os.Stat(m.config.Path)
+	if err != nil {
+		return newError("failed to open rule provider ", m.config.Tag).Base(err)
+	}
+	content, err := filesystem.ReadFile(m.config.Path)
+	if err != nil {
+		return newError("failed to read rule provider ", m.config.Tag).Base(err)
+	}
+
+	rules, err := parseProviderRules(m.config, content)
+	if err != nil {
+		return newError("failed to parse rule provider ", m.config.Tag).Base(err)
+	}
+	m.rules.Store(rules)
+	m.modTime = info.ModTime()
+	m.size = info.Size()
+	return nil
+}
+
+func parseProviderRules(config *RuleProvider, content []byte) (*providerRules, error) {
+	var domains []*Domain
+	var cidrs []*CIDR
+
+	switch config.Format {
+	case RuleProvider_GeoSiteList:
+		var list GeoSiteList
+		if err := proto.Unmarshal(content, &list); err != nil {
+			return nil, err
+		}
+		code := strings.ToUpper(config.Code)
+		for _, site := range list.Entry {
+			if strings.ToUpper(site.CountryCode) == code {
+				domains = site.Domain
+				break
+			}
+		}
+		if domains == nil {
+			return nil, newError("code not found in GeoSiteList: ", config.Code)
+		}
+	case RuleProvider_Text, RuleProvider_YAML:
+		var lines []string
+		if config.Format == RuleProvider_YAML {
+			var err error
+			if lines, err = parseYAMLPayload(content); err != nil {
+				return nil, err
+			}
+		} else {
+			lines = parseTextLines(content)
+		}
+		for _, line := range lines {
+			d, c, err := parseProviderLine(config.Behavior, line)
+			if err != nil {
+				return nil, err
+			}
+			if d != nil {
+				domains = append(domains, d)
+			}
+			if c != nil {
+				cidrs = append(cidrs, c)
+			}
+		}
+	default:
+		return nil, newError("unknown rule provider format: ", config.Format)
+	}
+
+	rules := new(providerRules)
+	if len(domains) > 0 {
+		matcher, err := NewDomainMatcher(domains)
+		if err != nil {
+			return nil, err
+		}
+		rules.domains = matcher
+	}
+	if len(cidrs) > 0 {
+		matcher := new(GeoIPMatcher)
+		if err := matcher.Init(cidrs); err != nil {
+			return nil, err
+		}
+		rules.ips = matcher
+	}
+	return rules, nil
+}
+
+func parseTextLines(content []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseYAMLPayload reads the entries of the "payload" sequences from the
+// documents of a YAML file, as used by rule provider files of other proxies.
+func parseYAMLPayload(content []byte) ([]string, error) {
+	var entries []string
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document struct {
+			Payload []string `yaml:"payload"`
+		}
+		if err := decoder.Decode(&document); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, newError("failed to parse YAML rule provider").Base(err)
+		}
+		for _, entry := range document.Payload {
+			if entry = strings.TrimSpace(entry); len(entry) > 0 {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+func parseProviderLine(behavior RuleProvider_Behavior, line string) (*Domain, *CIDR, error) {
+	switch behavior {
+	case RuleProvider_Domain:
+		d, err := parseProviderDomain(line)
+		return d, nil, err
+	case RuleProvider_IPCIDR:
+		c, err := parseProviderCIDR(line)
+		return nil, c, err
+	case RuleProvider_Classical:
+		parts := strings.Split(line, ",")
+		if len(parts) < 2 {
+			return nil, nil, newError("invalid rule: ", line)
+		}
+		value := strings.TrimSpace(parts[1])
+		switch strings.ToUpper(strings.TrimSpace(parts[0])) {
+		case "DOMAIN":
+			return &Domain{Type: Domain_Full, Value: value}, nil, nil
+		case "DOMAIN-SUFFIX":
+			return &Domain{Type: Domain_Domain, Value: value}, nil, nil
+		case "DOMAIN-KEYWORD":
+			return &Domain{Type: Domain_Plain, Value: value}, nil, nil
+		case "DOMAIN-REGEX":
+			return &Domain{Type: Domain_Regex, Value: value}, nil, nil
+		case "IP-CIDR", "IP-CIDR6":
+			c, err := parseProviderCIDR(value)
+			return nil, c, err
+		default:
+			newError("unsupported rule ignored: ", line).AtDebug().WriteToLog()
+			return nil, nil, nil
+		}
+	default:
+		return nil, nil, newError("unknown rule provider behavior: ", behavior)
+	}
+}
+
+func parseProviderDomain(s string) (*Domain, error) {
+	switch {
+	case strings.HasPrefix(s, "domain:"):
+		return &Domain{Type: Domain_Domain, Value: s[7:]}, nil
+	case strings.HasPrefix(s, "full:"):
+		return &Domain{Type: Domain_Full, Value: s[5:]}, nil
+	case strings.HasPrefix(s, "keyword:"):
+		return &Domain{Type: Domain_Plain, Value: s[8:]}, nil
+	case strings.HasPrefix(s, "regexp:"):
+		return &Domain{Type: Domain_Regex, Value: s[7:]}, nil
+	case strings.HasPrefix(s, "+."):
+		return &Domain{Type: Domain_Domain, Value: s[2:]}, nil
+	case strings.HasPrefix(s, "."):
+		return &Domain{Type: Domain_Domain, Value: s[1:]}, nil
+	case strings.Contains(s, ":"):
+		return nil, newError("invalid domain: ", s)
+	default:
+		return &Domain{Type: Domain_Domain, Value: s}, nil
+	}
+}
+
+func parseProviderCIDR(s string) (*CIDR, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, newError("invalid IP: ", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &CIDR{Ip: []byte(ip4), Prefix: 32}, nil
+		}
+		return &CIDR{Ip: []byte(ip), Prefix: 128}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, newError("invalid CIDR: ", s).Base(err)
+	}
+	prefix, _ := ipNet.Mask.Size()
+	ip := ipNet.IP
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return &CIDR{Ip: []byte(ip), Prefix: uint32(prefix)}, nil
+}
+
+// ProviderGroup matches if any of its rule providers matches.
+type ProviderGroup []*ProviderMatcher
+
+func (g ProviderGroup) Apply(ctx *Context) bool {
+	for _, m := range g {
+		if m.Apply(ctx) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package router_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	. "v2ray.com/core/app/router"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
+)
+
+func TestProviderMatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "v2ray-rule-provider")
+	common.Must(err)
+	defer os.RemoveAll(dir)
+
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		common.Must(ioutil.WriteFile(path, content, 0644))
+		return path
+	}
+
+	geosite, err := proto.Marshal(&GeoSiteList{
+		Entry: []*GeoSite{
+			{
+				CountryCode: "ADS",
+				Domain:      []*Domain{{Type: Domain_Domain, Value: "doubleclick.net"}},
+			},
+		},
+	})
+	common.Must(err)
+
+	testCases := []struct {
+		config  *RuleProvider
+		content []byte
+		match   []net.Address
+		miss    []net.Address
+	}{
+		{
+			config: &RuleProvider{
+				Behavior: RuleProvider_Domain,
+				Format:   RuleProvider_Text,
+			},
+			content: []byte("# ads\nexample.com\nfull:v2ray.com\n\nkeyword:tracker\n"),
+			match:   []net.Address{net.DomainAddress("www.example.com"), net.DomainAddress("v2ray.com"), net.DomainAddress("a.tracker.org")},
+			miss:    []net.Address{net.DomainAddress("www.v2ray.com"), net.DomainAddress("example.org")},
+		},
+		{
+			config: &RuleProvider{
+				Behavior: RuleProvider_IPCIDR,
+				Format:   RuleProvider_YAML,
+			},
+			content: []byte("payload:\n  - '10.0.0.0/8'\n  - \"2001:db8::/32\"\n  - 192.168.1.1 # router\n"),
+			match:   []net.Address{net.ParseAddress("10.1.2.3"), net.ParseAddress("2001:db8::1"), net.ParseAddress("192.168.1.1")},
+			miss:    []net.Address{net.ParseAddress("192.168.1.2"), net.ParseAddress("8.8.8.8")},
+		},
+		{
+			config: &RuleProvider{
+				Behavior: RuleProvider_Domain,
+				Format:   RuleProvider_YAML,
+			},
+			content: []byte("payload: ['example.com', \"full:v2ray.com\"]\n---\npayload:\n  - 'keyword:a#b' # quoted\n"),
+			match:   []net.Address{net.DomainAddress("www.example.com"), net.DomainAddress("v2ray.com"), net.DomainAddress("a#b.org")},
+			miss:    []net.Address{net.DomainAddress("www.v2ray.com"), net.DomainAddress("ab.org")},
+		},
+		{
+			config: &RuleProvider{
+				Behavior: RuleProvider_Classical,
+				Format:   RuleProvider_YAML,
+			},
+			content: []byte("payload:\n  - DOMAIN-SUFFIX,google.com\n  - DOMAIN,v2ray.com\n  - IP-CIDR,8.8.8.0/24,no-resolve\n  - PROCESS-NAME,curl\n"),
+			match:   []net.Address{net.DomainAddress("www.google.com"), net.DomainAddress("v2ray.com"), net.ParseAddress("8.8.8.8")},
+			miss:    []net.Address{net.DomainAddress("www.v2ray.com"), net.ParseAddress("8.8.4.4")},
+		},
+		{
+			config: &RuleProvider{
+				Behavior: RuleProvider_Domain,
+				Format:   RuleProvider_GeoSiteList,
+				Code:     "ads",
+			},
+			content: geosite,
+			match:   []net.Address{net.DomainAddress("ad.doubleclick.net")},
+			miss:    []net.Address{net.DomainAddress("v2ray.com")},
+		},
+	}
+
+	for i, test := range testCases {
+		test.config.Tag = "provider"
+		test.config.Path = write("rules"+strconv.Itoa(i), test.content)
+		matcher, err := NewProviderMatcher(test.config)
+		common.Must(err)
+
+		for _, addr := range test.match {
+			if !matcher.Apply(withOutbound(&session.Outbound{Target: net.TCPDestination(addr, 80)})) {
+				t.Error("case ", i, ": expect ", addr, " to match")
+			}
+		}
+		for _, addr := range test.miss {
+			if matcher.Apply(withOutbound(&session.Outbound{Target: net.TCPDestination(addr, 80)})) {
+				t.Error("case ", i, ": expect ", addr, " not to match")
+			}
+		}
+	}
+
+	if _, err := NewProviderMatcher(&RuleProvider{
+		Tag:      "provider",
+		Behavior: RuleProvider_IPCIDR,
+		Path:     write("bad", []byte("v2ray.com\n")),
+	}); err == nil {
+		t.Error("expect error for malformed CIDR")
+	}
+	if _, err := NewProviderMatcher(&RuleProvider{
+		Tag:      "provider",
+		Behavior: RuleProvider_Domain,
+		Format:   RuleProvider_YAML,
+		Path:     write("bad.yaml", []byte("payload:\n  - [v2ray.com\n")),
+	}); err == nil {
+		t.Error("expect error for malformed YAML")
+	}
+}
+
+func TestRouterRuleProviderReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "v2ray-rule-provider")
+	common.Must(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "direct.txt")
+	common.Must(ioutil.WriteFile(path, []byte("example.com\n"), 0644))
+
+	r := new(Router)
+	common.Must(r.Init(&Config{
+		RuleProvider: []*RuleProvider{
+			{
+				Tag:      "direct",
+				Path:     path,
+				Interval: 1,
+			},
+		},
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "direct",
+				},
+				RuleProvider: []string{"direct"},
+			},
+		},
+	}, nil, nil, nil, nil))
+	common.Must(r.Start())
+	defer r.Close()
+
+	route := func(domain string) string {
+		trace, err := r.TestRoute(withOutbound(&session.Outbound{Target: net.TCPDestination(net.DomainAddress(domain), 80)}))
+		common.Must(err)
+		return trace.OutboundTag
+	}
+
+	if tag := route("example.com"); tag != "direct" {
+		t.Error("expect direct, but got ", tag)
+	}
+	if tag := route("v2ray.com"); tag != "" {
+		t.Error("expect no route, but got ", tag)
+	}
+
+	common.Must(ioutil.WriteFile(path, []byte("example.com\nv2ray.com\n"), 0644))
+	deadline := time.Now().Add(5 * time.Second)
+	for route("v2ray.com") != "direct" {
+		if time.Now().After(deadline) {
+			t.Fatal("rule provider is not reloaded")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Malformed updates are ignored.
+	common.Must(ioutil.WriteFile(path, []byte("regexp:(\n"), 0644))
+	time.Sleep(1500 * time.Millisecond)
+	if tag := route("v2ray.com"); tag != "direct" {
+		t.Error("expect previous rules to be kept, but got ", tag)
+	}
+}
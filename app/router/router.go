@@ -6,12 +6,15 @@ package router
 
 import (
 	"context"
+	"sync"
 
 	"v2ray.com/core"
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/errors"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/session"
 	"v2ray.com/core/features/dns"
+	"v2ray.com/core/features/health"
 	"v2ray.com/core/features/outbound"
 	"v2ray.com/core/features/routing"
 )
@@ -19,8 +22,9 @@ import (
 func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		r := new(Router)
-		if err := core.RequireFeatures(ctx, func(d dns.Client, ohm outbound.Manager) error {
-			return r.Init(config.(*Config), d, ohm)
+		sc := &dispatcherSessionCounter{v: core.MustFromContext(ctx)}
+		if err := core.RequireFeatures(ctx, func(d dns.Client, ohm outbound.Manager, hr health.Registry) error {
+			return r.Init(config.(*Config), d, ohm, hr, sc)
 		}); err != nil {
 			return nil, err
 		}
@@ -28,106 +32,390 @@ func init() {
 	}))
 }
 
+// dispatcherSessionCounter reads the session counts from the dispatcher of the instance. The dispatcher
+// is looked up on each call, as it depends on the router and can't be required when the router is created.
+type dispatcherSessionCounter struct {
+	v *core.Instance
+}
+
+// ActiveSessions implements routing.SessionCounter.
+func (c *dispatcherSessionCounter) ActiveSessions(tag string) int {
+	if sc, ok := c.v.GetFeature(routing.DispatcherType()).(routing.SessionCounter); ok {
+		return sc.ActiveSessions(tag)
+	}
+	return 0
+}
+
 // Router is an implementation of routing.Router.
 type Router struct {
 	domainStrategy Config_DomainStrategy
+	providers      map[string]*ProviderMatcher
+	dns            dns.Client
+	ohm            outbound.Manager
+	health         health.Registry
+	sessions       routing.SessionCounter
+
+	access         sync.RWMutex
+	running        bool
 	rules          []*Rule
+	balancingRules []*BalancingRule
 	balancers      map[string]*Balancer
-	dns            dns.Client
 }
 
 // Init initializes the Router.
-func (r *Router) Init(config *Config, d dns.Client, ohm outbound.Manager) error {
+func (r *Router) Init(config *Config, d dns.Client, ohm outbound.Manager, hr health.Registry, sc routing.SessionCounter) error {
 	r.domainStrategy = config.DomainStrategy
 	r.dns = d
+	r.ohm = ohm
+	r.health = hr
+	r.sessions = sc
 
+	r.balancingRules = config.BalancingRule
 	r.balancers = make(map[string]*Balancer, len(config.BalancingRule))
 	for _, rule := range config.BalancingRule {
-		balancer, err := rule.Build(ohm)
+		if _, found := r.balancers[rule.Tag]; found {
+			return newError("duplicated balancer ", rule.Tag)
+		}
+		balancer, err := rule.Build(ohm, hr, sc)
 		if err != nil {
 			return err
 		}
 		r.balancers[rule.Tag] = balancer
 	}
 
-	r.rules = make([]*Rule, 0, len(config.Rule))
-	for _, rule := range config.Rule {
-		cond, err := rule.BuildCondition()
+	r.providers = make(map[string]*ProviderMatcher, len(config.RuleProvider))
+	for _, provider := range config.RuleProvider {
+		if _, found := r.providers[provider.Tag]; found {
+			return newError("duplicated rule provider ", provider.Tag)
+		}
+		matcher, err := NewProviderMatcher(provider)
 		if err != nil {
 			return err
 		}
-		rr := &Rule{
-			Condition: cond,
-			Tag:       rule.GetTag(),
+		r.providers[provider.Tag] = matcher
+	}
+
+	rules, err := r.buildRules(config.Rule)
+	if err != nil {
+		return err
+	}
+	r.rules = rules
+
+	return nil
+}
+
+// buildRule builds a rule from its config. Callers must hold access, unless the router is not yet running.
+func (r *Router) buildRule(rule *RoutingRule) (*Rule, error) {
+	cond, err := rule.buildCondition(r.providers)
+	if err != nil {
+		return nil, err
+	}
+	action, err := rule.BuildAction()
+	if err != nil {
+		return nil, err
+	}
+	rr := &Rule{
+		Condition: cond,
+		Tag:       rule.GetTag(),
+		Action:    action,
+		config:    rule,
+	}
+	if rule.RewriteAddress != nil || rule.RewritePort != 0 {
+		if action != routing.ActionRoute {
+			return nil, newError("destination rewrite only works with route action")
+		}
+		if rule.RewritePort > 65535 {
+			return nil, newError("invalid rewrite port: ", rule.RewritePort)
+		}
+		if rule.RewriteAddress != nil {
+			rr.rewriteAddress = rule.RewriteAddress.AsAddress()
+		}
+		rr.rewritePort = net.Port(rule.RewritePort)
+	}
+	btag := rule.GetBalancingTag()
+	if len(btag) > 0 {
+		brule, found := r.balancers[btag]
+		if !found {
+			return nil, newError("balancer ", btag, " not found")
+		}
+		rr.Balancer = brule
+	}
+	return rr, nil
+}
+
+func (r *Router) buildRules(config []*RoutingRule) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(config))
+	for _, rule := range config {
+		rr, err := r.buildRule(rule)
+		if err != nil {
+			return nil, err
 		}
-		btag := rule.GetBalancingTag()
-		if len(btag) > 0 {
-			brule, found := r.balancers[btag]
-			if !found {
-				return newError("balancer ", btag, " not found")
+		rules = append(rules, rr)
+	}
+	return rules, nil
+}
+
+func (r *Router) getRules() []*Rule {
+	r.access.RLock()
+	defer r.access.RUnlock()
+
+	return r.rules
+}
+
+// ListRules returns the routing rules currently in effect, in the order they are applied.
+func (r *Router) ListRules() []*RoutingRule {
+	rules := r.getRules()
+	config := make([]*RoutingRule, 0, len(rules))
+	for _, rule := range rules {
+		config = append(config, rule.config)
+	}
+	return config
+}
+
+// ListBalancers returns the configuration of all balancers.
+func (r *Router) ListBalancers() []*BalancingRule {
+	r.access.RLock()
+	defer r.access.RUnlock()
+
+	config := make([]*BalancingRule, len(r.balancingRules))
+	copy(config, r.balancingRules)
+	return config
+}
+
+// GetBalancer returns the balancer with the given tag, or nil if it doesn't exist.
+func (r *Router) GetBalancer(tag string) *Balancer {
+	r.access.RLock()
+	defer r.access.RUnlock()
+
+	return r.balancers[tag]
+}
+
+// AddBalancer builds and adds a balancer, which can then be referenced by routing rules.
+func (r *Router) AddBalancer(config *BalancingRule) error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	if _, found := r.balancers[config.Tag]; found {
+		return newError("duplicated balancer ", config.Tag)
+	}
+	balancer, err := config.Build(r.ohm, r.health, r.sessions)
+	if err != nil {
+		return err
+	}
+	if r.running {
+		if runnable, ok := balancer.strategy.(common.Runnable); ok {
+			if err := runnable.Start(); err != nil {
+				return err
 			}
-			rr.Balancer = brule
 		}
-		r.rules = append(r.rules, rr)
 	}
 
+	// Like rules, the balancer list is never modified in place, as it may be returned by ListBalancers.
+	balancingRules := make([]*BalancingRule, 0, len(r.balancingRules)+1)
+	balancingRules = append(balancingRules, r.balancingRules...)
+	r.balancingRules = append(balancingRules, config)
+	r.balancers[config.Tag] = balancer
 	return nil
 }
 
-func (r *Router) PickRoute(ctx context.Context) (string, error) {
-	rule, err := r.pickRouteInternal(ctx)
+// RemoveBalancer stops and removes the balancer with the given tag. It fails if any routing rule still uses the balancer.
+func (r *Router) RemoveBalancer(tag string) error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	balancer, found := r.balancers[tag]
+	if !found {
+		return newError("balancer ", tag, " not found")
+	}
+	for idx, rule := range r.rules {
+		if rule.Balancer == balancer {
+			return newError("balancer ", tag, " is used by rule ", idx)
+		}
+	}
+
+	balancingRules := make([]*BalancingRule, 0, len(r.balancingRules))
+	for _, config := range r.balancingRules {
+		if config.Tag != tag {
+			balancingRules = append(balancingRules, config)
+		}
+	}
+	r.balancingRules = balancingRules
+	delete(r.balancers, tag)
+	return common.Close(balancer.strategy)
+}
+
+// AddRule inserts a routing rule at the given index. The rule is appended if
+// index is negative or beyond the last rule.
+func (r *Router) AddRule(rule *RoutingRule, index int) error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	rr, err := r.buildRule(rule)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	if index < 0 || index > len(r.rules) {
+		index = len(r.rules)
 	}
-	return rule.GetTag()
+	// Rules are never modified in place, as they may be in use by PickRoute.
+	rules := make([]*Rule, 0, len(r.rules)+1)
+	rules = append(rules, r.rules[:index]...)
+	rules = append(rules, rr)
+	rules = append(rules, r.rules[index:]...)
+	r.rules = rules
+	return nil
 }
 
-func isDomainOutbound(outbound *session.Outbound) bool {
-	return outbound != nil && outbound.Target.IsValid() && outbound.Target.Address.Family().IsDomain()
+// RemoveRule removes the routing rule at the given index.
+func (r *Router) RemoveRule(index int) error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	if index < 0 || index >= len(r.rules) {
+		return newError("rule index out of range: ", index)
+	}
+	rules := make([]*Rule, 0, len(r.rules)-1)
+	rules = append(rules, r.rules[:index]...)
+	rules = append(rules, r.rules[index+1:]...)
+	r.rules = rules
+	return nil
+}
+
+// ReplaceRules replaces all routing rules at once. Existing rules stay in
+// effect if any of the new rules fails to build.
+func (r *Router) ReplaceRules(config []*RoutingRule) error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	rules, err := r.buildRules(config)
+	if err != nil {
+		return err
+	}
+	r.rules = rules
+	return nil
 }
 
 // PickRoute implements routing.Router.
-func (r *Router) pickRouteInternal(ctx context.Context) (*Rule, error) {
+func (r *Router) PickRoute(ctx context.Context) (string, error) {
+	route, err := r.PickRouteAction(ctx)
+	if err != nil {
+		return "", err
+	}
+	if route.Action != routing.ActionRoute {
+		return "", newError("request is handled by rule action: ", route.Action)
+	}
+	return route.OutboundTag, nil
+}
+
+// PickRouteAction implements routing.ActionRouter.
+func (r *Router) PickRouteAction(ctx context.Context) (*routing.Route, error) {
 	sessionContext := &Context{
 		Inbound:  session.InboundFromContext(ctx),
 		Outbound: session.OutboundFromContext(ctx),
 		Content:  session.ContentFromContext(ctx),
 	}
+	rule, err := r.pickRouteInternal(sessionContext)
+	if err != nil {
+		return nil, err
+	}
+	return r.buildRoute(rule, sessionContext)
+}
+
+func (r *Router) buildRoute(rule *Rule, sessionContext *Context) (*routing.Route, error) {
+	route := &routing.Route{
+		Action: rule.Action,
+	}
+	if rule.Action != routing.ActionRoute {
+		return route, nil
+	}
 
+	tag, err := rule.GetTag(sessionContext)
+	if err != nil {
+		return nil, err
+	}
+	route.OutboundTag = tag
+	if sessionContext.Outbound != nil && sessionContext.Outbound.Target.IsValid() {
+		route.Target = rule.Rewrite(sessionContext.Outbound.Target)
+	}
+	return route, nil
+}
+
+func isDomainOutbound(outbound *session.Outbound) bool {
+	return outbound != nil && outbound.Target.IsValid() && outbound.Target.Address.Family().IsDomain()
+}
+
+func (r *Router) pickRouteInternal(sessionContext *Context) (*Rule, error) {
+	rules := r.getRules()
+	if idx := r.matchRule(rules, sessionContext); idx >= 0 {
+		return rules[idx], nil
+	}
+	return nil, common.ErrNoClue
+}
+
+// matchRule returns the index of the first rule that applies to the context, or -1 if there is none.
+func (r *Router) matchRule(rules []*Rule, sessionContext *Context) int {
 	if r.domainStrategy == Config_IpOnDemand {
 		sessionContext.dnsClient = r.dns
 	}
 
-	for _, rule := range r.rules {
+	for idx, rule := range rules {
 		if rule.Apply(sessionContext) {
-			return rule, nil
+			return idx
 		}
 	}
 
 	if r.domainStrategy != Config_IpIfNonMatch || !isDomainOutbound(sessionContext.Outbound) {
-		return nil, common.ErrNoClue
+		return -1
 	}
 
 	sessionContext.dnsClient = r.dns
 
 	// Try applying rules again if we have IPs.
-	for _, rule := range r.rules {
+	for idx, rule := range rules {
 		if rule.Apply(sessionContext) {
-			return rule, nil
+			return idx
 		}
 	}
 
-	return nil, common.ErrNoClue
+	return -1
 }
 
 // Start implements common.Runnable.
-func (*Router) Start() error {
+func (r *Router) Start() error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	r.running = true
+	for _, b := range r.balancers {
+		if runnable, ok := b.strategy.(common.Runnable); ok {
+			if err := runnable.Start(); err != nil {
+				return err
+			}
+		}
+	}
+	for _, p := range r.providers {
+		if err := p.Start(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // Close implements common.Closable.
-func (*Router) Close() error {
-	return nil
+func (r *Router) Close() error {
+	r.access.Lock()
+	defer r.access.Unlock()
+
+	r.running = false
+	var errs []error
+	for _, b := range r.balancers {
+		errs = append(errs, common.Close(b.strategy))
+	}
+	for _, p := range r.providers {
+		errs = append(errs, p.Close())
+	}
+	return errors.Combine(errs...)
 }
 
 // Type implement common.HasType.
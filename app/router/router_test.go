@@ -5,11 +5,13 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
 	. "v2ray.com/core/app/router"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/session"
 	"v2ray.com/core/features/outbound"
+	"v2ray.com/core/features/routing"
 	"v2ray.com/core/testing/mocks"
 )
 
@@ -41,7 +43,7 @@ func TestSimpleRouter(t *testing.T) {
 	common.Must(r.Init(config, mockDns, &mockOutboundManager{
 		Manager:         mockOhm,
 		HandlerSelector: mockHs,
-	}))
+	}, nil, nil))
 
 	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2ray.com"), 80)})
 	tag, err := r.PickRoute(ctx)
@@ -82,7 +84,7 @@ func TestSimpleBalancer(t *testing.T) {
 	common.Must(r.Init(config, mockDns, &mockOutboundManager{
 		Manager:         mockOhm,
 		HandlerSelector: mockHs,
-	}))
+	}, nil, nil))
 
 	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2ray.com"), 80)})
 	tag, err := r.PickRoute(ctx)
@@ -117,7 +119,7 @@ func TestIPOnDemand(t *testing.T) {
 	mockDns.EXPECT().LookupIP(gomock.Eq("v2ray.com")).Return([]net.IP{{192, 168, 0, 1}}, nil).AnyTimes()
 
 	r := new(Router)
-	common.Must(r.Init(config, mockDns, nil))
+	common.Must(r.Init(config, mockDns, nil, nil, nil))
 
 	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2ray.com"), 80)})
 	tag, err := r.PickRoute(ctx)
@@ -152,7 +154,7 @@ func TestIPIfNonMatchDomain(t *testing.T) {
 	mockDns.EXPECT().LookupIP(gomock.Eq("v2ray.com")).Return([]net.IP{{192, 168, 0, 1}}, nil).AnyTimes()
 
 	r := new(Router)
-	common.Must(r.Init(config, mockDns, nil))
+	common.Must(r.Init(config, mockDns, nil, nil, nil))
 
 	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2ray.com"), 80)})
 	tag, err := r.PickRoute(ctx)
@@ -186,7 +188,7 @@ func TestIPIfNonMatchIP(t *testing.T) {
 	mockDns := mocks.NewDNSClient(mockCtl)
 
 	r := new(Router)
-	common.Must(r.Init(config, mockDns, nil))
+	common.Must(r.Init(config, mockDns, nil, nil, nil))
 
 	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.LocalHostIP, 80)})
 	tag, err := r.PickRoute(ctx)
@@ -195,3 +197,226 @@ func TestIPIfNonMatchIP(t *testing.T) {
 		t.Error("expect tag 'test', bug actually ", tag)
 	}
 }
+
+func TestRouterUpdateRules(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "tcp",
+				},
+				Networks: []net.Network{net.Network_TCP},
+			},
+		},
+	}
+
+	r := new(Router)
+	common.Must(r.Init(config, nil, nil, nil, nil))
+
+	pick := func() string {
+		ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2ray.com"), 80)})
+		tag, err := r.PickRoute(ctx)
+		if err != nil {
+			return ""
+		}
+		return tag
+	}
+
+	if tag := pick(); tag != "tcp" {
+		t.Error("expect tag 'tcp', but actually ", tag)
+	}
+
+	common.Must(r.AddRule(&RoutingRule{
+		TargetTag: &RoutingRule_Tag{
+			Tag: "v2ray",
+		},
+		Domain: []*Domain{{Type: Domain_Domain, Value: "v2ray.com"}},
+	}, 0))
+	if tag := pick(); tag != "v2ray" {
+		t.Error("expect tag 'v2ray', but actually ", tag)
+	}
+	if n := len(r.ListRules()); n != 2 {
+		t.Error("expect 2 rules, but got ", n)
+	}
+
+	common.Must(r.RemoveRule(0))
+	if tag := pick(); tag != "tcp" {
+		t.Error("expect tag 'tcp', but actually ", tag)
+	}
+	if err := r.RemoveRule(1); err == nil {
+		t.Error("expect error when removing rule out of range")
+	}
+
+	if err := r.ReplaceRules([]*RoutingRule{
+		{
+			TargetTag: &RoutingRule_Tag{
+				Tag: "udp",
+			},
+			Networks: []net.Network{net.Network_UDP},
+		},
+		{
+			TargetTag: &RoutingRule_BalancingTag{
+				BalancingTag: "not-exist",
+			},
+		},
+	}); err == nil {
+		t.Error("expect error when replacing rules with unknown balancer")
+	}
+	if tag := pick(); tag != "tcp" {
+		t.Error("expect rules unchanged after failed replacement, but got ", tag)
+	}
+
+	common.Must(r.ReplaceRules([]*RoutingRule{
+		{
+			TargetTag: &RoutingRule_Tag{
+				Tag: "udp",
+			},
+			Networks: []net.Network{net.Network_UDP},
+		},
+	}))
+	if tag := pick(); tag != "" {
+		t.Error("expect no route, but got ", tag)
+	}
+}
+
+func TestRouterTestRoute(t *testing.T) {
+	config := &Config{
+		DomainStrategy: Config_IpIfNonMatch,
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "udp",
+				},
+				Networks: []net.Network{net.Network_UDP},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "lan",
+				},
+				Cidr: []*CIDR{
+					{
+						Ip:     []byte{192, 168, 0, 0},
+						Prefix: 16,
+					},
+				},
+				Networks:   []net.Network{net.Network_TCP},
+				InboundTag: []string{"socks"},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDns := mocks.NewDNSClient(mockCtl)
+	mockDns.EXPECT().LookupIP(gomock.Eq("v2ray.com")).Return([]net.IP{{192, 168, 0, 1}}, nil).AnyTimes()
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockOhm.EXPECT().GetDefaultHandler().Return(taggedHandler{tag: "direct"}).AnyTimes()
+
+	r := new(Router)
+	common.Must(r.Init(config, mockDns, mockOhm, nil, nil))
+
+	trace, err := r.TestRoute(&Context{
+		Inbound:  &session.Inbound{Tag: "socks"},
+		Outbound: &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2ray.com"), 80)},
+	})
+	common.Must(err)
+	if trace.RuleIndex != 1 {
+		t.Error("expect rule 1, but got ", trace.RuleIndex)
+	}
+	if trace.OutboundTag != "lan" {
+		t.Error("expect outbound 'lan', but got ", trace.OutboundTag)
+	}
+	if len(trace.ResolvedIPs) != 1 || trace.ResolvedIPs[0].String() != "192.168.0.1" {
+		t.Error("unexpected resolved IPs: ", trace.ResolvedIPs)
+	}
+	if r := cmp.Diff(trace.Rules, []RuleTrace{
+		{Index: 0, Matched: false, Conditions: []ConditionTrace{{"network", false}}},
+		{Index: 1, Matched: true, Conditions: []ConditionTrace{{"inboundTag", true}, {"network", true}, {"ip", true}}},
+	}); r != "" {
+		t.Error(r)
+	}
+
+	trace, err = r.TestRoute(&Context{
+		Outbound: &session.Outbound{Target: net.TCPDestination(net.IPAddress([]byte{10, 0, 0, 1}), 80)},
+	})
+	common.Must(err)
+	if trace.RuleIndex != -1 || len(trace.Rules) != 2 {
+		t.Error("expect no rule to match, but got ", trace.RuleIndex, " ", trace.Rules)
+	}
+	if trace.OutboundTag != "direct" {
+		t.Error("expect the default outbound 'direct', but got ", trace.OutboundTag)
+	}
+}
+
+type taggedHandler struct {
+	outbound.Handler
+	tag string
+}
+
+func (h taggedHandler) Tag() string {
+	return h.tag
+}
+
+func TestRouterActions(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				Domain: []*Domain{{Type: Domain_Domain, Value: "ads.com"}},
+				Action: RoutingRule_RejectHTTP,
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "direct",
+				},
+				Domain:         []*Domain{{Type: Domain_Domain, Value: "example.com"}},
+				PortList:       &net.PortList{Range: []*net.PortRange{net.SinglePortRange(80)}},
+				RewriteAddress: net.NewIPOrDomain(net.ParseAddress("10.0.0.5")),
+				RewritePort:    8080,
+			},
+			{
+				Networks: []net.Network{net.Network_UDP},
+				Action:   RoutingRule_Drop,
+			},
+		},
+	}
+
+	r := new(Router)
+	common.Must(r.Init(config, nil, nil, nil, nil))
+
+	pick := func(dest net.Destination) *routing.Route {
+		route, err := r.PickRouteAction(session.ContextWithOutbound(context.Background(), &session.Outbound{Target: dest}))
+		common.Must(err)
+		return route
+	}
+
+	if route := pick(net.TCPDestination(net.DomainAddress("www.ads.com"), 443)); route.Action != routing.ActionRejectHTTP {
+		t.Error("expect reject-http, but got ", route.Action)
+	}
+	if _, err := r.PickRoute(session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.ads.com"), 443)})); err == nil {
+		t.Error("expect error from PickRoute for rejected request")
+	}
+
+	route := pick(net.TCPDestination(net.DomainAddress("www.example.com"), 80))
+	if route.Action != routing.ActionRoute || route.OutboundTag != "direct" {
+		t.Error("unexpected route: ", route)
+	}
+	if route.Target != net.TCPDestination(net.ParseAddress("10.0.0.5"), 8080) {
+		t.Error("expect rewritten destination, but got ", route.Target)
+	}
+
+	if _, err := r.PickRouteAction(session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("www.example.com"), 443)})); err != common.ErrNoClue {
+		t.Error("expect no rule to match, but got ", err)
+	}
+	if route := pick(net.UDPDestination(net.ParseAddress("8.8.8.8"), 53)); route.Action != routing.ActionDrop {
+		t.Error("expect drop, but got ", route.Action)
+	}
+
+	if err := r.AddRule(&RoutingRule{
+		Networks:    []net.Network{net.Network_TCP},
+		Action:      RoutingRule_RejectRST,
+		RewritePort: 80,
+	}, -1); err == nil {
+		t.Error("expect error for rewrite with reject action")
+	}
+}
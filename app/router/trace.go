@@ -0,0 +1,142 @@
+// +build !confonly
+
+package router
+
+import (
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/features/routing"
+)
+
+// ConditionTrace is the result of one condition of a routing rule.
+type ConditionTrace struct {
+	Name    string
+	Matched bool
+}
+
+// RuleTrace is the result of one routing rule.
+type RuleTrace struct {
+	Index      int
+	Matched    bool
+	Conditions []ConditionTrace
+}
+
+// RouteTrace explains how a request is routed.
+type RouteTrace struct {
+	// RuleIndex is the index of the matching rule, or -1 if no rule matches.
+	RuleIndex int
+	// Rules are the results of the rules evaluated, up to and including the matching rule.
+	Rules []RuleTrace
+	// ResolvedIPs are the IPs of the destination domain, if it was resolved during routing.
+	ResolvedIPs []net.IP
+	// BalancerTag is the tag of the balancer of the matching rule, if any.
+	BalancerTag string
+	// Action is the action of the matching rule.
+	Action routing.Action
+	// OutboundTag is the outbound that the request is routed to. It is the default outbound if no rule matches.
+	OutboundTag string
+	// Target is the rewritten destination of the request, or invalid if it is not rewritten.
+	Target net.Destination
+}
+
+// TestRoute routes the request described by ctx without dispatching it, and explains the decision.
+func (r *Router) TestRoute(ctx *Context) (*RouteTrace, error) {
+	trace := &RouteTrace{
+		RuleIndex: -1,
+	}
+
+	rules := r.getRules()
+	idx := r.matchRule(rules, ctx)
+	if isDomainOutbound(ctx.Outbound) {
+		trace.ResolvedIPs = ctx.Outbound.ResolvedIPs
+	}
+	evaluated := rules
+	if idx >= 0 {
+		evaluated = rules[:idx+1]
+	}
+	for i, rule := range evaluated {
+		trace.Rules = append(trace.Rules, traceRule(i, rule, ctx))
+	}
+	if idx < 0 {
+		if r.ohm != nil {
+			if handler := r.ohm.GetDefaultHandler(); handler != nil {
+				trace.OutboundTag = handler.Tag()
+			}
+		}
+		return trace, nil
+	}
+
+	rule := rules[idx]
+	trace.RuleIndex = idx
+
+	if rule.Balancer != nil {
+		trace.BalancerTag = rule.config.GetBalancingTag()
+	}
+	trace.Action = rule.Action
+	if rule.Action != routing.ActionRoute {
+		return trace, nil
+	}
+	// Peek instead of picking, so that testing a route doesn't affect the balancer.
+	tag, err := rule.PeekTag(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trace.OutboundTag = tag
+	if ctx.Outbound != nil && ctx.Outbound.Target.IsValid() {
+		trace.Target = rule.Rewrite(ctx.Outbound.Target)
+	}
+
+	return trace, nil
+}
+
+func traceRule(idx int, rule *Rule, ctx *Context) RuleTrace {
+	trace := RuleTrace{
+		Index:   idx,
+		Matched: rule.Apply(ctx),
+	}
+	if conds, ok := rule.Condition.(*ConditionChan); ok {
+		for _, cond := range *conds {
+			trace.Conditions = append(trace.Conditions, ConditionTrace{
+				Name:    conditionName(cond),
+				Matched: cond.Apply(ctx),
+			})
+		}
+	}
+	return trace
+}
+
+func conditionName(cond Condition) string {
+	switch c := cond.(type) {
+	case *DomainMatcher:
+		return "domain"
+	case *UserMatcher:
+		return "user"
+	case *InboundTagMatcher:
+		return "inboundTag"
+	case *PortMatcher:
+		return "port"
+	case NetworkMatcher:
+		return "network"
+	case *MultiGeoIPMatcher:
+		if c.onSource {
+			return "source"
+		}
+		return "ip"
+	case *CustomGeoIPMatcher:
+		if c.onSource {
+			return "customSource"
+		}
+		return "customIp"
+	case *ProtocolMatcher:
+		return "protocol"
+	case *AttributeMatcher:
+		return "attrs"
+	case *ApplicationMatcher:
+		return "application"
+	case *ScheduleMatcher:
+		return "schedule"
+	case ProviderGroup:
+		return "ruleProvider"
+	default:
+		return "unknown"
+	}
+}
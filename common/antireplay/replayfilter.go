@@ -0,0 +1,47 @@
+// Package antireplay rejects requests whose nonces have been seen recently.
+package antireplay
+
+import (
+	"sync"
+	"time"
+)
+
+// ReplayFilter remembers nonces for at least the interval, in two generations.
+type ReplayFilter struct {
+	sync.Mutex
+	interval time.Duration
+	rotated  time.Time
+	current  map[string]struct{}
+	previous map[string]struct{}
+}
+
+// NewReplayFilter creates a ReplayFilter that remembers nonces for at least the interval.
+func NewReplayFilter(interval time.Duration) *ReplayFilter {
+	return &ReplayFilter{
+		interval: interval,
+		rotated:  time.Now(),
+		current:  make(map[string]struct{}),
+		previous: make(map[string]struct{}),
+	}
+}
+
+// Check returns true and remembers the nonce, if it hasn't been seen.
+func (f *ReplayFilter) Check(nonce []byte) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	if now := time.Now(); now.Sub(f.rotated) > f.interval {
+		f.previous = f.current
+		f.current = make(map[string]struct{})
+		f.rotated = now
+	}
+
+	if _, found := f.current[string(nonce)]; found {
+		return false
+	}
+	if _, found := f.previous[string(nonce)]; found {
+		return false
+	}
+	f.current[string(nonce)] = struct{}{}
+	return true
+}
@@ -0,0 +1,33 @@
+package antireplay_test
+
+import (
+	"testing"
+	"time"
+
+	. "v2ray.com/core/common/antireplay"
+)
+
+func TestReplayFilter(t *testing.T) {
+	f := NewReplayFilter(time.Millisecond * 100)
+
+	if !f.Check([]byte("a")) {
+		t.Error("expect a new nonce to pass")
+	}
+	if f.Check([]byte("a")) {
+		t.Error("expect a replayed nonce to be rejected")
+	}
+	if !f.Check([]byte("b")) {
+		t.Error("expect a new nonce to pass")
+	}
+
+	// A nonce is remembered in the generation after the one it's seen in.
+	time.Sleep(time.Millisecond * 150)
+	if f.Check([]byte("b")) {
+		t.Error("expect a nonce of the previous generation to be rejected")
+	}
+
+	time.Sleep(time.Millisecond * 150)
+	if !f.Check([]byte("a")) {
+		t.Error("expect an expired nonce to pass")
+	}
+}
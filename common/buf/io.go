@@ -5,6 +5,8 @@ import (
 	"net"
 	"syscall"
 	"time"
+
+	"v2ray.com/core/common"
 )
 
 // Reader extends io.Reader with MultiBuffer.
@@ -51,6 +53,19 @@ func WriteAllBytes(writer io.Writer, payload []byte) error {
 	return nil
 }
 
+// WriteHeaderAndPayload writes the header and then the payload, in a single
+// write if the payload fits into the header buffer.
+func WriteHeaderAndPayload(writer io.Writer, header *Buffer, payload []byte) error {
+	if int32(len(payload)) <= Size-header.Len() {
+		common.Must2(header.Write(payload))
+		return WriteAllBytes(writer, header.Bytes())
+	}
+	if err := WriteAllBytes(writer, header.Bytes()); err != nil {
+		return err
+	}
+	return WriteAllBytes(writer, payload)
+}
+
 func isPacketReader(reader io.Reader) bool {
 	_, ok := reader.(net.PacketConn)
 	return ok
@@ -5,20 +5,45 @@ import (
 	"time"
 )
 
+// DBService receives the records passed to InsertRecord.
+//
+// Deprecated: Implement the Sink of v2ray.com/core/features/record instead.
 type DBService interface {
 	InsertProxyLog(target, tag string, startTime, endTime int64, uploadBytes, downloadBytes int32, recordType, dnsQueryType int32, dnsRequest, dnsResponse string, dnsNumIPs int32)
 }
 
+// DefaultDBService is written to by the running Recorder, like its other sinks.
+// Without a Recorder, InsertRecord writes to it directly.
 var DefaultDBService DBService
 var dbAccess sync.Mutex
 
-func InsertRecord(record *ProxyRecord) {
-	record.EndTime = time.Now().UnixNano()
+var recordInserter func(*ProxyRecord)
 
+// RegisterRecordInserter sets the function that InsertRecord forwards records
+// to, which is Insert of the running Recorder. nil unsets it.
+func RegisterRecordInserter(insert func(*ProxyRecord)) {
 	dbAccess.Lock()
 	defer dbAccess.Unlock()
 
-	if DefaultDBService != nil {
+	recordInserter = insert
+}
+
+// InsertRecord passes the finished record to the running Recorder, or writes
+// it to DefaultDBService if there is none.
+//
+// Deprecated: Use Insert of the Recorder feature in v2ray.com/core/features/record.
+func InsertRecord(record *ProxyRecord) {
+	record.EndTime = time.Now().UnixNano()
+
+	dbAccess.Lock()
+	insert := recordInserter
+	if insert == nil && DefaultDBService != nil {
 		DefaultDBService.InsertProxyLog(record.Target, record.Tag, record.StartTime, record.EndTime, record.UploadBytes, record.DownloadBytes, record.RecordType, record.DNSQueryType, record.DNSRequest, record.DNSResponse, record.DNSNumIPs)
 	}
+	dbAccess.Unlock()
+
+	if insert != nil {
+		// The Recorder never blocks.
+		insert(record)
+	}
 }
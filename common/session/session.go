@@ -45,6 +45,8 @@ type Inbound struct {
 	// User is the user that authencates for the inbound. May be nil if the protocol allows anounymous traffic.
 	User     *protocol.MemoryUser
 	NoSource bool
+	// Conn is the underlying connection of the inbound. May be nil if the connection is not from a listener, such as UDP.
+	Conn net.Conn
 }
 
 // Outbound is the metadata of an outbound connection.
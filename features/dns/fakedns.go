@@ -0,0 +1,69 @@
+package dns
+
+import (
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/features"
+)
+
+// FakeDNSEngine is a V2Ray feature that hands out addresses from reserved IP pools
+// in place of real DNS answers, and maps them back to the domains.
+//
+// v2ray:api:beta
+type FakeDNSEngine interface {
+	features.Feature
+
+	// GetFakeIPForDomain returns the fake addresses of the domain, one from each pool.
+	GetFakeIPForDomain(domain string) []net.Address
+	// GetDomainFromFakeDNS returns the domain that the fake address was handed out for,
+	// or an empty string if the address is not mapped.
+	GetDomainFromFakeDNS(ip net.Address) string
+	// IsIPInIPPool returns whether the address belongs to one of the pools.
+	IsIPInIPPool(ip net.Address) bool
+}
+
+// FakeDNSLookup is an optional feature of Clients that answer some domains with fake addresses.
+// Fake addresses are only meant for the queries of DNS inbounds, so LookupIP and the like never
+// return them.
+//
+// v2ray:api:beta
+type FakeDNSLookup interface {
+	// LookupFakeIP returns the fake IPv4 and/or IPv6 addresses of the domain. It returns false
+	// if the domain is not answered with fake addresses, and should be resolved as usual.
+	LookupFakeIP(domain string, ipv4, ipv6 bool) ([]net.IP, bool)
+}
+
+// FakeDNSEngineType returns the type of FakeDNSEngine interface. Can be used for implementing common.HasType.
+//
+// v2ray:api:beta
+func FakeDNSEngineType() interface{} {
+	return (*FakeDNSEngine)(nil)
+}
+
+// NoopFakeDNSEngine is an implementation of FakeDNSEngine without any pool.
+type NoopFakeDNSEngine struct{}
+
+// Type implements common.HasType.
+func (NoopFakeDNSEngine) Type() interface{} {
+	return FakeDNSEngineType()
+}
+
+// Start implements common.Runnable.
+func (NoopFakeDNSEngine) Start() error { return nil }
+
+// Close implements common.Closable.
+func (NoopFakeDNSEngine) Close() error { return nil }
+
+// GetFakeIPForDomain implements FakeDNSEngine.
+func (NoopFakeDNSEngine) GetFakeIPForDomain(string) []net.Address {
+	return nil
+}
+
+// GetDomainFromFakeDNS implements FakeDNSEngine.
+func (NoopFakeDNSEngine) GetDomainFromFakeDNS(net.Address) string {
+	return ""
+}
+
+// IsIPInIPPool implements FakeDNSEngine.
+func (NoopFakeDNSEngine) IsIPInIPPool(net.Address) bool {
+	return false
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"time"
+
+	"v2ray.com/core/features"
+)
+
+// Observation is what happened to a single session that was dispatched through an outbound.
+type Observation struct {
+	// DialFailed is true if the outbound failed before any data came back, e.g. it could not reach its server.
+	DialFailed bool
+	// FirstByteTimeout is true if data was sent through the outbound, but nothing came back within the handshake timeout.
+	FirstByteTimeout bool
+	// FirstByte is the time between dispatching the session and receiving the first downlink byte. Zero if nothing was received.
+	FirstByte time.Duration
+	// DownlinkBytes is the number of bytes received through the outbound.
+	DownlinkBytes int64
+}
+
+// Failed returns true if the observation counts as a failure of the outbound.
+func (o Observation) Failed() bool {
+	return o.DialFailed || o.FirstByteTimeout
+}
+
+// Succeeded returns true if the observation counts as a success of the outbound. A session that neither failed nor received anything, e.g. a UDP or a cancelled one, tells nothing about the outbound.
+func (o Observation) Succeeded() bool {
+	return !o.Failed() && o.DownlinkBytes > 0
+}
+
+// Status is a snapshot of the health of an outbound.
+type Status struct {
+	// Healthy is false if the outbound is currently marked down.
+	Healthy bool
+	// ConsecutiveFailures is the number of failed sessions since the last successful one.
+	ConsecutiveFailures uint32
+	// Sessions is the total number of observed sessions.
+	Sessions uint64
+	// Failures is the total number of failed sessions.
+	Failures uint64
+	// AverageFirstByte is a moving average of the time to first byte of successful sessions.
+	AverageFirstByte time.Duration
+	// LastFailure is the time of the last failed session.
+	LastFailure time.Time
+}
+
+// Registry is a feature that keeps track of outbound health as observed from real traffic.
+//
+// v2ray:api:beta
+type Registry interface {
+	features.Feature
+
+	// Report records an observation on the outbound with the given tag.
+	Report(tag string, o Observation)
+	// IsHealthy returns false if the outbound with the given tag is marked down. Unknown outbounds are healthy.
+	IsHealthy(tag string) bool
+	// GetStatus returns the health status of the outbound with the given tag, or false if nothing was observed yet.
+	GetStatus(tag string) (Status, bool)
+}
+
+// RegistryType returns the type of Registry interface. Can be used to implement common.HasType.
+//
+// v2ray:api:beta
+func RegistryType() interface{} {
+	return (*Registry)(nil)
+}
+
+// NoopRegistry is an implementation of Registry, which doesn't track anything and considers every outbound healthy.
+type NoopRegistry struct{}
+
+// Type implements common.HasType.
+func (NoopRegistry) Type() interface{} {
+	return RegistryType()
+}
+
+// Report implements Registry.
+func (NoopRegistry) Report(string, Observation) {}
+
+// IsHealthy implements Registry.
+func (NoopRegistry) IsHealthy(string) bool {
+	return true
+}
+
+// GetStatus implements Registry.
+func (NoopRegistry) GetStatus(string) (Status, bool) {
+	return Status{}, false
+}
+
+// Start implements common.Runnable.
+func (NoopRegistry) Start() error { return nil }
+
+// Close implements common.Closable.
+func (NoopRegistry) Close() error { return nil }
@@ -0,0 +1,60 @@
+package record
+
+//go:generate errorgen
+
+import (
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/features"
+)
+
+// Sink receives finished records, such as a file or a database.
+//
+// v2ray:api:beta
+type Sink interface {
+	common.Closable
+
+	// Write writes a batch of records. Calls are never concurrent, and the records must not be modified.
+	Write(records []*session.ProxyRecord) error
+}
+
+// Recorder is a feature that passes finished records of connections and DNS queries to sinks.
+//
+// v2ray:api:beta
+type Recorder interface {
+	features.Feature
+
+	// Insert queues the record for all sinks. It never blocks, and the record may be dropped if the sinks don't keep up.
+	Insert(record *session.ProxyRecord)
+	// AddSink adds a sink, which receives the records inserted afterwards.
+	AddSink(sink Sink) error
+}
+
+// RecorderType returns the type of Recorder interface. Can be used to implement common.HasType.
+//
+// v2ray:api:beta
+func RecorderType() interface{} {
+	return (*Recorder)(nil)
+}
+
+// NoopRecorder is an implementation of Recorder, which drops all records.
+type NoopRecorder struct{}
+
+// Type implements common.HasType.
+func (NoopRecorder) Type() interface{} {
+	return RecorderType()
+}
+
+// Insert implements Recorder.
+func (NoopRecorder) Insert(*session.ProxyRecord) {}
+
+// AddSink implements Recorder.
+func (NoopRecorder) AddSink(Sink) error {
+	return newError("not implemented")
+}
+
+// Start implements common.Runnable.
+func (NoopRecorder) Start() error { return nil }
+
+// Close implements common.Closable.
+func (NoopRecorder) Close() error { return nil }
@@ -19,6 +19,12 @@ type Dispatcher interface {
 	Dispatch(ctx context.Context, dest net.Destination) (*transport.Link, error)
 }
 
+// SessionCounter is implemented by Dispatchers that keep track of the sessions going through each outbound.
+type SessionCounter interface {
+	// ActiveSessions returns the number of sessions currently going through the outbound with the given tag.
+	ActiveSessions(tag string) int
+}
+
 // DispatcherType returns the type of Dispatcher interface. Can be used to implement common.HasType.
 //
 // v2ray:api:stable
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
 	"v2ray.com/core/features"
 )
 
@@ -17,6 +18,51 @@ type Router interface {
 	PickRoute(ctx context.Context) (string, error)
 }
 
+// Action is what to do with a request.
+type Action int
+
+const (
+	// ActionRoute sends the request to an outbound.
+	ActionRoute Action = iota
+	// ActionRejectRST closes the inbound connection with a TCP RST.
+	ActionRejectRST
+	// ActionRejectHTTP responds with HTTP 403 to sniffed HTTP/1 requests, and closes the inbound connection.
+	ActionRejectHTTP
+	// ActionDrop discards the request silently, and keeps the inbound connection open until the client
+	// closes it or it idles out.
+	ActionDrop
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionRoute:
+		return "route"
+	case ActionRejectRST:
+		return "reject-rst"
+	case ActionRejectHTTP:
+		return "reject-http"
+	case ActionDrop:
+		return "drop"
+	default:
+		return "unknown"
+	}
+}
+
+// Route is the routing decision for a request.
+type Route struct {
+	Action Action
+	// OutboundTag is the tag of the outbound for ActionRoute. The default outbound is used if empty.
+	OutboundTag string
+	// Target is the destination to dispatch the request to instead of the original one. It is invalid if the destination is not rewritten.
+	Target net.Destination
+}
+
+// ActionRouter is implemented by Routers that decide on actions besides picking outbounds.
+type ActionRouter interface {
+	// PickRouteAction returns the routing decision for the given context. It returns common.ErrNoClue if no rule applies.
+	PickRouteAction(ctx context.Context) (*Route, error)
+}
+
 // RouterType return the type of Router interface. Can be used to implement common.HasType.
 //
 // v2ray:api:stable
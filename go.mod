@@ -4,6 +4,7 @@ require (
 	github.com/golang/mock v1.2.0
 	github.com/golang/protobuf v1.2.1-0.20190205222052-c823c79ea157
 	github.com/google/go-cmp v0.2.0
+	github.com/klauspost/cpuid/v2 v2.0.12 // indirect
 	github.com/mellow-io/go-tun2socks v1.0.8 // indirect
 	github.com/miekg/dns v1.1.22
 	github.com/oschwald/maxminddb-golang v1.5.0
@@ -13,7 +14,9 @@ require (
 	golang.org/x/net v0.0.0-20191021144547-ec77196f6094
 	golang.org/x/sync v0.0.0-20190423024810-112230192c58
 	google.golang.org/grpc v1.18.0
+	gopkg.in/yaml.v2 v2.4.0
 	h12.io/socks v1.0.0
+	lukechampine.com/blake3 v1.1.7
 )
 
 replace v2ray.com/core => github.com/mellow-io/v2ray-core v0.0.0-20200621073531-898a5935c60d
@@ -4,8 +4,11 @@ import (
 	"strings"
 
 	"v2ray.com/core/app/commander"
+	dnsservice "v2ray.com/core/app/dns/command"
 	loggerservice "v2ray.com/core/app/log/command"
 	handlerservice "v2ray.com/core/app/proxyman/command"
+	recordservice "v2ray.com/core/app/record/command"
+	routingservice "v2ray.com/core/app/router/command"
 	statsservice "v2ray.com/core/app/stats/command"
 	"v2ray.com/core/common/serial"
 )
@@ -29,6 +32,12 @@ func (c *ApiConfig) Build() (*commander.Config, error) {
 			services = append(services, serial.ToTypedMessage(&loggerservice.Config{}))
 		case "statsservice":
 			services = append(services, serial.ToTypedMessage(&statsservice.Config{}))
+		case "routingservice":
+			services = append(services, serial.ToTypedMessage(&routingservice.Config{}))
+		case "dnsservice":
+			services = append(services, serial.ToTypedMessage(&dnsservice.Config{}))
+		case "recordservice":
+			services = append(services, serial.ToTypedMessage(&recordservice.Config{}))
 		}
 	}
 
@@ -6,14 +6,19 @@ import (
 	"strings"
 
 	"v2ray.com/core/app/dns"
+	"v2ray.com/core/app/dns/fakedns"
 	"v2ray.com/core/app/router"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/transport/internet/tls"
 )
 
 type NameServerConfig struct {
-	Address *Address
-	Port    uint16
-	Domains []string
+	Address     *Address
+	Port        uint16
+	Domains     []string
+	TLSSettings *TLSConfig
+	ExpectedIPs StringList
+	RejectedIPs StringList
 }
 
 func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
@@ -25,14 +30,20 @@ func (c *NameServerConfig) UnmarshalJSON(data []byte) error {
 	}
 
 	var advanced struct {
-		Address *Address `json:"address"`
-		Port    uint16   `json:"port"`
-		Domains []string `json:"domains"`
+		Address     *Address   `json:"address"`
+		Port        uint16     `json:"port"`
+		Domains     []string   `json:"domains"`
+		TLSSettings *TLSConfig `json:"tlsSettings"`
+		ExpectedIPs StringList `json:"expectedIPs"`
+		RejectedIPs StringList `json:"rejectedIPs"`
 	}
 	if err := json.Unmarshal(data, &advanced); err == nil {
 		c.Address = advanced.Address
 		c.Port = advanced.Port
 		c.Domains = advanced.Domains
+		c.TLSSettings = advanced.TLSSettings
+		c.ExpectedIPs = advanced.ExpectedIPs
+		c.RejectedIPs = advanced.RejectedIPs
 		return nil
 	}
 
@@ -75,14 +86,42 @@ func (c *NameServerConfig) Build() (*dns.NameServer, error) {
 		}
 	}
 
-	return &dns.NameServer{
+	ns := &dns.NameServer{
 		Address: &net.Endpoint{
 			Network: net.Network_UDP,
 			Address: c.Address.Build(),
 			Port:    uint32(c.Port),
 		},
 		PrioritizedDomain: domains,
-	}, nil
+	}
+
+	if c.TLSSettings != nil {
+		ts, err := c.TLSSettings.Build()
+		if err != nil {
+			return nil, newError("failed to build TLS settings of name server").Base(err)
+		}
+		ns.TlsSettings = ts.(*tls.Config)
+	}
+
+	if len(c.ExpectedIPs) > 0 {
+		geoips, codes, err := toCidrList(c.ExpectedIPs)
+		if err != nil {
+			return nil, newError("invalid expected IPs").Base(err)
+		}
+		ns.ExpectedIps = geoips
+		ns.ExpectedCustomGeoip = codes
+	}
+
+	if len(c.RejectedIPs) > 0 {
+		geoips, codes, err := toCidrList(c.RejectedIPs)
+		if err != nil {
+			return nil, newError("invalid rejected IPs").Base(err)
+		}
+		ns.RejectedIps = geoips
+		ns.RejectedCustomGeoip = codes
+	}
+
+	return ns, nil
 }
 
 var typeMap = map[router.Domain_Type]dns.DomainMatchingType{
@@ -92,12 +131,36 @@ var typeMap = map[router.Domain_Type]dns.DomainMatchingType{
 	router.Domain_Regex:  dns.DomainMatchingType_Regex,
 }
 
+// DNSCacheConfig is the JSON config of the DNS cache.
+type DNSCacheConfig struct {
+	ServeStale   bool   `json:"serveStale"`
+	StaleTTL     uint32 `json:"staleTtl"`
+	Prefetch     bool   `json:"prefetch"`
+	PrefetchHits uint32 `json:"prefetchHits"`
+	MaxEntries   uint32 `json:"maxEntries"`
+	SnapshotPath string `json:"snapshotPath"`
+}
+
+// Build converts the config to its protobuf form.
+func (c *DNSCacheConfig) Build() *dns.CacheConfig {
+	return &dns.CacheConfig{
+		ServeStale:   c.ServeStale,
+		StaleTtl:     c.StaleTTL,
+		Prefetch:     c.Prefetch,
+		PrefetchHits: c.PrefetchHits,
+		MaxEntries:   c.MaxEntries,
+		SnapshotPath: c.SnapshotPath,
+	}
+}
+
 // DnsConfig is a JSON serializable object for dns.Config.
 type DnsConfig struct {
 	Servers  []*NameServerConfig `json:"servers"`
 	Hosts    map[string]*Address `json:"hosts"`
 	ClientIP *Address            `json:"clientIp"`
 	Tag      string              `json:"tag"`
+	Cache    *DNSCacheConfig     `json:"cache"`
+	Strategy string              `json:"queryStrategy"`
 }
 
 func getHostMapping(addr *Address) *dns.Config_HostMapping {
@@ -125,6 +188,21 @@ func (c *DnsConfig) Build() (*dns.Config, error) {
 		config.ClientIp = []byte(c.ClientIP.IP())
 	}
 
+	if c.Cache != nil {
+		config.Cache = c.Cache.Build()
+	}
+
+	switch strings.ToLower(c.Strategy) {
+	case "", "sequential":
+		config.QueryStrategy = dns.QueryStrategy_Sequential
+	case "parallel":
+		config.QueryStrategy = dns.QueryStrategy_Parallel
+	case "fastest":
+		config.QueryStrategy = dns.QueryStrategy_Fastest
+	default:
+		return nil, newError("unknown query strategy: ", c.Strategy)
+	}
+
 	for _, server := range c.Servers {
 		ns, err := server.Build()
 		if err != nil {
@@ -192,3 +270,48 @@ func (c *DnsConfig) Build() (*dns.Config, error) {
 
 	return config, nil
 }
+
+type FakeDNSPoolConfig struct {
+	IPPool  string `json:"ipPool"`
+	LRUSize int64  `json:"poolSize"`
+}
+
+// FakeDNSConfig is a JSON serializable object for fakedns.Config. It is either
+// a single pool or a list of pools.
+type FakeDNSConfig struct {
+	Pools []*FakeDNSPoolConfig
+}
+
+func (c *FakeDNSConfig) UnmarshalJSON(data []byte) error {
+	var pool FakeDNSPoolConfig
+	if err := json.Unmarshal(data, &pool); err == nil {
+		c.Pools = []*FakeDNSPoolConfig{&pool}
+		return nil
+	}
+
+	var pools []*FakeDNSPoolConfig
+	if err := json.Unmarshal(data, &pools); err == nil {
+		c.Pools = pools
+		return nil
+	}
+
+	return newError("invalid fakedns config: ", string(data))
+}
+
+// Build implements Buildable
+func (c *FakeDNSConfig) Build() (*fakedns.Config, error) {
+	config := new(fakedns.Config)
+	for _, pool := range c.Pools {
+		if _, _, err := net.ParseCIDR(pool.IPPool); err != nil {
+			return nil, newError("invalid fake DNS pool: ", pool.IPPool).Base(err)
+		}
+		config.Pools = append(config.Pools, &fakedns.FakeDnsPool{
+			IpPool:  pool.IPPool,
+			LruSize: pool.LRUSize,
+		})
+	}
+	if len(config.Pools) == 0 {
+		return nil, newError("no fake DNS pool")
+	}
+	return config, nil
+}
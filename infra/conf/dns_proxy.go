@@ -1,15 +1,46 @@
 package conf
 
 import (
+	"strings"
+
 	"github.com/golang/protobuf/proto"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/proxy/dns"
 )
 
+type DNSBlockListConfig struct {
+	Domains  []string `json:"domains"`
+	Response string   `json:"response"`
+}
+
+// Build builds the block list of the DNS outbound.
+func (c *DNSBlockListConfig) Build() (*dns.BlockList, error) {
+	blockList := new(dns.BlockList)
+	for _, d := range c.Domains {
+		rules, err := parseDomainRule(d)
+		if err != nil {
+			return nil, newError("invalid domain rule: ", d).Base(err)
+		}
+		blockList.Domain = append(blockList.Domain, rules...)
+	}
+
+	switch strings.ToLower(c.Response) {
+	case "", "nxdomain":
+		blockList.Response = dns.BlockList_NXDomain
+	case "zeroip", "0.0.0.0":
+		blockList.Response = dns.BlockList_ZeroIP
+	default:
+		return nil, newError("unknown block response: ", c.Response)
+	}
+	return blockList, nil
+}
+
 type DnsOutboundConfig struct {
-	Network Network  `json:"network"`
-	Address *Address `json:"address"`
-	Port    uint16   `json:"port"`
+	Network    Network             `json:"network"`
+	Address    *Address            `json:"address"`
+	Port       uint16              `json:"port"`
+	NonIPQuery string              `json:"nonIPQuery"`
+	BlockList  *DNSBlockListConfig `json:"blockList"`
 }
 
 func (c *DnsOutboundConfig) Build() (proto.Message, error) {
@@ -22,5 +53,77 @@ func (c *DnsOutboundConfig) Build() (proto.Message, error) {
 	if c.Address != nil {
 		config.Server.Address = c.Address.Build()
 	}
+
+	switch strings.ToLower(c.NonIPQuery) {
+	case "", "forward":
+		config.NonIpQuery = dns.Config_Forward
+	case "nodata":
+		config.NonIpQuery = dns.Config_NoData
+	case "nxdomain":
+		config.NonIpQuery = dns.Config_NXDomain
+	case "refused":
+		config.NonIpQuery = dns.Config_Refused
+	default:
+		return nil, newError("unknown policy for non-IP queries: ", c.NonIPQuery)
+	}
+
+	if c.BlockList != nil {
+		blockList, err := c.BlockList.Build()
+		if err != nil {
+			return nil, newError("failed to build block list").Base(err)
+		}
+		config.BlockList = blockList
+	}
+	return config, nil
+}
+
+type DNSUpstreamConfig struct {
+	Network Network  `json:"network"`
+	Address *Address `json:"address"`
+	Port    uint16   `json:"port"`
+	Domains []string `json:"domains"`
+}
+
+// Build builds the upstream of the DNS inbound.
+func (c *DNSUpstreamConfig) Build() (*dns.Upstream, error) {
+	if c.Address == nil {
+		return nil, newError("upstream address is not specified")
+	}
+
+	upstream := &dns.Upstream{
+		Address: &net.Endpoint{
+			Network: c.Network.Build(),
+			Address: c.Address.Build(),
+			Port:    uint32(c.Port),
+		},
+	}
+	for _, d := range c.Domains {
+		rules, err := parseDomainRule(d)
+		if err != nil {
+			return nil, newError("invalid domain rule: ", d).Base(err)
+		}
+		upstream.Domain = append(upstream.Domain, rules...)
+	}
+	return upstream, nil
+}
+
+type DnsInboundConfig struct {
+	Upstreams []*DNSUpstreamConfig `json:"upstreams"`
+	TTL       uint32               `json:"ttl"`
+	UserLevel uint32               `json:"userLevel"`
+}
+
+func (c *DnsInboundConfig) Build() (proto.Message, error) {
+	config := &dns.ServerConfig{
+		Ttl:       c.TTL,
+		UserLevel: c.UserLevel,
+	}
+	for _, u := range c.Upstreams {
+		upstream, err := u.Build()
+		if err != nil {
+			return nil, newError("failed to build DNS upstream").Base(err)
+		}
+		config.Upstream = append(config.Upstream, upstream)
+	}
 	return config, nil
 }
@@ -3,6 +3,7 @@ package conf_test
 import (
 	"testing"
 
+	"v2ray.com/core/app/router"
 	"v2ray.com/core/common/net"
 	. "v2ray.com/core/infra/conf"
 	"v2ray.com/core/proxy/dns"
@@ -29,5 +30,83 @@ func TestDnsProxyConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Input: `{
+				"nonIPQuery": "refused",
+				"blockList": {
+					"domains": ["domain:ads.example.com", "tracker"],
+					"response": "zeroip"
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &dns.Config{
+				Server:     &net.Endpoint{},
+				NonIpQuery: dns.Config_Refused,
+				BlockList: &dns.BlockList{
+					Domain: []*router.Domain{
+						{
+							Type:  router.Domain_Domain,
+							Value: "ads.example.com",
+						},
+						{
+							Type:  router.Domain_Plain,
+							Value: "tracker",
+						},
+					},
+					Response: dns.BlockList_ZeroIP,
+				},
+			},
+		},
+	})
+}
+
+func TestDnsInboundConfig(t *testing.T) {
+	creator := func() Buildable {
+		return new(DnsInboundConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"upstreams": [
+					{
+						"address": "114.114.114.114",
+						"port": 53,
+						"domains": ["domain:example.cn"]
+					},
+					{
+						"address": "8.8.8.8",
+						"port": 53,
+						"network": "tcp"
+					}
+				],
+				"ttl": 300
+			}`,
+			Parser: loadJSON(creator),
+			Output: &dns.ServerConfig{
+				Upstream: []*dns.Upstream{
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.IPAddress([]byte{114, 114, 114, 114})),
+							Port:    53,
+						},
+						Domain: []*router.Domain{
+							{
+								Type:  router.Domain_Domain,
+								Value: "example.cn",
+							},
+						},
+					},
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_TCP,
+							Address: net.NewIPOrDomain(net.IPAddress([]byte{8, 8, 8, 8})),
+							Port:    53,
+						},
+					},
+				},
+				Ttl: 300,
+			},
+		},
 	})
 }
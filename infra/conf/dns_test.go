@@ -8,12 +8,14 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"v2ray.com/core/app/dns"
+	"v2ray.com/core/app/dns/fakedns"
 	"v2ray.com/core/app/router"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/platform"
 	"v2ray.com/core/common/platform/filesystem"
 	. "v2ray.com/core/infra/conf"
+	"v2ray.com/core/transport/internet/tls"
 )
 
 func init() {
@@ -126,5 +128,138 @@ func TestDnsConfigParsing(t *testing.T) {
 				ClientIp: []byte{10, 0, 0, 1},
 			},
 		},
+		{
+			Input: `{
+				"servers": [
+					"https://dns.google/dns-query",
+					{
+						"address": "tls://1.1.1.1",
+						"tlsSettings": {
+							"serverName": "cloudflare-dns.com"
+						}
+					},
+					{
+						"address": "114.114.114.114",
+						"expectedIPs": ["geoip:cn", "10.0.0.0/8"],
+						"rejectedIPs": ["10.1.0.0/16"]
+					}
+				]
+			}`,
+			Parser: parserCreator(),
+			Output: &dns.Config{
+				NameServer: []*dns.NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.DomainAddress("https://dns.google/dns-query")),
+							Network: net.Network_UDP,
+							Port:    53,
+						},
+					},
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.DomainAddress("tls://1.1.1.1")),
+							Network: net.Network_UDP,
+						},
+						TlsSettings: &tls.Config{
+							ServerName: "cloudflare-dns.com",
+						},
+					},
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.IPAddress([]byte{114, 114, 114, 114})),
+							Network: net.Network_UDP,
+						},
+						ExpectedIps: []*router.GeoIP{
+							{
+								Cidr: []*router.CIDR{{Ip: []byte{10, 0, 0, 0}, Prefix: 8}},
+							},
+						},
+						ExpectedCustomGeoip: []string{"CN"},
+						RejectedIps: []*router.GeoIP{
+							{
+								Cidr: []*router.CIDR{{Ip: []byte{10, 1, 0, 0}, Prefix: 16}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Input: `{
+				"servers": ["8.8.8.8"],
+				"queryStrategy": "Parallel",
+				"cache": {
+					"serveStale": true,
+					"staleTtl": 3600,
+					"prefetch": true,
+					"snapshotPath": "/var/lib/v2ray/dns.cache"
+				}
+			}`,
+			Parser: parserCreator(),
+			Output: &dns.Config{
+				NameServer: []*dns.NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.IPAddress([]byte{8, 8, 8, 8})),
+							Network: net.Network_UDP,
+							Port:    53,
+						},
+					},
+				},
+				QueryStrategy: dns.QueryStrategy_Parallel,
+				Cache: &dns.CacheConfig{
+					ServeStale:   true,
+					StaleTtl:     3600,
+					Prefetch:     true,
+					SnapshotPath: "/var/lib/v2ray/dns.cache",
+				},
+			},
+		},
+	})
+}
+
+func TestFakeDNSConfig(t *testing.T) {
+	parser := func(s string) (proto.Message, error) {
+		config := new(FakeDNSConfig)
+		if err := json.Unmarshal([]byte(s), config); err != nil {
+			return nil, err
+		}
+		return config.Build()
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"ipPool": "198.18.0.0/15",
+				"poolSize": 10000
+			}`,
+			Parser: parser,
+			Output: &fakedns.Config{
+				Pools: []*fakedns.FakeDnsPool{
+					{
+						IpPool:  "198.18.0.0/15",
+						LruSize: 10000,
+					},
+				},
+			},
+		},
+		{
+			Input: `[
+				{"ipPool": "198.18.0.0/15"},
+				{"ipPool": "fc00::/18", "poolSize": 65535}
+			]`,
+			Parser: parser,
+			Output: &fakedns.Config{
+				Pools: []*fakedns.FakeDnsPool{
+					{
+						IpPool: "198.18.0.0/15",
+					},
+					{
+						IpPool:  "fc00::/18",
+						LruSize: 65535,
+					},
+				},
+			},
+		},
 	})
 }
@@ -0,0 +1,51 @@
+package conf
+
+import (
+	"strings"
+
+	"v2ray.com/core/app/record"
+)
+
+type RecordSinkConfig struct {
+	Type     string `json:"type"`
+	Path     string `json:"path"`
+	Capacity uint32 `json:"capacity"`
+}
+
+func (c *RecordSinkConfig) Build() (*record.SinkConfig, error) {
+	switch strings.ToLower(c.Type) {
+	case "file":
+		return &record.SinkConfig{
+			Sink: &record.SinkConfig_File{
+				File: &record.FileSink{Path: c.Path},
+			},
+		}, nil
+	case "memory":
+		return &record.SinkConfig{
+			Sink: &record.SinkConfig_Memory{
+				Memory: &record.MemorySink{Capacity: c.Capacity},
+			},
+		}, nil
+	default:
+		return nil, newError("unknown record sink type: ", c.Type)
+	}
+}
+
+type RecordConfig struct {
+	Sinks      []*RecordSinkConfig `json:"sinks"`
+	BufferSize uint32              `json:"bufferSize"`
+}
+
+func (c *RecordConfig) Build() (*record.Config, error) {
+	config := &record.Config{
+		BufferSize: c.BufferSize,
+	}
+	for _, s := range c.Sinks {
+		sink, err := s.Build()
+		if err != nil {
+			return nil, newError("failed to build record sink").Base(err)
+		}
+		config.Sink = append(config.Sink, sink)
+	}
+	return config, nil
+}
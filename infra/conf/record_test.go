@@ -0,0 +1,54 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"v2ray.com/core/app/record"
+	. "v2ray.com/core/infra/conf"
+)
+
+func TestRecordConfig(t *testing.T) {
+	parser := func(s string) (proto.Message, error) {
+		config := new(RecordConfig)
+		if err := json.Unmarshal([]byte(s), config); err != nil {
+			return nil, err
+		}
+		return config.Build()
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"bufferSize": 256,
+				"sinks": [
+					{"type": "file", "path": "/var/log/v2ray/records.json"},
+					{"type": "memory", "capacity": 100}
+				]
+			}`,
+			Parser: parser,
+			Output: &record.Config{
+				BufferSize: 256,
+				Sink: []*record.SinkConfig{
+					{
+						Sink: &record.SinkConfig_File{
+							File: &record.FileSink{Path: "/var/log/v2ray/records.json"},
+						},
+					},
+					{
+						Sink: &record.SinkConfig_Memory{
+							Memory: &record.MemorySink{Capacity: 100},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	// No database driver is built in, so database sinks are not configurable in JSON.
+	if _, err := parser(`{"sinks": [{"type": "database", "path": "/var/lib/v2ray/records.db"}]}`); err == nil {
+		t.Error("expect an error for a database sink")
+	}
+}
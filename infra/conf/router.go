@@ -2,9 +2,12 @@ package conf
 
 import (
 	"encoding/json"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"v2ray.com/core/app/measure"
 	"v2ray.com/core/app/router"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/platform/filesystem"
@@ -35,11 +38,128 @@ type RouterRulesConfig struct {
 	DomainStrategy string            `json:"domainStrategy"`
 }
 
-type BalancingRule struct {
-	Tag       string     `json:"tag"`
-	Selectors StringList `json:"selector"`
+type ProbeConfig struct {
+	Type           string `json:"type"`
+	Target         string `json:"target"`
+	URL            string `json:"url"`
+	Content        string `json:"content"`
+	ExpectedStatus uint32 `json:"expectedStatus"`
+	Domain         string `json:"domain"`
+
+	TotalMeasures     uint32 `json:"totalMeasures"`
+	Interval          uint32 `json:"interval"`
+	Delay             uint32 `json:"delay"`
+	Timeout           uint32 `json:"timeout"`
+	Tolerance         uint32 `json:"tolerance"`
+	FailureThreshold  uint32 `json:"failureThreshold"`
+	RecoveryThreshold uint32 `json:"recoveryThreshold"`
+}
+
+func parseProbeTarget(target string, defaultPort uint32) (*net.IPOrDomain, uint32, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		if defaultPort == 0 {
+			return nil, 0, newError("invalid probe target: ", target).Base(err)
+		}
+		host, portStr = target, strconv.Itoa(int(defaultPort))
+	}
+	if len(host) == 0 {
+		return nil, 0, newError("empty host in probe target: ", target)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		return nil, 0, newError("invalid port in probe target: ", target)
+	}
+	return net.NewIPOrDomain(net.ParseAddress(host)), uint32(port), nil
+}
+
+func (c *ProbeConfig) Build() (*measure.ProbeConfig, error) {
+	config := &measure.ProbeConfig{
+		Content:           []byte(c.Content),
+		Domain:            c.Domain,
+		ExpectedStatus:    c.ExpectedStatus,
+		TotalMeasures:     c.TotalMeasures,
+		Interval:          c.Interval,
+		Delay:             c.Delay,
+		Timeout:           c.Timeout,
+		Tolerance:         c.Tolerance,
+		FailureThreshold:  c.FailureThreshold,
+		RecoveryThreshold: c.RecoveryThreshold,
+	}
+
+	var err error
+	switch strings.ToLower(c.Type) {
+	case "", "tcp":
+		config.Kind = measure.ProbeConfig_TCP
+		config.Address, config.Port, err = parseProbeTarget(c.Target, 0)
+	case "tls":
+		config.Kind = measure.ProbeConfig_TLS
+		config.Address, config.Port, err = parseProbeTarget(c.Target, 443)
+	case "http":
+		config.Kind = measure.ProbeConfig_HTTP
+		u, perr := url.Parse(c.URL)
+		if perr != nil {
+			return nil, newError("invalid probe url: ", c.URL).Base(perr)
+		}
+		var defaultPort uint32
+		switch u.Scheme {
+		case "http":
+			defaultPort = 80
+		case "https":
+			defaultPort = 443
+			config.Tls = true
+		default:
+			return nil, newError("unsupported scheme in probe url: ", c.URL)
+		}
+		config.Path = u.RequestURI()
+		config.Address, config.Port, err = parseProbeTarget(u.Host, defaultPort)
+	case "dns":
+		config.Kind = measure.ProbeConfig_DNS
+		config.Address, config.Port, err = parseProbeTarget(c.Target, 53)
+	default:
+		return nil, newError("unknown probe type: ", c.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	Strategy      string `json:"strategy"`
+	if config.TotalMeasures == 0 {
+		config.TotalMeasures = 3
+	}
+	if config.Interval == 0 {
+		config.Interval = 120
+	}
+	if config.Delay == 0 {
+		config.Delay = 1
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 5
+	}
+	if config.Tolerance == 0 {
+		config.Tolerance = 300
+	}
+	if config.FailureThreshold == 0 {
+		config.FailureThreshold = 3
+	}
+	if config.RecoveryThreshold == 0 {
+		config.RecoveryThreshold = 2
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+type BalancingRule struct {
+	Tag       string            `json:"tag"`
+	Selectors StringList        `json:"selector"`
+	Strategy  string            `json:"strategy"`
+	HashKey   string            `json:"hashKey"`
+	Weights   map[string]uint32 `json:"weights"`
+	Probe     *ProbeConfig      `json:"probe"`
+
+	// Deprecated. Use probe.
 	TotalMeasures uint32 `json:"totalMeasures"`
 	Interval      uint32 `json:"interval"`
 	Delay         uint32 `json:"delay"`
@@ -47,6 +167,10 @@ type BalancingRule struct {
 	Tolerance     uint32 `json:"tolerance"`
 	ProbeTarget   string `json:"probeTarget"`
 	ProbeContent  string `json:"probeContent"`
+
+	// Deprecated. Use failureThreshold and recoveryThreshold in probe.
+	FailureThreshold  uint32 `json:"failureThreshold"`
+	RecoveryThreshold uint32 `json:"recoveryThreshold"`
 }
 
 func splitCustomGeoipRules(rule *router.RoutingRule) []*router.RoutingRule {
@@ -75,51 +199,158 @@ func (r *BalancingRule) Build() (*router.BalancingRule, error) {
 	if len(r.Selectors) == 0 {
 		return nil, newError("empty selector list")
 	}
-	bs := router.BalancingRule_Random
-	if r.Strategy == "latency" {
+	var bs router.BalancingRule_BalancingStrategy
+	switch strings.ToLower(r.Strategy) {
+	case "", "random":
+		bs = router.BalancingRule_Random
+	case "latency":
 		bs = router.BalancingRule_Latency
+	case "failover":
+		bs = router.BalancingRule_Failover
+	case "consistenthash":
+		bs = router.BalancingRule_ConsistentHash
+	case "weightedroundrobin", "roundrobin":
+		bs = router.BalancingRule_WeightedRoundRobin
+	case "leastconnections", "leastconn":
+		bs = router.BalancingRule_LeastConnections
+	default:
+		return nil, newError("unknown balancing strategy: ", r.Strategy)
+	}
+
+	var hashKey router.BalancingRule_HashKey
+	switch strings.ToLower(r.HashKey) {
+	case "", "source", "sourceip":
+		hashKey = router.BalancingRule_SourceIP
+	case "domain", "destination":
+		hashKey = router.BalancingRule_Domain
+	case "user", "email":
+		hashKey = router.BalancingRule_UserEmail
+	default:
+		return nil, newError("unknown hash key: ", r.HashKey)
 	}
-	totalMeasures := r.TotalMeasures
-	interval := r.Interval
-	delay := r.Delay
-	timeout := r.Timeout
-	tolerance := r.Tolerance
-	probeTarget := r.ProbeTarget
-	probeContent := r.ProbeContent
-	if totalMeasures == 0 {
-		totalMeasures = 3
+
+	rule := &router.BalancingRule{
+		Tag:               r.Tag,
+		OutboundSelector:  []string(r.Selectors),
+		BalancingStrategy: bs,
+		HashKey:           hashKey,
+		Weight:            r.Weights,
 	}
-	if interval == 0 {
-		interval = 120
+	// Only Latency and Failover strategies probe outbounds.
+	if bs == router.BalancingRule_Latency || bs == router.BalancingRule_Failover {
+		probe, err := r.buildProbe()
+		if err != nil {
+			return nil, newError("invalid probe in balancer ", r.Tag).Base(err)
+		}
+		rule.Probe = probe
 	}
-	if delay == 0 {
-		delay = 1
+	return rule, nil
+}
+
+func (r *BalancingRule) buildProbe() (*measure.ProbeConfig, error) {
+	var probe ProbeConfig
+	if r.Probe != nil {
+		probe = *r.Probe
+	} else {
+		probe = ProbeConfig{
+			Type:          "tls",
+			Target:        "www.google.com:443",
+			Content:       r.ProbeContent,
+			TotalMeasures: r.TotalMeasures,
+			Interval:      r.Interval,
+			Delay:         r.Delay,
+			Timeout:       r.Timeout,
+			Tolerance:     r.Tolerance,
+		}
+		if len(r.ProbeTarget) > 0 {
+			parts := strings.SplitN(r.ProbeTarget, ":", 2)
+			if len(parts) != 2 {
+				return nil, newError("invalid probeTarget: ", r.ProbeTarget, ", expecting proto:host:port")
+			}
+			probe.Type, probe.Target = parts[0], parts[1]
+		}
+		if len(probe.Content) == 0 {
+			probe.Content = "HEAD / HTTP/1.1\r\n\r\n"
+		}
 	}
-	if timeout == 0 {
-		timeout = 5
+
+	if r.FailureThreshold > 0 {
+		newError("failureThreshold in balancer ", r.Tag, " is deprecated, use failureThreshold in probe instead").AtWarning().WriteToLog()
+		if probe.FailureThreshold == 0 {
+			probe.FailureThreshold = r.FailureThreshold
+		}
 	}
-	if tolerance == 0 {
-		tolerance = 300
+	if r.RecoveryThreshold > 0 {
+		newError("recoveryThreshold in balancer ", r.Tag, " is deprecated, use recoveryThreshold in probe instead").AtWarning().WriteToLog()
+		if probe.RecoveryThreshold == 0 {
+			probe.RecoveryThreshold = r.RecoveryThreshold
+		}
 	}
-	if len(probeTarget) == 0 {
-		probeTarget = "tls:www.google.com:443"
+	return probe.Build()
+}
+
+type RuleProvider struct {
+	Tag      string `json:"tag"`
+	Behavior string `json:"behavior"`
+	Format   string `json:"format"`
+	Path     string `json:"path"`
+	Interval uint32 `json:"interval"`
+	Code     string `json:"code"`
+}
+
+func (p *RuleProvider) Build() (*router.RuleProvider, error) {
+	if len(p.Tag) == 0 {
+		return nil, newError("empty rule provider tag")
 	}
-	if len(probeContent) == 0 {
-		probeContent = "HEAD / HTTP/1.1\r\n\r\n"
+	if len(p.Path) == 0 {
+		return nil, newError("empty path of rule provider ", p.Tag)
 	}
 
-	return &router.BalancingRule{
-		Tag:               r.Tag,
-		OutboundSelector:  []string(r.Selectors),
-		BalancingStrategy: bs,
-		TotalMeasures:     totalMeasures,
-		Interval:          interval,
-		Delay:             delay,
-		Timeout:           timeout,
-		Tolerance:         tolerance,
-		ProbeTarget:       probeTarget,
-		ProbeContent:      probeContent,
-	}, nil
+	config := &router.RuleProvider{
+		Tag:      p.Tag,
+		Path:     p.Path,
+		Interval: p.Interval,
+		Code:     p.Code,
+	}
+
+	switch strings.ToLower(p.Behavior) {
+	case "", "domain":
+		config.Behavior = router.RuleProvider_Domain
+	case "ipcidr", "ip":
+		config.Behavior = router.RuleProvider_IPCIDR
+	case "classical":
+		config.Behavior = router.RuleProvider_Classical
+	default:
+		return nil, newError("unknown behavior of rule provider ", p.Tag, ": ", p.Behavior)
+	}
+
+	format := strings.ToLower(p.Format)
+	if len(format) == 0 {
+		// Guess the format from the file extension.
+		switch {
+		case strings.HasSuffix(p.Path, ".yaml"), strings.HasSuffix(p.Path, ".yml"):
+			format = "yaml"
+		case strings.HasSuffix(p.Path, ".dat"):
+			format = "geosite"
+		default:
+			format = "text"
+		}
+	}
+	switch format {
+	case "text":
+		config.Format = router.RuleProvider_Text
+	case "yaml":
+		config.Format = router.RuleProvider_YAML
+	case "geosite":
+		config.Format = router.RuleProvider_GeoSiteList
+		if len(p.Code) == 0 {
+			return nil, newError("code is not set for GeoSiteList in rule provider ", p.Tag)
+		}
+	default:
+		return nil, newError("unknown format of rule provider ", p.Tag, ": ", p.Format)
+	}
+
+	return config, nil
 }
 
 type RouterConfig struct {
@@ -127,6 +358,7 @@ type RouterConfig struct {
 	RuleList       []json.RawMessage  `json:"rules"`
 	DomainStrategy *string            `json:"domainStrategy"`
 	Balancers      []*BalancingRule   `json:"balancers"`
+	RuleProviders  []*RuleProvider    `json:"ruleProviders"`
 }
 
 func (c *RouterConfig) getDomainStrategy() router.Config_DomainStrategy {
@@ -176,6 +408,13 @@ func (c *RouterConfig) Build() (*router.Config, error) {
 		}
 		config.BalancingRule = append(config.BalancingRule, balancer)
 	}
+	for _, rawProvider := range c.RuleProviders {
+		provider, err := rawProvider.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.RuleProvider = append(config.RuleProvider, provider)
+	}
 	return config, nil
 }
 
@@ -457,6 +696,151 @@ func toCidrList(ips StringList) ([]*router.GeoIP, []string, error) {
 	return geoipList, customGeoipList, nil
 }
 
+var weekdayNames = map[string]uint32{
+	"sun": 0, "sunday": 0,
+	"mon": 1, "monday": 1,
+	"tue": 2, "tuesday": 2,
+	"wed": 3, "wednesday": 3,
+	"thu": 4, "thursday": 4,
+	"fri": 5, "friday": 5,
+	"sat": 6, "saturday": 6,
+}
+
+func parseWeekday(s string) (uint32, error) {
+	d, found := weekdayNames[strings.ToLower(s)]
+	if !found {
+		return 0, newError("invalid weekday: ", s)
+	}
+	return d, nil
+}
+
+// parseWeekdays parses a comma separated list of weekdays and weekday ranges, such as "Mon-Fri,Sun".
+func parseWeekdays(s string) ([]uint32, error) {
+	var weekdays []uint32
+	for _, item := range strings.Split(s, ",") {
+		switch strings.ToLower(item) {
+		case "weekdays":
+			weekdays = append(weekdays, 1, 2, 3, 4, 5)
+			continue
+		case "weekends":
+			weekdays = append(weekdays, 6, 0)
+			continue
+		}
+		parts := strings.SplitN(item, "-", 2)
+		from, err := parseWeekday(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if len(parts) == 2 {
+			if to, err = parseWeekday(parts[1]); err != nil {
+				return nil, err
+			}
+		}
+		// Ranges may wrap around the week, such as "Fri-Mon".
+		for d := from; ; d = (d + 1) % 7 {
+			weekdays = append(weekdays, d)
+			if d == to {
+				break
+			}
+		}
+	}
+	return weekdays, nil
+}
+
+// parseTimeOfDay parses "hh:mm" or "hh:mm:ss" into seconds after midnight.
+func parseTimeOfDay(s string) (uint32, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return 0, newError("invalid time: ", s)
+	}
+	var seconds uint32
+	for i, limit := range []uint64{24, 60, 60}[:len(parts)] {
+		v, err := strconv.ParseUint(parts[i], 10, 8)
+		if err != nil || v >= limit && !(i == 0 && v == 24) {
+			return 0, newError("invalid time: ", s)
+		}
+		seconds = seconds*60 + uint32(v)
+	}
+	if len(parts) == 2 {
+		seconds *= 60
+	}
+	if seconds > 24*60*60 {
+		return 0, newError("invalid time: ", s)
+	}
+	return seconds, nil
+}
+
+// parseSchedule parses a schedule in the form of "[weekdays] start-end [timezone]", such as "Mon-Fri 09:00-18:00 Asia/Shanghai".
+func parseSchedule(s string) (*router.Schedule, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 3 {
+		return nil, newError("invalid schedule: ", s)
+	}
+
+	schedule := new(router.Schedule)
+	if !strings.Contains(fields[0], ":") {
+		weekdays, err := parseWeekdays(fields[0])
+		if err != nil {
+			return nil, newError("invalid schedule: ", s).Base(err)
+		}
+		schedule.Weekday = weekdays
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return nil, newError("time range is not set in schedule: ", s)
+	}
+
+	times := strings.SplitN(fields[0], "-", 2)
+	if len(times) != 2 {
+		return nil, newError("invalid time range in schedule: ", s)
+	}
+	var err error
+	if schedule.Start, err = parseTimeOfDay(times[0]); err != nil {
+		return nil, newError("invalid schedule: ", s).Base(err)
+	}
+	if schedule.End, err = parseTimeOfDay(times[1]); err != nil {
+		return nil, newError("invalid schedule: ", s).Base(err)
+	}
+	if schedule.Start == 24*60*60 {
+		return nil, newError("invalid start time in schedule: ", s)
+	}
+
+	if len(fields) == 2 {
+		if _, err := time.LoadLocation(fields[1]); err != nil {
+			return nil, newError("unknown time zone in schedule: ", s).Base(err)
+		}
+		schedule.Timezone = fields[1]
+	}
+
+	return schedule, nil
+}
+
+// parseRewrite parses a rewrite destination in the form of "host:port", "host" or ":port".
+func parseRewrite(s string) (*net.IPOrDomain, uint32, error) {
+	host, portStr := s, ""
+	if h, p, err := net.SplitHostPort(s); err == nil {
+		host, portStr = h, p
+	}
+
+	var address *net.IPOrDomain
+	if len(host) > 0 {
+		address = net.NewIPOrDomain(net.ParseAddress(host))
+	}
+	var port uint32
+	if len(portStr) > 0 {
+		p, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil || p == 0 {
+			return nil, 0, newError("invalid port in rewrite: ", s)
+		}
+		port = uint32(p)
+	}
+	if address == nil && port == 0 {
+		return nil, 0, newError("invalid rewrite: ", s)
+	}
+	return address, port, nil
+}
+
 func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	type RawFieldRule struct {
 		RouterRule
@@ -470,6 +854,10 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		Protocols   *StringList  `json:"protocol"`
 		Attributes  string       `json:"attrs"`
 		Application *StringList  `json:"app"`
+		Schedule    *StringList  `json:"schedule"`
+		Providers   *StringList  `json:"ruleProvider"`
+		Action      string       `json:"action"`
+		Rewrite     string       `json:"rewrite"`
 	}
 	rawFieldRule := new(RawFieldRule)
 	err := json.Unmarshal(msg, rawFieldRule)
@@ -478,6 +866,31 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 	}
 
 	rule := new(router.RoutingRule)
+	switch strings.ToLower(rawFieldRule.Action) {
+	case "", "route":
+		rule.Action = router.RoutingRule_Route
+	case "reject", "reject-rst":
+		rule.Action = router.RoutingRule_RejectRST
+	case "reject-http":
+		rule.Action = router.RoutingRule_RejectHTTP
+	case "drop":
+		rule.Action = router.RoutingRule_Drop
+	default:
+		return nil, newError("unknown action in routing rule: ", rawFieldRule.Action)
+	}
+
+	if len(rawFieldRule.Rewrite) > 0 {
+		if rule.Action != router.RoutingRule_Route {
+			return nil, newError("rewrite only works with route action")
+		}
+		address, port, err := parseRewrite(rawFieldRule.Rewrite)
+		if err != nil {
+			return nil, err
+		}
+		rule.RewriteAddress = address
+		rule.RewritePort = port
+	}
+
 	if len(rawFieldRule.OutboundTag) > 0 {
 		rule.TargetTag = &router.RoutingRule_Tag{
 			Tag: rawFieldRule.OutboundTag,
@@ -486,7 +899,7 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		rule.TargetTag = &router.RoutingRule_BalancingTag{
 			BalancingTag: rawFieldRule.BalancerTag,
 		}
-	} else {
+	} else if rule.Action == router.RoutingRule_Route && len(rawFieldRule.Rewrite) == 0 {
 		return nil, newError("neither outboundTag nor balancerTag is specified in routing rule")
 	}
 
@@ -553,6 +966,22 @@ func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
 		}
 	}
 
+	if rawFieldRule.Schedule != nil {
+		for _, s := range *rawFieldRule.Schedule {
+			schedule, err := parseSchedule(s)
+			if err != nil {
+				return nil, err
+			}
+			rule.Schedule = append(rule.Schedule, schedule)
+		}
+	}
+
+	if rawFieldRule.Providers != nil {
+		for _, s := range *rawFieldRule.Providers {
+			rule.RuleProvider = append(rule.RuleProvider, s)
+		}
+	}
+
 	return rule, nil
 }
 
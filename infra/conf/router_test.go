@@ -6,7 +6,9 @@ import (
 
 	"github.com/golang/protobuf/proto"
 
+	"v2ray.com/core/app/measure"
 	"v2ray.com/core/app/router"
+	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
 	. "v2ray.com/core/infra/conf"
 )
@@ -262,3 +264,330 @@ func TestRouterConfig(t *testing.T) {
 		},
 	})
 }
+
+func TestBalancingRuleProbe(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(BalancingRule)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"tag": "b1",
+				"selector": ["proxy"],
+				"strategy": "failover",
+				"probe": {
+					"type": "http",
+					"url": "https://www.gstatic.com/generate_204",
+					"expectedStatus": 204,
+					"interval": 30,
+					"failureThreshold": 5
+				}
+			}`,
+			Parser: createParser(),
+			Output: &router.BalancingRule{
+				Tag:               "b1",
+				OutboundSelector:  []string{"proxy"},
+				BalancingStrategy: router.BalancingRule_Failover,
+				Probe: &measure.ProbeConfig{
+					Kind:              measure.ProbeConfig_HTTP,
+					Address:           net.NewIPOrDomain(net.DomainAddress("www.gstatic.com")),
+					Port:              443,
+					Path:              "/generate_204",
+					Tls:               true,
+					ExpectedStatus:    204,
+					TotalMeasures:     3,
+					Interval:          30,
+					Delay:             1,
+					Timeout:           5,
+					Tolerance:         300,
+					FailureThreshold:  5,
+					RecoveryThreshold: 2,
+				},
+			},
+		},
+		{
+			Input: `{
+				"tag": "b2",
+				"selector": ["proxy"],
+				"strategy": "latency",
+				"probe": {
+					"type": "dns",
+					"target": "8.8.8.8",
+					"domain": "www.google.com"
+				}
+			}`,
+			Parser: createParser(),
+			Output: &router.BalancingRule{
+				Tag:               "b2",
+				OutboundSelector:  []string{"proxy"},
+				BalancingStrategy: router.BalancingRule_Latency,
+				Probe: &measure.ProbeConfig{
+					Kind:              measure.ProbeConfig_DNS,
+					Address:           net.NewIPOrDomain(net.IPAddress([]byte{8, 8, 8, 8})),
+					Port:              53,
+					Domain:            "www.google.com",
+					TotalMeasures:     3,
+					Interval:          120,
+					Delay:             1,
+					Timeout:           5,
+					Tolerance:         300,
+					FailureThreshold:  3,
+					RecoveryThreshold: 2,
+				},
+			},
+		},
+		{
+			Input: `{
+				"tag": "b3",
+				"selector": ["proxy"],
+				"strategy": "failover",
+				"failureThreshold": 4,
+				"recoveryThreshold": 1
+			}`,
+			Parser: createParser(),
+			Output: &router.BalancingRule{
+				Tag:               "b3",
+				OutboundSelector:  []string{"proxy"},
+				BalancingStrategy: router.BalancingRule_Failover,
+				Probe: &measure.ProbeConfig{
+					Kind:              measure.ProbeConfig_TLS,
+					Address:           net.NewIPOrDomain(net.DomainAddress("www.google.com")),
+					Port:              443,
+					Content:           []byte("HEAD / HTTP/1.1\r\n\r\n"),
+					TotalMeasures:     3,
+					Interval:          120,
+					Delay:             1,
+					Timeout:           5,
+					Tolerance:         300,
+					FailureThreshold:  4,
+					RecoveryThreshold: 1,
+				},
+			},
+		},
+		{
+			Input: `{
+				"tag": "b4",
+				"selector": ["proxy"],
+				"probe": {"type": "icmp"}
+			}`,
+			Parser: createParser(),
+			Output: &router.BalancingRule{
+				Tag:              "b4",
+				OutboundSelector: []string{"proxy"},
+			},
+		},
+	})
+
+	for _, input := range []string{
+		`{"tag": "b", "selector": ["proxy"], "strategy": "latency", "probeTarget": "www.google.com"}`,
+		`{"tag": "b", "selector": ["proxy"], "strategy": "latency", "probe": {"type": "tcp", "target": "www.google.com"}}`,
+		`{"tag": "b", "selector": ["proxy"], "strategy": "failover", "probe": {"type": "dns", "target": "8.8.8.8:53"}}`,
+		`{"tag": "b", "selector": ["proxy"], "strategy": "failover", "probe": {"type": "http", "url": "ftp://www.google.com/"}}`,
+		`{"tag": "b", "selector": ["proxy"], "strategy": "latency", "probe": {"type": "icmp", "target": "www.google.com:443"}}`,
+		`{"tag": "b", "selector": ["proxy"], "strategy": "fastest"}`,
+	} {
+		if _, err := createParser()(input); err == nil {
+			t.Error("expect error for malformed balancer: ", input)
+		}
+	}
+}
+
+func TestBalancingRuleWeights(t *testing.T) {
+	config := new(BalancingRule)
+	common.Must(json.Unmarshal([]byte(`{
+		"tag": "b",
+		"selector": ["proxy"],
+		"strategy": "weightedRoundRobin",
+		"weights": {"proxy-fast": 10, "proxy-slow": 1}
+	}`), config))
+	rule, err := config.Build()
+	common.Must(err)
+
+	if rule.BalancingStrategy != router.BalancingRule_WeightedRoundRobin {
+		t.Error("expect WeightedRoundRobin strategy, but got ", rule.BalancingStrategy)
+	}
+	if rule.Weight["proxy-fast"] != 10 || rule.Weight["proxy-slow"] != 1 {
+		t.Error("unexpected weights: ", rule.Weight)
+	}
+}
+
+func TestRoutingRuleSchedule(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			return ParseRule(json.RawMessage(s))
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"outboundTag": "metered",
+				"schedule": ["Mon-Fri 09:00-18:00 Asia/Shanghai", "weekends 22:30-06:00", "00:00-24:00"]
+			}`,
+			Parser: createParser(),
+			Output: &router.RoutingRule{
+				TargetTag: &router.RoutingRule_Tag{
+					Tag: "metered",
+				},
+				Schedule: []*router.Schedule{
+					{
+						Weekday:  []uint32{1, 2, 3, 4, 5},
+						Start:    9 * 3600,
+						End:      18 * 3600,
+						Timezone: "Asia/Shanghai",
+					},
+					{
+						Weekday: []uint32{6, 0},
+						Start:   22*3600 + 30*60,
+						End:     6 * 3600,
+					},
+					{
+						Start: 0,
+						End:   24 * 3600,
+					},
+				},
+			},
+		},
+	})
+
+	for _, input := range []string{
+		`{"outboundTag": "t", "schedule": "Mon-Fri"}`,
+		`{"outboundTag": "t", "schedule": "Someday 09:00-18:00"}`,
+		`{"outboundTag": "t", "schedule": "09:00-25:00"}`,
+		`{"outboundTag": "t", "schedule": "24:00-06:00"}`,
+		`{"outboundTag": "t", "schedule": "09:00-18:00 Mars/Olympus"}`,
+	} {
+		if _, err := createParser()(input); err == nil {
+			t.Error("expect error for malformed schedule: ", input)
+		}
+	}
+}
+
+func TestRuleProvider(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(RuleProvider)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input:  `{"tag": "ads", "path": "/etc/v2ray/ads.txt", "interval": 3600}`,
+			Parser: createParser(),
+			Output: &router.RuleProvider{
+				Tag:      "ads",
+				Behavior: router.RuleProvider_Domain,
+				Format:   router.RuleProvider_Text,
+				Path:     "/etc/v2ray/ads.txt",
+				Interval: 3600,
+			},
+		},
+		{
+			Input:  `{"tag": "lan", "behavior": "ipcidr", "path": "lan.yaml"}`,
+			Parser: createParser(),
+			Output: &router.RuleProvider{
+				Tag:      "lan",
+				Behavior: router.RuleProvider_IPCIDR,
+				Format:   router.RuleProvider_YAML,
+				Path:     "lan.yaml",
+			},
+		},
+		{
+			Input:  `{"tag": "cn", "path": "geosite.dat", "code": "cn"}`,
+			Parser: createParser(),
+			Output: &router.RuleProvider{
+				Tag:      "cn",
+				Behavior: router.RuleProvider_Domain,
+				Format:   router.RuleProvider_GeoSiteList,
+				Path:     "geosite.dat",
+				Code:     "cn",
+			},
+		},
+	})
+
+	for _, input := range []string{
+		`{"path": "ads.txt"}`,
+		`{"tag": "ads"}`,
+		`{"tag": "ads", "path": "ads.txt", "behavior": "process"}`,
+		`{"tag": "ads", "path": "ads.txt", "format": "json"}`,
+		`{"tag": "ads", "path": "geosite.dat"}`,
+	} {
+		if _, err := createParser()(input); err == nil {
+			t.Error("expect error for malformed rule provider: ", input)
+		}
+	}
+}
+
+func TestRoutingRuleAction(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			return ParseRule(json.RawMessage(s))
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input:  `{"domain": ["domain:ads.com"], "action": "reject-http"}`,
+			Parser: createParser(),
+			Output: &router.RoutingRule{
+				Domain: []*router.Domain{{Type: router.Domain_Domain, Value: "ads.com"}},
+				Action: router.RoutingRule_RejectHTTP,
+			},
+		},
+		{
+			Input:  `{"network": "udp", "action": "drop"}`,
+			Parser: createParser(),
+			Output: &router.RoutingRule{
+				Networks: []net.Network{net.Network_UDP},
+				Action:   router.RoutingRule_Drop,
+			},
+		},
+		{
+			Input:  `{"domain": ["domain:example.com"], "port": 80, "rewrite": "10.0.0.5:8080", "outboundTag": "direct"}`,
+			Parser: createParser(),
+			Output: &router.RoutingRule{
+				TargetTag: &router.RoutingRule_Tag{
+					Tag: "direct",
+				},
+				Domain: []*router.Domain{{Type: router.Domain_Domain, Value: "example.com"}},
+				PortList: &net.PortList{
+					Range: []*net.PortRange{{From: 80, To: 80}},
+				},
+				RewriteAddress: net.NewIPOrDomain(net.ParseAddress("10.0.0.5")),
+				RewritePort:    8080,
+			},
+		},
+		{
+			Input:  `{"port": 53, "rewrite": ":5353"}`,
+			Parser: createParser(),
+			Output: &router.RoutingRule{
+				PortList: &net.PortList{
+					Range: []*net.PortRange{{From: 53, To: 53}},
+				},
+				RewritePort: 5353,
+			},
+		},
+	})
+
+	for _, input := range []string{
+		`{"port": 53}`,
+		`{"port": 53, "action": "block"}`,
+		`{"port": 53, "action": "drop", "rewrite": "1.1.1.1"}`,
+		`{"port": 53, "rewrite": "1.1.1.1:http"}`,
+	} {
+		if _, err := createParser()(input); err == nil {
+			t.Error("expect error for malformed rule: ", input)
+		}
+	}
+}
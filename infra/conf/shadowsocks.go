@@ -26,19 +26,33 @@ func cipherFromString(c string) shadowsocks.CipherType {
 		return shadowsocks.CipherType_AES_256_GCM
 	case "chacha20-poly1305", "aead_chacha20_poly1305", "chacha20-ietf-poly1305":
 		return shadowsocks.CipherType_CHACHA20_POLY1305
+	case "2022-blake3-aes-128-gcm":
+		return shadowsocks.CipherType_AES_128_GCM_2022
+	case "2022-blake3-aes-256-gcm":
+		return shadowsocks.CipherType_AES_256_GCM_2022
+	case "2022-blake3-chacha20-poly1305":
+		return shadowsocks.CipherType_CHACHA20_POLY1305_2022
 	default:
 		return shadowsocks.CipherType_UNKNOWN
 	}
 }
 
+type ShadowsocksUserConfig struct {
+	Cipher   string `json:"method"`
+	Password string `json:"password"`
+	Level    byte   `json:"level"`
+	Email    string `json:"email"`
+}
+
 type ShadowsocksServerConfig struct {
-	Cipher      string       `json:"method"`
-	Password    string       `json:"password"`
-	UDP         bool         `json:"udp"`
-	Level       byte         `json:"level"`
-	Email       string       `json:"email"`
-	OTA         *bool        `json:"ota"`
-	NetworkList *NetworkList `json:"network"`
+	Cipher      string                   `json:"method"`
+	Password    string                   `json:"password"`
+	UDP         bool                     `json:"udp"`
+	Level       byte                     `json:"level"`
+	Email       string                   `json:"email"`
+	OTA         *bool                    `json:"ota"`
+	NetworkList *NetworkList             `json:"network"`
+	Users       []*ShadowsocksUserConfig `json:"clients"`
 }
 
 func (v *ShadowsocksServerConfig) Build() (proto.Message, error) {
@@ -46,7 +60,33 @@ func (v *ShadowsocksServerConfig) Build() (proto.Message, error) {
 	config.UdpEnabled = v.UDP
 	config.Network = v.NetworkList.Build()
 
+	// Users in "clients" use the method of the server unless they have their own.
+	for _, user := range v.Users {
+		if len(user.Password) == 0 {
+			return nil, newError("Shadowsocks password is not specified.")
+		}
+		cipher := user.Cipher
+		if len(cipher) == 0 {
+			cipher = v.Cipher
+		}
+		account := &shadowsocks.Account{
+			Password:   user.Password,
+			CipherType: cipherFromString(cipher),
+		}
+		if account.CipherType == shadowsocks.CipherType_UNKNOWN {
+			return nil, newError("unknown cipher method: ", cipher)
+		}
+		config.Users = append(config.Users, &protocol.User{
+			Email:   user.Email,
+			Level:   uint32(user.Level),
+			Account: serial.ToTypedMessage(account),
+		})
+	}
+
 	if len(v.Password) == 0 {
+		if len(config.Users) > 0 {
+			return config, nil
+		}
 		return nil, newError("Shadowsocks password is not specified.")
 	}
 	account := &shadowsocks.Account{
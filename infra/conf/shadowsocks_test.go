@@ -32,5 +32,57 @@ func TestShadowsocksServerConfigParsing(t *testing.T) {
 				Network: []net.Network{net.Network_TCP},
 			},
 		},
+		{
+			Input: `{
+				"method": "aes-128-gcm",
+				"clients": [
+					{
+						"password": "password-1",
+						"email": "love@v2ray.com"
+					},
+					{
+						"method": "chacha20-poly1305",
+						"password": "password-2",
+						"level": 1
+					}
+				]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &shadowsocks.ServerConfig{
+				Users: []*protocol.User{
+					{
+						Email: "love@v2ray.com",
+						Account: serial.ToTypedMessage(&shadowsocks.Account{
+							CipherType: shadowsocks.CipherType_AES_128_GCM,
+							Password:   "password-1",
+						}),
+					},
+					{
+						Level: 1,
+						Account: serial.ToTypedMessage(&shadowsocks.Account{
+							CipherType: shadowsocks.CipherType_CHACHA20_POLY1305,
+							Password:   "password-2",
+						}),
+					},
+				},
+				Network: []net.Network{net.Network_TCP},
+			},
+		},
+		{
+			Input: `{
+				"method": "2022-blake3-aes-128-gcm",
+				"password": "AAECAwQFBgcICQoLDA0ODw=="
+			}`,
+			Parser: loadJSON(creator),
+			Output: &shadowsocks.ServerConfig{
+				User: &protocol.User{
+					Account: serial.ToTypedMessage(&shadowsocks.Account{
+						CipherType: shadowsocks.CipherType_AES_128_GCM_2022,
+						Password:   "AAECAwQFBgcICQoLDA0ODw==",
+					}),
+				},
+				Network: []net.Network{net.Network_TCP},
+			},
+		},
 	})
 }
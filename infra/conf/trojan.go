@@ -0,0 +1,109 @@
+package conf
+
+import (
+	"github.com/golang/protobuf/proto"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/trojan"
+)
+
+type TrojanUserConfig struct {
+	Password string `json:"password"`
+	Level    byte   `json:"level"`
+	Email    string `json:"email"`
+}
+
+func (c *TrojanUserConfig) Build() (*protocol.User, error) {
+	if len(c.Password) == 0 {
+		return nil, newError("Trojan password is not specified.")
+	}
+	return &protocol.User{
+		Level:   uint32(c.Level),
+		Email:   c.Email,
+		Account: serial.ToTypedMessage(&trojan.Account{Password: c.Password}),
+	}, nil
+}
+
+type TrojanFallbackConfig struct {
+	Address *Address `json:"address"`
+	Port    uint16   `json:"port"`
+}
+
+type TrojanServerConfig struct {
+	Clients  []*TrojanUserConfig   `json:"clients"`
+	Fallback *TrojanFallbackConfig `json:"fallback"`
+}
+
+func (c *TrojanServerConfig) Build() (proto.Message, error) {
+	config := new(trojan.ServerConfig)
+
+	for _, client := range c.Clients {
+		user, err := client.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.User = append(config.User, user)
+	}
+
+	if c.Fallback != nil {
+		if c.Fallback.Port == 0 {
+			return nil, newError("Trojan fallback port is not specified.")
+		}
+		address := c.Fallback.Address
+		if address == nil {
+			address = &Address{Address: net.LocalHostIP}
+		}
+		config.Fallback = &trojan.Fallback{
+			Address: address.Build(),
+			Port:    uint32(c.Fallback.Port),
+		}
+	}
+
+	return config, nil
+}
+
+type TrojanServerTarget struct {
+	Address  *Address `json:"address"`
+	Port     uint16   `json:"port"`
+	Password string   `json:"password"`
+	Email    string   `json:"email"`
+	Level    byte     `json:"level"`
+}
+
+type TrojanClientConfig struct {
+	Servers []*TrojanServerTarget `json:"servers"`
+}
+
+func (c *TrojanClientConfig) Build() (proto.Message, error) {
+	config := new(trojan.ClientConfig)
+
+	if len(c.Servers) == 0 {
+		return nil, newError("0 Trojan server configured.")
+	}
+
+	for _, server := range c.Servers {
+		if server.Address == nil {
+			return nil, newError("Trojan server address is not set.")
+		}
+		if server.Port == 0 {
+			return nil, newError("Invalid Trojan port.")
+		}
+		user, err := (&TrojanUserConfig{
+			Password: server.Password,
+			Level:    server.Level,
+			Email:    server.Email,
+		}).Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Server = append(config.Server, &protocol.ServerEndpoint{
+			Address: server.Address.Build(),
+			Port:    uint32(server.Port),
+			User:    []*protocol.User{user},
+		})
+	}
+
+	return config, nil
+}
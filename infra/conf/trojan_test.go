@@ -0,0 +1,78 @@
+package conf_test
+
+import (
+	"testing"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	. "v2ray.com/core/infra/conf"
+	"v2ray.com/core/proxy/trojan"
+)
+
+func TestTrojanServerConfigParsing(t *testing.T) {
+	creator := func() Buildable {
+		return new(TrojanServerConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"clients": [{
+					"password": "v2ray-password",
+					"email": "love@v2ray.com",
+					"level": 1
+				}],
+				"fallback": {
+					"port": 80
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &trojan.ServerConfig{
+				User: []*protocol.User{
+					{
+						Level:   1,
+						Email:   "love@v2ray.com",
+						Account: serial.ToTypedMessage(&trojan.Account{Password: "v2ray-password"}),
+					},
+				},
+				Fallback: &trojan.Fallback{
+					Address: net.NewIPOrDomain(net.LocalHostIP),
+					Port:    80,
+				},
+			},
+		},
+	})
+}
+
+func TestTrojanClientConfigParsing(t *testing.T) {
+	creator := func() Buildable {
+		return new(TrojanClientConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"servers": [{
+					"address": "127.0.0.1",
+					"port": 443,
+					"password": "v2ray-password"
+				}]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &trojan.ClientConfig{
+				Server: []*protocol.ServerEndpoint{
+					{
+						Address: net.NewIPOrDomain(net.LocalHostIP),
+						Port:    443,
+						User: []*protocol.User{
+							{
+								Account: serial.ToTypedMessage(&trojan.Account{Password: "v2ray-password"}),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"v2ray.com/core"
 	"v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/health"
 	"v2ray.com/core/app/proxyman"
 	"v2ray.com/core/app/stats"
 	"v2ray.com/core/common/serial"
@@ -19,6 +20,9 @@ var (
 		"socks":         func() interface{} { return new(SocksServerConfig) },
 		"vmess":         func() interface{} { return new(VMessInboundConfig) },
 		"mtproto":       func() interface{} { return new(MTProtoServerConfig) },
+		"dns":           func() interface{} { return new(DnsInboundConfig) },
+		"trojan":        func() interface{} { return new(TrojanServerConfig) },
+		"vless":         func() interface{} { return new(VLessInboundConfig) },
 	}, "protocol", "settings")
 
 	outboundConfigLoader = NewJSONConfigLoader(ConfigCreatorCache{
@@ -30,6 +34,8 @@ var (
 		"socks":       func() interface{} { return new(SocksClientConfig) },
 		"mtproto":     func() interface{} { return new(MTProtoClientConfig) },
 		"dns":         func() interface{} { return new(DnsOutboundConfig) },
+		"trojan":      func() interface{} { return new(TrojanClientConfig) },
+		"vless":       func() interface{} { return new(VLessOutboundConfig) },
 	}, "protocol", "settings")
 )
 
@@ -279,11 +285,24 @@ func (c *StatsConfig) Build() (*stats.Config, error) {
 	return &stats.Config{}, nil
 }
 
+type HealthConfig struct {
+	FailureThreshold uint32 `json:"failureThreshold"`
+	DownDuration     uint32 `json:"downDuration"`
+}
+
+func (c *HealthConfig) Build() (*health.Config, error) {
+	return &health.Config{
+		FailureThreshold: c.FailureThreshold,
+		DownDuration:     c.DownDuration,
+	}, nil
+}
+
 type Config struct {
 	Port            uint16                 `json:"port"` // Port of this Point server. Deprecated.
 	LogConfig       *LogConfig             `json:"log"`
 	RouterConfig    *RouterConfig          `json:"routing"`
 	DNSConfig       *DnsConfig             `json:"dns"`
+	FakeDNS         *FakeDNSConfig         `json:"fakedns"`
 	InboundConfigs  []InboundDetourConfig  `json:"inbounds"`
 	OutboundConfigs []OutboundDetourConfig `json:"outbounds"`
 	InboundConfig   *InboundDetourConfig   `json:"inbound"`        // Deprecated.
@@ -294,6 +313,8 @@ type Config struct {
 	Policy          *PolicyConfig          `json:"policy"`
 	Api             *ApiConfig             `json:"api"`
 	Stats           *StatsConfig           `json:"stats"`
+	Health          *HealthConfig          `json:"health"`
+	Record          *RecordConfig          `json:"record"`
 	Reverse         *ReverseConfig         `json:"reverse"`
 }
 
@@ -341,6 +362,22 @@ func (c *Config) Build() (*core.Config, error) {
 		config.App = append(config.App, serial.ToTypedMessage(statsConf))
 	}
 
+	if c.Health != nil {
+		healthConf, err := c.Health.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.App = append(config.App, serial.ToTypedMessage(healthConf))
+	}
+
+	if c.Record != nil {
+		recordConf, err := c.Record.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.App = append(config.App, serial.ToTypedMessage(recordConf))
+	}
+
 	if c.LogConfig != nil {
 		config.App = append(config.App, serial.ToTypedMessage(c.LogConfig.Build()))
 	} else {
@@ -355,6 +392,14 @@ func (c *Config) Build() (*core.Config, error) {
 		config.App = append(config.App, serial.ToTypedMessage(routerConfig))
 	}
 
+	if c.FakeDNS != nil {
+		fakeDNSConf, err := c.FakeDNS.Build()
+		if err != nil {
+			return nil, newError("failed to parse fakedns config").Base(err)
+		}
+		config.App = append(config.App, serial.ToTypedMessage(fakeDNSConf))
+	}
+
 	if c.DNSConfig != nil {
 		dnsApp, err := c.DNSConfig.Build()
 		if err != nil {
@@ -0,0 +1,147 @@
+package conf
+
+import (
+	"encoding/json"
+
+	"github.com/golang/protobuf/proto"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/common/uuid"
+	"v2ray.com/core/proxy/vless"
+	"v2ray.com/core/proxy/vless/inbound"
+	"v2ray.com/core/proxy/vless/outbound"
+)
+
+type VLessAccount struct {
+	ID         string `json:"id"`
+	Flow       string `json:"flow"`
+	Encryption string `json:"encryption"`
+}
+
+// Build implements Buildable
+func (a *VLessAccount) Build() (*vless.Account, error) {
+	if _, err := uuid.ParseString(a.ID); err != nil {
+		return nil, newError("invalid VLESS id: ", a.ID).Base(err)
+	}
+	// No flow is implemented yet, but the field is kept for clients that send it.
+	if len(a.Flow) > 0 {
+		return nil, newError("VLESS flow ", a.Flow, " is not supported")
+	}
+	return &vless.Account{
+		Id:         a.ID,
+		Flow:       a.Flow,
+		Encryption: a.Encryption,
+	}, nil
+}
+
+func buildVLessUser(rawData json.RawMessage) (*protocol.User, *VLessAccount, error) {
+	user := new(protocol.User)
+	if err := json.Unmarshal(rawData, user); err != nil {
+		return nil, nil, newError("invalid VLESS user").Base(err)
+	}
+	account := new(VLessAccount)
+	if err := json.Unmarshal(rawData, account); err != nil {
+		return nil, nil, newError("invalid VLESS user").Base(err)
+	}
+	pb, err := account.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	user.Account = serial.ToTypedMessage(pb)
+	return user, account, nil
+}
+
+type VLessFallbackConfig struct {
+	Address *Address `json:"address"`
+	Port    uint16   `json:"port"`
+}
+
+type VLessInboundConfig struct {
+	Users      []json.RawMessage    `json:"clients"`
+	Decryption string               `json:"decryption"`
+	Fallback   *VLessFallbackConfig `json:"fallback"`
+}
+
+// Build implements Buildable
+func (c *VLessInboundConfig) Build() (proto.Message, error) {
+	if c.Decryption != vless.None {
+		return nil, newError(`VLESS decryption must be "none"`)
+	}
+	config := &inbound.Config{
+		Decryption: c.Decryption,
+	}
+
+	config.User = make([]*protocol.User, len(c.Users))
+	for idx, rawData := range c.Users {
+		user, account, err := buildVLessUser(rawData)
+		if err != nil {
+			return nil, err
+		}
+		if len(account.Encryption) > 0 {
+			return nil, newError("VLESS users of inbound must not set encryption")
+		}
+		config.User[idx] = user
+	}
+
+	if c.Fallback != nil {
+		if c.Fallback.Port == 0 {
+			return nil, newError("VLESS fallback port is not specified")
+		}
+		address := c.Fallback.Address
+		if address == nil {
+			address = &Address{Address: net.LocalHostIP}
+		}
+		config.Fallback = &inbound.Fallback{
+			Address: address.Build(),
+			Port:    uint32(c.Fallback.Port),
+		}
+	}
+
+	return config, nil
+}
+
+type VLessOutboundTarget struct {
+	Address *Address          `json:"address"`
+	Port    uint16            `json:"port"`
+	Users   []json.RawMessage `json:"users"`
+}
+
+type VLessOutboundConfig struct {
+	Receivers []*VLessOutboundTarget `json:"vnext"`
+}
+
+// Build implements Buildable
+func (c *VLessOutboundConfig) Build() (proto.Message, error) {
+	config := new(outbound.Config)
+
+	if len(c.Receivers) == 0 {
+		return nil, newError("0 VLESS receiver configured")
+	}
+	for _, rec := range c.Receivers {
+		if len(rec.Users) == 0 {
+			return nil, newError("0 user configured for VLESS outbound")
+		}
+		if rec.Address == nil {
+			return nil, newError("address is not set in VLESS outbound config")
+		}
+		spec := &protocol.ServerEndpoint{
+			Address: rec.Address.Build(),
+			Port:    uint32(rec.Port),
+		}
+		for _, rawUser := range rec.Users {
+			user, account, err := buildVLessUser(rawUser)
+			if err != nil {
+				return nil, err
+			}
+			if account.Encryption != vless.None {
+				return nil, newError(`VLESS users of outbound must set encryption to "none"`)
+			}
+			spec.User = append(spec.User, user)
+		}
+		config.Vnext = append(config.Vnext, spec)
+	}
+
+	return config, nil
+}
@@ -80,12 +80,14 @@ type VMessInboundConfig struct {
 	Defaults     *VMessDefaultConfig `json:"default"`
 	DetourConfig *VMessDetourConfig  `json:"detour"`
 	SecureOnly   bool                `json:"disableInsecureEncryption"`
+	AEADOnly     bool                `json:"disableLegacyHeader"`
 }
 
 // Build implements Buildable
 func (c *VMessInboundConfig) Build() (proto.Message, error) {
 	config := &inbound.Config{
 		SecureEncryptionOnly: c.SecureOnly,
+		AeadHeaderOnly:       c.AEADOnly,
 	}
 
 	if c.Defaults != nil {
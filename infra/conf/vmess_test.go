@@ -113,5 +113,31 @@ func TestVMessInbound(t *testing.T) {
 				SecureEncryptionOnly: true,
 			},
 		},
+		{
+			Input: `{
+				"clients": [
+					{
+						"id": "27848739-7e62-4138-9fd3-098a63964b6b",
+						"email": "love@v2ray.com"
+					}
+				],
+				"disableLegacyHeader": true
+			}`,
+			Parser: loadJSON(creator),
+			Output: &inbound.Config{
+				User: []*protocol.User{
+					{
+						Email: "love@v2ray.com",
+						Account: serial.ToTypedMessage(&vmess.Account{
+							Id: "27848739-7e62-4138-9fd3-098a63964b6b",
+							SecuritySettings: &protocol.SecurityConfig{
+								Type: protocol.SecurityType_AUTO,
+							},
+						}),
+					},
+				},
+				AeadHeaderOnly: true,
+			},
+		},
 	})
 }
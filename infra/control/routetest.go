@@ -0,0 +1,131 @@
+package control
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	routingService "v2ray.com/core/app/router/command"
+	"v2ray.com/core/common"
+	v2net "v2ray.com/core/common/net"
+)
+
+type RouteTestCommand struct{}
+
+func (c *RouteTestCommand) Name() string {
+	return "route-test"
+}
+
+func (c *RouteTestCommand) Description() Description {
+	return Description{
+		Short: "Explain how a request would be routed",
+		Usage: []string{
+			"v2ctl route-test [--server=127.0.0.1:8080] [--timeout=3] [--inbound=tag] [--source=ip] [--user=email] [--network=tcp] [--protocol=http] [--app=name] <destination>[:port]",
+			"Ask a running V2Ray process, through RoutingService, which rule and outbound a request would be routed to.",
+			"Examples:",
+			"v2ctl route-test --inbound=socks www.google.com:443",
+			"v2ctl route-test --network=udp --source=192.168.1.2 8.8.8.8:53",
+		},
+	}
+}
+
+func (c *RouteTestCommand) Execute(args []string) error {
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+
+	serverAddr := fs.String("server", "127.0.0.1:8080", "Server address")
+	timeout := fs.Int("timeout", 3, "Timeout in seconds")
+	inboundTag := fs.String("inbound", "", "Tag of the inbound")
+	sourceIP := fs.String("source", "", "Source IP")
+	userEmail := fs.String("user", "", "Email of the user")
+	network := fs.String("network", "tcp", "Network, tcp or udp")
+	sniffed := fs.String("protocol", "", "Sniffed protocol, such as http or tls")
+	apps := fs.String("app", "", "Comma separated application names")
+
+	if err := fs.Parse(args); err != nil {
+		return newError("flag parsing").Base(err)
+	}
+	if fs.NArg() < 1 {
+		return newError("destination not specified")
+	}
+
+	request := &routingService.TestRouteRequest{
+		InboundTag: *inboundTag,
+		SourceIp:   *sourceIP,
+		UserEmail:  *userEmail,
+		Protocol:   *sniffed,
+	}
+	if len(*apps) > 0 {
+		request.Application = strings.Split(*apps, ",")
+	}
+
+	switch strings.ToLower(*network) {
+	case "tcp":
+		request.Network = v2net.Network_TCP
+	case "udp":
+		request.Network = v2net.Network_UDP
+	default:
+		return newError("unknown network: ", *network)
+	}
+
+	request.Destination = fs.Arg(0)
+	if host, port, err := net.SplitHostPort(fs.Arg(0)); err == nil {
+		p, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return newError("invalid port: ", port).Base(err)
+		}
+		request.Destination = host
+		request.Port = uint32(p)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, *serverAddr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return newError("failed to dial ", *serverAddr).Base(err)
+	}
+	defer conn.Close()
+
+	client := routingService.NewRoutingServiceClient(conn)
+	resp, err := client.TestRoute(ctx, request)
+	if err != nil {
+		return newError("failed to test route").Base(err)
+	}
+
+	if len(resp.ResolvedIp) > 0 {
+		fmt.Println("Resolved IPs:", strings.Join(resp.ResolvedIp, ", "))
+	}
+	for _, rule := range resp.Rule {
+		fmt.Printf("Rule %d: %v\n", rule.Index, rule.Matched)
+		for _, cond := range rule.Condition {
+			fmt.Printf("\t%s: %v\n", cond.Name, cond.Matched)
+		}
+	}
+	if resp.RuleIndex < 0 {
+		fmt.Println("No rule matches, routed to the default outbound:", resp.OutboundTag)
+		return nil
+	}
+	fmt.Println("Matched rule:", resp.RuleIndex)
+	if resp.Action != "route" {
+		fmt.Println("Action:", resp.Action)
+		return nil
+	}
+	if len(resp.Rewrite) > 0 {
+		fmt.Println("Rewritten to:", resp.Rewrite)
+	}
+	if len(resp.BalancerTag) > 0 {
+		fmt.Println("Balancer:", resp.BalancerTag)
+	}
+	fmt.Println("Outbound:", resp.OutboundTag)
+	return nil
+}
+
+func init() {
+	common.Must(RegisterCommand(&RouteTestCommand{}))
+}
@@ -10,14 +10,20 @@ import (
 
 	// Default commander and all its services. This is an optional feature.
 	_ "v2ray.com/core/app/commander"
+	_ "v2ray.com/core/app/dns/command"
 	_ "v2ray.com/core/app/log/command"
 	_ "v2ray.com/core/app/proxyman/command"
+	_ "v2ray.com/core/app/record/command"
+	_ "v2ray.com/core/app/router/command"
 	_ "v2ray.com/core/app/stats/command"
 
 	// Other optional features.
 	_ "v2ray.com/core/app/dns"
+	_ "v2ray.com/core/app/dns/fakedns"
+	_ "v2ray.com/core/app/health"
 	_ "v2ray.com/core/app/log"
 	_ "v2ray.com/core/app/policy"
+	_ "v2ray.com/core/app/record"
 	_ "v2ray.com/core/app/reverse"
 	_ "v2ray.com/core/app/router"
 	_ "v2ray.com/core/app/stats"
@@ -31,6 +37,9 @@ import (
 	_ "v2ray.com/core/proxy/mtproto"
 	_ "v2ray.com/core/proxy/shadowsocks"
 	_ "v2ray.com/core/proxy/socks"
+	_ "v2ray.com/core/proxy/trojan"
+	_ "v2ray.com/core/proxy/vless/inbound"
+	_ "v2ray.com/core/proxy/vless/outbound"
 	_ "v2ray.com/core/proxy/vmess/inbound"
 	_ "v2ray.com/core/proxy/vmess/outbound"
 
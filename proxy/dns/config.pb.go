@@ -4,6 +4,7 @@ import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
 	math "math"
+	router "v2ray.com/core/app/router"
 	net "v2ray.com/core/common/net"
 )
 
@@ -18,12 +19,78 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type Config_NonIPQuery int32
+
+const (
+	// Queries are sent to the server.
+	Config_Forward Config_NonIPQuery = 0
+	// Queries are answered with NOERROR and no records.
+	Config_NoData Config_NonIPQuery = 1
+	// Queries are answered with NXDOMAIN.
+	Config_NXDomain Config_NonIPQuery = 2
+	// Queries are answered with REFUSED.
+	Config_Refused Config_NonIPQuery = 3
+)
+
+var Config_NonIPQuery_name = map[int32]string{
+	0: "Forward",
+	1: "NoData",
+	2: "NXDomain",
+	3: "Refused",
+}
+
+var Config_NonIPQuery_value = map[string]int32{
+	"Forward":  0,
+	"NoData":   1,
+	"NXDomain": 2,
+	"Refused":  3,
+}
+
+func (x Config_NonIPQuery) String() string {
+	return proto.EnumName(Config_NonIPQuery_name, int32(x))
+}
+
+func (Config_NonIPQuery) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_c49bb2d51e576d57, []int{0, 0}
+}
+
+type BlockList_Response int32
+
+const (
+	// Queries are answered with NXDOMAIN.
+	BlockList_NXDomain BlockList_Response = 0
+	// A and AAAA queries are answered with 0.0.0.0 and ::, others with no records.
+	BlockList_ZeroIP BlockList_Response = 1
+)
+
+var BlockList_Response_name = map[int32]string{
+	0: "NXDomain",
+	1: "ZeroIP",
+}
+
+var BlockList_Response_value = map[string]int32{
+	"NXDomain": 0,
+	"ZeroIP":   1,
+}
+
+func (x BlockList_Response) String() string {
+	return proto.EnumName(BlockList_Response_name, int32(x))
+}
+
+func (BlockList_Response) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_c49bb2d51e576d57, []int{1, 0}
+}
+
 type Config struct {
 	// Server is the DNS server address. If specified, this address overrides the original one.
-	Server               *net.Endpoint `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
-	XXX_unrecognized     []byte        `json:"-"`
-	XXX_sizecache        int32         `json:"-"`
+	Server *net.Endpoint `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	// How queries of types other than A and AAAA are handled.
+	NonIpQuery Config_NonIPQuery `protobuf:"varint,2,opt,name=non_ip_query,json=nonIpQuery,proto3,enum=v2ray.core.proxy.dns.Config_NonIPQuery" json:"non_ip_query,omitempty"`
+	// Queries of the domains in the block list are answered locally.
+	BlockList            *BlockList `protobuf:"bytes,3,opt,name=block_list,json=blockList,proto3" json:"block_list,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
 func (m *Config) Reset()         { *m = Config{} }
@@ -58,8 +125,182 @@ func (m *Config) GetServer() *net.Endpoint {
 	return nil
 }
 
+func (m *Config) GetNonIpQuery() Config_NonIPQuery {
+	if m != nil {
+		return m.NonIpQuery
+	}
+	return Config_Forward
+}
+
+func (m *Config) GetBlockList() *BlockList {
+	if m != nil {
+		return m.BlockList
+	}
+	return nil
+}
+
+type BlockList struct {
+	Domain               []*router.Domain   `protobuf:"bytes,1,rep,name=domain,proto3" json:"domain,omitempty"`
+	Response             BlockList_Response `protobuf:"varint,2,opt,name=response,proto3,enum=v2ray.core.proxy.dns.BlockList_Response" json:"response,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *BlockList) Reset()         { *m = BlockList{} }
+func (m *BlockList) String() string { return proto.CompactTextString(m) }
+func (*BlockList) ProtoMessage()    {}
+func (*BlockList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c49bb2d51e576d57, []int{1}
+}
+
+func (m *BlockList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockList.Unmarshal(m, b)
+}
+func (m *BlockList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockList.Marshal(b, m, deterministic)
+}
+func (m *BlockList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockList.Merge(m, src)
+}
+func (m *BlockList) XXX_Size() int {
+	return xxx_messageInfo_BlockList.Size(m)
+}
+func (m *BlockList) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockList proto.InternalMessageInfo
+
+func (m *BlockList) GetDomain() []*router.Domain {
+	if m != nil {
+		return m.Domain
+	}
+	return nil
+}
+
+func (m *BlockList) GetResponse() BlockList_Response {
+	if m != nil {
+		return m.Response
+	}
+	return BlockList_NXDomain
+}
+
+type Upstream struct {
+	// Address of the upstream server. The network defaults to UDP.
+	Address *net.Endpoint `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Domains whose queries are forwarded to this upstream. An upstream without
+	// domains takes the queries of all other domains.
+	Domain               []*router.Domain `protobuf:"bytes,2,rep,name=domain,proto3" json:"domain,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *Upstream) Reset()         { *m = Upstream{} }
+func (m *Upstream) String() string { return proto.CompactTextString(m) }
+func (*Upstream) ProtoMessage()    {}
+func (*Upstream) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c49bb2d51e576d57, []int{2}
+}
+
+func (m *Upstream) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Upstream.Unmarshal(m, b)
+}
+func (m *Upstream) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Upstream.Marshal(b, m, deterministic)
+}
+func (m *Upstream) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Upstream.Merge(m, src)
+}
+func (m *Upstream) XXX_Size() int {
+	return xxx_messageInfo_Upstream.Size(m)
+}
+func (m *Upstream) XXX_DiscardUnknown() {
+	xxx_messageInfo_Upstream.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Upstream proto.InternalMessageInfo
+
+func (m *Upstream) GetAddress() *net.Endpoint {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *Upstream) GetDomain() []*router.Domain {
+	if m != nil {
+		return m.Domain
+	}
+	return nil
+}
+
+// ServerConfig is the config of the DNS inbound, which answers A and AAAA
+// queries from the DNS app, and forwards queries of other types to upstreams.
+type ServerConfig struct {
+	Upstream []*Upstream `protobuf:"bytes,1,rep,name=upstream,proto3" json:"upstream,omitempty"`
+	// TTL in seconds of the answers from the DNS app. Default to 60.
+	Ttl                  uint32   `protobuf:"varint,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	UserLevel            uint32   `protobuf:"varint,3,opt,name=user_level,json=userLevel,proto3" json:"user_level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ServerConfig) Reset()         { *m = ServerConfig{} }
+func (m *ServerConfig) String() string { return proto.CompactTextString(m) }
+func (*ServerConfig) ProtoMessage()    {}
+func (*ServerConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c49bb2d51e576d57, []int{3}
+}
+
+func (m *ServerConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServerConfig.Unmarshal(m, b)
+}
+func (m *ServerConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServerConfig.Marshal(b, m, deterministic)
+}
+func (m *ServerConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServerConfig.Merge(m, src)
+}
+func (m *ServerConfig) XXX_Size() int {
+	return xxx_messageInfo_ServerConfig.Size(m)
+}
+func (m *ServerConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServerConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServerConfig proto.InternalMessageInfo
+
+func (m *ServerConfig) GetUpstream() []*Upstream {
+	if m != nil {
+		return m.Upstream
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetTtl() uint32 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetUserLevel() uint32 {
+	if m != nil {
+		return m.UserLevel
+	}
+	return 0
+}
+
 func init() {
+	proto.RegisterEnum("v2ray.core.proxy.dns.Config_NonIPQuery", Config_NonIPQuery_name, Config_NonIPQuery_value)
+	proto.RegisterEnum("v2ray.core.proxy.dns.BlockList_Response", BlockList_Response_name, BlockList_Response_value)
 	proto.RegisterType((*Config)(nil), "v2ray.core.proxy.dns.Config")
+	proto.RegisterType((*BlockList)(nil), "v2ray.core.proxy.dns.BlockList")
+	proto.RegisterType((*Upstream)(nil), "v2ray.core.proxy.dns.Upstream")
+	proto.RegisterType((*ServerConfig)(nil), "v2ray.core.proxy.dns.ServerConfig")
 }
 
 func init() {
@@ -67,17 +308,35 @@ func init() {
 }
 
 var fileDescriptor_c49bb2d51e576d57 = []byte{
-	// 190 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x8e, 0x31, 0xab, 0xc2, 0x30,
-	0x14, 0x46, 0xe9, 0x7b, 0xd0, 0x21, 0x6f, 0x2b, 0x1d, 0xca, 0x5b, 0xde, 0x43, 0x10, 0x04, 0xe1,
-	0x06, 0xea, 0xa0, 0xab, 0x56, 0xf7, 0xd2, 0xc1, 0xc1, 0xad, 0x26, 0x51, 0x32, 0xe4, 0xde, 0x72,
-	0x13, 0x8a, 0xfd, 0x4b, 0xfe, 0x4a, 0x31, 0x55, 0x10, 0x71, 0xfe, 0xce, 0x77, 0x38, 0x62, 0xda,
-	0x97, 0xdc, 0x0e, 0xa0, 0xc8, 0x49, 0x45, 0x6c, 0x64, 0xc7, 0x74, 0x19, 0xa4, 0x46, 0x2f, 0x15,
-	0xe1, 0xc9, 0x9e, 0xa1, 0x63, 0x0a, 0x94, 0xe5, 0x4f, 0x8c, 0x0d, 0x44, 0x04, 0x34, 0xfa, 0xdf,
-	0xf9, 0xdb, 0x59, 0x91, 0x73, 0x84, 0x12, 0x4d, 0x90, 0xda, 0xf8, 0x60, 0xb1, 0x0d, 0x96, 0x70,
-	0x54, 0x4c, 0xd6, 0x22, 0xad, 0xa2, 0x32, 0x5b, 0x8a, 0xd4, 0x1b, 0xee, 0x0d, 0x17, 0xc9, 0x7f,
-	0x32, 0xfb, 0x29, 0xff, 0xe0, 0xc5, 0x3e, 0x3a, 0x00, 0x4d, 0x80, 0x1d, 0xea, 0x8e, 0x2c, 0x86,
-	0xe6, 0x81, 0x6f, 0x56, 0xa2, 0x50, 0xe4, 0xe0, 0x53, 0x4b, 0x9d, 0x1c, 0xbe, 0x35, 0xfa, 0xeb,
-	0x57, 0xbe, 0x2f, 0x9b, 0x76, 0x80, 0xea, 0xbe, 0xd6, 0x71, 0xdd, 0xa2, 0x3f, 0xa6, 0xb1, 0x61,
-	0x71, 0x0b, 0x00, 0x00, 0xff, 0xff, 0x1c, 0x84, 0x9c, 0x79, 0xef, 0x00, 0x00, 0x00,
+	// 479 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0x5d, 0x8b, 0xd3, 0x4e,
+	0x14, 0xc6, 0xff, 0x69, 0xa1, 0xdb, 0x9e, 0x76, 0xff, 0x84, 0x61, 0x2f, 0xca, 0xc2, 0x6a, 0x09,
+	0xbe, 0x14, 0x84, 0x09, 0x54, 0xc4, 0x97, 0x0b, 0x91, 0xdd, 0x2a, 0x14, 0x96, 0x52, 0x47, 0x14,
+	0xd9, 0x9b, 0x32, 0xcd, 0xcc, 0x4a, 0x30, 0x99, 0x33, 0xce, 0x4c, 0xaa, 0x45, 0x3f, 0x83, 0x1f,
+	0xc4, 0x0b, 0x3f, 0xa3, 0x64, 0x92, 0x74, 0xbb, 0x6b, 0x41, 0xbd, 0x3b, 0x9c, 0xfc, 0xe6, 0x39,
+	0xcf, 0x73, 0x72, 0xe0, 0xee, 0x7a, 0x62, 0xf8, 0x86, 0x26, 0x98, 0xc7, 0x09, 0x1a, 0x19, 0x6b,
+	0x83, 0x5f, 0x36, 0xb1, 0x50, 0x36, 0x4e, 0x50, 0x5d, 0xa6, 0x1f, 0xa8, 0x36, 0xe8, 0x90, 0x1c,
+	0x35, 0x98, 0x91, 0xd4, 0x23, 0x54, 0x28, 0x7b, 0xfc, 0xe0, 0xc6, 0xe3, 0x04, 0xf3, 0x1c, 0x55,
+	0xac, 0xa4, 0x8b, 0x85, 0xb4, 0x2e, 0x55, 0xdc, 0xa5, 0xa8, 0x2a, 0x89, 0xe3, 0x7b, 0x37, 0x60,
+	0xae, 0x75, 0x6c, 0xb0, 0x70, 0xd2, 0x5c, 0x1b, 0x15, 0x7d, 0x6f, 0x41, 0xe7, 0xcc, 0x37, 0xc8,
+	0x63, 0xe8, 0x58, 0x69, 0xd6, 0xd2, 0x0c, 0x83, 0x51, 0x30, 0xee, 0x4f, 0x6e, 0xd3, 0x1d, 0x1b,
+	0xd5, 0x30, 0xaa, 0xa4, 0xa3, 0x2f, 0x95, 0xd0, 0x98, 0x2a, 0xc7, 0x6a, 0x9c, 0xcc, 0x60, 0xa0,
+	0x50, 0x2d, 0x53, 0xbd, 0xfc, 0x54, 0x48, 0xb3, 0x19, 0xb6, 0x46, 0xc1, 0xf8, 0xff, 0xc9, 0x7d,
+	0xba, 0x2f, 0x05, 0xad, 0x86, 0xd1, 0x39, 0xaa, 0xd9, 0xe2, 0x75, 0x89, 0x33, 0x50, 0xa8, 0x66,
+	0xda, 0xd7, 0xe4, 0x39, 0xc0, 0x2a, 0xc3, 0xe4, 0xe3, 0x32, 0x4b, 0xad, 0x1b, 0xb6, 0x7f, 0xf7,
+	0x71, 0x25, 0x74, 0x5a, 0x72, 0xe7, 0xa9, 0x75, 0xac, 0xb7, 0x6a, 0xca, 0xe8, 0x05, 0xc0, 0x95,
+	0x32, 0xe9, 0xc3, 0xc1, 0x2b, 0x34, 0x9f, 0xb9, 0x11, 0xe1, 0x7f, 0x04, 0xa0, 0x33, 0xc7, 0x29,
+	0x77, 0x3c, 0x0c, 0xc8, 0x00, 0xba, 0xf3, 0xf7, 0x53, 0xcc, 0x79, 0xaa, 0xc2, 0x56, 0x89, 0x31,
+	0x79, 0x59, 0x58, 0x29, 0xc2, 0x76, 0xf4, 0x33, 0x80, 0xde, 0x56, 0x9a, 0x3c, 0x82, 0x8e, 0xf0,
+	0xd8, 0x30, 0x18, 0xb5, 0xc7, 0xfd, 0xc9, 0xc9, 0xae, 0x17, 0xae, 0x35, 0xad, 0x76, 0x4a, 0x2b,
+	0x2d, 0x56, 0xc3, 0x64, 0x0a, 0x5d, 0x23, 0xad, 0x46, 0x65, 0x65, 0xbd, 0x8d, 0xf1, 0x1f, 0x42,
+	0x50, 0x56, 0xf3, 0x6c, 0xfb, 0x32, 0xba, 0x03, 0xdd, 0xa6, 0x7b, 0xcd, 0xb1, 0xcf, 0x72, 0x21,
+	0x0d, 0xce, 0x16, 0x61, 0x10, 0x7d, 0x83, 0xee, 0x5b, 0x6d, 0x9d, 0x91, 0x3c, 0x27, 0x4f, 0xe1,
+	0x80, 0x0b, 0x61, 0xa4, 0xb5, 0x7f, 0xfb, 0x0f, 0x1b, 0x7e, 0x27, 0x69, 0xeb, 0x1f, 0x92, 0x46,
+	0x5f, 0x61, 0xf0, 0xc6, 0x5f, 0x41, 0x7d, 0x44, 0xcf, 0xa0, 0x5b, 0xd4, 0x6e, 0xea, 0x95, 0xdd,
+	0xda, 0x9f, 0xbc, 0xf1, 0xcc, 0xb6, 0x3c, 0x09, 0xa1, 0xed, 0x5c, 0xe6, 0x17, 0x76, 0xc8, 0xca,
+	0x92, 0x9c, 0x00, 0x14, 0x56, 0x9a, 0x65, 0x26, 0xd7, 0x32, 0xf3, 0xe7, 0x70, 0xc8, 0x7a, 0x65,
+	0xe7, 0xbc, 0x6c, 0x9c, 0x3e, 0x81, 0x61, 0x82, 0xf9, 0x5e, 0xfd, 0x45, 0x70, 0xd1, 0x16, 0xca,
+	0xfe, 0x68, 0x1d, 0xbd, 0x9b, 0x30, 0xbe, 0xa1, 0x67, 0xe5, 0xd7, 0x85, 0xff, 0x3a, 0x55, 0x76,
+	0xd5, 0xf1, 0xd7, 0xff, 0xf0, 0xd7, 0x00, 0x58, 0x86, 0x6d, 0xac, 0x91, 0x03, 0x00, 0x00,
 }
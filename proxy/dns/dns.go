@@ -5,11 +5,13 @@ package dns
 import (
 	"context"
 	"io"
+	"strings"
 	"sync"
 
 	"golang.org/x/net/dns/dnsmessage"
 
 	"v2ray.com/core"
+	"v2ray.com/core/app/router"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
 	"v2ray.com/core/common/net"
@@ -33,6 +35,9 @@ func init() {
 	}))
 }
 
+// blockedTTL is the TTL of the answers for blocked domains.
+const blockedTTL = 3600
+
 type ownLinkVerifier interface {
 	IsOwnLink(ctx context.Context) bool
 }
@@ -41,7 +46,11 @@ type Handler struct {
 	ipv4Lookup      dns.IPv4Lookup
 	ipv6Lookup      dns.IPv6Lookup
 	ownLinkVerifier ownLinkVerifier
+	fakeLookup      dns.FakeDNSLookup
 	server          net.Destination
+	nonIPQuery      Config_NonIPQuery
+	blockList       *router.DomainMatcher
+	blockResponse   BlockList_Response
 }
 
 func (h *Handler) Init(config *Config, dnsClient dns.Client) error {
@@ -61,9 +70,23 @@ func (h *Handler) Init(config *Config, dnsClient dns.Client) error {
 		h.ownLinkVerifier = v
 	}
 
+	if v, ok := dnsClient.(dns.FakeDNSLookup); ok {
+		h.fakeLookup = v
+	}
+
 	if config.Server != nil {
 		h.server = config.Server.AsDestination()
 	}
+
+	h.nonIPQuery = config.NonIpQuery
+	if config.BlockList != nil && len(config.BlockList.Domain) > 0 {
+		matcher, err := router.NewDomainMatcher(config.BlockList.Domain)
+		if err != nil {
+			return newError("failed to create block list").Base(err)
+		}
+		h.blockList = matcher
+		h.blockResponse = config.BlockList.Response
+	}
 	return nil
 }
 
@@ -71,28 +94,63 @@ func (h *Handler) isOwnLink(ctx context.Context) bool {
 	return h.ownLinkVerifier != nil && h.ownLinkVerifier.IsOwnLink(ctx)
 }
 
-func parseIPQuery(b []byte) (r bool, domain string, id uint16, qType dnsmessage.Type) {
+func parseQuestion(b []byte) (id uint16, q dnsmessage.Question, err error) {
 	var parser dnsmessage.Parser
 	header, err := parser.Start(b)
 	if err != nil {
-		newError("parser start").Base(err).WriteToLog()
-		return
+		return 0, q, newError("failed to parse DNS header").Base(err)
 	}
 
-	id = header.ID
-	q, err := parser.Question()
+	q, err = parser.Question()
 	if err != nil {
-		newError("question").Base(err).WriteToLog()
-		return
+		return 0, q, newError("failed to parse DNS question").Base(err)
 	}
-	qType = q.Type
-	if qType != dnsmessage.TypeA && qType != dnsmessage.TypeAAAA {
-		return
+	return header.ID, q, nil
+}
+
+func isIPQuery(qType dnsmessage.Type) bool {
+	return qType == dnsmessage.TypeA || qType == dnsmessage.TypeAAAA
+}
+
+// handleQuery answers the query locally, if it is an IP query, a query of a
+// blocked domain, or a non-IP query that is not forwarded. It returns false if
+// the query should be sent to the server.
+func (h *Handler) handleQuery(id uint16, q dnsmessage.Question, writer dns_proto.MessageWriter) bool {
+	domain := q.Name.String()
+
+	if h.blockList != nil && h.blockList.ApplyDomain(strings.TrimSuffix(domain, ".")) {
+		newError("blocked DNS query for ", domain).AtDebug().WriteToLog()
+		switch {
+		case h.blockResponse == BlockList_ZeroIP && q.Type == dnsmessage.TypeA:
+			go h.writeAnswer(id, q.Type, domain, 0, []net.IP{net.AnyIP.IP()}, blockedTTL, writer)
+		case h.blockResponse == BlockList_ZeroIP && q.Type == dnsmessage.TypeAAAA:
+			go h.writeAnswer(id, q.Type, domain, 0, []net.IP{net.AnyIPv6.IP()}, blockedTTL, writer)
+		case h.blockResponse == BlockList_ZeroIP:
+			go h.writeAnswer(id, q.Type, domain, 0, nil, blockedTTL, writer)
+		default:
+			go h.writeAnswer(id, q.Type, domain, uint16(dnsmessage.RCodeNameError), nil, blockedTTL, writer)
+		}
+		return true
+	}
+
+	if isIPQuery(q.Type) {
+		go h.handleIPQuery(id, q.Type, domain, writer)
+		return true
 	}
 
-	domain = q.Name.String()
-	r = true
-	return
+	var rcode dnsmessage.RCode
+	switch h.nonIPQuery {
+	case Config_NoData:
+		rcode = dnsmessage.RCodeSuccess
+	case Config_NXDomain:
+		rcode = dnsmessage.RCodeNameError
+	case Config_Refused:
+		rcode = dnsmessage.RCodeRefused
+	default:
+		return false
+	}
+	go h.writeAnswer(id, q.Type, domain, uint16(rcode), nil, 0, writer)
+	return true
 }
 
 // Process implements proxy.Outbound.
@@ -170,9 +228,11 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, d internet.
 			}
 
 			if !h.isOwnLink(ctx) {
-				isIPQuery, domain, id, qType := parseIPQuery(b.Bytes())
-				if isIPQuery {
-					go h.handleIPQuery(id, qType, domain, writer)
+				id, q, err := parseQuestion(b.Bytes())
+				if err != nil {
+					newError("failed to parse DNS query").Base(err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+				} else if h.handleQuery(id, q, writer) {
+					b.Release()
 					continue
 				}
 			}
@@ -211,11 +271,20 @@ func (h *Handler) handleIPQuery(id uint16, qType dnsmessage.Type, domain string,
 	var ips []net.IP
 	var err error
 
-	switch qType {
-	case dnsmessage.TypeA:
-		ips, err = h.ipv4Lookup.LookupIPv4(domain)
-	case dnsmessage.TypeAAAA:
-		ips, err = h.ipv6Lookup.LookupIPv6(domain)
+	fake := false
+	if h.fakeLookup != nil {
+		ips, fake = h.fakeLookup.LookupFakeIP(domain, qType == dnsmessage.TypeA, qType == dnsmessage.TypeAAAA)
+		if fake && len(ips) == 0 {
+			err = dns.ErrEmptyResponse
+		}
+	}
+	if !fake {
+		switch qType {
+		case dnsmessage.TypeA:
+			ips, err = h.ipv4Lookup.LookupIPv4(domain)
+		case dnsmessage.TypeAAAA:
+			ips, err = h.ipv6Lookup.LookupIPv6(domain)
+		}
 	}
 
 	rcode := dns.RCodeFromError(err)
@@ -224,6 +293,15 @@ func (h *Handler) handleIPQuery(id uint16, qType dnsmessage.Type, domain string,
 		return
 	}
 
+	var ttl uint32 = 600
+	if fake {
+		// Fake IPs are only valid while their mappings are kept, so clients should not cache them for long.
+		ttl = 1
+	}
+	h.writeAnswer(id, qType, domain, rcode, ips, ttl, writer)
+}
+
+func (h *Handler) writeAnswer(id uint16, qType dnsmessage.Type, domain string, rcode uint16, ips []net.IP, ttl uint32, writer dns_proto.MessageWriter) {
 	b := buf.New()
 	rawBytes := b.Extend(buf.Size)
 	builder := dnsmessage.NewBuilder(rawBytes[:0], dnsmessage.Header{
@@ -243,7 +321,7 @@ func (h *Handler) handleIPQuery(id uint16, qType dnsmessage.Type, domain string,
 	}))
 	common.Must(builder.StartAnswers())
 
-	rHeader := dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(domain), Class: dnsmessage.ClassINET, TTL: 600}
+	rHeader := dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(domain), Class: dnsmessage.ClassINET, TTL: ttl}
 	for _, ip := range ips {
 		if len(ip) == net.IPv4len {
 			var r dnsmessage.AResource
@@ -15,11 +15,13 @@ import (
 	"v2ray.com/core/app/proxyman"
 	_ "v2ray.com/core/app/proxyman/inbound"
 	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/app/router"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/serial"
 	dns_proxy "v2ray.com/core/proxy/dns"
 	"v2ray.com/core/proxy/dokodemo"
+	"v2ray.com/core/proxy/freedom"
 	"v2ray.com/core/testing/servers/tcp"
 	"v2ray.com/core/testing/servers/udp"
 )
@@ -362,3 +364,95 @@ func TestUDP2TCPDNSTunnel(t *testing.T) {
 		t.Error(r)
 	}
 }
+
+func TestDNSBlockList(t *testing.T) {
+	port := tcp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "tcp",
+		Handler: &staticHandler{},
+	}
+	defer dnsServer.Shutdown()
+
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	serverPort := tcp.PickPort()
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dnsapp.Config{
+				NameServer: []*dnsapp.NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.DomainAddress("tcp://127.0.0.1:" + port.String())),
+						},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(net.LocalHostIP),
+					Port:     uint32(port),
+					Networks: []net.Network{net.Network_TCP},
+				}),
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(serverPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&dns_proxy.Config{
+					NonIpQuery: dns_proxy.Config_Refused,
+					BlockList: &dns_proxy.BlockList{
+						Domain: []*router.Domain{
+							{
+								Type:  router.Domain_Domain,
+								Value: "ads.example.com",
+							},
+						},
+						Response: dns_proxy.BlockList_ZeroIP,
+					},
+				}),
+			},
+			{
+				Tag:           "direct",
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	c := &dns.Client{
+		Net: "tcp",
+	}
+	exchange := func(name string, qtype uint16) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion(name, qtype)
+		in, _, err := c.Exchange(m, "127.0.0.1:"+serverPort.String())
+		common.Must(err)
+		return in
+	}
+
+	if in := exchange("tracker.ads.example.com.", dns.TypeA); len(in.Answer) != 1 || !in.Answer[0].(*dns.A).A.Equal(net.IP{0, 0, 0, 0}) {
+		t.Error("expect 0.0.0.0 for blocked domain, but got ", in.Answer)
+	}
+	if in := exchange("google.com.", dns.TypeMX); in.Rcode != dns.RcodeRefused {
+		t.Error("expect REFUSED for MX query, but got ", in.Rcode)
+	}
+	if in := exchange("google.com.", dns.TypeA); len(in.Answer) != 1 || !in.Answer[0].(*dns.A).A.Equal(net.IP{8, 8, 8, 8}) {
+		t.Error("expect 8.8.8.8, but got ", in.Answer)
+	}
+}
@@ -0,0 +1,490 @@
+// +build !confonly
+
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/errors"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	dns_proto "v2ray.com/core/common/protocol/dns"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/signal"
+	"v2ray.com/core/features/dns"
+	"v2ray.com/core/features/policy"
+	"v2ray.com/core/features/routing"
+	"v2ray.com/core/transport/internet"
+)
+
+const (
+	// ednsUDPSize is the largest UDP response to send, if the client supports EDNS0.
+	ednsUDPSize = 1232
+	// plainUDPSize is the largest UDP response to send to clients without EDNS0 (RFC 1035).
+	plainUDPSize = 512
+
+	defaultServerTTL = 60
+	forwardTimeout   = time.Second * 4
+)
+
+func init() {
+	common.Must(common.RegisterConfig((*ServerConfig)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		s := new(Server)
+		if err := core.RequireFeatures(ctx, func(dnsClient dns.Client, pm policy.Manager) error {
+			return s.Init(config.(*ServerConfig), dnsClient, pm)
+		}); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}))
+}
+
+type upstream struct {
+	dest    net.Destination
+	matcher *router.DomainMatcher
+}
+
+// Server is an inbound DNS server on UDP and TCP. A and AAAA queries are
+// answered by the DNS app, so that static hosts and the cache apply. Queries of
+// other types are forwarded to the upstream of their domains.
+type Server struct {
+	config        *ServerConfig
+	ipv4Lookup    dns.IPv4Lookup
+	ipv6Lookup    dns.IPv6Lookup
+	fakeLookup    dns.FakeDNSLookup
+	policyManager policy.Manager
+	upstreams     []*upstream
+	ttl           uint32
+}
+
+// Init initializes the Server with necessary parameters.
+func (s *Server) Init(config *ServerConfig, dnsClient dns.Client, pm policy.Manager) error {
+	ipv4lookup, ok := dnsClient.(dns.IPv4Lookup)
+	if !ok {
+		return newError("dns.Client doesn't implement IPv4Lookup")
+	}
+	s.ipv4Lookup = ipv4lookup
+
+	ipv6lookup, ok := dnsClient.(dns.IPv6Lookup)
+	if !ok {
+		return newError("dns.Client doesn't implement IPv6Lookup")
+	}
+	s.ipv6Lookup = ipv6lookup
+
+	s.config = config
+	if v, ok := dnsClient.(dns.FakeDNSLookup); ok {
+		s.fakeLookup = v
+	}
+	s.policyManager = pm
+	s.ttl = config.Ttl
+	if s.ttl == 0 {
+		s.ttl = defaultServerTTL
+	}
+
+	for _, u := range config.Upstream {
+		if u.Address == nil {
+			return newError("upstream address is not specified")
+		}
+		dest := u.Address.AsDestination()
+		if dest.Network == net.Network_Unknown {
+			dest.Network = net.Network_UDP
+		}
+		if dest.Port == 0 {
+			dest.Port = 53
+		}
+		up := &upstream{
+			dest: dest,
+		}
+		if len(u.Domain) > 0 {
+			matcher, err := router.NewDomainMatcher(u.Domain)
+			if err != nil {
+				return newError("failed to create domain matcher of upstream ", dest).Base(err)
+			}
+			up.matcher = matcher
+		}
+		s.upstreams = append(s.upstreams, up)
+	}
+
+	return nil
+}
+
+// Network implements proxy.Inbound.
+func (*Server) Network() []net.Network {
+	return []net.Network{net.Network_TCP, net.Network_UDP}
+}
+
+// Process implements proxy.Inbound.
+func (s *Server) Process(ctx context.Context, network net.Network, conn internet.Connection, dispatcher routing.Dispatcher) error {
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		inbound.User = &protocol.MemoryUser{
+			Level: s.config.UserLevel,
+		}
+	}
+
+	plcy := s.policyManager.ForLevel(s.config.UserLevel)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
+	go func() {
+		<-ctx.Done()
+		conn.Close() // nolint: errcheck
+	}()
+
+	var reader dns_proto.MessageReader
+	if network == net.Network_TCP {
+		reader = dns_proto.NewTCPReader(buf.NewReader(conn))
+	} else {
+		reader = &dns_proto.UDPReader{
+			Reader: buf.NewPacketReader(conn),
+		}
+	}
+
+	var writeAccess sync.Mutex
+	write := func(msg []byte) error {
+		writeAccess.Lock()
+		defer writeAccess.Unlock()
+
+		if network == net.Network_TCP {
+			b := make([]byte, 2, 2+len(msg))
+			binary.BigEndian.PutUint16(b, uint16(len(msg)))
+			msg = append(b, msg...)
+		}
+		_, err := conn.Write(msg)
+		return err
+	}
+
+	for {
+		b, err := reader.ReadMessage()
+		if err != nil {
+			if errors.Cause(err) == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return newError("failed to read query").Base(err)
+		}
+		timer.Update()
+
+		query := append([]byte(nil), b.Bytes()...)
+		b.Release()
+
+		go func() {
+			resp := s.handleQuery(ctx, query, network, dispatcher)
+			if resp == nil {
+				return
+			}
+			if err := write(resp); err != nil {
+				newError("failed to write response").Base(err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+				return
+			}
+			timer.Update()
+		}()
+	}
+}
+
+// queryInfo is what the Server needs to know about a query.
+type queryInfo struct {
+	header   dnsmessage.Header
+	question dnsmessage.Question
+	// udpSize is the UDP payload size in the EDNS0 option, or 0 if there is no EDNS0 option.
+	udpSize uint16
+}
+
+func parseQuery(b []byte) (*queryInfo, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(b)
+	if err != nil {
+		return nil, err
+	}
+	q, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+	info := &queryInfo{
+		header:   header,
+		question: q,
+	}
+
+	if err := parser.SkipAllQuestions(); err != nil {
+		return info, nil
+	}
+	if err := parser.SkipAllAnswers(); err != nil {
+		return info, nil
+	}
+	if err := parser.SkipAllAuthorities(); err != nil {
+		return info, nil
+	}
+	for {
+		h, err := parser.AdditionalHeader()
+		if err != nil {
+			break
+		}
+		if h.Type == dnsmessage.TypeOPT {
+			info.udpSize = uint16(h.Class)
+		}
+		if err := parser.SkipAdditional(); err != nil {
+			break
+		}
+	}
+	return info, nil
+}
+
+// maxResponseSize returns the size limit of the response to the query.
+func (q *queryInfo) maxResponseSize(network net.Network) int {
+	switch {
+	case network == net.Network_TCP:
+		return 65535
+	case q.udpSize == 0:
+		return plainUDPSize
+	case q.udpSize < plainUDPSize:
+		return plainUDPSize
+	case q.udpSize > ednsUDPSize:
+		return ednsUDPSize
+	default:
+		return int(q.udpSize)
+	}
+}
+
+// newResponse creates the response to the query without any record.
+func (q *queryInfo) newResponse(rcode dnsmessage.RCode) *dnsmessage.Message {
+	msg := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 q.header.ID,
+			Response:           true,
+			OpCode:             q.header.OpCode,
+			RecursionDesired:   q.header.RecursionDesired,
+			RecursionAvailable: true,
+			RCode:              rcode,
+		},
+		Questions: []dnsmessage.Question{q.question},
+	}
+	if q.udpSize > 0 {
+		var opt dnsmessage.Resource
+		common.Must(opt.Header.SetEDNS0(ednsUDPSize, dnsmessage.RCodeSuccess, false))
+		opt.Body = &dnsmessage.OPTResource{}
+		msg.Additionals = append(msg.Additionals, opt)
+	}
+	return msg
+}
+
+func (s *Server) handleQuery(ctx context.Context, query []byte, network net.Network, dispatcher routing.Dispatcher) []byte {
+	q, err := parseQuery(query)
+	if err != nil {
+		newError("failed to parse query").Base(err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+		return nil
+	}
+
+	var resp []byte
+	switch {
+	case q.header.Response || q.header.OpCode != 0:
+		resp = pack(q.newResponse(dnsmessage.RCodeNotImplemented))
+	case q.question.Type == dnsmessage.TypeA || q.question.Type == dnsmessage.TypeAAAA:
+		resp = pack(s.answerIP(q))
+	default:
+		resp = s.forward(ctx, q, query, dispatcher)
+	}
+	if resp == nil {
+		return nil
+	}
+
+	return truncate(resp, q.maxResponseSize(network))
+}
+
+func pack(msg *dnsmessage.Message) []byte {
+	b, err := msg.Pack()
+	if err != nil {
+		newError("failed to pack response").Base(err).WriteToLog()
+		return nil
+	}
+	return b
+}
+
+// truncate replaces the response with an empty one with the TC bit set, if it
+// is larger than maxSize, so that the client asks again over TCP (RFC 7766).
+func truncate(resp []byte, maxSize int) []byte {
+	if len(resp) <= maxSize {
+		return resp
+	}
+
+	var parser dnsmessage.Parser
+	header, err := parser.Start(resp)
+	if err != nil {
+		return nil
+	}
+	questions, err := parser.AllQuestions()
+	if err != nil {
+		return nil
+	}
+	header.Truncated = true
+	return pack(&dnsmessage.Message{
+		Header:    header,
+		Questions: questions,
+	})
+}
+
+func (s *Server) answerIP(q *queryInfo) *dnsmessage.Message {
+	domain := strings.TrimSuffix(q.question.Name.String(), ".")
+
+	var ips []net.IP
+	var err error
+	fake := false
+	if s.fakeLookup != nil {
+		ips, fake = s.fakeLookup.LookupFakeIP(domain, q.question.Type == dnsmessage.TypeA, q.question.Type == dnsmessage.TypeAAAA)
+	}
+	switch {
+	case fake:
+	case q.question.Type == dnsmessage.TypeA:
+		ips, err = s.ipv4Lookup.LookupIPv4(domain)
+	default:
+		ips, err = s.ipv6Lookup.LookupIPv6(domain)
+	}
+
+	if rcode := dns.RCodeFromError(err); rcode != 0 {
+		return q.newResponse(dnsmessage.RCode(rcode))
+	}
+	if err != nil && err != dns.ErrEmptyResponse {
+		newError("failed to lookup IP for ", domain).Base(err).WriteToLog()
+		return q.newResponse(dnsmessage.RCodeServerFailure)
+	}
+
+	msg := q.newResponse(dnsmessage.RCodeSuccess)
+	ttl := s.ttl
+	if fake {
+		// Fake IPs are only valid while their mappings are kept, so clients should not cache them for long.
+		ttl = 1
+	}
+	rHeader := dnsmessage.ResourceHeader{
+		Name:  q.question.Name,
+		Class: dnsmessage.ClassINET,
+		TTL:   ttl,
+	}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			if q.question.Type != dnsmessage.TypeA {
+				continue
+			}
+			var r dnsmessage.AResource
+			copy(r.A[:], ip4)
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: rHeader, Body: &r})
+		} else if q.question.Type == dnsmessage.TypeAAAA {
+			var r dnsmessage.AAAAResource
+			copy(r.AAAA[:], ip.To16())
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: rHeader, Body: &r})
+		}
+	}
+	return msg
+}
+
+// upstreamFor returns the upstream for the domain. Upstreams with matching
+// domains are preferred over the ones without domains.
+func (s *Server) upstreamFor(domain string) *upstream {
+	var fallback *upstream
+	for _, u := range s.upstreams {
+		if u.matcher == nil {
+			if fallback == nil {
+				fallback = u
+			}
+			continue
+		}
+		if u.matcher.ApplyDomain(domain) {
+			return u
+		}
+	}
+	return fallback
+}
+
+// forward sends the query to its upstream, and returns the response. A
+// truncated response over UDP is queried again over TCP.
+func (s *Server) forward(ctx context.Context, q *queryInfo, query []byte, dispatcher routing.Dispatcher) []byte {
+	domain := strings.TrimSuffix(q.question.Name.String(), ".")
+	u := s.upstreamFor(domain)
+	if u == nil {
+		newError("no upstream for ", domain, ", query type ", q.question.Type).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+		return pack(q.newResponse(dnsmessage.RCodeRefused))
+	}
+
+	resp, err := exchange(ctx, dispatcher, u.dest, q.header.ID, query)
+	if err == nil && u.dest.Network == net.Network_UDP && isTruncated(resp) {
+		dest := u.dest
+		dest.Network = net.Network_TCP
+		resp, err = exchange(ctx, dispatcher, dest, q.header.ID, query)
+	}
+	if err != nil {
+		newError("failed to forward query of ", domain, " to ", u.dest).Base(err).WriteToLog(session.ExportIDToError(ctx))
+		return pack(q.newResponse(dnsmessage.RCodeServerFailure))
+	}
+	return resp
+}
+
+func isTruncated(resp []byte) bool {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(resp)
+	return err == nil && header.Truncated
+}
+
+// exchange sends the query to dest through the dispatcher, and waits for the response.
+func exchange(ctx context.Context, dispatcher routing.Dispatcher, dest net.Destination, id uint16, query []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, forwardTimeout)
+	defer cancel()
+
+	link, err := dispatcher.Dispatch(ctx, dest)
+	if err != nil {
+		return nil, newError("failed to dispatch query").Base(err)
+	}
+	defer common.Close(link.Writer) // nolint: errcheck
+	go func() {
+		<-ctx.Done()
+		common.Interrupt(link.Reader)
+	}()
+
+	var mb buf.MultiBuffer
+	if dest.Network == net.Network_TCP {
+		size := buf.New()
+		binary.BigEndian.PutUint16(size.Extend(2), uint16(len(query)))
+		mb = append(mb, size)
+	}
+	mb = buf.MergeBytes(mb, query)
+	if err := link.Writer.WriteMultiBuffer(mb); err != nil {
+		return nil, newError("failed to send query").Base(err)
+	}
+
+	if dest.Network == net.Network_TCP {
+		reader := &buf.BufferedReader{Reader: link.Reader}
+		for {
+			size, err := serial.ReadUint16(reader)
+			if err != nil {
+				return nil, err
+			}
+			resp := make([]byte, size)
+			if _, err := io.ReadFull(reader, resp); err != nil {
+				return nil, err
+			}
+			if len(resp) >= 2 && binary.BigEndian.Uint16(resp) == id {
+				return resp, nil
+			}
+		}
+	}
+
+	reader := &dns_proto.UDPReader{Reader: link.Reader}
+	for {
+		b, err := reader.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		resp := append([]byte(nil), b.Bytes()...)
+		b.Release()
+		if len(resp) >= 2 && binary.BigEndian.Uint16(resp) == id {
+			return resp, nil
+		}
+	}
+}
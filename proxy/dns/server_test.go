@@ -0,0 +1,186 @@
+package dns_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/dispatcher"
+	dnsapp "v2ray.com/core/app/dns"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	dns_proxy "v2ray.com/core/proxy/dns"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/testing/servers/udp"
+	_ "v2ray.com/core/transport/internet/tcp"
+	_ "v2ray.com/core/transport/internet/udp"
+)
+
+type recordHandler struct{}
+
+func (*recordHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	ans := new(dns.Msg)
+	ans.SetReply(r)
+
+	q := r.Question[0]
+	switch {
+	case q.Name == "google.com." && q.Qtype == dns.TypeA:
+		rr, err := dns.NewRR("google.com. IN A 8.8.8.8")
+		common.Must(err)
+		ans.Answer = append(ans.Answer, rr)
+	case q.Name == "v2ray.com." && q.Qtype == dns.TypeMX:
+		rr, err := dns.NewRR("v2ray.com. IN MX 10 mail.v2ray.com.")
+		common.Must(err)
+		ans.Answer = append(ans.Answer, rr)
+	case q.Name == "big.v2ray.com." && q.Qtype == dns.TypeTXT:
+		for i := 0; i < 8; i++ {
+			rr, err := dns.NewRR("big.v2ray.com. IN TXT \"" + strings.Repeat("v", 200) + "\"")
+			common.Must(err)
+			ans.Answer = append(ans.Answer, rr)
+		}
+	case q.Name == "notexist.google.com.":
+		ans.Rcode = dns.RcodeNameError
+	}
+	w.WriteMsg(ans)
+}
+
+func TestDNSServer(t *testing.T) {
+	upstreamPort := tcp.PickPort()
+	upstream := dns.Server{
+		Addr:    "127.0.0.1:" + upstreamPort.String(),
+		Net:     "tcp",
+		Handler: &recordHandler{},
+	}
+	defer upstream.Shutdown()
+	go upstream.ListenAndServe()
+	time.Sleep(time.Second)
+
+	tcpPort := tcp.PickPort()
+	udpPort := udp.PickPort()
+	receiver := func(port net.Port) *serial.TypedMessage {
+		return serial.ToTypedMessage(&proxyman.ReceiverConfig{
+			PortRange: net.SinglePortRange(port),
+			Listen:    net.NewIPOrDomain(net.LocalHostIP),
+		})
+	}
+	serverConfig := serial.ToTypedMessage(&dns_proxy.ServerConfig{
+		Upstream: []*dns_proxy.Upstream{
+			{
+				Address: &net.Endpoint{
+					Network: net.Network_TCP,
+					Address: net.NewIPOrDomain(net.LocalHostIP),
+					Port:    uint32(upstreamPort),
+				},
+			},
+		},
+	})
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dnsapp.Config{
+				NameServer: []*dnsapp.NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: net.NewIPOrDomain(net.DomainAddress("tcp://127.0.0.1:" + upstreamPort.String())),
+						},
+					},
+				},
+				StaticHosts: []*dnsapp.Config_HostMapping{
+					{
+						Type:   dnsapp.DomainMatchingType_Full,
+						Domain: "router.lan",
+						Ip:     [][]byte{{192, 168, 1, 1}},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ProxySettings:    serverConfig,
+				ReceiverSettings: receiver(tcpPort),
+			},
+			{
+				ProxySettings:    serverConfig,
+				ReceiverSettings: receiver(udpPort),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	exchange := func(network string, name string, qtype uint16, edns bool) *dns.Msg {
+		t.Helper()
+		m := new(dns.Msg)
+		m.SetQuestion(name, qtype)
+		if edns {
+			m.SetEdns0(1232, false)
+		}
+		c := &dns.Client{
+			Net:     network,
+			UDPSize: 1232,
+		}
+		addr := "127.0.0.1:" + udpPort.String()
+		if network == "tcp" {
+			addr = "127.0.0.1:" + tcpPort.String()
+		}
+		in, _, err := c.Exchange(m, addr)
+		if err != nil {
+			t.Fatal(network, " ", name, ": ", err)
+		}
+		return in
+	}
+
+	for _, network := range []string{"udp", "tcp"} {
+		in := exchange(network, "google.com.", dns.TypeA, false)
+		if len(in.Answer) != 1 || in.Answer[0].(*dns.A).A.String() != "8.8.8.8" {
+			t.Error(network, ": unexpected answer of google.com: ", in.Answer)
+		}
+
+		in = exchange(network, "router.lan.", dns.TypeA, true)
+		if len(in.Answer) != 1 || in.Answer[0].(*dns.A).A.String() != "192.168.1.1" {
+			t.Error(network, ": unexpected answer of static host: ", in.Answer)
+		}
+		if in.IsEdns0() == nil {
+			t.Error(network, ": expect EDNS0 in response")
+		}
+
+		in = exchange(network, "notexist.google.com.", dns.TypeAAAA, false)
+		if in.Rcode != dns.RcodeNameError {
+			t.Error(network, ": expect NXDOMAIN, but got ", in.Rcode)
+		}
+
+		in = exchange(network, "v2ray.com.", dns.TypeMX, false)
+		if len(in.Answer) != 1 || in.Answer[0].(*dns.MX).Mx != "mail.v2ray.com." {
+			t.Error(network, ": unexpected answer of MX: ", in.Answer)
+		}
+	}
+
+	// The large TXT answer doesn't fit into UDP, and is truncated.
+	in := exchange("udp", "big.v2ray.com.", dns.TypeTXT, true)
+	if !in.Truncated || len(in.Answer) != 0 {
+		t.Error("expect truncated response, but got ", in)
+	}
+	in = exchange("tcp", "big.v2ray.com.", dns.TypeTXT, true)
+	if in.Truncated || len(in.Answer) != 8 {
+		t.Error("expect full response over TCP, but got ", len(in.Answer), " answers")
+	}
+}
@@ -0,0 +1,65 @@
+// +build !confonly
+
+package proxy
+
+import (
+	"context"
+	"io"
+
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/signal"
+	"v2ray.com/core/common/task"
+	"v2ray.com/core/features/policy"
+	"v2ray.com/core/transport/internet"
+)
+
+// ReadFirstPayload reads from the reader until at least size bytes arrive,
+// which is enough to tell an authenticated client from anything else. It
+// stops early if what has arrived can't start a valid header, as told by
+// isPrefix, or if the reader ends or its deadline is hit in between.
+func ReadFirstPayload(reader io.Reader, size int32, isPrefix func([]byte) bool) (*buf.Buffer, error) {
+	first := buf.New()
+	for first.Len() < size && isPrefix(first.Bytes()) {
+		if _, err := first.ReadFrom(reader); err != nil {
+			if first.IsEmpty() {
+				first.Release()
+				return nil, err
+			}
+			break
+		}
+	}
+	return first, nil
+}
+
+// Fallback forwards the connection to dest, including what has been read by
+// reader, and relays the response back.
+func Fallback(ctx context.Context, sessionPolicy policy.Session, dest net.Destination, conn internet.Connection, reader *buf.BufferedReader) error {
+	newError("forwarding request from ", conn.RemoteAddr(), " to fallback ", dest).WriteToLog(session.ExportIDToError(ctx))
+
+	fallbackConn, err := internet.DialSystem(ctx, dest, nil)
+	if err != nil {
+		buf.ReleaseMulti(reader.Buffer)
+		return newError("failed to dial fallback ", dest).Base(err)
+	}
+	defer fallbackConn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+
+	requestDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
+		return buf.Copy(reader, buf.NewWriter(fallbackConn), buf.UpdateActivity(timer))
+	}
+
+	responseDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
+		return buf.Copy(buf.NewReader(fallbackConn), buf.NewWriter(conn), buf.UpdateActivity(timer))
+	}
+
+	if err := task.Run(ctx, requestDone, responseDone); err != nil {
+		return newError("fallback connection ends").Base(err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package proxy_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+
+	"v2ray.com/core/common"
+	. "v2ray.com/core/proxy"
+)
+
+func TestReadFirstPayload(t *testing.T) {
+	payload := []byte("0123456789")
+	isDigits := func(b []byte) bool {
+		return len(bytes.Trim(b, "0123456789")) == 0
+	}
+
+	// The header may arrive in pieces.
+	first, err := ReadFirstPayload(iotest.OneByteReader(bytes.NewReader(payload)), 4, isDigits)
+	common.Must(err)
+	if first.String() != "0123" {
+		t.Error("expect the first 4 bytes, but got ", first.String())
+	}
+	first.Release()
+
+	// A short payload is returned as is when the reader ends.
+	first, err = ReadFirstPayload(bytes.NewReader(payload[:2]), 4, isDigits)
+	common.Must(err)
+	if first.String() != "01" {
+		t.Error("expect the whole payload, but got ", first.String())
+	}
+	first.Release()
+
+	// Reading stops once the payload can't be a header.
+	first, err = ReadFirstPayload(iotest.OneByteReader(bytes.NewReader([]byte("GET / HTTP/1.1"))), 4, isDigits)
+	common.Must(err)
+	if first.String() != "G" {
+		t.Error("expect to stop at the first byte, but got ", first.String())
+	}
+	first.Release()
+
+	if _, err := ReadFirstPayload(bytes.NewReader(nil), 4, isDigits); err == nil {
+		t.Error("expect an error for an empty reader")
+	}
+}
@@ -5,6 +5,8 @@
 // 2. Register a config creator through common.RegisterConfig.
 package proxy
 
+//go:generate errorgen
+
 import (
 	"context"
 
@@ -0,0 +1,69 @@
+// +build !confonly
+
+package shadowsocks
+
+import (
+	"crypto/aes"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/antireplay"
+)
+
+const (
+	// requestFixedHeaderSize is the size of the type, the timestamp and the
+	// length of the variable-length header, in the header of a request.
+	requestFixedHeaderSize = 1 + 8 + 2
+
+	// packetHeaderSize is the size of the session ID and the packet ID of a UDP packet.
+	packetHeaderSize = 8 + 8
+
+	// saltReplayInterval is how long the salts of requests are remembered,
+	// which is longer than the time window of their timestamps.
+	saltReplayInterval = time.Second * 60
+)
+
+func (c *AEAD2022Cipher) probeSize() int32 {
+	return c.IVSize() + requestFixedHeaderSize + 16
+}
+
+// canOpenFirstChunk returns true if the key opens the fixed-length header after the salt.
+func (c *AEAD2022Cipher) canOpenFirstChunk(key []byte, data []byte) bool {
+	saltLen := c.IVSize()
+	if int32(len(data)) <= saltLen {
+		return false
+	}
+	auth := c.createAuthenticator(key, data[:saltLen])
+	end := saltLen + requestFixedHeaderSize + int32(auth.Overhead())
+	if int32(len(data)) < end {
+		return false
+	}
+	_, err := auth.Open(nil, data[saltLen:end])
+	return err == nil
+}
+
+// canOpenPacket returns true if the key opens the UDP packet.
+func (c *AEAD2022Cipher) canOpenPacket(key []byte, data []byte) bool {
+	if c.PacketAEADCreator != nil {
+		aead := c.PacketAEADCreator(key)
+		if len(data) < aead.NonceSize()+aead.Overhead() {
+			return false
+		}
+		_, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+		return err == nil
+	}
+
+	if len(data) < packetHeaderSize {
+		return false
+	}
+	var header [packetHeaderSize]byte
+	block, err := aes.NewCipher(key)
+	common.Must(err)
+	block.Decrypt(header[:], data[:packetHeaderSize])
+	_, err = c.sessionAEAD(key, header[:8]).Open(nil, header[4:], data[packetHeaderSize:], nil)
+	return err == nil
+}
+
+// requestSalts are the salts of all requests received recently. Salts are
+// random, so those of all users are kept together.
+var requestSalts = antireplay.NewReplayFilter(saltReplayInterval)
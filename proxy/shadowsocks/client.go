@@ -106,7 +106,7 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 
 	if request.Command == protocol.RequestCommandTCP {
 		bufferedWriter := buf.NewBufferedWriter(buf.NewWriter(conn))
-		bodyWriter, err := WriteTCPRequest(request, bufferedWriter)
+		requestIV, bodyWriter, err := WriteTCPRequest(request, bufferedWriter)
 		if err != nil {
 			return newError("failed to write request").Base(err)
 		}
@@ -123,7 +123,7 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 		responseDone := func() error {
 			defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
 
-			responseReader, err := ReadTCPResponse(user, conn)
+			responseReader, err := ReadTCPResponse(user, requestIV, conn)
 			if err != nil {
 				return err
 			}
@@ -140,10 +140,18 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	}
 
 	if request.Command == protocol.RequestCommandUDP {
+		var udpSession *UDPSession
+		if _, ok := account.Cipher.(*AEAD2022Cipher); ok {
+			udpSession, err = NewUDPSession(account, false)
+			if err != nil {
+				return newError("failed to create UDP session").Base(err)
+			}
+		}
 
 		writer := &UDPWriter{
 			Writer:  conn,
 			Request: request,
+			Session: udpSession,
 		}
 
 		requestDone := func() error {
@@ -159,8 +167,9 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 			defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
 
 			reader := &UDPReader{
-				Reader: conn,
-				User:   user,
+				Reader:  conn,
+				User:    user,
+				Session: udpSession,
 			}
 
 			if err := buf.CopyPacket(reader, link.Writer, buf.UpdateActivity(timer)); err != nil {
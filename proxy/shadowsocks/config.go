@@ -6,13 +6,17 @@ import (
 	"crypto/cipher"
 	"crypto/md5"
 	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
 	"io"
 
 	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/hkdf"
+	"lukechampine.com/blake3"
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/bytespool"
 	"v2ray.com/core/common/crypto"
 	"v2ray.com/core/common/protocol"
 )
@@ -46,6 +50,12 @@ func createChacha20Poly1305(key []byte) cipher.AEAD {
 	return chacha20
 }
 
+func createXChacha20Poly1305(key []byte) cipher.AEAD {
+	xchacha20, err := chacha20poly1305.NewX(key)
+	common.Must(err)
+	return xchacha20
+}
+
 func (a *Account) getCipher() (Cipher, error) {
 	switch a.CipherType {
 	case CipherType_AES_128_CFB:
@@ -74,6 +84,22 @@ func (a *Account) getCipher() (Cipher, error) {
 			IVBytes:         32,
 			AEADAuthCreator: createChacha20Poly1305,
 		}, nil
+	case CipherType_AES_128_GCM_2022:
+		return &AEAD2022Cipher{
+			KeyBytes:        16,
+			AEADAuthCreator: createAesGcm,
+		}, nil
+	case CipherType_AES_256_GCM_2022:
+		return &AEAD2022Cipher{
+			KeyBytes:        32,
+			AEADAuthCreator: createAesGcm,
+		}, nil
+	case CipherType_CHACHA20_POLY1305_2022:
+		return &AEAD2022Cipher{
+			KeyBytes:          32,
+			AEADAuthCreator:   createChacha20Poly1305,
+			PacketAEADCreator: createXChacha20Poly1305,
+		}, nil
 	case CipherType_NONE:
 		return NoneCipher{}, nil
 	default:
@@ -87,6 +113,19 @@ func (a *Account) AsAccount() (protocol.Account, error) {
 	if err != nil {
 		return nil, newError("failed to get cipher").Base(err)
 	}
+	if _, ok := cipher.(*AEAD2022Cipher); ok {
+		key, err := base64.StdEncoding.DecodeString(a.Password)
+		if err != nil {
+			return nil, newError("password of Shadowsocks 2022 is not in base64").Base(err)
+		}
+		if int32(len(key)) != cipher.KeySize() {
+			return nil, newError("password of Shadowsocks 2022 must be a key of ", cipher.KeySize(), " bytes")
+		}
+		return &MemoryAccount{
+			Cipher: cipher,
+			Key:    key,
+		}, nil
+	}
 	return &MemoryAccount{
 		Cipher:      cipher,
 		Key:         passwordToCipherKey([]byte(a.Password), cipher.KeySize()),
@@ -220,6 +259,143 @@ func (c *AEADCipher) DecodePacket(key []byte, b *buf.Buffer) error {
 	return nil
 }
 
+func (c *AEADCipher) probeSize() int32 {
+	return c.IVSize() + 2 + 16
+}
+
+// canOpenFirstChunk returns true if the key opens the size of the first chunk after the salt.
+func (c *AEADCipher) canOpenFirstChunk(key []byte, data []byte) bool {
+	ivLen := c.IVSize()
+	if int32(len(data)) <= ivLen {
+		return false
+	}
+	auth := c.createAuthenticator(key, data[:ivLen])
+	end := ivLen + 2 + int32(auth.Overhead())
+	if int32(len(data)) < end {
+		return false
+	}
+	_, err := auth.Open(nil, data[ivLen:end])
+	return err == nil
+}
+
+// canOpenPacket returns true if the key opens the UDP packet.
+func (c *AEADCipher) canOpenPacket(key []byte, data []byte) bool {
+	ivLen := c.IVSize()
+	if int32(len(data)) <= ivLen {
+		return false
+	}
+	auth := c.createAuthenticator(key, data[:ivLen])
+	_, err := auth.Open(nil, data[ivLen:])
+	return err == nil
+}
+
+// AEAD2022Cipher represents the AEAD ciphers of Shadowsocks 2022. The key is
+// used as is, and the session keys are derived with BLAKE3.
+type AEAD2022Cipher struct {
+	KeyBytes        int32
+	AEADAuthCreator func(key []byte) cipher.AEAD
+	// PacketAEADCreator creates the AEAD sealing whole UDP packets with the key.
+	// If it is nil, UDP packets have separate headers encrypted with AES, and
+	// their bodies are sealed with session keys.
+	PacketAEADCreator func(key []byte) cipher.AEAD
+}
+
+func (*AEAD2022Cipher) IsAEAD() bool {
+	return true
+}
+
+func (c *AEAD2022Cipher) KeySize() int32 {
+	return c.KeyBytes
+}
+
+func (c *AEAD2022Cipher) IVSize() int32 {
+	return c.KeyBytes
+}
+
+// sessionAEAD returns the AEAD with the key of the session with the salt or the ID.
+func (c *AEAD2022Cipher) sessionAEAD(key []byte, salt []byte) cipher.AEAD {
+	material := make([]byte, 0, len(key)+len(salt))
+	material = append(material, key...)
+	material = append(material, salt...)
+	subkey := make([]byte, c.KeyBytes)
+	blake3.DeriveKey(subkey, "shadowsocks 2022 session subkey", material)
+	return c.AEADAuthCreator(subkey)
+}
+
+func (c *AEAD2022Cipher) createAuthenticator(key []byte, salt []byte) *crypto.AEADAuthenticator {
+	return &crypto.AEADAuthenticator{
+		AEAD:           c.sessionAEAD(key, salt),
+		NonceGenerator: crypto.GenerateInitialAEADNonce(),
+	}
+}
+
+// NewEncryptionWriter returns a writer of the chunks of a stream, whose nonces
+// start from zero. The headers of the stream are written by WriteTCPRequest
+// and WriteTCPResponse.
+func (c *AEAD2022Cipher) NewEncryptionWriter(key []byte, iv []byte, writer io.Writer) (buf.Writer, error) {
+	return newChunkWriter2022(c.createAuthenticator(key, iv), writer), nil
+}
+
+// NewDecryptionReader returns a reader of the chunks written by NewEncryptionWriter.
+func (c *AEAD2022Cipher) NewDecryptionReader(key []byte, iv []byte, reader io.Reader) (buf.Reader, error) {
+	return &chunkReader2022{auth: c.createAuthenticator(key, iv), reader: reader}, nil
+}
+
+func (c *AEAD2022Cipher) EncodePacket(key []byte, b *buf.Buffer) error {
+	return newError("Shadowsocks 2022 packets are encoded in UDP sessions")
+}
+
+func (c *AEAD2022Cipher) DecodePacket(key []byte, b *buf.Buffer) error {
+	return newError("Shadowsocks 2022 packets are decoded in UDP sessions")
+}
+
+func newChunkWriter2022(auth *crypto.AEADAuthenticator, writer io.Writer) buf.Writer {
+	return crypto.NewAuthenticationWriter(auth, &crypto.AEADChunkSizeParser{
+		Auth: auth,
+	}, writer, protocol.TransferTypeStream, nil)
+}
+
+// chunkReader2022 reads the chunks of a stream, each of which is the sealed
+// length and the sealed payload. Payloads may be as long as 0xFFFF bytes.
+type chunkReader2022 struct {
+	auth   *crypto.AEADAuthenticator
+	reader io.Reader
+	// pending is the payload read with the header, returned before the chunks.
+	pending buf.MultiBuffer
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (r *chunkReader2022) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	if !r.pending.IsEmpty() {
+		mb := r.pending
+		r.pending = nil
+		return mb, nil
+	}
+
+	overhead := r.auth.Overhead()
+	var sizeBytes [2 + 16]byte
+	if _, err := io.ReadFull(r.reader, sizeBytes[:2+overhead]); err != nil {
+		return nil, err
+	}
+	size, err := r.auth.Open(sizeBytes[:0], sizeBytes[:2+overhead])
+	if err != nil {
+		return nil, newError("failed to open chunk length").Base(err)
+	}
+	length := int32(binary.BigEndian.Uint16(size)) + int32(overhead)
+
+	payload := bytespool.Alloc(length)
+	defer bytespool.Free(payload)
+
+	if _, err := io.ReadFull(r.reader, payload[:length]); err != nil {
+		return nil, err
+	}
+	opened, err := r.auth.Open(payload[:0], payload[:length])
+	if err != nil {
+		return nil, newError("failed to open chunk").Base(err)
+	}
+	return buf.MergeBytes(nil, opened), nil
+}
+
 type ChaCha20 struct {
 	IVBytes int32
 }
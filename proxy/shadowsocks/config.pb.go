@@ -31,30 +31,40 @@ const (
 	CipherType_AES_256_GCM       CipherType = 6
 	CipherType_CHACHA20_POLY1305 CipherType = 7
 	CipherType_NONE              CipherType = 8
+	// Shadowsocks 2022 ciphers, whose passwords are base64 encoded keys.
+	CipherType_AES_128_GCM_2022       CipherType = 9
+	CipherType_AES_256_GCM_2022       CipherType = 10
+	CipherType_CHACHA20_POLY1305_2022 CipherType = 11
 )
 
 var CipherType_name = map[int32]string{
-	0: "UNKNOWN",
-	1: "AES_128_CFB",
-	2: "AES_256_CFB",
-	3: "CHACHA20",
-	4: "CHACHA20_IETF",
-	5: "AES_128_GCM",
-	6: "AES_256_GCM",
-	7: "CHACHA20_POLY1305",
-	8: "NONE",
+	0:  "UNKNOWN",
+	1:  "AES_128_CFB",
+	2:  "AES_256_CFB",
+	3:  "CHACHA20",
+	4:  "CHACHA20_IETF",
+	5:  "AES_128_GCM",
+	6:  "AES_256_GCM",
+	7:  "CHACHA20_POLY1305",
+	8:  "NONE",
+	9:  "AES_128_GCM_2022",
+	10: "AES_256_GCM_2022",
+	11: "CHACHA20_POLY1305_2022",
 }
 
 var CipherType_value = map[string]int32{
-	"UNKNOWN":           0,
-	"AES_128_CFB":       1,
-	"AES_256_CFB":       2,
-	"CHACHA20":          3,
-	"CHACHA20_IETF":     4,
-	"AES_128_GCM":       5,
-	"AES_256_GCM":       6,
-	"CHACHA20_POLY1305": 7,
-	"NONE":              8,
+	"UNKNOWN":                0,
+	"AES_128_CFB":            1,
+	"AES_256_CFB":            2,
+	"CHACHA20":               3,
+	"CHACHA20_IETF":          4,
+	"AES_128_GCM":            5,
+	"AES_256_GCM":            6,
+	"CHACHA20_POLY1305":      7,
+	"NONE":                   8,
+	"AES_128_GCM_2022":       9,
+	"AES_256_GCM_2022":       10,
+	"CHACHA20_POLY1305_2022": 11,
 }
 
 func (x CipherType) String() string {
@@ -151,12 +161,15 @@ func (m *Account) GetOta() Account_OneTimeAuth {
 type ServerConfig struct {
 	// UdpEnabled specified whether or not to enable UDP for Shadowsocks.
 	// Deprecated. Use 'network' field.
-	UdpEnabled           bool           `protobuf:"varint,1,opt,name=udp_enabled,json=udpEnabled,proto3" json:"udp_enabled,omitempty"` // Deprecated: Do not use.
-	User                 *protocol.User `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
-	Network              []net.Network  `protobuf:"varint,3,rep,packed,name=network,proto3,enum=v2ray.core.common.net.Network" json:"network,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
-	XXX_unrecognized     []byte         `json:"-"`
-	XXX_sizecache        int32          `json:"-"`
+	UdpEnabled bool           `protobuf:"varint,1,opt,name=udp_enabled,json=udpEnabled,proto3" json:"udp_enabled,omitempty"` // Deprecated: Do not use.
+	User       *protocol.User `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Network    []net.Network  `protobuf:"varint,3,rep,packed,name=network,proto3,enum=v2ray.core.common.net.Network" json:"network,omitempty"`
+	// Users are the users of the server besides 'user', told apart by their
+	// keys. All of them must use AEAD ciphers if there are more than one.
+	Users                []*protocol.User `protobuf:"bytes,4,rep,name=users,proto3" json:"users,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
 }
 
 func (m *ServerConfig) Reset()         { *m = ServerConfig{} }
@@ -206,6 +219,13 @@ func (m *ServerConfig) GetNetwork() []net.Network {
 	return nil
 }
 
+func (m *ServerConfig) GetUsers() []*protocol.User {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
 type ClientConfig struct {
 	Server               []*protocol.ServerEndpoint `protobuf:"bytes,1,rep,name=server,proto3" json:"server,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
@@ -258,38 +278,40 @@ func init() {
 }
 
 var fileDescriptor_8d089a30c2106007 = []byte{
-	// 522 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x92, 0xc1, 0x6e, 0xd3, 0x4e,
-	0x10, 0xc6, 0xbb, 0x71, 0xff, 0x4d, 0xfe, 0xe3, 0x50, 0xdc, 0x95, 0x90, 0xac, 0xa8, 0x42, 0x56,
-	0x38, 0x10, 0x2a, 0xb1, 0x4e, 0x5c, 0x8a, 0x7a, 0x75, 0x4c, 0x4a, 0x2b, 0xc0, 0x89, 0x9c, 0x14,
-	0x04, 0x17, 0xcb, 0x5d, 0x2f, 0xc4, 0x6a, 0xe2, 0xb5, 0xd6, 0x76, 0x43, 0x9e, 0x86, 0x03, 0x37,
-	0xde, 0x8c, 0xb7, 0x40, 0x5e, 0x3b, 0xa9, 0x85, 0xaa, 0x70, 0x88, 0x94, 0x99, 0xfd, 0x7d, 0x9f,
-	0x66, 0xbe, 0x31, 0xbc, 0xbc, 0xb3, 0x44, 0xb0, 0x26, 0x94, 0x2f, 0x4d, 0xca, 0x05, 0x33, 0x13,
-	0xc1, 0xbf, 0xaf, 0xcd, 0x74, 0x1e, 0x84, 0x7c, 0x95, 0x72, 0x7a, 0x9b, 0x9a, 0x94, 0xc7, 0x5f,
-	0xa3, 0x6f, 0x24, 0x11, 0x3c, 0xe3, 0xf8, 0x78, 0x83, 0x0b, 0x46, 0x24, 0x4a, 0x6a, 0x68, 0xe7,
-	0xf9, 0x5f, 0x66, 0x94, 0x2f, 0x97, 0x3c, 0x36, 0x63, 0x96, 0x15, 0xbf, 0x15, 0x17, 0xb7, 0xa5,
-	0x4d, 0xe7, 0xc5, 0xc3, 0xa0, 0x7c, 0xa4, 0x7c, 0x61, 0xe6, 0x29, 0x13, 0x15, 0xda, 0xff, 0x07,
-	0x9a, 0x32, 0x71, 0xc7, 0x84, 0x9f, 0x26, 0x8c, 0x96, 0x8a, 0xee, 0x6f, 0x04, 0x4d, 0x9b, 0x52,
-	0x9e, 0xc7, 0x19, 0xee, 0x40, 0x2b, 0x09, 0xd2, 0x74, 0xc5, 0x45, 0xa8, 0x23, 0x03, 0xf5, 0xfe,
-	0xf7, 0xb6, 0x35, 0xbe, 0x02, 0x95, 0x46, 0xc9, 0x9c, 0x09, 0x3f, 0x5b, 0x27, 0x4c, 0x6f, 0x18,
-	0xa8, 0x77, 0x68, 0xf5, 0xc8, 0xae, 0x0d, 0x89, 0x23, 0x05, 0xb3, 0x75, 0xc2, 0x3c, 0xa0, 0xdb,
-	0xff, 0xd8, 0x01, 0x85, 0x67, 0x81, 0xae, 0x48, 0x8b, 0xc1, 0x6e, 0x8b, 0x6a, 0x34, 0x32, 0x8e,
-	0xd9, 0x2c, 0x5a, 0x32, 0x3b, 0xcf, 0xe6, 0x5e, 0xa1, 0xee, 0x5a, 0xa0, 0xd6, 0x7a, 0xb8, 0x05,
-	0xfb, 0x76, 0x9e, 0x71, 0x6d, 0x0f, 0xb7, 0xa1, 0xf5, 0x26, 0x4a, 0x83, 0x9b, 0x05, 0x0b, 0x35,
-	0x84, 0x55, 0x68, 0x8e, 0xe2, 0xb2, 0x68, 0x74, 0x7f, 0x22, 0x68, 0x4f, 0x65, 0x02, 0x8e, 0x3c,
-	0x13, 0x7e, 0x06, 0x6a, 0x1e, 0x26, 0x3e, 0x2b, 0x09, 0xb9, 0x73, 0x6b, 0xd8, 0xd0, 0x91, 0x07,
-	0x79, 0x98, 0x54, 0x3a, 0xfc, 0x0a, 0xf6, 0x8b, 0x84, 0xe5, 0xca, 0xaa, 0x65, 0xd4, 0xe7, 0x2d,
-	0xe3, 0x25, 0x9b, 0x78, 0xc9, 0x75, 0xca, 0x84, 0x27, 0x69, 0x7c, 0x0e, 0xcd, 0xea, 0x8a, 0xba,
-	0x62, 0x28, 0xbd, 0x43, 0xeb, 0xe9, 0x03, 0xc2, 0x98, 0x65, 0xc4, 0x2d, 0x29, 0x6f, 0x83, 0x77,
-	0x3d, 0x68, 0x3b, 0x8b, 0x88, 0xc5, 0x59, 0x35, 0xe4, 0x10, 0x0e, 0xca, 0xb3, 0xe9, 0xc8, 0x50,
-	0x7a, 0xaa, 0x75, 0xb2, 0x6b, 0x82, 0x72, 0xbd, 0x51, 0x1c, 0x26, 0x3c, 0x8a, 0x33, 0xaf, 0x52,
-	0x9e, 0xfc, 0x40, 0x00, 0xf7, 0xd7, 0x28, 0x52, 0xb9, 0x76, 0xdf, 0xb9, 0xe3, 0x4f, 0xae, 0xb6,
-	0x87, 0x1f, 0x83, 0x6a, 0x8f, 0xa6, 0xfe, 0xc0, 0x3a, 0xf7, 0x9d, 0x8b, 0xa1, 0x86, 0x36, 0x0d,
-	0xeb, 0xec, 0xb5, 0x6c, 0x34, 0x8a, 0x48, 0x9d, 0x4b, 0xdb, 0xb9, 0xb4, 0xad, 0xbe, 0xa6, 0xe0,
-	0x23, 0x78, 0xb4, 0xa9, 0xfc, 0xab, 0xd1, 0xec, 0x42, 0xdb, 0xaf, 0x5b, 0xbc, 0x75, 0x3e, 0x68,
-	0xff, 0xd5, 0x2d, 0x8a, 0xc6, 0x01, 0x7e, 0x02, 0x47, 0x5b, 0xd1, 0x64, 0xfc, 0xfe, 0xf3, 0xe0,
-	0xb4, 0x7f, 0xa6, 0x35, 0x8b, 0xb3, 0xb9, 0x63, 0x77, 0xa4, 0xb5, 0x86, 0x13, 0x30, 0x28, 0x5f,
-	0xee, 0xfc, 0x18, 0x26, 0xe8, 0x8b, 0x5a, 0x2b, 0x7f, 0x35, 0x8e, 0x3f, 0x5a, 0x5e, 0xb0, 0x26,
-	0x4e, 0x41, 0x4f, 0x24, 0x3d, 0xbd, 0x7f, 0xbe, 0x39, 0x90, 0xa1, 0x9c, 0xfe, 0x09, 0x00, 0x00,
-	0xff, 0xff, 0xdc, 0x7e, 0x6b, 0x61, 0xb5, 0x03, 0x00, 0x00,
+	// 553 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0x61, 0x6f, 0x93, 0x40,
+	0x18, 0xc7, 0x47, 0xe9, 0xd6, 0xee, 0x61, 0x4e, 0x76, 0x51, 0x43, 0x9a, 0xc5, 0x90, 0xfa, 0xc2,
+	0xba, 0x44, 0xe8, 0x6e, 0x6e, 0xd9, 0x5b, 0x8a, 0x9d, 0x5b, 0x54, 0xda, 0xb0, 0x4e, 0xa3, 0x6f,
+	0x08, 0x3b, 0x4e, 0x4b, 0xd6, 0x72, 0xe4, 0x80, 0xd5, 0x7e, 0x25, 0xbf, 0x92, 0x1f, 0xc0, 0xf8,
+	0x2d, 0x0c, 0x07, 0x74, 0x44, 0x97, 0xea, 0x0b, 0x12, 0x9e, 0xe7, 0x7e, 0xff, 0x3f, 0xf7, 0xfc,
+	0x1f, 0xe0, 0xe5, 0x2d, 0xe6, 0xfe, 0xd2, 0x20, 0x6c, 0x6e, 0x12, 0xc6, 0xa9, 0x19, 0x73, 0xf6,
+	0x6d, 0x69, 0x26, 0x53, 0x3f, 0x60, 0x8b, 0x84, 0x91, 0x9b, 0xc4, 0x24, 0x2c, 0xfa, 0x12, 0x7e,
+	0x35, 0x62, 0xce, 0x52, 0x86, 0xf6, 0x2b, 0x9c, 0x53, 0x43, 0xa0, 0x46, 0x0d, 0xed, 0x3c, 0xff,
+	0xc3, 0x8c, 0xb0, 0xf9, 0x9c, 0x45, 0x66, 0x44, 0xd3, 0xfc, 0x59, 0x30, 0x7e, 0x53, 0xd8, 0x74,
+	0x5e, 0xdc, 0x0f, 0x8a, 0x43, 0xc2, 0x66, 0x66, 0x96, 0x50, 0x5e, 0xa2, 0xfd, 0x7f, 0xa0, 0x09,
+	0xe5, 0xb7, 0x94, 0x7b, 0x49, 0x4c, 0x49, 0xa1, 0xe8, 0xfe, 0x92, 0xa0, 0x65, 0x11, 0xc2, 0xb2,
+	0x28, 0x45, 0x1d, 0x68, 0xc7, 0x7e, 0x92, 0x2c, 0x18, 0x0f, 0x34, 0x49, 0x97, 0x7a, 0xdb, 0xee,
+	0xaa, 0x46, 0x17, 0xa0, 0x90, 0x30, 0x9e, 0x52, 0xee, 0xa5, 0xcb, 0x98, 0x6a, 0x0d, 0x5d, 0xea,
+	0xed, 0xe2, 0x9e, 0xb1, 0x6e, 0x42, 0xc3, 0x16, 0x82, 0xc9, 0x32, 0xa6, 0x2e, 0x90, 0xd5, 0x3b,
+	0xb2, 0x41, 0x66, 0xa9, 0xaf, 0xc9, 0xc2, 0xe2, 0x70, 0xbd, 0x45, 0x79, 0x35, 0x63, 0x14, 0xd1,
+	0x49, 0x38, 0xa7, 0x56, 0x96, 0x4e, 0xdd, 0x5c, 0xdd, 0xc5, 0xa0, 0xd4, 0x7a, 0xa8, 0x0d, 0x4d,
+	0x2b, 0x4b, 0x99, 0xba, 0x81, 0x76, 0xa0, 0xfd, 0x3a, 0x4c, 0xfc, 0xeb, 0x19, 0x0d, 0x54, 0x09,
+	0x29, 0xd0, 0x1a, 0x46, 0x45, 0xd1, 0xe8, 0xfe, 0x90, 0x60, 0xe7, 0x52, 0x24, 0x60, 0x8b, 0x35,
+	0xa1, 0x67, 0xa0, 0x64, 0x41, 0xec, 0xd1, 0x82, 0x10, 0x33, 0xb7, 0x07, 0x0d, 0x4d, 0x72, 0x21,
+	0x0b, 0xe2, 0x52, 0x87, 0x5e, 0x41, 0x33, 0x4f, 0x58, 0x8c, 0xac, 0x60, 0xbd, 0x7e, 0xdf, 0x22,
+	0x5e, 0xa3, 0x8a, 0xd7, 0xb8, 0x4a, 0x28, 0x77, 0x05, 0x8d, 0x4e, 0xa1, 0x55, 0x6e, 0x51, 0x93,
+	0x75, 0xb9, 0xb7, 0x8b, 0x9f, 0xde, 0x23, 0x8c, 0x68, 0x6a, 0x38, 0x05, 0xe5, 0x56, 0x38, 0x3a,
+	0x81, 0xcd, 0xdc, 0x21, 0xd1, 0x9a, 0xba, 0xfc, 0x5f, 0x1f, 0x2c, 0xf0, 0xae, 0x0b, 0x3b, 0xf6,
+	0x2c, 0xa4, 0x51, 0x5a, 0x0e, 0x37, 0x80, 0xad, 0x62, 0xdd, 0x9a, 0x24, 0x8c, 0x0e, 0xd6, 0x19,
+	0x15, 0xb1, 0x0c, 0xa3, 0x20, 0x66, 0x61, 0x94, 0xba, 0xa5, 0xf2, 0xe0, 0xa7, 0x04, 0x70, 0xb7,
+	0xc5, 0x3c, 0xcd, 0x2b, 0xe7, 0xad, 0x33, 0xfa, 0xe8, 0xa8, 0x1b, 0xe8, 0x21, 0x28, 0xd6, 0xf0,
+	0xd2, 0x3b, 0xc4, 0xa7, 0x9e, 0x7d, 0x36, 0x50, 0xa5, 0xaa, 0x81, 0x8f, 0x4f, 0x44, 0xa3, 0x91,
+	0xaf, 0xc2, 0x3e, 0xb7, 0xec, 0x73, 0x0b, 0xf7, 0x55, 0x19, 0xed, 0xc1, 0x83, 0xaa, 0xf2, 0x2e,
+	0x86, 0x93, 0x33, 0xb5, 0x59, 0xb7, 0x78, 0x63, 0xbf, 0x57, 0x37, 0xeb, 0x16, 0x79, 0x63, 0x0b,
+	0x3d, 0x86, 0xbd, 0x95, 0x68, 0x3c, 0x7a, 0xf7, 0xe9, 0xf0, 0xa8, 0x7f, 0xac, 0xb6, 0xf2, 0x75,
+	0x3b, 0x23, 0x67, 0xa8, 0xb6, 0xd1, 0x23, 0x50, 0x6b, 0x16, 0x1e, 0xee, 0x63, 0xac, 0x6e, 0x57,
+	0xdd, 0xd2, 0xa7, 0xe8, 0x02, 0xea, 0xc0, 0x93, 0xbf, 0xcc, 0x8a, 0x33, 0x65, 0x30, 0x06, 0x9d,
+	0xb0, 0xf9, 0xda, 0x9f, 0x71, 0x2c, 0x7d, 0x56, 0x6a, 0xe5, 0xf7, 0xc6, 0xfe, 0x07, 0xec, 0xfa,
+	0x4b, 0xc3, 0xce, 0xe9, 0xb1, 0xa0, 0x2f, 0xef, 0x8e, 0xaf, 0xb7, 0x44, 0xb8, 0x47, 0xbf, 0x07,
+	0x00, 0xc0, 0xfb, 0xbf, 0x4f, 0x35, 0x04, 0x00, 0x00,
 }
@@ -28,9 +28,12 @@ var addrParser = protocol.NewAddressParser(
 	}),
 )
 
-// ReadTCPSession reads a Shadowsocks TCP session from the given reader, returns its header and remaining parts.
-func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.RequestHeader, buf.Reader, error) {
+// ReadTCPSession reads a Shadowsocks TCP session from the given reader, returns its header, its IV and remaining parts.
+func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.RequestHeader, []byte, buf.Reader, error) {
 	account := user.Account.(*MemoryAccount)
+	if cipher, ok := account.Cipher.(*AEAD2022Cipher); ok {
+		return readTCPSession2022(user, account, cipher, reader)
+	}
 
 	buffer := buf.New()
 	defer buffer.Release()
@@ -39,7 +42,7 @@ func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.Requ
 	var iv []byte
 	if ivLen > 0 {
 		if _, err := buffer.ReadFullFrom(reader, ivLen); err != nil {
-			return nil, nil, newError("failed to read IV").Base(err)
+			return nil, nil, nil, newError("failed to read IV").Base(err)
 		}
 
 		iv = append([]byte(nil), buffer.BytesTo(ivLen)...)
@@ -47,7 +50,7 @@ func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.Requ
 
 	r, err := account.Cipher.NewDecryptionReader(account.Key, iv, reader)
 	if err != nil {
-		return nil, nil, newError("failed to initialize decoding stream").Base(err).AtError()
+		return nil, nil, nil, newError("failed to initialize decoding stream").Base(err).AtError()
 	}
 	br := &buf.BufferedReader{Reader: r}
 	reader = nil
@@ -63,7 +66,7 @@ func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.Requ
 
 	addr, port, err := addrParser.ReadAddressPort(buffer, br)
 	if err != nil {
-		return nil, nil, newError("failed to read address").Base(err)
+		return nil, nil, nil, newError("failed to read address").Base(err)
 	}
 
 	request.Address = addr
@@ -75,11 +78,11 @@ func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.Requ
 		}
 
 		if request.Option.Has(RequestOptionOneTimeAuth) && account.OneTimeAuth == Account_Disabled {
-			return nil, nil, newError("rejecting connection with OTA enabled, while server disables OTA")
+			return nil, nil, nil, newError("rejecting connection with OTA enabled, while server disables OTA")
 		}
 
 		if !request.Option.Has(RequestOptionOneTimeAuth) && account.OneTimeAuth == Account_Enabled {
-			return nil, nil, newError("rejecting connection with OTA disabled, while server enables OTA")
+			return nil, nil, nil, newError("rejecting connection with OTA disabled, while server enables OTA")
 		}
 	}
 
@@ -89,16 +92,16 @@ func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.Requ
 
 		_, err := buffer.ReadFullFrom(br, AuthSize)
 		if err != nil {
-			return nil, nil, newError("Failed to read OTA").Base(err)
+			return nil, nil, nil, newError("Failed to read OTA").Base(err)
 		}
 
 		if !bytes.Equal(actualAuth, buffer.BytesFrom(-AuthSize)) {
-			return nil, nil, newError("invalid OTA")
+			return nil, nil, nil, newError("invalid OTA")
 		}
 	}
 
 	if request.Address == nil {
-		return nil, nil, newError("invalid remote address.")
+		return nil, nil, nil, newError("invalid remote address.")
 	}
 
 	var chunkReader buf.Reader
@@ -108,13 +111,16 @@ func ReadTCPSession(user *protocol.MemoryUser, reader io.Reader) (*protocol.Requ
 		chunkReader = buf.NewReader(br)
 	}
 
-	return request, chunkReader, nil
+	return request, iv, chunkReader, nil
 }
 
-// WriteTCPRequest writes Shadowsocks request into the given writer, and returns a writer for body.
-func WriteTCPRequest(request *protocol.RequestHeader, writer io.Writer) (buf.Writer, error) {
+// WriteTCPRequest writes Shadowsocks request into the given writer, and returns its IV and a writer for body.
+func WriteTCPRequest(request *protocol.RequestHeader, writer io.Writer) ([]byte, buf.Writer, error) {
 	user := request.User
 	account := user.Account.(*MemoryAccount)
+	if cipher, ok := account.Cipher.(*AEAD2022Cipher); ok {
+		return writeTCPRequest2022(request, cipher, account.Key, writer)
+	}
 
 	if account.Cipher.IsAEAD() {
 		request.Option.Clear(RequestOptionOneTimeAuth)
@@ -125,19 +131,19 @@ func WriteTCPRequest(request *protocol.RequestHeader, writer io.Writer) (buf.Wri
 		iv = make([]byte, account.Cipher.IVSize())
 		common.Must2(rand.Read(iv))
 		if err := buf.WriteAllBytes(writer, iv); err != nil {
-			return nil, newError("failed to write IV")
+			return nil, nil, newError("failed to write IV")
 		}
 	}
 
 	w, err := account.Cipher.NewEncryptionWriter(account.Key, iv, writer)
 	if err != nil {
-		return nil, newError("failed to create encoding stream").Base(err).AtError()
+		return nil, nil, newError("failed to create encoding stream").Base(err).AtError()
 	}
 
 	header := buf.New()
 
 	if err := addrParser.WriteAddressPort(header, request.Address, request.Port); err != nil {
-		return nil, newError("failed to write address").Base(err)
+		return nil, nil, newError("failed to write address").Base(err)
 	}
 
 	if request.Option.Has(RequestOptionOneTimeAuth) {
@@ -150,7 +156,7 @@ func WriteTCPRequest(request *protocol.RequestHeader, writer io.Writer) (buf.Wri
 	}
 
 	if err := w.WriteMultiBuffer(buf.MultiBuffer{header}); err != nil {
-		return nil, newError("failed to write header").Base(err)
+		return nil, nil, newError("failed to write header").Base(err)
 	}
 
 	var chunkWriter buf.Writer
@@ -160,11 +166,15 @@ func WriteTCPRequest(request *protocol.RequestHeader, writer io.Writer) (buf.Wri
 		chunkWriter = w
 	}
 
-	return chunkWriter, nil
+	return iv, chunkWriter, nil
 }
 
-func ReadTCPResponse(user *protocol.MemoryUser, reader io.Reader) (buf.Reader, error) {
+// ReadTCPResponse reads the response of the request with the IV, and returns a reader for body.
+func ReadTCPResponse(user *protocol.MemoryUser, requestIV []byte, reader io.Reader) (buf.Reader, error) {
 	account := user.Account.(*MemoryAccount)
+	if cipher, ok := account.Cipher.(*AEAD2022Cipher); ok {
+		return readTCPResponse2022(cipher, account.Key, requestIV, reader)
+	}
 
 	var iv []byte
 	if account.Cipher.IVSize() > 0 {
@@ -177,9 +187,18 @@ func ReadTCPResponse(user *protocol.MemoryUser, reader io.Reader) (buf.Reader, e
 	return account.Cipher.NewDecryptionReader(account.Key, iv, reader)
 }
 
-func WriteTCPResponse(request *protocol.RequestHeader, writer io.Writer) (buf.Writer, error) {
+// WriteTCPResponse returns a writer for the body of the response to the request with the IV.
+func WriteTCPResponse(request *protocol.RequestHeader, requestIV []byte, writer io.Writer) (buf.Writer, error) {
 	user := request.User
 	account := user.Account.(*MemoryAccount)
+	if cipher, ok := account.Cipher.(*AEAD2022Cipher); ok {
+		return &responseWriter2022{
+			writer:      writer,
+			cipher:      cipher,
+			key:         account.Key,
+			requestSalt: requestIV,
+		}, nil
+	}
 
 	var iv []byte
 	if account.Cipher.IVSize() > 0 {
@@ -296,6 +315,20 @@ func DecodeUDPPacket(user *protocol.MemoryUser, payload *buf.Buffer) (*protocol.
 type UDPReader struct {
 	Reader io.Reader
 	User   *protocol.MemoryUser
+	// Session decodes the packets instead, for Shadowsocks 2022 ciphers.
+	Session *UDPSession
+}
+
+func (v *UDPReader) decodePacket(buffer *buf.Buffer) (net.Destination, *buf.Buffer, error) {
+	if v.Session != nil {
+		dest, err := v.Session.DecodePacket(buffer)
+		return dest, buffer, err
+	}
+	request, payload, err := DecodeUDPPacket(v.User, buffer)
+	if err != nil {
+		return net.Destination{}, nil, err
+	}
+	return request.Destination(), payload, nil
 }
 
 func (v *UDPReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
@@ -305,7 +338,7 @@ func (v *UDPReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
 		buffer.Release()
 		return nil, err
 	}
-	_, payload, err := DecodeUDPPacket(v.User, buffer)
+	_, payload, err := v.decodePacket(buffer)
 	if err != nil {
 		buffer.Release()
 		return nil, err
@@ -320,22 +353,34 @@ func (v *UDPReader) ReadPacket() (*buf.Buffer, *net.UDPAddr, error) {
 		buffer.Release()
 		return nil, nil, err
 	}
-	request, payload, err := DecodeUDPPacket(v.User, buffer)
+	dest, payload, err := v.decodePacket(buffer)
 	if err != nil {
 		buffer.Release()
 		return nil, nil, nil // return nil error to ignore invalid packets
 	}
-	return payload, request.Destination().UDPAddr(), nil
+	return payload, dest.UDPAddr(), nil
 }
 
 type UDPWriter struct {
 	Writer  io.Writer
 	Request *protocol.RequestHeader
+	// Session encodes the packets instead, for Shadowsocks 2022 ciphers.
+	Session *UDPSession
 }
 
 // Write implements buf.LinkWriter.
 func (w *UDPWriter) WritePacket(payload *buf.Buffer, addr *net.UDPAddr) error {
-	packet, err := EncodeUDPPacket(w.Request, payload.Bytes(), addr)
+	var packet *buf.Buffer
+	var err error
+	if w.Session != nil {
+		dest := w.Request.Destination()
+		if addr != nil {
+			dest = net.DestinationFromAddr(addr)
+		}
+		packet, err = w.Session.EncodePacket(payload.Bytes(), dest)
+	} else {
+		packet, err = EncodeUDPPacket(w.Request, payload.Bytes(), addr)
+	}
 	if err != nil {
 		return err
 	}
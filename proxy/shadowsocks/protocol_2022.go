@@ -0,0 +1,476 @@
+// +build !confonly
+
+package shadowsocks
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/dice"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+)
+
+const (
+	// Types of the headers of Shadowsocks 2022, from the client or the server.
+	headerTypeClient = 0
+	headerTypeServer = 1
+
+	// maxTimeDifference is how far the timestamp in a header may be from now.
+	maxTimeDifference = time.Second * 30
+
+	// maxPaddingLength is the max length of the padding of a request header.
+	maxPaddingLength = 900
+
+	// maxChunkSize is the max length of the payload of a chunk.
+	maxChunkSize = 0xFFFF
+)
+
+func checkTimestamp(timestamp uint64) error {
+	diff := time.Now().Sub(time.Unix(int64(timestamp), 0))
+	if diff > maxTimeDifference || diff < -maxTimeDifference {
+		return newError("timestamp is ", diff, " away from now")
+	}
+	return nil
+}
+
+func putTimestamp(b []byte) {
+	binary.BigEndian.PutUint64(b, uint64(time.Now().Unix()))
+}
+
+// writeTCPRequest2022 writes the salt, the fixed-length header and the
+// variable-length header of a request. Without initial payload, the variable-
+// length header has random padding.
+func writeTCPRequest2022(request *protocol.RequestHeader, c *AEAD2022Cipher, key []byte, writer io.Writer) ([]byte, buf.Writer, error) {
+	salt := make([]byte, c.IVSize())
+	common.Must2(rand.Read(salt))
+	auth := c.createAuthenticator(key, salt)
+
+	variable := buf.New()
+	defer variable.Release()
+
+	if err := addrParser.WriteAddressPort(variable, request.Address, request.Port); err != nil {
+		return nil, nil, newError("failed to write address").Base(err)
+	}
+	paddingLen := 1 + dice.Roll(maxPaddingLength)
+	binary.BigEndian.PutUint16(variable.Extend(2), uint16(paddingLen))
+	common.Must2(rand.Read(variable.Extend(int32(paddingLen))))
+
+	var fixed [requestFixedHeaderSize]byte
+	fixed[0] = headerTypeClient
+	putTimestamp(fixed[1:9])
+	binary.BigEndian.PutUint16(fixed[9:], uint16(variable.Len()))
+
+	header := make([]byte, 0, len(salt)+len(fixed)+int(variable.Len())+2*auth.Overhead())
+	header = append(header, salt...)
+	header, err := auth.Seal(header, fixed[:])
+	common.Must(err)
+	header, err = auth.Seal(header, variable.Bytes())
+	common.Must(err)
+
+	if err := buf.WriteAllBytes(writer, header); err != nil {
+		return nil, nil, newError("failed to write request header").Base(err)
+	}
+	return salt, newChunkWriter2022(auth, writer), nil
+}
+
+// readTCPSession2022 reads the headers written by writeTCPRequest2022, and
+// rejects replayed salts and stale timestamps.
+func readTCPSession2022(user *protocol.MemoryUser, account *MemoryAccount, c *AEAD2022Cipher, reader io.Reader) (*protocol.RequestHeader, []byte, buf.Reader, error) {
+	salt := make([]byte, c.IVSize())
+	if _, err := io.ReadFull(reader, salt); err != nil {
+		return nil, nil, nil, newError("failed to read salt").Base(err)
+	}
+	auth := c.createAuthenticator(account.Key, salt)
+
+	fixed := make([]byte, requestFixedHeaderSize+auth.Overhead())
+	if _, err := io.ReadFull(reader, fixed); err != nil {
+		return nil, nil, nil, newError("failed to read fixed-length header").Base(err)
+	}
+	fixed, err := auth.Open(fixed[:0], fixed)
+	if err != nil {
+		return nil, nil, nil, newError("failed to open fixed-length header").Base(err)
+	}
+	if fixed[0] != headerTypeClient {
+		return nil, nil, nil, newError("unexpected header type: ", fixed[0])
+	}
+	if err := checkTimestamp(binary.BigEndian.Uint64(fixed[1:9])); err != nil {
+		return nil, nil, nil, err
+	}
+	if !requestSalts.Check(salt) {
+		return nil, nil, nil, newError("replayed salt")
+	}
+
+	variable := make([]byte, int(binary.BigEndian.Uint16(fixed[9:]))+auth.Overhead())
+	if _, err := io.ReadFull(reader, variable); err != nil {
+		return nil, nil, nil, newError("failed to read variable-length header").Base(err)
+	}
+	variable, err = auth.Open(variable[:0], variable)
+	if err != nil {
+		return nil, nil, nil, newError("failed to open variable-length header").Base(err)
+	}
+
+	r := bytes.NewReader(variable)
+	addr, port, err := addrParser.ReadAddressPort(nil, r)
+	if err != nil {
+		return nil, nil, nil, newError("failed to read address").Base(err)
+	}
+	var paddingLen [2]byte
+	if _, err := io.ReadFull(r, paddingLen[:]); err != nil {
+		return nil, nil, nil, newError("failed to read padding length").Base(err)
+	}
+	padding := int(binary.BigEndian.Uint16(paddingLen[:]))
+	if padding > r.Len() {
+		return nil, nil, nil, newError("invalid padding length: ", padding)
+	}
+	common.Must2(r.Seek(int64(padding), io.SeekCurrent))
+	initial := variable[len(variable)-r.Len():]
+
+	request := &protocol.RequestHeader{
+		Version: Version,
+		User:    user,
+		Command: protocol.RequestCommandTCP,
+		Address: addr,
+		Port:    port,
+	}
+	body := &chunkReader2022{auth: auth, reader: reader}
+	if len(initial) > 0 {
+		body.pending = buf.MergeBytes(nil, initial)
+	}
+	return request, salt, body, nil
+}
+
+// responseWriter2022 writes the header of a response with the first payload,
+// whose length is in the header, and then the chunks of the rest.
+type responseWriter2022 struct {
+	writer      io.Writer
+	cipher      *AEAD2022Cipher
+	key         []byte
+	requestSalt []byte
+	body        buf.Writer
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (w *responseWriter2022) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if w.body != nil {
+		return w.body.WriteMultiBuffer(mb)
+	}
+	if mb.IsEmpty() {
+		return nil
+	}
+
+	salt := make([]byte, w.cipher.IVSize())
+	common.Must2(rand.Read(salt))
+	auth := w.cipher.createAuthenticator(w.key, salt)
+
+	firstLen := mb.Len()
+	if firstLen > maxChunkSize {
+		firstLen = maxChunkSize
+	}
+	first := make([]byte, firstLen)
+	mb, _ = buf.SplitBytes(mb, first)
+
+	fixed := make([]byte, 1+8+len(w.requestSalt)+2)
+	fixed[0] = headerTypeServer
+	putTimestamp(fixed[1:9])
+	copy(fixed[9:], w.requestSalt)
+	binary.BigEndian.PutUint16(fixed[9+len(w.requestSalt):], uint16(firstLen))
+
+	header := make([]byte, 0, len(salt)+len(fixed)+len(first)+2*auth.Overhead())
+	header = append(header, salt...)
+	header, err := auth.Seal(header, fixed)
+	common.Must(err)
+	header, err = auth.Seal(header, first)
+	common.Must(err)
+
+	if err := buf.WriteAllBytes(w.writer, header); err != nil {
+		buf.ReleaseMulti(mb)
+		return newError("failed to write response header").Base(err)
+	}
+	w.body = newChunkWriter2022(auth, w.writer)
+	if mb.IsEmpty() {
+		return nil
+	}
+	return w.body.WriteMultiBuffer(mb)
+}
+
+// readTCPResponse2022 reads the header written by responseWriter2022, which
+// must be of the request with the salt.
+func readTCPResponse2022(c *AEAD2022Cipher, key []byte, requestSalt []byte, reader io.Reader) (buf.Reader, error) {
+	salt := make([]byte, c.IVSize())
+	if _, err := io.ReadFull(reader, salt); err != nil {
+		return nil, newError("failed to read salt").Base(err)
+	}
+	auth := c.createAuthenticator(key, salt)
+
+	fixed := make([]byte, 1+8+len(requestSalt)+2+auth.Overhead())
+	if _, err := io.ReadFull(reader, fixed); err != nil {
+		return nil, newError("failed to read fixed-length header").Base(err)
+	}
+	fixed, err := auth.Open(fixed[:0], fixed)
+	if err != nil {
+		return nil, newError("failed to open fixed-length header").Base(err)
+	}
+	if fixed[0] != headerTypeServer {
+		return nil, newError("unexpected header type: ", fixed[0])
+	}
+	if err := checkTimestamp(binary.BigEndian.Uint64(fixed[1:9])); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(fixed[9:9+len(requestSalt)], requestSalt) {
+		return nil, newError("response is not of the request")
+	}
+
+	first := make([]byte, int(binary.BigEndian.Uint16(fixed[9+len(requestSalt):]))+auth.Overhead())
+	if _, err := io.ReadFull(reader, first); err != nil {
+		return nil, newError("failed to read first payload").Base(err)
+	}
+	first, err = auth.Open(first[:0], first)
+	if err != nil {
+		return nil, newError("failed to open first payload").Base(err)
+	}
+
+	body := &chunkReader2022{auth: auth, reader: reader}
+	if len(first) > 0 {
+		body.pending = buf.MergeBytes(nil, first)
+	}
+	return body, nil
+}
+
+// packetWindow rejects replayed packet IDs, and those too old to tell.
+type packetWindow struct {
+	started bool
+	last    uint64
+	seen    uint64
+}
+
+// Accept returns true and remembers the packet ID, if it hasn't been seen.
+func (w *packetWindow) Accept(id uint64) bool {
+	if !w.started || id > w.last {
+		shift := id - w.last
+		switch {
+		case !w.started || shift >= 64:
+			w.seen = 1
+		default:
+			w.seen = w.seen<<shift | 1
+		}
+		w.started = true
+		w.last = id
+		return true
+	}
+	diff := w.last - id
+	if diff >= 64 || w.seen&(1<<diff) != 0 {
+		return false
+	}
+	w.seen |= 1 << diff
+	return true
+}
+
+// UDPSession is one side of a UDP session of Shadowsocks 2022. The packets of
+// each side have the session ID of the side, and are numbered by packet IDs.
+type UDPSession struct {
+	access   sync.Mutex
+	account  *MemoryAccount
+	cipher   *AEAD2022Cipher
+	isServer bool
+
+	// block encrypts the headers of packets, if they are separate. Otherwise
+	// packetAEAD seals whole packets.
+	block      cipher.Block
+	packetAEAD cipher.AEAD
+
+	id       uint64
+	packetID uint64
+	aead     cipher.AEAD
+
+	peerStarted bool
+	peerID      uint64
+	peerAEAD    cipher.AEAD
+	window      packetWindow
+}
+
+// NewUDPSession creates a new UDPSession of the client or the server, with
+// an account of a Shadowsocks 2022 cipher.
+func NewUDPSession(account *MemoryAccount, isServer bool) (*UDPSession, error) {
+	c, ok := account.Cipher.(*AEAD2022Cipher)
+	if !ok {
+		return nil, newError("not a Shadowsocks 2022 account")
+	}
+	s := &UDPSession{
+		account:  account,
+		cipher:   c,
+		isServer: isServer,
+	}
+	if c.PacketAEADCreator != nil {
+		s.packetAEAD = c.PacketAEADCreator(account.Key)
+	} else {
+		block, err := aes.NewCipher(account.Key)
+		if err != nil {
+			return nil, newError("failed to create header cipher").Base(err)
+		}
+		s.block = block
+	}
+	s.reset()
+	return s, nil
+}
+
+// reset starts the session with a new ID.
+func (s *UDPSession) reset() {
+	var id [8]byte
+	common.Must2(rand.Read(id[:]))
+	s.id = binary.BigEndian.Uint64(id[:])
+	s.packetID = 0
+	if s.block != nil {
+		s.aead = s.cipher.sessionAEAD(s.account.Key, id[:])
+	}
+}
+
+func (s *UDPSession) headerType() (mine byte, peer byte) {
+	if s.isServer {
+		return headerTypeServer, headerTypeClient
+	}
+	return headerTypeClient, headerTypeServer
+}
+
+// EncodePacket seals the payload into a packet of the session. The
+// destination is the target of a client, or the source of a server.
+func (s *UDPSession) EncodePacket(payload []byte, dest net.Destination) (*buf.Buffer, error) {
+	s.access.Lock()
+	id, packetID, aead, peerID := s.id, s.packetID, s.aead, s.peerID
+	s.packetID++
+	s.access.Unlock()
+
+	var prefixLen int32
+	if s.block == nil {
+		aead = s.packetAEAD
+		prefixLen = int32(aead.NonceSize())
+	}
+
+	b := buf.New()
+	if prefixLen > 0 {
+		common.Must2(rand.Read(b.Extend(prefixLen)))
+	}
+	header := b.Extend(packetHeaderSize)
+	binary.BigEndian.PutUint64(header[:8], id)
+	binary.BigEndian.PutUint64(header[8:], packetID)
+
+	mine, _ := s.headerType()
+	common.Must(b.WriteByte(mine))
+	putTimestamp(b.Extend(8))
+	if s.isServer {
+		binary.BigEndian.PutUint64(b.Extend(8), peerID)
+	}
+	binary.BigEndian.PutUint16(b.Extend(2), 0)
+	if err := addrParser.WriteAddressPort(b, dest.Address, dest.Port); err != nil {
+		b.Release()
+		return nil, newError("failed to write address").Base(err)
+	}
+	if b.Len()+int32(len(payload)+aead.Overhead()) > buf.Size {
+		b.Release()
+		return nil, newError("UDP payload too large: ", len(payload))
+	}
+	common.Must2(b.Write(payload))
+	b.Extend(int32(aead.Overhead()))
+
+	if s.block == nil {
+		plainText := b.BytesRange(prefixLen, b.Len()-int32(aead.Overhead()))
+		aead.Seal(plainText[:0], b.BytesTo(prefixLen), plainText, nil)
+		return b, nil
+	}
+
+	plainText := b.BytesRange(packetHeaderSize, b.Len()-int32(aead.Overhead()))
+	aead.Seal(plainText[:0], header[4:], plainText, nil)
+	s.block.Encrypt(header, header)
+	return b, nil
+}
+
+// DecodePacket opens a packet of the peer in place, leaving the payload in
+// it, and returns the destination in the packet.
+func (s *UDPSession) DecodePacket(b *buf.Buffer) (net.Destination, error) {
+	var header []byte
+	var aead cipher.AEAD
+
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	if s.block == nil {
+		aead = s.packetAEAD
+		nonceLen := int32(aead.NonceSize())
+		if b.Len() < nonceLen+packetHeaderSize+int32(aead.Overhead()) {
+			return net.Destination{}, newError("insufficient data: ", b.Len())
+		}
+		plainText, err := aead.Open(b.BytesFrom(nonceLen)[:0], b.BytesTo(nonceLen), b.BytesFrom(nonceLen), nil)
+		if err != nil {
+			return net.Destination{}, newError("failed to open packet").Base(err)
+		}
+		b.Resize(nonceLen, nonceLen+int32(len(plainText)))
+		header = b.BytesTo(packetHeaderSize)
+	} else {
+		if b.Len() <= packetHeaderSize {
+			return net.Destination{}, newError("insufficient data: ", b.Len())
+		}
+		header = b.BytesTo(packetHeaderSize)
+		s.block.Decrypt(header, header)
+		aead = s.peerAEAD
+		if !s.peerStarted || binary.BigEndian.Uint64(header[:8]) != s.peerID {
+			aead = s.cipher.sessionAEAD(s.account.Key, header[:8])
+		}
+		plainText, err := aead.Open(b.BytesFrom(packetHeaderSize)[:0], header[4:], b.BytesFrom(packetHeaderSize), nil)
+		if err != nil {
+			return net.Destination{}, newError("failed to open packet").Base(err)
+		}
+		b.Resize(0, packetHeaderSize+int32(len(plainText)))
+	}
+
+	peerID := binary.BigEndian.Uint64(header[:8])
+	packetID := binary.BigEndian.Uint64(header[8:])
+	b.Advance(packetHeaderSize)
+
+	_, peer := s.headerType()
+	if b.Len() < 1+8+2 || b.Byte(0) != peer {
+		return net.Destination{}, newError("unexpected header type")
+	}
+	if err := checkTimestamp(binary.BigEndian.Uint64(b.BytesRange(1, 9))); err != nil {
+		return net.Destination{}, err
+	}
+	b.Advance(1 + 8)
+	if !s.isServer {
+		if b.Len() < 8+2 || binary.BigEndian.Uint64(b.BytesTo(8)) != s.id {
+			return net.Destination{}, newError("packet is not of the session")
+		}
+		b.Advance(8)
+	}
+	paddingLen := int32(binary.BigEndian.Uint16(b.BytesTo(2)))
+	if b.Len() < 2+paddingLen {
+		return net.Destination{}, newError("invalid padding length: ", paddingLen)
+	}
+	b.Advance(2 + paddingLen)
+
+	// A new session of the peer starts a new session of the server.
+	if !s.peerStarted || peerID != s.peerID {
+		if s.isServer && s.peerStarted {
+			s.reset()
+		}
+		s.peerStarted = true
+		s.peerID = peerID
+		s.peerAEAD = aead
+		s.window = packetWindow{}
+	}
+	if !s.window.Accept(packetID) {
+		return net.Destination{}, newError("replayed packet ", packetID)
+	}
+
+	addr, port, err := addrParser.ReadAddressPort(nil, b)
+	if err != nil {
+		return net.Destination{}, newError("failed to parse address").Base(err)
+	}
+	return net.UDPDestination(addr, port), nil
+}
@@ -1,9 +1,18 @@
 package shadowsocks_test
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/chacha20poly1305"
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/buf"
@@ -36,7 +45,7 @@ func TestUDPEncoding(t *testing.T) {
 
 	data := buf.New()
 	common.Must2(data.WriteString("test string"))
-	encodedData, err := EncodeUDPPacket(request, data.Bytes())
+	encodedData, err := EncodeUDPPacket(request, data.Bytes(), nil)
 	common.Must(err)
 
 	decodedRequest, decodedData, err := DecodeUDPPacket(request.User, encodedData)
@@ -116,12 +125,12 @@ func TestTCPRequest(t *testing.T) {
 		cache := buf.New()
 		defer cache.Release()
 
-		writer, err := WriteTCPRequest(request, cache)
+		_, writer, err := WriteTCPRequest(request, cache)
 		common.Must(err)
 
 		common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{data}))
 
-		decodedRequest, reader, err := ReadTCPSession(request.User, cache)
+		decodedRequest, _, reader, err := ReadTCPSession(request.User, cache)
 		common.Must(err)
 		if r := cmp.Diff(decodedRequest, request); r != "" {
 			t.Error("request: ", r)
@@ -150,16 +159,16 @@ func TestUDPReaderWriter(t *testing.T) {
 	cache := buf.New()
 	defer cache.Release()
 
-	writer := &buf.SequentialWriter{Writer: &UDPWriter{
+	writer := &UDPWriter{
 		Writer: cache,
 		Request: &protocol.RequestHeader{
 			Version: Version,
-			Address: net.DomainAddress("v2ray.com"),
+			Address: net.LocalHostIP,
 			Port:    123,
 			User:    user,
 			Option:  RequestOptionOneTimeAuth,
 		},
-	}}
+	}
 
 	reader := &UDPReader{
 		Reader: cache,
@@ -169,24 +178,389 @@ func TestUDPReaderWriter(t *testing.T) {
 	{
 		b := buf.New()
 		common.Must2(b.WriteString("test payload"))
-		common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+		common.Must(writer.WritePacket(b, nil))
 
-		payload, err := reader.ReadMultiBuffer()
+		payload, addr, err := reader.ReadPacket()
 		common.Must(err)
-		if payload[0].String() != "test payload" {
-			t.Error("unexpected output: ", payload[0].String())
+		if payload.String() != "test payload" {
+			t.Error("unexpected output: ", payload.String())
+		}
+		if addr.Port != 123 {
+			t.Error("unexpected port: ", addr.Port)
 		}
 	}
 
 	{
 		b := buf.New()
 		common.Must2(b.WriteString("test payload 2"))
-		common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{b}))
+		common.Must(writer.WritePacket(b, nil))
+
+		payload, addr, err := reader.ReadPacket()
+		common.Must(err)
+		if payload.String() != "test payload 2" {
+			t.Error("unexpected output: ", payload.String())
+		}
+		if addr.Port != 123 {
+			t.Error("unexpected port: ", addr.Port)
+		}
+	}
+}
+
+func newKey2022(size int) []byte {
+	key := make([]byte, size)
+	common.Must2(rand.Read(key))
+	return key
+}
+
+func toAccount2022(key []byte, cipherType CipherType) protocol.Account {
+	return toAccount(&Account{
+		Password:   base64.StdEncoding.EncodeToString(key),
+		CipherType: cipherType,
+	})
+}
+
+// sequence returns the bytes from start, as the keys and salts of vectors.
+func sequence(start byte, size int) []byte {
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = start + byte(i)
+	}
+	return b
+}
+
+// gcm2022 creates the AEAD of a session with a session key from the vectors.
+func gcm2022(subkey string) cipher.AEAD {
+	key, err := hex.DecodeString(subkey)
+	common.Must(err)
+	block, err := aes.NewCipher(key)
+	common.Must(err)
+	aead, err := cipher.NewGCM(block)
+	common.Must(err)
+	return aead
+}
+
+func TestAccount2022(t *testing.T) {
+	if _, err := (&Account{
+		Password:   base64.StdEncoding.EncodeToString(newKey2022(16)),
+		CipherType: CipherType_AES_256_GCM_2022,
+	}).AsAccount(); err == nil {
+		t.Error("expected error of key size, but nil")
+	}
+	if _, err := (&Account{
+		Password:   "not a key",
+		CipherType: CipherType_AES_128_GCM_2022,
+	}).AsAccount(); err == nil {
+		t.Error("expected error of base64, but nil")
+	}
+}
+
+func TestTCPRequest2022(t *testing.T) {
+	cases := []struct {
+		cipherType CipherType
+		keySize    int
+	}{
+		{CipherType_AES_128_GCM_2022, 16},
+		{CipherType_AES_256_GCM_2022, 32},
+		{CipherType_CHACHA20_POLY1305_2022, 32},
+	}
+
+	for _, c := range cases {
+		request := &protocol.RequestHeader{
+			Version: Version,
+			Command: protocol.RequestCommandTCP,
+			Address: net.DomainAddress("v2ray.com"),
+			Port:    1234,
+			User: &protocol.MemoryUser{
+				Email:   "love@v2ray.com",
+				Account: toAccount2022(newKey2022(c.keySize), c.cipherType),
+			},
+		}
+
+		cache := buf.New()
+		requestSalt, writer, err := WriteTCPRequest(request, cache)
+		common.Must(err)
+		common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("test request"))))
+		replay := append([]byte(nil), cache.Bytes()...)
 
-		payload, err := reader.ReadMultiBuffer()
+		decodedRequest, decodedSalt, reader, err := ReadTCPSession(request.User, cache)
 		common.Must(err)
-		if payload[0].String() != "test payload 2" {
-			t.Error("unexpected output: ", payload[0].String())
+		if decodedRequest.User != request.User || decodedRequest.Destination() != request.Destination() {
+			t.Error("unexpected request: ", decodedRequest)
+		}
+		if r := cmp.Diff(decodedSalt, requestSalt); r != "" {
+			t.Error("salt: ", r)
+		}
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		if s := mb.String(); s != "test request" {
+			t.Error("unexpected request payload: ", s)
+		}
+
+		if _, _, _, err := ReadTCPSession(request.User, bytes.NewReader(replay)); err == nil {
+			t.Error("expected error of replayed salt, but nil")
+		}
+
+		cache.Clear()
+		writer, err = WriteTCPResponse(request, decodedSalt, cache)
+		common.Must(err)
+		common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("test response"))))
+		common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("test response 2"))))
+		response := append([]byte(nil), cache.Bytes()...)
+
+		reader, err = ReadTCPResponse(request.User, requestSalt, cache)
+		common.Must(err)
+		for _, expected := range []string{"test response", "test response 2"} {
+			mb, err := reader.ReadMultiBuffer()
+			common.Must(err)
+			if s := mb.String(); s != expected {
+				t.Error("unexpected response payload: ", s)
+			}
+		}
+
+		if _, err := ReadTCPResponse(request.User, make([]byte, len(requestSalt)), bytes.NewReader(response)); err == nil {
+			t.Error("expected error of request salt, but nil")
+		}
+		cache.Release()
+	}
+}
+
+// The vectors below were computed following the specification of Shadowsocks
+// 2022, with the BLAKE3 implementation of github.com/zeebo/blake3 and the AEADs
+// of the Go standard library and golang.org/x/crypto.
+
+func TestAEAD2022Vector(t *testing.T) {
+	cases := []struct {
+		cipherType CipherType
+		keySize    int
+		stream     string
+	}{
+		{CipherType_AES_128_GCM_2022, 16, "a34ecd7b5e17ccc60b9c3465c6cad9c95dc05ebda64fcb34270119e9bf866ecae8a0ff5625c3dafb8c06ef73a960"},
+		{CipherType_AES_256_GCM_2022, 32, "4eedce41a7c0950193918b221794dcf9099f132f290aa6da765773a6b20adc90bb6aea9d0d8b9fb612ba77839ebf"},
+		{CipherType_CHACHA20_POLY1305_2022, 32, "3b35e95c6bcd4cd385d526dc92f3af10f5166372e9690530a0ccc1003c6e2c7ced8a215492116b9c2a073b093c49"},
+	}
+
+	for _, c := range cases {
+		account := toAccount2022(sequence(0, c.keySize), c.cipherType).(*MemoryAccount)
+		salt := sequence(0x80, c.keySize)
+
+		cache := buf.New()
+		writer, err := account.Cipher.NewEncryptionWriter(account.Key, salt, cache)
+		common.Must(err)
+		common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, []byte("test payload"))))
+		if r := cmp.Diff(hex.EncodeToString(cache.Bytes()), c.stream); r != "" {
+			t.Error(c.cipherType, ": ", r)
+		}
+
+		reader, err := account.Cipher.NewDecryptionReader(account.Key, salt, cache)
+		common.Must(err)
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		if s := mb.String(); s != "test payload" {
+			t.Error("unexpected payload: ", s)
+		}
+		cache.Release()
+	}
+}
+
+// buildResponse2022 builds a response of 2022-blake3-aes-128-gcm with the key
+// of the vectors, and the first payload in the header.
+func buildResponse2022(requestSalt []byte, timestamp time.Time, payload []byte) []byte {
+	salt := sequence(0x80, 16)
+	aead := gcm2022("722b3033c5d021365a8521bfb41157a3")
+	nonce := make([]byte, aead.NonceSize())
+
+	fixed := []byte{1}
+	fixed = append(fixed, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(fixed[1:], uint64(timestamp.Unix()))
+	fixed = append(fixed, requestSalt...)
+	fixed = append(fixed, byte(len(payload)>>8), byte(len(payload)))
+
+	response := append([]byte(nil), salt...)
+	response = aead.Seal(response, nonce, fixed, nil)
+	nonce[0]++
+	response = aead.Seal(response, nonce, payload, nil)
+	return response
+}
+
+func TestTCPResponse2022Vector(t *testing.T) {
+	user := &protocol.MemoryUser{
+		Account: toAccount2022(sequence(0, 16), CipherType_AES_128_GCM_2022),
+	}
+	requestSalt := newKey2022(16)
+
+	reader, err := ReadTCPResponse(user, requestSalt, bytes.NewReader(buildResponse2022(requestSalt, time.Now(), []byte("initial payload"))))
+	common.Must(err)
+	mb, err := reader.ReadMultiBuffer()
+	common.Must(err)
+	if s := mb.String(); s != "initial payload" {
+		t.Error("unexpected initial payload: ", s)
+	}
+
+	if _, err := ReadTCPResponse(user, requestSalt, bytes.NewReader(buildResponse2022(requestSalt, time.Now().Add(-time.Minute), []byte("stale")))); err == nil {
+		t.Error("expected error of stale timestamp, but nil")
+	}
+	if _, err := ReadTCPResponse(user, newKey2022(16), bytes.NewReader(buildResponse2022(requestSalt, time.Now(), []byte("other")))); err == nil {
+		t.Error("expected error of request salt, but nil")
+	}
+}
+
+func TestUDPSession2022(t *testing.T) {
+	cases := []struct {
+		cipherType CipherType
+		keySize    int
+	}{
+		{CipherType_AES_128_GCM_2022, 16},
+		{CipherType_CHACHA20_POLY1305_2022, 32},
+	}
+
+	for _, c := range cases {
+		account := toAccount2022(newKey2022(c.keySize), c.cipherType).(*MemoryAccount)
+		client, err := NewUDPSession(account, false)
+		common.Must(err)
+		server, err := NewUDPSession(account, true)
+		common.Must(err)
+
+		target := net.UDPDestination(net.DomainAddress("v2ray.com"), 53)
+		packet, err := client.EncodePacket([]byte("test request"), target)
+		common.Must(err)
+		replay := buf.New()
+		common.Must2(replay.Write(packet.Bytes()))
+
+		dest, err := server.DecodePacket(packet)
+		common.Must(err)
+		if dest != target {
+			t.Error("unexpected destination: ", dest)
+		}
+		if s := packet.String(); s != "test request" {
+			t.Error("unexpected request payload: ", s)
+		}
+		if _, err := server.DecodePacket(replay); err == nil {
+			t.Error("expected error of replayed packet, but nil")
+		}
+
+		source := net.UDPDestination(net.LocalHostIP, 53)
+		packet, err = server.EncodePacket([]byte("test response"), source)
+		common.Must(err)
+		dest, err = client.DecodePacket(packet)
+		common.Must(err)
+		if dest != source {
+			t.Error("unexpected source: ", dest)
+		}
+		if s := packet.String(); s != "test response" {
+			t.Error("unexpected response payload: ", s)
+		}
+
+		// Another client doesn't accept packets of the session.
+		other, err := NewUDPSession(account, false)
+		common.Must(err)
+		packet, err = server.EncodePacket([]byte("test response"), source)
+		common.Must(err)
+		if _, err := other.DecodePacket(packet); err == nil {
+			t.Error("expected error of session ID, but nil")
+		}
+	}
+}
+
+func TestUDPSession2022Vector(t *testing.T) {
+	header := sequence(0x40, 16)
+	binary.BigEndian.PutUint64(header[8:], 1)
+	body := []byte{0}
+	body = append(body, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(body[1:], uint64(time.Now().Unix()))
+	body = append(body, 0x00, 0x03)
+	body = append(body, 0, 0, 0)
+	body = append(body, 0x01, 127, 0, 0, 1, 0x00, 0x35)
+	body = append(body, []byte("test payload")...)
+
+	cases := []struct {
+		cipherType CipherType
+		keySize    int
+		// The session key of the session ID 0x40...0x47, for the AES methods.
+		sessionKey string
+	}{
+		{CipherType_AES_128_GCM_2022, 16, "db77776024cdc3e34fb4952aeb2463ab"},
+		{CipherType_AES_256_GCM_2022, 32, "9c02726c7f2d5de0aa8b553c0b4d0007efb1873ac70f471698c47b3e2f0ceb34"},
+		{CipherType_CHACHA20_POLY1305_2022, 32, ""},
+	}
+
+	for _, c := range cases {
+		key := sequence(0, c.keySize)
+		server, err := NewUDPSession(toAccount2022(key, c.cipherType).(*MemoryAccount), true)
+		common.Must(err)
+
+		packet := buf.New()
+		if c.cipherType == CipherType_CHACHA20_POLY1305_2022 {
+			// Packets of 2022-blake3-chacha20-poly1305 are sealed as a whole, with the key.
+			aead, err := chacha20poly1305.NewX(key)
+			common.Must(err)
+			nonce := sequence(0x80, aead.NonceSize())
+			common.Must2(packet.Write(nonce))
+			common.Must2(packet.Write(aead.Seal(nil, nonce, append(append([]byte(nil), header...), body...), nil)))
+		} else {
+			sealed := gcm2022(c.sessionKey).Seal(nil, header[4:], body, nil)
+			block, err := aes.NewCipher(key)
+			common.Must(err)
+			encrypted := make([]byte, len(header))
+			block.Encrypt(encrypted, header)
+			common.Must2(packet.Write(encrypted))
+			common.Must2(packet.Write(sealed))
+		}
+
+		dest, err := server.DecodePacket(packet)
+		if err != nil {
+			t.Error(c.cipherType, ": ", err)
+			continue
+		}
+		if dest != net.UDPDestination(net.LocalHostIP, 53) {
+			t.Error(c.cipherType, ": unexpected destination: ", dest)
+		}
+		if s := packet.String(); s != "test payload" {
+			t.Error(c.cipherType, ": unexpected payload: ", s)
+		}
+	}
+}
+
+func TestUDPReaderWriter2022(t *testing.T) {
+	user := &protocol.MemoryUser{
+		Account: toAccount2022(newKey2022(16), CipherType_AES_128_GCM_2022),
+	}
+	client, err := NewUDPSession(user.Account.(*MemoryAccount), false)
+	common.Must(err)
+	server, err := NewUDPSession(user.Account.(*MemoryAccount), true)
+	common.Must(err)
+
+	cache := buf.New()
+	defer cache.Release()
+
+	writer := &UDPWriter{
+		Writer: cache,
+		Request: &protocol.RequestHeader{
+			Version: Version,
+			Address: net.LocalHostIP,
+			Port:    123,
+			User:    user,
+		},
+		Session: client,
+	}
+
+	reader := &UDPReader{
+		Reader:  cache,
+		User:    user,
+		Session: server,
+	}
+
+	for _, payload := range []string{"test payload", "test payload 2"} {
+		b := buf.New()
+		common.Must2(b.WriteString(payload))
+		common.Must(writer.WritePacket(b, nil))
+
+		decoded, addr, err := reader.ReadPacket()
+		common.Must(err)
+		if decoded.String() != payload {
+			t.Error("unexpected output: ", decoded.String())
+		}
+		if addr.Port != 123 {
+			t.Error("unexpected port: ", addr.Port)
 		}
 	}
 }
@@ -24,31 +24,53 @@ import (
 
 type Server struct {
 	config        ServerConfig
-	user          *protocol.MemoryUser
+	validator     *Validator
 	policyManager policy.Manager
 }
 
 // NewServer create a new Shadowsocks server.
 func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
-	if config.GetUser() == nil {
-		return nil, newError("user is not specified")
+	users := config.Users
+	if config.User != nil {
+		users = append([]*protocol.User{config.User}, users...)
 	}
-
-	mUser, err := config.User.ToMemoryUser()
-	if err != nil {
-		return nil, newError("failed to parse user account").Base(err)
+	if len(users) == 0 {
+		return nil, newError("user is not specified")
 	}
 
 	v := core.MustFromContext(ctx)
 	s := &Server{
 		config:        *config,
-		user:          mUser,
+		validator:     NewValidator(),
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 	}
 
+	for _, user := range users {
+		mUser, err := user.ToMemoryUser()
+		if err != nil {
+			return nil, newError("failed to parse user account").Base(err)
+		}
+		if err := s.AddUser(ctx, mUser); err != nil {
+			return nil, newError("failed to add user").Base(err)
+		}
+	}
+
 	return s, nil
 }
 
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, user *protocol.MemoryUser) error {
+	return s.validator.Add(user)
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, email string) error {
+	if len(email) == 0 {
+		return newError("Email must not be empty.")
+	}
+	return s.validator.Remove(email)
+}
+
 func (s *Server) Network() []net.Network {
 	list := s.config.Network
 	if len(list) == 0 {
@@ -71,6 +93,24 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn internet
 	}
 }
 
+type key int
+
+const (
+	udpSessionKey key = iota
+)
+
+func contextWithUDPSession(ctx context.Context, udpSession *UDPSession) context.Context {
+	return context.WithValue(ctx, udpSessionKey, udpSession)
+}
+
+func udpSessionFromContext(ctx context.Context) *UDPSession {
+	udpSession := ctx.Value(udpSessionKey)
+	if udpSession == nil {
+		return nil
+	}
+	return udpSession.(*UDPSession)
+}
+
 func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection, dispatcher routing.Dispatcher) error {
 	udpServer := udp.NewDispatcher(dispatcher, func(ctx context.Context, packet *udp_proto.Packet) {
 		request := protocol.RequestHeaderFromContext(ctx)
@@ -79,7 +119,13 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 		}
 
 		payload := packet.Payload
-		data, err := EncodeUDPPacket(request, payload.Bytes(), packet.Source.UDPAddr())
+		var data *buf.Buffer
+		var err error
+		if udpSession := udpSessionFromContext(ctx); udpSession != nil {
+			data, err = udpSession.EncodePacket(payload.Bytes(), packet.Source)
+		} else {
+			data, err = EncodeUDPPacket(request, payload.Bytes(), packet.Source.UDPAddr())
+		}
 		payload.Release()
 		if err != nil {
 			newError("failed to encode UDP packet").Base(err).AtWarning().WriteToLog(session.ExportIDToError(ctx))
@@ -90,12 +136,13 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 		conn.Write(data.Bytes())
 	})
 
-	account := s.user.Account.(*MemoryAccount)
 	inbound := session.InboundFromContext(ctx)
 	if inbound == nil {
 		panic("no inbound metadata")
 	}
-	inbound.User = s.user
+
+	// Packets of Shadowsocks 2022 from the source are in a UDP session.
+	var udpSession *UDPSession
 
 	reader := buf.NewPacketReader(conn)
 	for {
@@ -105,7 +152,12 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 		}
 
 		for _, payload := range mpayload {
-			request, data, err := DecodeUDPPacket(s.user, payload)
+			var request *protocol.RequestHeader
+			var data *buf.Buffer
+			user, err := s.validator.Get(inbound.Source.Address, payload.Bytes(), protocol.RequestCommandUDP)
+			if err == nil {
+				request, data, err = s.decodeUDPPacket(user, &udpSession, payload)
+			}
 			if err != nil {
 				if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.Source.IsValid() {
 					newError("dropping invalid UDP packet from: ", inbound.Source).Base(err).WriteToLog(session.ExportIDToError(ctx))
@@ -121,6 +173,7 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 				continue
 			}
 
+			account := user.Account.(*MemoryAccount)
 			if request.Option.Has(RequestOptionOneTimeAuth) && account.OneTimeAuth == Account_Disabled {
 				newError("client payload enables OTA but server doesn't allow it").WriteToLog(session.ExportIDToError(ctx))
 				payload.Release()
@@ -133,6 +186,7 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 				continue
 			}
 
+			inbound.User = user
 			dest := request.Destination()
 			if inbound.Source.IsValid() {
 				ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
@@ -146,6 +200,9 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 			newError("tunnelling request to ", dest).WriteToLog(session.ExportIDToError(ctx))
 
 			ctx = protocol.ContextWithRequestHeader(ctx, request)
+			if udpSession != nil {
+				ctx = contextWithUDPSession(ctx, udpSession)
+			}
 			udpServer.Dispatch(ctx, dest, data)
 		}
 	}
@@ -153,18 +210,45 @@ func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection
 	return nil
 }
 
+// decodeUDPPacket decodes the packet of the user. Packets of Shadowsocks 2022
+// are decoded in the UDP session, which starts over if the user changes.
+func (s *Server) decodeUDPPacket(user *protocol.MemoryUser, udpSession **UDPSession, payload *buf.Buffer) (*protocol.RequestHeader, *buf.Buffer, error) {
+	account := user.Account.(*MemoryAccount)
+	if _, ok := account.Cipher.(*AEAD2022Cipher); !ok {
+		return DecodeUDPPacket(user, payload)
+	}
+
+	if *udpSession == nil || (*udpSession).account != account {
+		newSession, err := NewUDPSession(account, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		*udpSession = newSession
+	}
+	dest, err := (*udpSession).DecodePacket(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &protocol.RequestHeader{
+		Version: Version,
+		User:    user,
+		Command: protocol.RequestCommandUDP,
+		Address: dest.Address,
+		Port:    dest.Port,
+	}, payload, nil
+}
+
 func (s *Server) handleConnection(ctx context.Context, conn internet.Connection, dispatcher routing.Dispatcher) error {
-	sessionPolicy := s.policyManager.ForLevel(s.user.Level)
+	sessionPolicy := s.policyManager.ForLevel(s.validator.MaxLevel())
 	conn.SetReadDeadline(time.Now().Add(sessionPolicy.Timeouts.Handshake))
 
 	inbound := session.InboundFromContext(ctx)
 	if inbound == nil {
 		panic("no inbound metadata")
 	}
-	inbound.User = s.user
 
 	bufferedReader := buf.BufferedReader{Reader: buf.NewReader(conn)}
-	request, bodyReader, err := ReadTCPSession(s.user, &bufferedReader)
+	request, requestIV, bodyReader, err := s.readTCPSession(conn, &bufferedReader)
 	if err != nil {
 		log.Record(&log.AccessMessage{
 			InboundTag: inbound.Tag,
@@ -176,6 +260,8 @@ func (s *Server) handleConnection(ctx context.Context, conn internet.Connection,
 		return newError("failed to create request from: ", conn.RemoteAddr()).Base(err)
 	}
 	conn.SetReadDeadline(time.Time{})
+	inbound.User = request.User
+	sessionPolicy = s.policyManager.ForLevel(request.User.Level)
 
 	dest := request.Destination()
 	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
@@ -200,7 +286,7 @@ func (s *Server) handleConnection(ctx context.Context, conn internet.Connection,
 		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
 
 		bufferedWriter := buf.NewBufferedWriter(buf.NewWriter(conn))
-		responseWriter, err := WriteTCPResponse(request, bufferedWriter)
+		responseWriter, err := WriteTCPResponse(request, requestIV, bufferedWriter)
 		if err != nil {
 			return newError("failed to write response").Base(err)
 		}
@@ -246,6 +332,29 @@ func (s *Server) handleConnection(ctx context.Context, conn internet.Connection,
 	return nil
 }
 
+// readTCPSession finds the user of the connection, and reads the session with
+// the key of the user. With more than one user, the salt and the first chunk
+// are read ahead to try the keys.
+func (s *Server) readTCPSession(conn internet.Connection, reader *buf.BufferedReader) (*protocol.RequestHeader, []byte, buf.Reader, error) {
+	var probe []byte
+	if s.validator.Count() > 1 {
+		first := buf.New()
+		if _, err := first.ReadFullFrom(conn, s.validator.ProbeSize()); err != nil {
+			first.Release()
+			return nil, nil, nil, newError("failed to read salt and first chunk").Base(err)
+		}
+		reader.Buffer = buf.MultiBuffer{first}
+		probe = first.Bytes()
+	}
+
+	user, err := s.validator.Get(net.DestinationFromAddr(conn.RemoteAddr()).Address, probe, protocol.RequestCommandTCP)
+	if err != nil {
+		buf.ReleaseMulti(reader.Buffer)
+		return nil, nil, nil, newError("failed to find user").Base(err)
+	}
+	return ReadTCPSession(user, reader)
+}
+
 func init() {
 	common.Must(common.RegisterConfig((*ServerConfig)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		return NewServer(ctx, config.(*ServerConfig))
@@ -9,6 +9,8 @@
 // * AES-128-CFB
 // * Chacha20
 // * Chacha20-IEFT
+// * AES-128-GCM, AES-256-GCM and Chacha20-Poly1305
+// * 2022-blake3-aes-128-gcm, 2022-blake3-aes-256-gcm and 2022-blake3-chacha20-poly1305
 //
 // R.I.P Shadowsocks
 package shadowsocks
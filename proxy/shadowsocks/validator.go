@@ -0,0 +1,183 @@
+// +build !confonly
+
+package shadowsocks
+
+import (
+	"strings"
+	"sync"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+)
+
+// maxMatchCacheSize is the number of sources whose users are remembered.
+const maxMatchCacheSize = 1024
+
+// probeCipher is a cipher whose key can be checked against the beginning of a
+// TCP session or a UDP packet.
+type probeCipher interface {
+	// probeSize returns the size of the salt and the sealed first chunk.
+	probeSize() int32
+	canOpenFirstChunk(key []byte, data []byte) bool
+	canOpenPacket(key []byte, data []byte) bool
+}
+
+// Validator keeps the users of a server. With more than one user, the user
+// of a connection or a packet is found by trying the keys of all users, in
+// which case all users must use AEAD or Shadowsocks 2022 ciphers.
+type Validator struct {
+	access sync.RWMutex
+	users  []*protocol.MemoryUser
+
+	// matches remembers the user last found for each source, which is tried first.
+	matchAccess sync.Mutex
+	matches     map[net.Address]*protocol.MemoryUser
+}
+
+// NewValidator creates a new Validator.
+func NewValidator() *Validator {
+	return &Validator{
+		matches: make(map[net.Address]*protocol.MemoryUser),
+	}
+}
+
+func isAEADAccount(account *MemoryAccount) bool {
+	_, ok := account.Cipher.(probeCipher)
+	return ok
+}
+
+// Add adds a user. The email of the user must be unique.
+func (v *Validator) Add(u *protocol.MemoryUser) error {
+	account, ok := u.Account.(*MemoryAccount)
+	if !ok {
+		return newError("not a Shadowsocks account")
+	}
+	email := strings.ToLower(u.Email)
+
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	for _, user := range v.users {
+		if len(email) > 0 && strings.ToLower(user.Email) == email {
+			return newError("user ", u.Email, " already exists")
+		}
+		if !isAEADAccount(account) || !isAEADAccount(user.Account.(*MemoryAccount)) {
+			return newError("only AEAD ciphers are supported with multiple users")
+		}
+	}
+	v.users = append(v.users, u)
+	return nil
+}
+
+// Remove removes the user by email.
+func (v *Validator) Remove(email string) error {
+	email = strings.ToLower(email)
+
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	for i, user := range v.users {
+		if strings.ToLower(user.Email) != email {
+			continue
+		}
+		v.users = append(v.users[:i:i], v.users[i+1:]...)
+
+		v.matchAccess.Lock()
+		for source, u := range v.matches {
+			if u == user {
+				delete(v.matches, source)
+			}
+		}
+		v.matchAccess.Unlock()
+		return nil
+	}
+	return newError("user ", email, " not found")
+}
+
+// Count returns the number of users.
+func (v *Validator) Count() int {
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	return len(v.users)
+}
+
+// MaxLevel returns the highest level of all users. Its policy applies to
+// handshakes, as the user is unknown until the handshake is read.
+func (v *Validator) MaxLevel() uint32 {
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	var level uint32
+	for _, user := range v.users {
+		if user.Level > level {
+			level = user.Level
+		}
+	}
+	return level
+}
+
+// ProbeSize returns the number of bytes at the beginning of a TCP session
+// needed to find its user among all users.
+func (v *Validator) ProbeSize() int32 {
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	var size int32
+	for _, user := range v.users {
+		cipher, ok := user.Account.(*MemoryAccount).Cipher.(probeCipher)
+		if ok && cipher.probeSize() > size {
+			size = cipher.probeSize()
+		}
+	}
+	return size
+}
+
+// Get returns the user whose key opens the beginning of a TCP session, or a
+// UDP packet, from the source. The only user is returned without checks.
+func (v *Validator) Get(source net.Address, data []byte, command protocol.RequestCommand) (*protocol.MemoryUser, error) {
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	switch len(v.users) {
+	case 0:
+		return nil, newError("no user")
+	case 1:
+		return v.users[0], nil
+	}
+
+	match := func(user *protocol.MemoryUser) bool {
+		account := user.Account.(*MemoryAccount)
+		cipher := account.Cipher.(probeCipher)
+		if command == protocol.RequestCommandUDP {
+			return cipher.canOpenPacket(account.Key, data)
+		}
+		return cipher.canOpenFirstChunk(account.Key, data)
+	}
+
+	var cached *protocol.MemoryUser
+	if source != nil {
+		v.matchAccess.Lock()
+		cached = v.matches[source]
+		v.matchAccess.Unlock()
+	}
+	if cached != nil && match(cached) {
+		return cached, nil
+	}
+
+	for _, user := range v.users {
+		if user == cached || !match(user) {
+			continue
+		}
+		if source != nil {
+			v.matchAccess.Lock()
+			if len(v.matches) >= maxMatchCacheSize {
+				v.matches = make(map[net.Address]*protocol.MemoryUser)
+			}
+			v.matches[source] = user
+			v.matchAccess.Unlock()
+		}
+		return user, nil
+	}
+	return nil, newError("no user matches")
+}
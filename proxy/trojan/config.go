@@ -0,0 +1,107 @@
+package trojan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"v2ray.com/core/common/protocol"
+)
+
+// MemoryAccount is an account type converted from Account.
+type MemoryAccount struct {
+	Password string
+	// Key is the hex encoded SHA224 hash of the password, which is sent in requests.
+	Key []byte
+}
+
+// AsAccount implements protocol.AsAccount.
+func (a *Account) AsAccount() (protocol.Account, error) {
+	if len(a.Password) == 0 {
+		return nil, newError("empty password")
+	}
+	return &MemoryAccount{
+		Password: a.Password,
+		Key:      hexSha224(a.Password),
+	}, nil
+}
+
+// Equals implements protocol.Account.Equals().
+func (a *MemoryAccount) Equals(another protocol.Account) bool {
+	if account, ok := another.(*MemoryAccount); ok {
+		return a.Password == account.Password
+	}
+	return false
+}
+
+func hexSha224(password string) []byte {
+	hash := sha256.Sum224([]byte(password))
+	key := make([]byte, hex.EncodedLen(len(hash)))
+	hex.Encode(key, hash[:])
+	return key
+}
+
+// Validator keeps the users of a server by their keys.
+type Validator struct {
+	access sync.RWMutex
+	users  map[string]*protocol.MemoryUser
+	emails map[string]string
+}
+
+// NewValidator creates a new Validator.
+func NewValidator() *Validator {
+	return &Validator{
+		users:  make(map[string]*protocol.MemoryUser),
+		emails: make(map[string]string),
+	}
+}
+
+// Add adds a user. The password and the email of the user must be unique.
+func (v *Validator) Add(u *protocol.MemoryUser) error {
+	account, ok := u.Account.(*MemoryAccount)
+	if !ok {
+		return newError("not a Trojan account")
+	}
+	key := string(account.Key)
+	email := strings.ToLower(u.Email)
+
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	if _, found := v.users[key]; found {
+		return newError("user with the same password already exists")
+	}
+	if len(email) > 0 {
+		if _, found := v.emails[email]; found {
+			return newError("user ", u.Email, " already exists")
+		}
+		v.emails[email] = key
+	}
+	v.users[key] = u
+	return nil
+}
+
+// Get returns the user with the key.
+func (v *Validator) Get(key []byte) *protocol.MemoryUser {
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	return v.users[string(key)]
+}
+
+// Remove removes the user by email.
+func (v *Validator) Remove(email string) error {
+	email = strings.ToLower(email)
+
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	key, found := v.emails[email]
+	if !found {
+		return newError("user ", email, " not found")
+	}
+	delete(v.emails, email)
+	delete(v.users, key)
+	return nil
+}
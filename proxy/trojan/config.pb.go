@@ -0,0 +1,231 @@
+package trojan
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+	net "v2ray.com/core/common/net"
+	protocol "v2ray.com/core/common/protocol"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Account struct {
+	Password             string   `protobuf:"bytes,1,opt,name=password,proto3" json:"password,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Account) Reset()         { *m = Account{} }
+func (m *Account) String() string { return proto.CompactTextString(m) }
+func (*Account) ProtoMessage()    {}
+func (*Account) Descriptor() ([]byte, []int) {
+	return fileDescriptor_27dab8c3a6f61031, []int{0}
+}
+
+func (m *Account) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Account.Unmarshal(m, b)
+}
+func (m *Account) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Account.Marshal(b, m, deterministic)
+}
+func (m *Account) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Account.Merge(m, src)
+}
+func (m *Account) XXX_Size() int {
+	return xxx_messageInfo_Account.Size(m)
+}
+func (m *Account) XXX_DiscardUnknown() {
+	xxx_messageInfo_Account.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Account proto.InternalMessageInfo
+
+func (m *Account) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+// Fallback is where the server forwards connections that don't speak Trojan,
+// usually a local web server.
+type Fallback struct {
+	Address              *net.IPOrDomain `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Port                 uint32          `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *Fallback) Reset()         { *m = Fallback{} }
+func (m *Fallback) String() string { return proto.CompactTextString(m) }
+func (*Fallback) ProtoMessage()    {}
+func (*Fallback) Descriptor() ([]byte, []int) {
+	return fileDescriptor_27dab8c3a6f61031, []int{1}
+}
+
+func (m *Fallback) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Fallback.Unmarshal(m, b)
+}
+func (m *Fallback) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Fallback.Marshal(b, m, deterministic)
+}
+func (m *Fallback) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Fallback.Merge(m, src)
+}
+func (m *Fallback) XXX_Size() int {
+	return xxx_messageInfo_Fallback.Size(m)
+}
+func (m *Fallback) XXX_DiscardUnknown() {
+	xxx_messageInfo_Fallback.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Fallback proto.InternalMessageInfo
+
+func (m *Fallback) GetAddress() *net.IPOrDomain {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *Fallback) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+type ServerConfig struct {
+	User                 []*protocol.User `protobuf:"bytes,1,rep,name=user,proto3" json:"user,omitempty"`
+	Fallback             *Fallback        `protobuf:"bytes,2,opt,name=fallback,proto3" json:"fallback,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *ServerConfig) Reset()         { *m = ServerConfig{} }
+func (m *ServerConfig) String() string { return proto.CompactTextString(m) }
+func (*ServerConfig) ProtoMessage()    {}
+func (*ServerConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_27dab8c3a6f61031, []int{2}
+}
+
+func (m *ServerConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServerConfig.Unmarshal(m, b)
+}
+func (m *ServerConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServerConfig.Marshal(b, m, deterministic)
+}
+func (m *ServerConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServerConfig.Merge(m, src)
+}
+func (m *ServerConfig) XXX_Size() int {
+	return xxx_messageInfo_ServerConfig.Size(m)
+}
+func (m *ServerConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServerConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServerConfig proto.InternalMessageInfo
+
+func (m *ServerConfig) GetUser() []*protocol.User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetFallback() *Fallback {
+	if m != nil {
+		return m.Fallback
+	}
+	return nil
+}
+
+type ClientConfig struct {
+	Server               []*protocol.ServerEndpoint `protobuf:"bytes,1,rep,name=server,proto3" json:"server,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *ClientConfig) Reset()         { *m = ClientConfig{} }
+func (m *ClientConfig) String() string { return proto.CompactTextString(m) }
+func (*ClientConfig) ProtoMessage()    {}
+func (*ClientConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_27dab8c3a6f61031, []int{3}
+}
+
+func (m *ClientConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClientConfig.Unmarshal(m, b)
+}
+func (m *ClientConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClientConfig.Marshal(b, m, deterministic)
+}
+func (m *ClientConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClientConfig.Merge(m, src)
+}
+func (m *ClientConfig) XXX_Size() int {
+	return xxx_messageInfo_ClientConfig.Size(m)
+}
+func (m *ClientConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClientConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClientConfig proto.InternalMessageInfo
+
+func (m *ClientConfig) GetServer() []*protocol.ServerEndpoint {
+	if m != nil {
+		return m.Server
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Account)(nil), "v2ray.core.proxy.trojan.Account")
+	proto.RegisterType((*Fallback)(nil), "v2ray.core.proxy.trojan.Fallback")
+	proto.RegisterType((*ServerConfig)(nil), "v2ray.core.proxy.trojan.ServerConfig")
+	proto.RegisterType((*ClientConfig)(nil), "v2ray.core.proxy.trojan.ClientConfig")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/proxy/trojan/config.proto", fileDescriptor_27dab8c3a6f61031)
+}
+
+var fileDescriptor_27dab8c3a6f61031 = []byte{
+	// 343 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x90, 0x3d, 0x4f, 0xeb, 0x30,
+	0x14, 0x86, 0x95, 0xde, 0xaa, 0xed, 0x75, 0x7b, 0x97, 0x2c, 0x8d, 0x7a, 0x97, 0xdc, 0x48, 0x57,
+	0x04, 0x06, 0x07, 0x05, 0x36, 0xc4, 0xd0, 0x16, 0x90, 0x98, 0xa8, 0xcc, 0xc7, 0x00, 0x03, 0x72,
+	0x1d, 0x17, 0x05, 0x12, 0x9f, 0xe8, 0xd8, 0x2d, 0x74, 0xe6, 0xdf, 0xf0, 0x2b, 0x51, 0x9d, 0xa4,
+	0xaa, 0xa0, 0xc0, 0x66, 0xcb, 0xcf, 0x7b, 0xfc, 0xbc, 0x87, 0x84, 0x8b, 0x18, 0xf9, 0x92, 0x0a,
+	0xc8, 0x23, 0x01, 0x28, 0xa3, 0x02, 0xe1, 0x65, 0x19, 0x19, 0x84, 0x47, 0xae, 0x22, 0x01, 0x6a,
+	0x96, 0x3e, 0xd0, 0x02, 0xc1, 0x80, 0xdb, 0xaf, 0x49, 0x94, 0xd4, 0x52, 0xb4, 0xa4, 0x06, 0x3b,
+	0x1f, 0x46, 0x08, 0xc8, 0x73, 0x50, 0x91, 0x92, 0x26, 0xe2, 0x49, 0x82, 0x52, 0xeb, 0x72, 0xc2,
+	0x60, 0x77, 0x3b, 0x68, 0x1f, 0x05, 0x64, 0xd1, 0x5c, 0x4b, 0xac, 0xd0, 0xfd, 0x1f, 0x50, 0x2d,
+	0x71, 0x21, 0xf1, 0x5e, 0x17, 0x52, 0x94, 0x89, 0xe0, 0x3f, 0x69, 0x0f, 0x85, 0x80, 0xb9, 0x32,
+	0xee, 0x80, 0x74, 0x0a, 0xae, 0xf5, 0x33, 0x60, 0xe2, 0x39, 0xbe, 0x13, 0xfe, 0x66, 0xeb, 0x7b,
+	0x70, 0x47, 0x3a, 0x67, 0x3c, 0xcb, 0xa6, 0x5c, 0x3c, 0xb9, 0x47, 0xa4, 0x5d, 0x09, 0x5a, 0xac,
+	0x1b, 0xff, 0xa3, 0x1b, 0x1d, 0xcb, 0x2f, 0xa9, 0x92, 0x86, 0x9e, 0x4f, 0x2e, 0xf0, 0x04, 0x72,
+	0x9e, 0x2a, 0x56, 0x27, 0x5c, 0x97, 0x34, 0x0b, 0x40, 0xe3, 0x35, 0x7c, 0x27, 0xfc, 0xc3, 0xec,
+	0x39, 0x78, 0x75, 0x48, 0xef, 0xd2, 0x9a, 0x8d, 0xed, 0xe6, 0xdc, 0x43, 0xd2, 0x5c, 0x95, 0xf2,
+	0x1c, 0xff, 0x57, 0xd8, 0x8d, 0xfd, 0x2d, 0xe3, 0xeb, 0x46, 0xf4, 0x5a, 0x4b, 0x64, 0x96, 0x76,
+	0x8f, 0x49, 0x67, 0x56, 0x39, 0x7a, 0x8d, 0xcf, 0x62, 0x9b, 0xcb, 0xa7, 0x75, 0x19, 0xb6, 0x8e,
+	0x04, 0x8c, 0xf4, 0xc6, 0x59, 0x2a, 0x95, 0xa9, 0x24, 0x46, 0xa4, 0x55, 0xae, 0xab, 0xd2, 0xd8,
+	0xfb, 0x4e, 0xa3, 0xd4, 0x3f, 0x55, 0x49, 0x01, 0xa9, 0x32, 0xac, 0x4a, 0x8e, 0x86, 0xe4, 0xaf,
+	0x80, 0xfc, 0x2b, 0x8b, 0x89, 0x73, 0xdb, 0x2a, 0x4f, 0x6f, 0x8d, 0xfe, 0x4d, 0xcc, 0xf8, 0x92,
+	0x8e, 0x57, 0xcc, 0xc4, 0x32, 0x57, 0xf6, 0x65, 0xda, 0xb2, 0x7f, 0x1c, 0xbc, 0x0f, 0x00, 0x08,
+	0x2a, 0xc8, 0x55, 0x72, 0x02, 0x00, 0x00,
+}
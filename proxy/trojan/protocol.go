@@ -0,0 +1,213 @@
+// +build !confonly
+
+package trojan
+
+import (
+	"encoding/binary"
+	"io"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+)
+
+const (
+	commandTCP byte = 0x01
+	commandUDP byte = 0x03
+
+	// keyLength is the length of the hex encoded SHA224 hash.
+	keyLength = 56
+)
+
+var (
+	crlf = []byte{'\r', '\n'}
+
+	addrParser = protocol.NewAddressParser(
+		protocol.AddressFamilyByte(0x01, net.AddressFamilyIPv4),
+		protocol.AddressFamilyByte(0x04, net.AddressFamilyIPv6),
+		protocol.AddressFamilyByte(0x03, net.AddressFamilyDomain),
+	)
+)
+
+// WriteHeader writes the request header for the destination. The network of the destination decides the command.
+func WriteHeader(writer io.Writer, account *MemoryAccount, destination net.Destination) error {
+	command := commandTCP
+	if destination.Network == net.Network_UDP {
+		command = commandUDP
+	}
+
+	buffer := buf.StackNew()
+	defer buffer.Release()
+
+	common.Must2(buffer.Write(account.Key))
+	common.Must2(buffer.Write(crlf))
+	common.Must(buffer.WriteByte(command))
+	if err := addrParser.WriteAddressPort(&buffer, destination.Address, destination.Port); err != nil {
+		return newError("failed to write address and port").Base(err)
+	}
+	common.Must2(buffer.Write(crlf))
+
+	return buf.WriteAllBytes(writer, buffer.Bytes())
+}
+
+// ReadKey reads the key of the request. It doesn't validate the key.
+func ReadKey(reader io.Reader) ([]byte, error) {
+	var b [keyLength + 2]byte
+	if _, err := io.ReadFull(reader, b[:]); err != nil {
+		return nil, newError("failed to read key").Base(err)
+	}
+	if b[keyLength] != crlf[0] || b[keyLength+1] != crlf[1] {
+		return nil, newError("invalid key terminator")
+	}
+	return b[:keyLength], nil
+}
+
+// ReadHeader reads the command and destination of the request, after the key.
+func ReadHeader(reader io.Reader) (net.Destination, error) {
+	buffer := buf.StackNew()
+	defer buffer.Release()
+
+	if _, err := buffer.ReadFullFrom(reader, 1); err != nil {
+		return net.Destination{}, newError("failed to read command").Base(err)
+	}
+
+	var network net.Network
+	switch buffer.Byte(0) {
+	case commandTCP:
+		network = net.Network_TCP
+	case commandUDP:
+		network = net.Network_UDP
+	default:
+		return net.Destination{}, newError("unknown command: ", buffer.Byte(0))
+	}
+
+	buffer.Clear()
+	addr, port, err := addrParser.ReadAddressPort(&buffer, reader)
+	if err != nil {
+		return net.Destination{}, newError("failed to read address and port").Base(err)
+	}
+
+	buffer.Clear()
+	if _, err := buffer.ReadFullFrom(reader, 2); err != nil {
+		return net.Destination{}, newError("failed to read header terminator").Base(err)
+	}
+
+	return net.Destination{
+		Network: network,
+		Address: addr,
+		Port:    port,
+	}, nil
+}
+
+// isKeyPrefix returns true if the payload may start with a key, which is a
+// lowercase hex string, and the CRLF after it.
+func isKeyPrefix(b []byte) bool {
+	for i, c := range b {
+		switch {
+		case i < keyLength:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+				return false
+			}
+		case i < keyLength+2:
+			if c != crlf[i-keyLength] {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// PacketWriter writes UDP packets to a Trojan stream. Packets without an address are sent to Target.
+type PacketWriter struct {
+	io.Writer
+	Target net.Destination
+}
+
+// WritePacket implements buf.LinkWriter.
+func (w *PacketWriter) WritePacket(payload *buf.Buffer, addr *net.UDPAddr) error {
+	defer payload.Release()
+
+	dest := w.Target
+	if addr != nil {
+		dest = net.DestinationFromAddr(addr)
+	}
+	return w.writePacket(payload.Bytes(), dest)
+}
+
+// WriteMultiBuffer implements buf.Writer. Each buffer is sent as a packet to Target.
+func (w *PacketWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+
+	for _, b := range mb {
+		if err := w.writePacket(b.Bytes(), w.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *PacketWriter) writePacket(payload []byte, dest net.Destination) error {
+	buffer := buf.New()
+	defer buffer.Release()
+
+	if err := addrParser.WriteAddressPort(buffer, dest.Address, dest.Port); err != nil {
+		return newError("failed to write address and port").Base(err)
+	}
+	binary.BigEndian.PutUint16(buffer.Extend(2), uint16(len(payload)))
+	common.Must2(buffer.Write(crlf))
+	return buf.WriteHeaderAndPayload(w.Writer, buffer, payload)
+}
+
+// PacketReader reads UDP packets from a Trojan stream.
+type PacketReader struct {
+	io.Reader
+}
+
+// ReadPacket implements buf.LinkReader.
+func (r *PacketReader) ReadPacket() (*buf.Buffer, *net.UDPAddr, error) {
+	payload, dest, err := r.readPacket()
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, dest.UDPAddr(), nil
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (r *PacketReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	payload, _, err := r.readPacket()
+	if err != nil {
+		return nil, err
+	}
+	return buf.MultiBuffer{payload}, nil
+}
+
+// ReadPacketFrom reads a packet and its destination, which is a UDP destination.
+func (r *PacketReader) ReadPacketFrom() (*buf.Buffer, net.Destination, error) {
+	return r.readPacket()
+}
+
+func (r *PacketReader) readPacket() (*buf.Buffer, net.Destination, error) {
+	buffer := buf.StackNew()
+	defer buffer.Release()
+
+	addr, port, err := addrParser.ReadAddressPort(&buffer, r.Reader)
+	if err != nil {
+		return nil, net.Destination{}, newError("failed to read address and port").Base(err)
+	}
+
+	buffer.Clear()
+	if _, err := buffer.ReadFullFrom(r.Reader, 4); err != nil {
+		return nil, net.Destination{}, newError("failed to read packet length").Base(err)
+	}
+	// Buffers hold 65535 bytes, so that packets of any length fit.
+	length := int32(binary.BigEndian.Uint16(buffer.BytesTo(2)))
+	payload := buf.New()
+	if _, err := payload.ReadFullFrom(r.Reader, length); err != nil {
+		payload.Release()
+		return nil, net.Destination{}, newError("failed to read packet payload").Base(err)
+	}
+	return payload, net.UDPDestination(addr, port), nil
+}
@@ -0,0 +1,93 @@
+package trojan_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	. "v2ray.com/core/proxy/trojan"
+)
+
+func toAccount(a *Account) *MemoryAccount {
+	account, err := a.AsAccount()
+	common.Must(err)
+	return account.(*MemoryAccount)
+}
+
+func TestHeader(t *testing.T) {
+	account := toAccount(&Account{Password: "password"})
+	if string(account.Key) != "d63dc919e201d7bc4c825630d2cf25fdc93d4b2f0d46706d29038d01" {
+		t.Fatal("unexpected key: ", string(account.Key))
+	}
+
+	for _, dest := range []net.Destination{
+		net.TCPDestination(net.DomainAddress("v2ray.com"), 443),
+		net.UDPDestination(net.IPAddress([]byte{8, 8, 8, 8}), 53),
+		net.TCPDestination(net.IPAddress([]byte{0x20, 0x01, 0x48, 0x60, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x88, 0x88}), 80),
+	} {
+		buffer := new(bytes.Buffer)
+		common.Must(WriteHeader(buffer, account, dest))
+
+		key, err := ReadKey(buffer)
+		common.Must(err)
+		if !bytes.Equal(key, account.Key) {
+			t.Error("unexpected key: ", string(key))
+		}
+		actual, err := ReadHeader(buffer)
+		common.Must(err)
+		if r := cmp.Diff(actual, dest); r != "" {
+			t.Error(r)
+		}
+		if buffer.Len() != 0 {
+			t.Error("unexpected trailing bytes: ", buffer.Len())
+		}
+	}
+}
+
+func TestPacket(t *testing.T) {
+	stream := new(bytes.Buffer)
+	writer := &PacketWriter{
+		Writer: stream,
+		Target: net.UDPDestination(net.DomainAddress("v2ray.com"), 53),
+	}
+
+	payload := buf.New()
+	payload.WriteString("first")
+	common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{payload}))
+
+	payload = buf.New()
+	payload.WriteString("second")
+	common.Must(writer.WritePacket(payload, &net.UDPAddr{IP: []byte{1, 2, 3, 4}, Port: 5353}))
+
+	// The largest packet that the length field allows.
+	large := make([]byte, 65535)
+	common.Must2(rand.Read(large))
+	payload = buf.New()
+	common.Must2(payload.Write(large))
+	common.Must(writer.WriteMultiBuffer(buf.MultiBuffer{payload}))
+
+	reader := &PacketReader{Reader: stream}
+	b, dest, err := reader.ReadPacketFrom()
+	common.Must(err)
+	if b.String() != "first" || dest != writer.Target {
+		t.Error("unexpected packet: ", b.String(), " from ", dest)
+	}
+	b, addr, err := reader.ReadPacket()
+	common.Must(err)
+	if b.String() != "second" || addr.String() != "1.2.3.4:5353" {
+		t.Error("unexpected packet: ", b.String(), " from ", addr)
+	}
+	b, _, err = reader.ReadPacketFrom()
+	common.Must(err)
+	if !bytes.Equal(b.Bytes(), large) {
+		t.Error("unexpected large packet of ", b.Len(), " bytes")
+	}
+	if _, _, err := reader.ReadPacket(); err == nil {
+		t.Error("expect error at the end of stream")
+	}
+}
@@ -0,0 +1,235 @@
+// +build !confonly
+
+package trojan
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"v2ray.com/core"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/errors"
+	"v2ray.com/core/common/log"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	udp_proto "v2ray.com/core/common/protocol/udp"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/signal"
+	"v2ray.com/core/common/task"
+	"v2ray.com/core/features/policy"
+	"v2ray.com/core/features/routing"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/transport/internet"
+	"v2ray.com/core/transport/internet/udp"
+)
+
+// Server is an inbound handler for Trojan protocol.
+type Server struct {
+	policyManager policy.Manager
+	validator     *Validator
+	fallback      net.Destination
+}
+
+// NewServer creates a new Trojan server.
+func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
+	v := core.MustFromContext(ctx)
+	s := &Server{
+		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		validator:     NewValidator(),
+	}
+
+	for _, user := range config.User {
+		mUser, err := user.ToMemoryUser()
+		if err != nil {
+			return nil, newError("failed to get Trojan user").Base(err)
+		}
+		if err := s.validator.Add(mUser); err != nil {
+			return nil, newError("failed to add user").Base(err)
+		}
+	}
+
+	if fb := config.Fallback; fb != nil {
+		if fb.Address == nil || fb.Port == 0 {
+			return nil, newError("fallback address or port is not specified")
+		}
+		s.fallback = net.TCPDestination(fb.Address.AsAddress(), net.Port(fb.Port))
+	}
+
+	return s, nil
+}
+
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	return s.validator.Add(u)
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, email string) error {
+	if len(email) == 0 {
+		return newError("email must not be empty")
+	}
+	return s.validator.Remove(email)
+}
+
+// Network implements proxy.Inbound.Network().
+func (s *Server) Network() []net.Network {
+	return []net.Network{net.Network_TCP}
+}
+
+// Process implements proxy.Inbound.Process().
+func (s *Server) Process(ctx context.Context, network net.Network, conn internet.Connection, dispatcher routing.Dispatcher) error {
+	sessionPolicy := s.policyManager.ForLevel(0)
+	conn.SetReadDeadline(time.Now().Add(sessionPolicy.Timeouts.Handshake))
+
+	// The password and the CRLF after it tell a Trojan client from anything
+	// else, which goes to the fallback as is.
+	first, err := proxy.ReadFirstPayload(conn, keyLength+2, isKeyPrefix)
+	if err != nil {
+		return newError("failed to read first payload from ", conn.RemoteAddr()).Base(err)
+	}
+	reader := &buf.BufferedReader{
+		Reader: buf.NewReader(conn),
+		Buffer: buf.MultiBuffer{first},
+	}
+
+	var user *protocol.MemoryUser
+	if first.Len() >= keyLength+2 && first.Byte(keyLength) == crlf[0] && first.Byte(keyLength+1) == crlf[1] {
+		user = s.validator.Get(first.BytesTo(keyLength))
+	}
+
+	inbound := session.InboundFromContext(ctx)
+	if inbound == nil {
+		panic("no inbound metadata")
+	}
+
+	if user == nil {
+		if !s.fallback.IsValid() {
+			buf.ReleaseMulti(reader.Buffer)
+			return newError("invalid Trojan request from ", conn.RemoteAddr())
+		}
+		conn.SetReadDeadline(time.Time{})
+		return proxy.Fallback(ctx, sessionPolicy, s.fallback, conn, reader)
+	}
+	first.Advance(keyLength + 2)
+
+	inbound.User = user
+	sessionPolicy = s.policyManager.ForLevel(user.Level)
+
+	dest, err := ReadHeader(reader)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			InboundTag: inbound.Tag,
+			From:       conn.RemoteAddr(),
+			To:         "",
+			Status:     log.AccessRejected,
+			Reason:     err,
+		})
+		return newError("failed to read request header from ", conn.RemoteAddr()).Base(err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+		InboundTag: inbound.Tag,
+		From:       conn.RemoteAddr(),
+		To:         dest,
+		Status:     log.AccessAccepted,
+		Reason:     "",
+	})
+
+	if dest.Network == net.Network_UDP {
+		return s.handleUDPPayload(ctx, sessionPolicy, conn, reader, dispatcher)
+	}
+	return s.handleConnection(ctx, sessionPolicy, dest, conn, reader, dispatcher)
+}
+
+func (s *Server) handleConnection(ctx context.Context, sessionPolicy policy.Session, dest net.Destination, conn internet.Connection, reader buf.Reader, dispatcher routing.Dispatcher) error {
+	newError("tunnelling request to ", dest).WriteToLog(session.ExportIDToError(ctx))
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+
+	ctx = policy.ContextWithBufferPolicy(ctx, sessionPolicy.Buffer)
+	link, err := dispatcher.Dispatch(ctx, dest)
+	if err != nil {
+		return newError("failed to dispatch request to ", dest).Base(err)
+	}
+
+	requestDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
+
+		if err := buf.Copy(reader, link.Writer, buf.UpdateActivity(timer)); err != nil {
+			return newError("failed to transport all TCP request").Base(err)
+		}
+		return nil
+	}
+
+	responseDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
+
+		if err := buf.Copy(link.Reader, buf.NewWriter(conn), buf.UpdateActivity(timer)); err != nil {
+			return newError("failed to transport all TCP response").Base(err)
+		}
+		return nil
+	}
+
+	var requestDoneAndCloseWriter = task.OnSuccess(requestDone, task.Close(link.Writer))
+	if err := task.Run(ctx, requestDoneAndCloseWriter, responseDone); err != nil {
+		common.Interrupt(link.Reader)
+		common.Interrupt(link.Writer)
+		return newError("connection ends").Base(err)
+	}
+
+	return nil
+}
+
+func (s *Server) handleUDPPayload(ctx context.Context, sessionPolicy policy.Session, conn internet.Connection, reader *buf.BufferedReader, dispatcher routing.Dispatcher) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+	// Closing the connection stops reading packets, once the session is idle.
+	go func() {
+		<-ctx.Done()
+		conn.Close() // nolint: errcheck
+	}()
+
+	// Responses of different destinations are written concurrently.
+	var writeAccess sync.Mutex
+	writer := &PacketWriter{Writer: conn}
+	udpServer := udp.NewDispatcher(dispatcher, func(ctx context.Context, packet *udp_proto.Packet) {
+		writeAccess.Lock()
+		defer writeAccess.Unlock()
+
+		if err := writer.WritePacket(packet.Payload, packet.Source.UDPAddr()); err != nil {
+			newError("failed to write UDP response").Base(err).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+			return
+		}
+		timer.Update()
+	})
+
+	inbound := session.InboundFromContext(ctx)
+	defer udpServer.RemoveRay(inbound.Source)
+
+	packetReader := &PacketReader{Reader: reader}
+	for {
+		payload, dest, err := packetReader.ReadPacketFrom()
+		if err != nil {
+			if errors.Cause(err) != io.EOF && ctx.Err() == nil {
+				return newError("failed to read UDP packet").Base(err)
+			}
+			return nil
+		}
+
+		timer.Update()
+		newError("tunnelling UDP packet to ", dest).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+		udpServer.Dispatch(ctx, dest, payload)
+	}
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*ServerConfig)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return NewServer(ctx, config.(*ServerConfig))
+	}))
+}
@@ -0,0 +1,9 @@
+// Package trojan contains the implementation of Trojan protocol.
+//
+// A Trojan request starts with the hex encoded SHA224 hash of the password,
+// followed by the command and the destination. The server forwards
+// connections that don't start with a known hash to a fallback, so that it
+// looks like a normal web server to probes.
+package trojan
+
+//go:generate errorgen
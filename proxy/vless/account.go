@@ -0,0 +1,43 @@
+// +build !confonly
+
+package vless
+
+import (
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/uuid"
+)
+
+// None is the only supported encryption of VLESS.
+const None = "none"
+
+// MemoryAccount is an in-memory form of VLESS account.
+type MemoryAccount struct {
+	// ID of the account.
+	ID *protocol.ID
+	// Flow of the connections of the account.
+	Flow string
+	// Encryption of the account. Used for client connections.
+	Encryption string
+}
+
+// Equals implements protocol.Account.
+func (a *MemoryAccount) Equals(account protocol.Account) bool {
+	vlessAccount, ok := account.(*MemoryAccount)
+	if !ok {
+		return false
+	}
+	return a.ID.Equals(vlessAccount.ID)
+}
+
+// AsAccount implements protocol.AsAccount.
+func (a *Account) AsAccount() (protocol.Account, error) {
+	id, err := uuid.ParseString(a.Id)
+	if err != nil {
+		return nil, newError("failed to parse ID").Base(err).AtError()
+	}
+	return &MemoryAccount{
+		ID:         protocol.NewID(id),
+		Flow:       a.Flow,
+		Encryption: a.Encryption,
+	}, nil
+}
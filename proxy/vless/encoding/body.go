@@ -0,0 +1,74 @@
+// +build !confonly
+
+package encoding
+
+import (
+	"encoding/binary"
+	"io"
+
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/protocol"
+)
+
+// EncodeBodyAddons returns the writer of the body of the request or response.
+// UDP packets are prefixed by their lengths, and everything else is written as is.
+func EncodeBodyAddons(writer io.Writer, request *protocol.RequestHeader, addons *Addons) buf.Writer {
+	if request.Command == protocol.RequestCommandUDP {
+		return &LengthPacketWriter{Writer: writer}
+	}
+	return buf.NewWriter(writer)
+}
+
+// DecodeBodyAddons returns the reader of the body written by EncodeBodyAddons.
+func DecodeBodyAddons(reader io.Reader, request *protocol.RequestHeader, addons *Addons) buf.Reader {
+	if request.Command == protocol.RequestCommandUDP {
+		return &LengthPacketReader{Reader: reader}
+	}
+	return buf.NewReader(reader)
+}
+
+// LengthPacketWriter writes each buffer as a packet, prefixed by its length in 2 bytes.
+type LengthPacketWriter struct {
+	io.Writer
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (w *LengthPacketWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+
+	for _, b := range mb {
+		if err := w.writePacket(b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *LengthPacketWriter) writePacket(payload []byte) error {
+	buffer := buf.New()
+	defer buffer.Release()
+
+	binary.BigEndian.PutUint16(buffer.Extend(2), uint16(len(payload)))
+	return buf.WriteHeaderAndPayload(w.Writer, buffer, payload)
+}
+
+// LengthPacketReader reads packets written by LengthPacketWriter.
+type LengthPacketReader struct {
+	io.Reader
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (r *LengthPacketReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r.Reader, length[:]); err != nil {
+		return nil, err
+	}
+	// Buffers hold 65535 bytes, so that packets of any length fit.
+	size := int32(binary.BigEndian.Uint16(length[:]))
+	b := buf.New()
+	if _, err := b.ReadFullFrom(r.Reader, size); err != nil {
+		b.Release()
+		return nil, newError("failed to read UDP packet").Base(err)
+	}
+	return buf.MultiBuffer{b}, nil
+}
@@ -0,0 +1,162 @@
+package encoding_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/uuid"
+	"v2ray.com/core/proxy/vless"
+	. "v2ray.com/core/proxy/vless/encoding"
+)
+
+func toAccount(a *vless.Account) protocol.Account {
+	account, err := a.AsAccount()
+	common.Must(err)
+	return account
+}
+
+func TestRequestSerialization(t *testing.T) {
+	user := &protocol.MemoryUser{
+		Level: 0,
+		Email: "test@v2ray.com",
+	}
+	id := uuid.New()
+	user.Account = toAccount(&vless.Account{
+		Id: id.String(),
+	})
+
+	validator := vless.NewValidator()
+	common.Must(validator.Add(user))
+
+	testCases := []struct {
+		request *protocol.RequestHeader
+		addons  *Addons
+	}{
+		{
+			request: &protocol.RequestHeader{
+				Version: Version,
+				User:    user,
+				Command: protocol.RequestCommandTCP,
+				Address: net.DomainAddress("www.v2ray.com"),
+				Port:    net.Port(443),
+			},
+			addons: &Addons{},
+		},
+		{
+			request: &protocol.RequestHeader{
+				Version: Version,
+				User:    user,
+				Command: protocol.RequestCommandUDP,
+				Address: net.LocalHostIPv6,
+				Port:    net.Port(53),
+			},
+			addons: &Addons{Flow: "flow", Seed: []byte{1, 2, 3}},
+		},
+		{
+			request: &protocol.RequestHeader{
+				Version: Version,
+				User:    user,
+				Command: protocol.RequestCommandMux,
+				Address: net.DomainAddress("v1.mux.cool"),
+			},
+			addons: &Addons{},
+		},
+	}
+
+	for _, tc := range testCases {
+		buffer := buf.New()
+		common.Must(EncodeRequestHeader(buffer, tc.request, tc.addons))
+
+		actualRequest, actualAddons, err := DecodeRequestHeader(buffer, validator)
+		common.Must(err)
+		if r := cmp.Diff(actualRequest, tc.request, cmp.AllowUnexported(protocol.ID{})); r != "" {
+			t.Error(r)
+		}
+		if !proto.Equal(actualAddons, tc.addons) {
+			t.Error("unexpected addons: ", actualAddons)
+		}
+		if !buffer.IsEmpty() {
+			t.Error("remaining bytes: ", buffer.Len())
+		}
+		buffer.Release()
+	}
+}
+
+func TestInvalidRequest(t *testing.T) {
+	id := uuid.New()
+	user := &protocol.MemoryUser{
+		Account: toAccount(&vless.Account{
+			Id: id.String(),
+		}),
+	}
+	request := &protocol.RequestHeader{
+		Version: Version,
+		User:    user,
+		Command: protocol.RequestCommandTCP,
+		Address: net.LocalHostIP,
+		Port:    net.Port(80),
+	}
+
+	buffer := buf.New()
+	defer buffer.Release()
+	common.Must(EncodeRequestHeader(buffer, request, nil))
+
+	// The user isn't known to the validator.
+	if _, _, err := DecodeRequestHeader(buffer, vless.NewValidator()); err == nil {
+		t.Error("nil error")
+	}
+}
+
+func TestResponseSerialization(t *testing.T) {
+	request := &protocol.RequestHeader{
+		Version: Version,
+	}
+	addons := &Addons{Flow: "flow"}
+
+	buffer := buf.New()
+	defer buffer.Release()
+	common.Must(EncodeResponseHeader(buffer, request, addons))
+
+	actualAddons, err := DecodeResponseHeader(buffer, request)
+	common.Must(err)
+	if !proto.Equal(actualAddons, addons) {
+		t.Error("unexpected addons: ", actualAddons)
+	}
+}
+
+func TestLengthPacket(t *testing.T) {
+	request := &protocol.RequestHeader{
+		Command: protocol.RequestCommandUDP,
+	}
+
+	stream := new(bytes.Buffer)
+	writer := EncodeBodyAddons(stream, request, nil)
+
+	// The largest packet that the length field allows.
+	large := make([]byte, 65535)
+	common.Must2(rand.Read(large))
+
+	mb := buf.MergeBytes(nil, []byte("abcd"))
+	mb = append(mb, buf.New(), buf.New())
+	common.Must2(mb[1].WriteString("efg"))
+	common.Must2(mb[2].Write(large))
+	common.Must(writer.WriteMultiBuffer(mb))
+
+	reader := DecodeBodyAddons(stream, request, nil)
+	for _, expected := range []string{"abcd", "efg", string(large)} {
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		if mb.String() != expected {
+			t.Error("unexpected packet of ", mb.Len(), " bytes")
+		}
+		buf.ReleaseMulti(mb)
+	}
+}
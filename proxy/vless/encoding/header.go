@@ -0,0 +1,165 @@
+// +build !confonly
+
+package encoding
+
+import (
+	"io"
+
+	"github.com/golang/protobuf/proto"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/uuid"
+	"v2ray.com/core/proxy/vless"
+)
+
+// muxCoolAddress is the destination of Mux requests, which carry no address.
+var muxCoolAddress = net.DomainAddress("v1.mux.cool")
+
+// EncodeRequestHeader writes the request header, which is the version, the ID
+// of the user, the addons, the command and the destination.
+func EncodeRequestHeader(writer io.Writer, request *protocol.RequestHeader, addons *Addons) error {
+	account, ok := request.User.Account.(*vless.MemoryAccount)
+	if !ok {
+		return newError("not a VLESS account")
+	}
+
+	buffer := buf.StackNew()
+	defer buffer.Release()
+
+	common.Must(buffer.WriteByte(request.Version))
+	common.Must2(buffer.Write(account.ID.Bytes()))
+	if err := encodeAddons(&buffer, addons); err != nil {
+		return err
+	}
+	common.Must(buffer.WriteByte(byte(request.Command)))
+	if request.Command != protocol.RequestCommandMux {
+		if err := addrParser.WriteAddressPort(&buffer, request.Address, request.Port); err != nil {
+			return newError("failed to write address and port").Base(err)
+		}
+	}
+
+	return buf.WriteAllBytes(writer, buffer.Bytes())
+}
+
+// DecodeRequestHeader reads the request header, and returns it with the user
+// found by the validator.
+func DecodeRequestHeader(reader io.Reader, validator *vless.Validator) (*protocol.RequestHeader, *Addons, error) {
+	buffer := buf.StackNew()
+	defer buffer.Release()
+
+	if _, err := buffer.ReadFullFrom(reader, 1+16); err != nil {
+		return nil, nil, newError("failed to read request version and ID").Base(err)
+	}
+	request := &protocol.RequestHeader{
+		Version: buffer.Byte(0),
+	}
+	if request.Version != Version {
+		return nil, nil, newError("invalid request version ", request.Version)
+	}
+
+	id, err := uuid.ParseBytes(buffer.BytesRange(1, 1+16))
+	common.Must(err)
+	if request.User = validator.Get(id); request.User == nil {
+		return nil, nil, newError("invalid request user ID")
+	}
+
+	addons, err := decodeAddons(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buffer.Clear()
+	if _, err := buffer.ReadFullFrom(reader, 1); err != nil {
+		return nil, nil, newError("failed to read request command").Base(err)
+	}
+	request.Command = protocol.RequestCommand(buffer.Byte(0))
+
+	switch request.Command {
+	case protocol.RequestCommandMux:
+		request.Address = muxCoolAddress
+	case protocol.RequestCommandTCP, protocol.RequestCommandUDP:
+		buffer.Clear()
+		addr, port, err := addrParser.ReadAddressPort(&buffer, reader)
+		if err != nil {
+			return nil, nil, newError("failed to read address and port").Base(err)
+		}
+		request.Address = addr
+		request.Port = port
+	default:
+		return nil, nil, newError("unknown request command ", request.Command)
+	}
+
+	return request, addons, nil
+}
+
+// EncodeResponseHeader writes the response header, which is the version of
+// the request and the addons.
+func EncodeResponseHeader(writer io.Writer, request *protocol.RequestHeader, addons *Addons) error {
+	buffer := buf.StackNew()
+	defer buffer.Release()
+
+	common.Must(buffer.WriteByte(request.Version))
+	if err := encodeAddons(&buffer, addons); err != nil {
+		return err
+	}
+
+	return buf.WriteAllBytes(writer, buffer.Bytes())
+}
+
+// DecodeResponseHeader reads the response header of the request, and returns the addons in it.
+func DecodeResponseHeader(reader io.Reader, request *protocol.RequestHeader) (*Addons, error) {
+	var version [1]byte
+	if _, err := io.ReadFull(reader, version[:]); err != nil {
+		return nil, newError("failed to read response version").Base(err)
+	}
+	if version[0] != request.Version {
+		return nil, newError("unexpected response version ", version[0])
+	}
+
+	return decodeAddons(reader)
+}
+
+// encodeAddons writes the addons with a leading byte of their length. Empty
+// addons are written as the length only.
+func encodeAddons(buffer *buf.Buffer, addons *Addons) error {
+	if addons == nil || proto.Size(addons) == 0 {
+		return buffer.WriteByte(0)
+	}
+
+	b, err := proto.Marshal(addons)
+	if err != nil {
+		return newError("failed to marshal addons").Base(err)
+	}
+	if len(b) > 255 {
+		return newError("addons too large: ", len(b))
+	}
+	common.Must(buffer.WriteByte(byte(len(b))))
+	common.Must2(buffer.Write(b))
+	return nil
+}
+
+func decodeAddons(reader io.Reader) (*Addons, error) {
+	buffer := buf.StackNew()
+	defer buffer.Release()
+
+	if _, err := buffer.ReadFullFrom(reader, 1); err != nil {
+		return nil, newError("failed to read addons length").Base(err)
+	}
+	addons := new(Addons)
+	length := int32(buffer.Byte(0))
+	if length == 0 {
+		return addons, nil
+	}
+
+	buffer.Clear()
+	if _, err := buffer.ReadFullFrom(reader, length); err != nil {
+		return nil, newError("failed to read addons").Base(err)
+	}
+	if err := proto.Unmarshal(buffer.Bytes(), addons); err != nil {
+		return nil, newError("failed to unmarshal addons").Base(err)
+	}
+	return addons, nil
+}
@@ -0,0 +1,157 @@
+package inbound
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+	net "v2ray.com/core/common/net"
+	protocol "v2ray.com/core/common/protocol"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// Fallback is where the inbound forwards connections that fail to
+// authenticate, usually a local web server.
+type Fallback struct {
+	Address              *net.IPOrDomain `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Port                 uint32          `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *Fallback) Reset()         { *m = Fallback{} }
+func (m *Fallback) String() string { return proto.CompactTextString(m) }
+func (*Fallback) ProtoMessage()    {}
+func (*Fallback) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b949f4cadf2c2c15, []int{0}
+}
+
+func (m *Fallback) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Fallback.Unmarshal(m, b)
+}
+func (m *Fallback) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Fallback.Marshal(b, m, deterministic)
+}
+func (m *Fallback) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Fallback.Merge(m, src)
+}
+func (m *Fallback) XXX_Size() int {
+	return xxx_messageInfo_Fallback.Size(m)
+}
+func (m *Fallback) XXX_DiscardUnknown() {
+	xxx_messageInfo_Fallback.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Fallback proto.InternalMessageInfo
+
+func (m *Fallback) GetAddress() *net.IPOrDomain {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *Fallback) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+type Config struct {
+	User []*protocol.User `protobuf:"bytes,1,rep,name=user,proto3" json:"user,omitempty"`
+	// Decryption of the payload. Only "none" is supported.
+	Decryption           string    `protobuf:"bytes,2,opt,name=decryption,proto3" json:"decryption,omitempty"`
+	Fallback             *Fallback `protobuf:"bytes,3,opt,name=fallback,proto3" json:"fallback,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Config) Reset()         { *m = Config{} }
+func (m *Config) String() string { return proto.CompactTextString(m) }
+func (*Config) ProtoMessage()    {}
+func (*Config) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b949f4cadf2c2c15, []int{1}
+}
+
+func (m *Config) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Config.Unmarshal(m, b)
+}
+func (m *Config) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Config.Marshal(b, m, deterministic)
+}
+func (m *Config) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Config.Merge(m, src)
+}
+func (m *Config) XXX_Size() int {
+	return xxx_messageInfo_Config.Size(m)
+}
+func (m *Config) XXX_DiscardUnknown() {
+	xxx_messageInfo_Config.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Config proto.InternalMessageInfo
+
+func (m *Config) GetUser() []*protocol.User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *Config) GetDecryption() string {
+	if m != nil {
+		return m.Decryption
+	}
+	return ""
+}
+
+func (m *Config) GetFallback() *Fallback {
+	if m != nil {
+		return m.Fallback
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Fallback)(nil), "v2ray.core.proxy.vless.inbound.Fallback")
+	proto.RegisterType((*Config)(nil), "v2ray.core.proxy.vless.inbound.Config")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/proxy/vless/inbound/config.proto", fileDescriptor_b949f4cadf2c2c15)
+}
+
+var fileDescriptor_b949f4cadf2c2c15 = []byte{
+	// 294 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x90, 0x41, 0x4b, 0xc3, 0x30,
+	0x14, 0xc7, 0xc9, 0x36, 0xb6, 0x99, 0xe1, 0x25, 0xa7, 0xb2, 0xc3, 0xa8, 0xbb, 0x58, 0x2f, 0x2f,
+	0x50, 0xbd, 0x79, 0x73, 0x43, 0xd8, 0xc9, 0x12, 0x70, 0x07, 0x3d, 0x65, 0x69, 0x26, 0xc5, 0x36,
+	0xaf, 0xa4, 0xdd, 0xb0, 0xdf, 0xc7, 0x93, 0x9f, 0x52, 0xf6, 0xd6, 0xca, 0x90, 0xa1, 0xb7, 0x47,
+	0xf2, 0xfb, 0xff, 0xf2, 0x7f, 0xe1, 0x72, 0x1f, 0x7b, 0xdd, 0x80, 0xc1, 0x42, 0x1a, 0xf4, 0x56,
+	0x96, 0x1e, 0x3f, 0x1a, 0xb9, 0xcf, 0x6d, 0x55, 0xc9, 0xcc, 0x6d, 0x70, 0xe7, 0x52, 0x69, 0xd0,
+	0x6d, 0xb3, 0x37, 0x28, 0x3d, 0xd6, 0x28, 0x66, 0x5d, 0xc0, 0x5b, 0x20, 0x18, 0x08, 0x86, 0x16,
+	0x9e, 0x5e, 0xff, 0x12, 0x1a, 0x2c, 0x0a, 0x74, 0xd2, 0xd9, 0x5a, 0xea, 0x34, 0xf5, 0x07, 0x94,
+	0x44, 0xd3, 0x9b, 0xf3, 0x20, 0x5d, 0x1a, 0xcc, 0xe5, 0xae, 0xb2, 0xfe, 0x88, 0xce, 0x5f, 0xf9,
+	0xf8, 0x51, 0xe7, 0xf9, 0x46, 0x9b, 0x77, 0x71, 0xcf, 0x47, 0xad, 0x27, 0x60, 0x21, 0x8b, 0x26,
+	0xf1, 0x15, 0x9c, 0x34, 0x3a, 0x4a, 0xc0, 0xd9, 0x1a, 0x56, 0xc9, 0x93, 0x5f, 0x62, 0xa1, 0x33,
+	0xa7, 0xba, 0x84, 0x10, 0x7c, 0x50, 0xa2, 0xaf, 0x83, 0x5e, 0xc8, 0xa2, 0x4b, 0x45, 0xf3, 0xfc,
+	0x93, 0xf1, 0xe1, 0x82, 0x36, 0x14, 0x77, 0x7c, 0x70, 0x78, 0x35, 0x60, 0x61, 0x3f, 0x9a, 0xc4,
+	0xe1, 0x19, 0x71, 0xd7, 0x0e, 0x9e, 0x2b, 0xeb, 0x15, 0xd1, 0x62, 0xc6, 0x79, 0x6a, 0x8d, 0x6f,
+	0xca, 0x3a, 0x43, 0x47, 0xea, 0x0b, 0x75, 0x72, 0x22, 0x96, 0x7c, 0xbc, 0x6d, 0xdb, 0x07, 0x7d,
+	0xaa, 0x1c, 0xc1, 0xdf, 0x9f, 0x08, 0xdd, 0xb6, 0xea, 0x27, 0xf9, 0x90, 0xf0, 0xb9, 0xc1, 0xe2,
+	0x9f, 0x60, 0xc2, 0x5e, 0x46, 0xed, 0xf8, 0xd5, 0x9b, 0xad, 0x63, 0xa5, 0x1b, 0x58, 0x1c, 0xd8,
+	0x84, 0xd8, 0x35, 0xb1, 0xab, 0x23, 0xb0, 0x19, 0xd2, 0x32, 0xb7, 0xdf, 0x03, 0x00, 0x2d, 0xde,
+	0xed, 0x92, 0x03, 0x02, 0x00, 0x00,
+}
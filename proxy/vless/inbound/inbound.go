@@ -0,0 +1,249 @@
+// +build !confonly
+
+package inbound
+
+//go:generate errorgen
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"v2ray.com/core"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/errors"
+	"v2ray.com/core/common/log"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/session"
+	"v2ray.com/core/common/signal"
+	"v2ray.com/core/common/task"
+	"v2ray.com/core/common/uuid"
+	"v2ray.com/core/features/policy"
+	"v2ray.com/core/features/routing"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/proxy/vless"
+	"v2ray.com/core/proxy/vless/encoding"
+	"v2ray.com/core/transport/internet"
+)
+
+// Handler is an inbound connection handler that handles messages in VLESS protocol.
+type Handler struct {
+	policyManager policy.Manager
+	validator     *vless.Validator
+	fallback      net.Destination
+}
+
+// New creates a new VLESS inbound handler.
+func New(ctx context.Context, config *Config) (*Handler, error) {
+	if config.Decryption != vless.None {
+		return nil, newError("unsupported decryption: ", config.Decryption)
+	}
+
+	v := core.MustFromContext(ctx)
+	handler := &Handler{
+		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		validator:     vless.NewValidator(),
+	}
+
+	for _, user := range config.User {
+		mUser, err := user.ToMemoryUser()
+		if err != nil {
+			return nil, newError("failed to get VLESS user").Base(err)
+		}
+		if err := handler.AddUser(ctx, mUser); err != nil {
+			return nil, newError("failed to add user").Base(err)
+		}
+	}
+
+	if fb := config.Fallback; fb != nil {
+		if fb.Address == nil || fb.Port == 0 {
+			return nil, newError("fallback address or port is not specified")
+		}
+		handler.fallback = net.TCPDestination(fb.Address.AsAddress(), net.Port(fb.Port))
+	}
+
+	return handler, nil
+}
+
+// Network implements proxy.Inbound.Network().
+func (*Handler) Network() []net.Network {
+	return []net.Network{net.Network_TCP}
+}
+
+// AddUser implements proxy.UserManager.AddUser().
+func (h *Handler) AddUser(ctx context.Context, user *protocol.MemoryUser) error {
+	return h.validator.Add(user)
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (h *Handler) RemoveUser(ctx context.Context, email string) error {
+	if len(email) == 0 {
+		return newError("Email must not be empty.")
+	}
+	return h.validator.Remove(email)
+}
+
+// isVersionPrefix returns true if the payload may start with the version and the ID of a user.
+func isVersionPrefix(b []byte) bool {
+	return len(b) == 0 || b[0] == encoding.Version
+}
+
+// isValidUser returns true if the first payload starts with the version and the ID of a user.
+func (h *Handler) isValidUser(first *buf.Buffer) bool {
+	if first.Len() < 1+16 || first.Byte(0) != encoding.Version {
+		return false
+	}
+	id, err := uuid.ParseBytes(first.BytesRange(1, 1+16))
+	common.Must(err)
+	return h.validator.Get(id) != nil
+}
+
+// Process implements proxy.Inbound.Process().
+func (h *Handler) Process(ctx context.Context, network net.Network, connection internet.Connection, dispatcher routing.Dispatcher) error {
+	sessionPolicy := h.policyManager.ForLevel(0)
+	if err := connection.SetReadDeadline(time.Now().Add(sessionPolicy.Timeouts.Handshake)); err != nil {
+		return newError("unable to set read deadline").Base(err).AtWarning()
+	}
+
+	// The version and the ID tell a VLESS client from anything else, which goes to the fallback as is.
+	first, err := proxy.ReadFirstPayload(connection, 1+16, isVersionPrefix)
+	if err != nil {
+		return newError("failed to read first payload from ", connection.RemoteAddr()).Base(err)
+	}
+	reader := &buf.BufferedReader{
+		Reader: buf.NewReader(connection),
+		Buffer: buf.MultiBuffer{first},
+	}
+
+	inbound := session.InboundFromContext(ctx)
+	if inbound == nil {
+		panic("no inbound metadata")
+	}
+
+	if h.fallback.IsValid() && !h.isValidUser(first) {
+		if err := connection.SetReadDeadline(time.Time{}); err != nil {
+			newError("unable to set back read deadline").Base(err).WriteToLog(session.ExportIDToError(ctx))
+		}
+		return proxy.Fallback(ctx, sessionPolicy, h.fallback, connection, reader)
+	}
+
+	request, requestAddons, err := encoding.DecodeRequestHeader(reader, h.validator)
+	if err != nil {
+		if errors.Cause(err) != io.EOF {
+			log.Record(&log.AccessMessage{
+				InboundTag: inbound.Tag,
+				From:       connection.RemoteAddr(),
+				To:         "",
+				Status:     log.AccessRejected,
+				Reason:     err,
+			})
+			err = newError("invalid request from ", connection.RemoteAddr()).Base(err).AtInfo()
+		}
+		return err
+	}
+	inbound.User = request.User
+
+	account := request.User.Account.(*vless.MemoryAccount)
+	if requestAddons.Flow != account.Flow {
+		log.Record(&log.AccessMessage{
+			InboundTag: inbound.Tag,
+			From:       connection.RemoteAddr(),
+			To:         "",
+			Status:     log.AccessRejected,
+			Reason:     "Unexpected flow",
+		})
+		return newError("client is using flow ", requestAddons.Flow, ", but ", account.Flow, " is expected")
+	}
+
+	if request.Command != protocol.RequestCommandMux {
+		ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+			InboundTag: inbound.Tag,
+			From:       connection.RemoteAddr(),
+			To:         request.Destination(),
+			Status:     log.AccessAccepted,
+			Reason:     "",
+		})
+	}
+
+	newError("received request for ", request.Destination()).WriteToLog(session.ExportIDToError(ctx))
+
+	if err := connection.SetReadDeadline(time.Time{}); err != nil {
+		newError("unable to set back read deadline").Base(err).WriteToLog(session.ExportIDToError(ctx))
+	}
+
+	sessionPolicy = h.policyManager.ForLevel(request.User.Level)
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+
+	ctx = policy.ContextWithBufferPolicy(ctx, sessionPolicy.Buffer)
+	link, err := dispatcher.Dispatch(ctx, request.Destination())
+	if err != nil {
+		return newError("failed to dispatch request to ", request.Destination()).Base(err)
+	}
+
+	// Outbounds exchange UDP payloads as packets of the link.
+	var input buf.Reader = link.Reader
+	var output buf.Writer = link.Writer
+	if request.Command == protocol.RequestCommandUDP {
+		input = &buf.PacketToStreamReader{Reader: link.Reader}
+		output = &buf.PacketToStreamWriter{Writer: link.Writer, Destination: request.Destination().UDPAddr()}
+	}
+
+	requestDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
+
+		bodyReader := encoding.DecodeBodyAddons(reader, request, requestAddons)
+		if err := buf.Copy(bodyReader, output, buf.UpdateActivity(timer)); err != nil {
+			return newError("failed to transfer request").Base(err)
+		}
+		return nil
+	}
+
+	responseDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
+
+		writer := buf.NewBufferedWriter(buf.NewWriter(connection))
+		responseAddons := &encoding.Addons{
+			Flow: requestAddons.Flow,
+		}
+		if err := encoding.EncodeResponseHeader(writer, request, responseAddons); err != nil {
+			return newError("failed to encode response header").Base(err)
+		}
+		bodyWriter := encoding.EncodeBodyAddons(writer, request, responseAddons)
+
+		// Optimize for small response packet
+		data, err := input.ReadMultiBuffer()
+		if err != nil {
+			return err
+		}
+		if err := bodyWriter.WriteMultiBuffer(data); err != nil {
+			return err
+		}
+		if err := writer.SetBuffered(false); err != nil {
+			return err
+		}
+
+		if err := buf.Copy(input, bodyWriter, buf.UpdateActivity(timer)); err != nil {
+			return newError("failed to transfer response").Base(err)
+		}
+		return nil
+	}
+
+	var requestDonePost = task.OnSuccess(requestDone, task.Close(link.Writer))
+	if err := task.Run(ctx, requestDonePost, responseDone); err != nil {
+		common.Interrupt(link.Reader)
+		common.Interrupt(link.Writer)
+		return newError("connection ends").Base(err)
+	}
+
+	return nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return New(ctx, config.(*Config))
+	}))
+}
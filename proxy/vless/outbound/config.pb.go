@@ -0,0 +1,83 @@
+package outbound
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+	protocol "v2ray.com/core/common/protocol"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Config struct {
+	Vnext                []*protocol.ServerEndpoint `protobuf:"bytes,1,rep,name=vnext,proto3" json:"vnext,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *Config) Reset()         { *m = Config{} }
+func (m *Config) String() string { return proto.CompactTextString(m) }
+func (*Config) ProtoMessage()    {}
+func (*Config) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3fe9294a32cd4ee3, []int{0}
+}
+
+func (m *Config) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Config.Unmarshal(m, b)
+}
+func (m *Config) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Config.Marshal(b, m, deterministic)
+}
+func (m *Config) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Config.Merge(m, src)
+}
+func (m *Config) XXX_Size() int {
+	return xxx_messageInfo_Config.Size(m)
+}
+func (m *Config) XXX_DiscardUnknown() {
+	xxx_messageInfo_Config.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Config proto.InternalMessageInfo
+
+func (m *Config) GetVnext() []*protocol.ServerEndpoint {
+	if m != nil {
+		return m.Vnext
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Config)(nil), "v2ray.core.proxy.vless.outbound.Config")
+}
+
+func init() {
+	proto.RegisterFile("v2ray.com/core/proxy/vless/outbound/config.proto", fileDescriptor_3fe9294a32cd4ee3)
+}
+
+var fileDescriptor_3fe9294a32cd4ee3 = []byte{
+	// 206 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x32, 0x28, 0x33, 0x2a, 0x4a,
+	0xac, 0xd4, 0x4b, 0xce, 0xcf, 0xd5, 0x4f, 0xce, 0x2f, 0x4a, 0xd5, 0x2f, 0x28, 0xca, 0xaf, 0xa8,
+	0xd4, 0x2f, 0xcb, 0x49, 0x2d, 0x2e, 0xd6, 0xcf, 0x2f, 0x2d, 0x49, 0xca, 0x2f, 0xcd, 0x4b, 0xd1,
+	0x4f, 0xce, 0xcf, 0x4b, 0xcb, 0x4c, 0xd7, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x92, 0x87, 0xe9,
+	0x28, 0x4a, 0xd5, 0x03, 0xab, 0xd6, 0x03, 0xab, 0xd6, 0x83, 0xa9, 0x96, 0x42, 0x37, 0x32, 0x39,
+	0x3f, 0x37, 0x37, 0x3f, 0x4f, 0x1f, 0xac, 0x3b, 0x39, 0x3f, 0x47, 0xbf, 0x38, 0xb5, 0xa8, 0x2c,
+	0xb5, 0x28, 0xbe, 0xb8, 0x20, 0x35, 0x19, 0x62, 0xa4, 0x92, 0x17, 0x17, 0x9b, 0x33, 0xd8, 0x0a,
+	0x21, 0x07, 0x2e, 0xd6, 0xb2, 0xbc, 0xd4, 0x8a, 0x12, 0x09, 0x46, 0x05, 0x66, 0x0d, 0x6e, 0x23,
+	0x2d, 0x3d, 0x24, 0xcb, 0x20, 0xe6, 0xe8, 0xc1, 0xcc, 0xd1, 0x0b, 0x06, 0x9b, 0xe3, 0x9a, 0x97,
+	0x52, 0x90, 0x9f, 0x99, 0x57, 0x12, 0x04, 0xd1, 0xe8, 0x14, 0xcc, 0xa5, 0x9c, 0x9c, 0x9f, 0xab,
+	0x47, 0xc0, 0x91, 0x01, 0x8c, 0x51, 0x1c, 0x30, 0xf6, 0x2a, 0x26, 0xf9, 0x30, 0xa3, 0xa0, 0xc4,
+	0x4a, 0x3d, 0x67, 0x90, 0xea, 0x00, 0xb0, 0xea, 0x30, 0xb0, 0x6a, 0x7f, 0xa8, 0x8a, 0x24, 0x36,
+	0xb0, 0xa5, 0xc6, 0x80, 0x01, 0x00, 0x14, 0xb6, 0x80, 0xec, 0x2e, 0x01, 0x00, 0x00,
+}
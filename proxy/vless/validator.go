@@ -0,0 +1,75 @@
+// +build !confonly
+
+package vless
+
+import (
+	"strings"
+	"sync"
+
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/uuid"
+)
+
+// Validator keeps the users of an inbound by their IDs.
+type Validator struct {
+	access sync.RWMutex
+	users  map[uuid.UUID]*protocol.MemoryUser
+	emails map[string]uuid.UUID
+}
+
+// NewValidator creates a new Validator.
+func NewValidator() *Validator {
+	return &Validator{
+		users:  make(map[uuid.UUID]*protocol.MemoryUser),
+		emails: make(map[string]uuid.UUID),
+	}
+}
+
+// Add adds a user. The ID and the email of the user must be unique.
+func (v *Validator) Add(u *protocol.MemoryUser) error {
+	account, ok := u.Account.(*MemoryAccount)
+	if !ok {
+		return newError("not a VLESS account")
+	}
+	id := account.ID.UUID()
+	email := strings.ToLower(u.Email)
+
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	if _, found := v.users[id]; found {
+		return newError("user with the same ID already exists")
+	}
+	if len(email) > 0 {
+		if _, found := v.emails[email]; found {
+			return newError("user ", u.Email, " already exists")
+		}
+		v.emails[email] = id
+	}
+	v.users[id] = u
+	return nil
+}
+
+// Get returns the user with the ID.
+func (v *Validator) Get(id uuid.UUID) *protocol.MemoryUser {
+	v.access.RLock()
+	defer v.access.RUnlock()
+
+	return v.users[id]
+}
+
+// Remove removes the user by email.
+func (v *Validator) Remove(email string) error {
+	email = strings.ToLower(email)
+
+	v.access.Lock()
+	defer v.access.Unlock()
+
+	id, found := v.emails[email]
+	if !found {
+		return newError("user ", email, " not found")
+	}
+	delete(v.emails, email)
+	delete(v.users, id)
+	return nil
+}
@@ -0,0 +1,8 @@
+// Package vless contains the implementation of VLESS protocol, a stateless
+// protocol that authenticates users by UUID.
+//
+// Unlike VMess, VLESS doesn't encrypt the payload. It is meant to run on top
+// of a secure transport, usually TLS.
+package vless
+
+//go:generate errorgen
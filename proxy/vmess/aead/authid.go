@@ -0,0 +1,123 @@
+package aead
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"hash/crc32"
+	"sync"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/antireplay"
+)
+
+// authIDWindow is how far the time in an auth ID may be from now.
+const authIDWindow = 120
+
+var (
+	ErrNotFound = newError("user not found")
+	ErrReplay   = newError("replayed auth ID")
+)
+
+// CreateAuthID creates an auth ID for the command key at the time in Unix seconds.
+// The auth ID is the time, 4 random bytes and the CRC32 of them, encrypted with AES.
+func CreateAuthID(cmdKey []byte, time int64) [16]byte {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time))
+	common.Must2(rand.Read(b[8:12]))
+	binary.BigEndian.PutUint32(b[12:], crc32.ChecksumIEEE(b[:12]))
+
+	var authID [16]byte
+	newAuthIDCipher(cmdKey).Encrypt(authID[:], b[:])
+	return authID
+}
+
+func newAuthIDCipher(cmdKey []byte) cipher.Block {
+	block, err := aes.NewCipher(KDF16(cmdKey, KDFSaltConstAuthIDEncryptionKey))
+	common.Must(err)
+	return block
+}
+
+// AuthIDDecoder decodes the auth IDs of a command key.
+type AuthIDDecoder struct {
+	block cipher.Block
+}
+
+// NewAuthIDDecoder creates a new AuthIDDecoder for the command key.
+func NewAuthIDDecoder(cmdKey []byte) *AuthIDDecoder {
+	return &AuthIDDecoder{block: newAuthIDCipher(cmdKey)}
+}
+
+// Decode returns the time in the auth ID, and false if the auth ID isn't of the command key.
+func (d *AuthIDDecoder) Decode(authID [16]byte) (int64, bool) {
+	var b [16]byte
+	d.block.Decrypt(b[:], authID[:])
+	if crc32.ChecksumIEEE(b[:12]) != binary.BigEndian.Uint32(b[12:]) {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(b[:8])), true
+}
+
+type authIDDecoderItem struct {
+	decoder *AuthIDDecoder
+	ticket  interface{}
+}
+
+// AuthIDDecoderHolder finds the owners of auth IDs, and rejects replayed ones.
+type AuthIDDecoderHolder struct {
+	access   sync.RWMutex
+	decoders map[string]*authIDDecoderItem
+	filter   *antireplay.ReplayFilter
+}
+
+// NewAuthIDDecoderHolder creates a new AuthIDDecoderHolder.
+func NewAuthIDDecoderHolder() *AuthIDDecoderHolder {
+	return &AuthIDDecoderHolder{
+		decoders: make(map[string]*authIDDecoderItem),
+		filter:   antireplay.NewReplayFilter(time.Second * authIDWindow * 2),
+	}
+}
+
+// AddUser adds the command key of a user, and the ticket returned by Match for it.
+func (h *AuthIDDecoderHolder) AddUser(cmdKey []byte, ticket interface{}) {
+	h.access.Lock()
+	defer h.access.Unlock()
+
+	h.decoders[string(cmdKey)] = &authIDDecoderItem{
+		decoder: NewAuthIDDecoder(cmdKey),
+		ticket:  ticket,
+	}
+}
+
+// RemoveUser removes the command key of a user.
+func (h *AuthIDDecoderHolder) RemoveUser(cmdKey []byte) {
+	h.access.Lock()
+	defer h.access.Unlock()
+
+	delete(h.decoders, string(cmdKey))
+}
+
+// Match returns the ticket of the user who created the auth ID. It returns
+// ErrNotFound if the auth ID isn't of any user, or is out of the time window,
+// and ErrReplay if the auth ID has been seen.
+func (h *AuthIDDecoderHolder) Match(authID [16]byte) (interface{}, error) {
+	h.access.RLock()
+	defer h.access.RUnlock()
+
+	for _, item := range h.decoders {
+		t, ok := item.decoder.Decode(authID)
+		if !ok {
+			continue
+		}
+		if delta := time.Now().Unix() - t; delta > authIDWindow || delta < -authIDWindow {
+			continue
+		}
+		if !h.filter.Check(authID[:]) {
+			return nil, ErrReplay
+		}
+		return item.ticket, nil
+	}
+	return nil, ErrNotFound
+}
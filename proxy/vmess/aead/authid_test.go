@@ -0,0 +1,49 @@
+package aead_test
+
+import (
+	"testing"
+	"time"
+
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/uuid"
+	. "v2ray.com/core/proxy/vmess/aead"
+)
+
+func newCmdKey() []byte {
+	return protocol.NewID(uuid.New()).CmdKey()
+}
+
+func TestAuthIDMatch(t *testing.T) {
+	key1 := newCmdKey()
+	key2 := newCmdKey()
+
+	holder := NewAuthIDDecoderHolder()
+	holder.AddUser(key1, "user1")
+	holder.AddUser(key2, "user2")
+
+	authID := CreateAuthID(key2, time.Now().Unix())
+	ticket, err := holder.Match(authID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket != "user2" {
+		t.Error("unexpected ticket: ", ticket)
+	}
+
+	if _, err := holder.Match(authID); err != ErrReplay {
+		t.Error("expect replay error, but got ", err)
+	}
+
+	if _, err := holder.Match(CreateAuthID(newCmdKey(), time.Now().Unix())); err != ErrNotFound {
+		t.Error("expect not found error for unknown key, but got ", err)
+	}
+
+	if _, err := holder.Match(CreateAuthID(key1, time.Now().Add(-time.Minute*5).Unix())); err != ErrNotFound {
+		t.Error("expect not found error for expired auth ID, but got ", err)
+	}
+
+	holder.RemoveUser(key1)
+	if _, err := holder.Match(CreateAuthID(key1, time.Now().Unix())); err != ErrNotFound {
+		t.Error("expect not found error for removed user, but got ", err)
+	}
+}
@@ -0,0 +1,126 @@
+package aead
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/crypto"
+)
+
+// SealVMessAEADHeader seals the request header with the command key. The
+// sealed header is the auth ID, the sealed length of the header, a random
+// nonce of 8 bytes and the sealed header. The auth ID is the additional data
+// of both.
+func SealVMessAEADHeader(cmdKey []byte, header []byte) []byte {
+	authID := CreateAuthID(cmdKey, time.Now().Unix())
+
+	var nonce [8]byte
+	common.Must2(rand.Read(nonce[:]))
+
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(header)))
+
+	lengthAEAD, lengthNonce := headerLengthAEAD(cmdKey, authID, nonce)
+	headerAEAD, headerNonce := headerPayloadAEAD(cmdKey, authID, nonce)
+
+	sealed := make([]byte, 0, 16+2+lengthAEAD.Overhead()+8+len(header)+headerAEAD.Overhead())
+	sealed = append(sealed, authID[:]...)
+	sealed = lengthAEAD.Seal(sealed, lengthNonce, length[:], authID[:])
+	sealed = append(sealed, nonce[:]...)
+	sealed = headerAEAD.Seal(sealed, headerNonce, header, authID[:])
+	return sealed
+}
+
+// OpenVMessAEADHeader reads the rest of a header sealed by SealVMessAEADHeader,
+// whose auth ID has been read, and returns the header.
+func OpenVMessAEADHeader(cmdKey []byte, authID [16]byte, reader io.Reader) ([]byte, error) {
+	var lengthSealed [2 + 16]byte
+	if _, err := io.ReadFull(reader, lengthSealed[:]); err != nil {
+		return nil, newError("failed to read header length").Base(err)
+	}
+	var nonce [8]byte
+	if _, err := io.ReadFull(reader, nonce[:]); err != nil {
+		return nil, newError("failed to read header nonce").Base(err)
+	}
+
+	lengthAEAD, lengthNonce := headerLengthAEAD(cmdKey, authID, nonce)
+	length, err := lengthAEAD.Open(nil, lengthNonce, lengthSealed[:], authID[:])
+	if err != nil {
+		return nil, newError("failed to open header length").Base(err)
+	}
+
+	headerAEAD, headerNonce := headerPayloadAEAD(cmdKey, authID, nonce)
+	headerSealed := make([]byte, int(binary.BigEndian.Uint16(length))+headerAEAD.Overhead())
+	if _, err := io.ReadFull(reader, headerSealed); err != nil {
+		return nil, newError("failed to read header").Base(err)
+	}
+	header, err := headerAEAD.Open(headerSealed[:0], headerNonce, headerSealed, authID[:])
+	if err != nil {
+		return nil, newError("failed to open header").Base(err)
+	}
+	return header, nil
+}
+
+func headerLengthAEAD(cmdKey []byte, authID [16]byte, nonce [8]byte) (aead cipher.AEAD, aeadNonce []byte) {
+	key := KDF16(cmdKey, KDFSaltConstVMessHeaderPayloadLengthAEADKey, string(authID[:]), string(nonce[:]))
+	aeadNonce = KDF(cmdKey, KDFSaltConstVMessHeaderPayloadLengthAEADIV, string(authID[:]), string(nonce[:]))[:12]
+	return crypto.NewAesGcm(key), aeadNonce
+}
+
+func headerPayloadAEAD(cmdKey []byte, authID [16]byte, nonce [8]byte) (aead cipher.AEAD, aeadNonce []byte) {
+	key := KDF16(cmdKey, KDFSaltConstVMessHeaderPayloadAEADKey, string(authID[:]), string(nonce[:]))
+	aeadNonce = KDF(cmdKey, KDFSaltConstVMessHeaderPayloadAEADIV, string(authID[:]), string(nonce[:]))[:12]
+	return crypto.NewAesGcm(key), aeadNonce
+}
+
+// SealResponseHeader seals the response header with the key and the IV of the
+// response body. The sealed header is the sealed length of the header and the
+// sealed header.
+func SealResponseHeader(bodyKey []byte, bodyIV []byte, header []byte) []byte {
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(header)))
+
+	lengthAEAD, lengthNonce := responseLengthAEAD(bodyKey, bodyIV)
+	headerAEAD, headerNonce := responsePayloadAEAD(bodyKey, bodyIV)
+
+	sealed := make([]byte, 0, 2+lengthAEAD.Overhead()+len(header)+headerAEAD.Overhead())
+	sealed = lengthAEAD.Seal(sealed, lengthNonce, length[:], nil)
+	sealed = headerAEAD.Seal(sealed, headerNonce, header, nil)
+	return sealed
+}
+
+// OpenResponseHeader reads a header sealed by SealResponseHeader, and returns the header.
+func OpenResponseHeader(bodyKey []byte, bodyIV []byte, reader io.Reader) ([]byte, error) {
+	lengthAEAD, lengthNonce := responseLengthAEAD(bodyKey, bodyIV)
+	lengthSealed := make([]byte, 2+lengthAEAD.Overhead())
+	if _, err := io.ReadFull(reader, lengthSealed); err != nil {
+		return nil, newError("failed to read response header length").Base(err)
+	}
+	length, err := lengthAEAD.Open(nil, lengthNonce, lengthSealed, nil)
+	if err != nil {
+		return nil, newError("failed to open response header length").Base(err)
+	}
+
+	headerAEAD, headerNonce := responsePayloadAEAD(bodyKey, bodyIV)
+	headerSealed := make([]byte, int(binary.BigEndian.Uint16(length))+headerAEAD.Overhead())
+	if _, err := io.ReadFull(reader, headerSealed); err != nil {
+		return nil, newError("failed to read response header").Base(err)
+	}
+	header, err := headerAEAD.Open(headerSealed[:0], headerNonce, headerSealed, nil)
+	if err != nil {
+		return nil, newError("failed to open response header").Base(err)
+	}
+	return header, nil
+}
+
+func responseLengthAEAD(bodyKey []byte, bodyIV []byte) (aead cipher.AEAD, aeadNonce []byte) {
+	return crypto.NewAesGcm(KDF16(bodyKey, KDFSaltConstAEADRespHeaderLenKey)), KDF(bodyIV, KDFSaltConstAEADRespHeaderLenIV)[:12]
+}
+
+func responsePayloadAEAD(bodyKey []byte, bodyIV []byte) (aead cipher.AEAD, aeadNonce []byte) {
+	return crypto.NewAesGcm(KDF16(bodyKey, KDFSaltConstAEADRespHeaderPayloadKey)), KDF(bodyIV, KDFSaltConstAEADRespHeaderPayloadIV)[:12]
+}
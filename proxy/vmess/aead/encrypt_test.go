@@ -0,0 +1,58 @@
+package aead_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"v2ray.com/core/common"
+	. "v2ray.com/core/proxy/vmess/aead"
+)
+
+func TestHeaderSealing(t *testing.T) {
+	key := newCmdKey()
+	header := []byte("Test Header")
+
+	sealed := SealVMessAEADHeader(key, header)
+
+	holder := NewAuthIDDecoderHolder()
+	holder.AddUser(key, "user")
+
+	var authID [16]byte
+	copy(authID[:], sealed)
+	_, err := holder.Match(authID)
+	common.Must(err)
+
+	opened, err := OpenVMessAEADHeader(key, authID, bytes.NewReader(sealed[16:]))
+	common.Must(err)
+	if !bytes.Equal(opened, header) {
+		t.Error("unexpected header: ", opened)
+	}
+
+	// The header is authenticated with the auth ID.
+	otherAuthID := CreateAuthID(key, time.Now().Unix())
+	if _, err := OpenVMessAEADHeader(key, otherAuthID, bytes.NewReader(sealed[16:])); err == nil {
+		t.Error("nil error")
+	}
+}
+
+func TestResponseHeaderSealing(t *testing.T) {
+	key := newCmdKey()
+	iv := newCmdKey()
+	header := []byte{1, 2, 3, 4}
+
+	sealed := SealResponseHeader(key, iv, header)
+	reader := bytes.NewReader(append(sealed, 'x'))
+	opened, err := OpenResponseHeader(key, iv, reader)
+	common.Must(err)
+	if !bytes.Equal(opened, header) {
+		t.Error("unexpected header: ", opened)
+	}
+	if reader.Len() != 1 {
+		t.Error("unexpected remaining bytes: ", reader.Len())
+	}
+
+	if _, err := OpenResponseHeader(iv, key, bytes.NewReader(sealed)); err == nil {
+		t.Error("nil error")
+	}
+}
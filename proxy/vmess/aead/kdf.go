@@ -0,0 +1,38 @@
+package aead
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"hash"
+
+	"v2ray.com/core/common"
+)
+
+// KDF derives a key of 32 bytes from the key and the path, with nested
+// HMAC-SHA256 whose innermost key is KDFSaltConstVMessAEADKDF.
+func KDF(key []byte, path ...string) []byte {
+	creator := &hmacCreator{value: []byte(KDFSaltConstVMessAEADKDF)}
+	for _, v := range path {
+		creator = &hmacCreator{value: []byte(v), parent: creator}
+	}
+	h := creator.Create()
+	common.Must2(h.Write(key))
+	return h.Sum(nil)
+}
+
+// KDF16 returns the first 16 bytes of KDF.
+func KDF16(key []byte, path ...string) []byte {
+	return KDF(key, path...)[:16]
+}
+
+type hmacCreator struct {
+	parent *hmacCreator
+	value  []byte
+}
+
+func (c *hmacCreator) Create() hash.Hash {
+	if c.parent == nil {
+		return hmac.New(sha256.New, c.value)
+	}
+	return hmac.New(c.parent.Create, c.value)
+}
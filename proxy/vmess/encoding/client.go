@@ -1,8 +1,10 @@
 package encoding
 
 import (
+	"bytes"
 	"crypto/md5"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/binary"
 	"hash"
 	"hash/fnv"
@@ -18,6 +20,7 @@ import (
 	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/common/serial"
 	"v2ray.com/core/proxy/vmess"
+	"v2ray.com/core/proxy/vmess/aead"
 )
 
 func hashTimestamp(h hash.Hash, t protocol.Timestamp) []byte {
@@ -30,6 +33,7 @@ func hashTimestamp(h hash.Hash, t protocol.Timestamp) []byte {
 
 // ClientSession stores connection session info for VMess client.
 type ClientSession struct {
+	isAEAD          bool
 	idHash          protocol.IDHash
 	requestBodyKey  [16]byte
 	requestBodyIV   [16]byte
@@ -39,17 +43,27 @@ type ClientSession struct {
 	responseHeader  byte
 }
 
-// NewClientSession creates a new ClientSession.
-func NewClientSession(idHash protocol.IDHash) *ClientSession {
+// NewClientSession creates a new ClientSession. The request header is sealed
+// with AEAD if isAEAD is true, or authenticated by the legacy hash of time otherwise.
+func NewClientSession(isAEAD bool, idHash protocol.IDHash) *ClientSession {
 	randomBytes := make([]byte, 33) // 16 + 16 + 1
 	common.Must2(rand.Read(randomBytes))
 
-	session := &ClientSession{}
+	session := &ClientSession{
+		isAEAD: isAEAD,
+	}
 	copy(session.requestBodyKey[:], randomBytes[:16])
 	copy(session.requestBodyIV[:], randomBytes[16:32])
 	session.responseHeader = randomBytes[32]
-	session.responseBodyKey = md5.Sum(session.requestBodyKey[:])
-	session.responseBodyIV = md5.Sum(session.requestBodyIV[:])
+	if isAEAD {
+		responseBodyKey := sha256.Sum256(session.requestBodyKey[:])
+		copy(session.responseBodyKey[:], responseBodyKey[:16])
+		responseBodyIV := sha256.Sum256(session.requestBodyIV[:])
+		copy(session.responseBodyIV[:], responseBodyIV[:16])
+	} else {
+		session.responseBodyKey = md5.Sum(session.requestBodyKey[:])
+		session.responseBodyIV = md5.Sum(session.requestBodyIV[:])
+	}
 	session.idHash = idHash
 
 	return session
@@ -58,9 +72,11 @@ func NewClientSession(idHash protocol.IDHash) *ClientSession {
 func (c *ClientSession) EncodeRequestHeader(header *protocol.RequestHeader, writer io.Writer) error {
 	timestamp := protocol.NewTimestampGenerator(protocol.NowTime(), 30)()
 	account := header.User.Account.(*vmess.MemoryAccount)
-	idHash := c.idHash(account.AnyValidID().Bytes())
-	common.Must2(serial.WriteUint64(idHash, uint64(timestamp)))
-	common.Must2(writer.Write(idHash.Sum(nil)))
+	if !c.isAEAD {
+		idHash := c.idHash(account.AnyValidID().Bytes())
+		common.Must2(serial.WriteUint64(idHash, uint64(timestamp)))
+		common.Must2(writer.Write(idHash.Sum(nil)))
+	}
 
 	buffer := buf.New()
 	defer buffer.Release()
@@ -92,6 +108,11 @@ func (c *ClientSession) EncodeRequestHeader(header *protocol.RequestHeader, writ
 		fnv1a.Sum(hashBytes[:0])
 	}
 
+	if c.isAEAD {
+		common.Must2(writer.Write(aead.SealVMessAEADHeader(account.ID.CmdKey(), buffer.Bytes())))
+		return nil
+	}
+
 	iv := hashTimestamp(md5.New(), timestamp)
 	aesStream := crypto.NewAesEncryptionStream(account.ID.CmdKey(), iv[:])
 	aesStream.XORKeyStream(buffer.Bytes(), buffer.Bytes())
@@ -164,10 +185,19 @@ func (c *ClientSession) DecodeResponseHeader(reader io.Reader) (*protocol.Respon
 	aesStream := crypto.NewAesDecryptionStream(c.responseBodyKey[:], c.responseBodyIV[:])
 	c.responseReader = crypto.NewCryptionReader(aesStream, reader)
 
+	headerReader := c.responseReader
+	if c.isAEAD {
+		data, err := aead.OpenResponseHeader(c.responseBodyKey[:], c.responseBodyIV[:], reader)
+		if err != nil {
+			return nil, newError("failed to read response header").Base(err)
+		}
+		headerReader = bytes.NewReader(data)
+	}
+
 	buffer := buf.StackNew()
 	defer buffer.Release()
 
-	if _, err := buffer.ReadFullFrom(c.responseReader, 4); err != nil {
+	if _, err := buffer.ReadFullFrom(headerReader, 4); err != nil {
 		return nil, newError("failed to read response header").Base(err)
 	}
 
@@ -184,7 +214,7 @@ func (c *ClientSession) DecodeResponseHeader(reader io.Reader) (*protocol.Respon
 		dataLen := int32(buffer.Byte(3))
 
 		buffer.Clear()
-		if _, err := buffer.ReadFullFrom(c.responseReader, dataLen); err != nil {
+		if _, err := buffer.ReadFullFrom(headerReader, dataLen); err != nil {
 			return nil, newError("failed to read response command").Base(err)
 		}
 		command, err := UnmarshalCommand(cmdID, buffer.Bytes())
@@ -42,7 +42,7 @@ func TestRequestSerialization(t *testing.T) {
 	}
 
 	buffer := buf.New()
-	client := NewClientSession(protocol.DefaultIDHash)
+	client := NewClientSession(false, protocol.DefaultIDHash)
 	common.Must(client.EncodeRequestHeader(expectedRequest, buffer))
 
 	buffer2 := buf.New()
@@ -92,7 +92,7 @@ func TestInvalidRequest(t *testing.T) {
 	}
 
 	buffer := buf.New()
-	client := NewClientSession(protocol.DefaultIDHash)
+	client := NewClientSession(false, protocol.DefaultIDHash)
 	common.Must(client.EncodeRequestHeader(expectedRequest, buffer))
 
 	buffer2 := buf.New()
@@ -133,7 +133,7 @@ func TestMuxRequest(t *testing.T) {
 	}
 
 	buffer := buf.New()
-	client := NewClientSession(protocol.DefaultIDHash)
+	client := NewClientSession(false, protocol.DefaultIDHash)
 	common.Must(client.EncodeRequestHeader(expectedRequest, buffer))
 
 	buffer2 := buf.New()
@@ -154,3 +154,69 @@ func TestMuxRequest(t *testing.T) {
 		t.Error(r)
 	}
 }
+
+func TestAEADRequestSerialization(t *testing.T) {
+	user := &protocol.MemoryUser{
+		Level: 0,
+		Email: "test@v2ray.com",
+	}
+	id := uuid.New()
+	account := &vmess.Account{
+		Id:      id.String(),
+		AlterId: 0,
+	}
+	user.Account = toAccount(account)
+
+	expectedRequest := &protocol.RequestHeader{
+		Version:  1,
+		User:     user,
+		Command:  protocol.RequestCommandTCP,
+		Address:  net.DomainAddress("www.v2ray.com"),
+		Port:     net.Port(443),
+		Security: protocol.SecurityType_AES128_GCM,
+	}
+
+	buffer := buf.New()
+	client := NewClientSession(true, protocol.DefaultIDHash)
+	common.Must(client.EncodeRequestHeader(expectedRequest, buffer))
+
+	buffer2 := buf.New()
+	buffer2.Write(buffer.Bytes())
+
+	sessionHistory := NewSessionHistory()
+	defer common.Close(sessionHistory)
+
+	userValidator := vmess.NewTimedUserValidator(protocol.DefaultIDHash)
+	userValidator.Add(user)
+	defer common.Close(userValidator)
+
+	server := NewServerSession(userValidator, sessionHistory)
+	actualRequest, err := server.DecodeRequestHeader(buffer)
+	common.Must(err)
+	if !server.IsAEAD() {
+		t.Error("expect AEAD header")
+	}
+
+	if r := cmp.Diff(actualRequest, expectedRequest, cmp.AllowUnexported(protocol.ID{})); r != "" {
+		t.Error(r)
+	}
+
+	response := buf.New()
+	server.EncodeResponseHeader(&protocol.ResponseHeader{}, response)
+	common.Must(server.EncodeResponseBody(actualRequest, response).WriteMultiBuffer(buf.MergeBytes(nil, []byte("abcd"))))
+
+	if _, err := client.DecodeResponseHeader(response); err != nil {
+		t.Fatal(err)
+	}
+	mb, err := client.DecodeResponseBody(expectedRequest, response).ReadMultiBuffer()
+	common.Must(err)
+	if mb.String() != "abcd" {
+		t.Error("unexpected response body: ", mb.String())
+	}
+
+	_, err = NewServerSession(userValidator, sessionHistory).DecodeRequestHeader(buffer2)
+	// anti replay attack
+	if err == nil {
+		t.Error("nil error")
+	}
+}
@@ -1,7 +1,9 @@
 package encoding
 
 import (
+	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/binary"
 	"hash/fnv"
 	"io"
@@ -18,6 +20,7 @@ import (
 	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/common/task"
 	"v2ray.com/core/proxy/vmess"
+	"v2ray.com/core/proxy/vmess/aead"
 )
 
 type sessionId struct {
@@ -97,6 +100,7 @@ type ServerSession struct {
 	responseBodyIV  [16]byte
 	responseWriter  io.Writer
 	responseHeader  byte
+	isAEAD          bool
 }
 
 // NewServerSession creates a new ServerSession, using the given UserValidator.
@@ -120,7 +124,14 @@ func parseSecurityType(b byte) protocol.SecurityType {
 	return protocol.SecurityType_UNKNOWN
 }
 
+// IsAEAD returns true if the request header decoded is sealed with AEAD, or
+// false if it is a legacy one.
+func (s *ServerSession) IsAEAD() bool {
+	return s.isAEAD
+}
+
 // DecodeRequestHeader decodes and returns (if successful) a RequestHeader from an input stream.
+// Both AEAD and legacy headers are accepted.
 func (s *ServerSession) DecodeRequestHeader(reader io.Reader) (*protocol.RequestHeader, error) {
 	buffer := buf.New()
 	defer buffer.Release()
@@ -129,17 +140,34 @@ func (s *ServerSession) DecodeRequestHeader(reader io.Reader) (*protocol.Request
 		return nil, newError("failed to read request header").Base(err)
 	}
 
-	user, timestamp, valid := s.userValidator.Get(buffer.Bytes())
-	if !valid {
-		return nil, newError("invalid user")
-	}
+	var decryptor io.Reader
+	user, found, err := s.userValidator.GetAEAD(buffer.Bytes())
+	switch {
+	case found:
+		var authID [16]byte
+		copy(authID[:], buffer.Bytes())
+		header, err := aead.OpenVMessAEADHeader(user.Account.(*vmess.MemoryAccount).ID.CmdKey(), authID, reader)
+		if err != nil {
+			return nil, newError("invalid AEAD header").Base(err)
+		}
+		decryptor = bytes.NewReader(header)
+		s.isAEAD = true
+	case err == aead.ErrReplay:
+		return nil, newError("invalid user").Base(err)
+	default:
+		var timestamp protocol.Timestamp
+		var valid bool
+		user, timestamp, valid = s.userValidator.Get(buffer.Bytes())
+		if !valid {
+			return nil, newError("invalid user")
+		}
 
-	iv := hashTimestamp(md5.New(), timestamp)
+		iv := hashTimestamp(md5.New(), timestamp)
+		aesStream := crypto.NewAesDecryptionStream(user.Account.(*vmess.MemoryAccount).ID.CmdKey(), iv[:])
+		decryptor = crypto.NewCryptionReader(aesStream, reader)
+	}
 	vmessAccount := user.Account.(*vmess.MemoryAccount)
 
-	aesStream := crypto.NewAesDecryptionStream(vmessAccount.ID.CmdKey(), iv[:])
-	decryptor := crypto.NewCryptionReader(aesStream, reader)
-
 	buffer.Clear()
 	if _, err := buffer.ReadFullFrom(decryptor, 38); err != nil {
 		return nil, newError("failed to read request header").Base(err)
@@ -273,17 +301,35 @@ func (s *ServerSession) DecodeRequestBody(request *protocol.RequestHeader, reade
 
 // EncodeResponseHeader writes encoded response header into the given writer.
 func (s *ServerSession) EncodeResponseHeader(header *protocol.ResponseHeader, writer io.Writer) {
-	s.responseBodyKey = md5.Sum(s.requestBodyKey[:])
-	s.responseBodyIV = md5.Sum(s.requestBodyIV[:])
+	if s.isAEAD {
+		responseBodyKey := sha256.Sum256(s.requestBodyKey[:])
+		copy(s.responseBodyKey[:], responseBodyKey[:16])
+		responseBodyIV := sha256.Sum256(s.requestBodyIV[:])
+		copy(s.responseBodyIV[:], responseBodyIV[:16])
+	} else {
+		s.responseBodyKey = md5.Sum(s.requestBodyKey[:])
+		s.responseBodyIV = md5.Sum(s.requestBodyIV[:])
+	}
 
 	aesStream := crypto.NewAesEncryptionStream(s.responseBodyKey[:], s.responseBodyIV[:])
 	encryptionWriter := crypto.NewCryptionWriter(aesStream, writer)
 	s.responseWriter = encryptionWriter
 
-	common.Must2(encryptionWriter.Write([]byte{s.responseHeader, byte(header.Option)}))
-	err := MarshalCommand(header.Command, encryptionWriter)
+	// The AEAD header is sealed as a whole, while the legacy one is encrypted
+	// in the same stream as the body.
+	var headerWriter io.Writer = encryptionWriter
+	if s.isAEAD {
+		headerWriter = bytes.NewBuffer(make([]byte, 0, 64))
+	}
+
+	common.Must2(headerWriter.Write([]byte{s.responseHeader, byte(header.Option)}))
+	err := MarshalCommand(header.Command, headerWriter)
 	if err != nil {
-		common.Must2(encryptionWriter.Write([]byte{0x00, 0x00}))
+		common.Must2(headerWriter.Write([]byte{0x00, 0x00}))
+	}
+
+	if s.isAEAD {
+		common.Must2(writer.Write(aead.SealResponseHeader(s.responseBodyKey[:], s.responseBodyIV[:], headerWriter.(*bytes.Buffer).Bytes())))
 	}
 }
 
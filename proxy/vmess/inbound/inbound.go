@@ -109,6 +109,7 @@ type Handler struct {
 	detours               *DetourConfig
 	sessionHistory        *encoding.SessionHistory
 	secure                bool
+	aeadOnly              bool
 }
 
 // New creates a new VMess inbound handler.
@@ -122,6 +123,7 @@ func New(ctx context.Context, config *Config) (*Handler, error) {
 		usersByEmail:          newUserByEmail(config.GetDefaultValue()),
 		sessionHistory:        encoding.NewSessionHistory(),
 		secure:                config.SecureEncryptionOnly,
+		aeadOnly:              config.AeadHeaderOnly,
 	}
 
 	for _, user := range config.User {
@@ -256,6 +258,22 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection i
 		return newError("client is using insecure encryption: ", request.Security)
 	}
 
+	if svrSession.IsAEAD() {
+		newError("client ", connection.RemoteAddr(), " is using AEAD header").AtInfo().WriteToLog(session.ExportIDToError(ctx))
+	} else {
+		if h.aeadOnly {
+			log.Record(&log.AccessMessage{
+				InboundTag: inbound.Tag,
+				From:       connection.RemoteAddr(),
+				To:         "",
+				Status:     log.AccessRejected,
+				Reason:     "Legacy header",
+			})
+			return newError("client ", connection.RemoteAddr(), " is using legacy header")
+		}
+		newError("client ", connection.RemoteAddr(), " is using legacy header").AtWarning().WriteToLog(session.ExportIDToError(ctx))
+	}
+
 	if request.Command != protocol.RequestCommandMux {
 		ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 			InboundTag: inbound.Tag,
@@ -112,7 +112,9 @@ func (v *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	input := link.Reader
 	output := link.Writer
 
-	session := encoding.NewClientSession(protocol.DefaultIDHash)
+	// AEAD headers are only sent when enabled, as servers without AEAD support refuse them.
+	isAEAD := aeadEnabled && len(account.AlterIDs) == 0
+	session := encoding.NewClientSession(isAEAD, protocol.DefaultIDHash)
 	sessionPolicy := v.policyManager.ForLevel(request.User.Level)
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -229,6 +231,7 @@ func (v *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 
 var (
 	enablePadding = false
+	aeadEnabled   = false
 )
 
 func shouldEnablePadding(s protocol.SecurityType) bool {
@@ -245,4 +248,10 @@ func init() {
 	if paddingValue != defaultFlagValue {
 		enablePadding = true
 	}
+
+	// Set V2RAY_VMESS_AEAD_ENABLED=true to send AEAD headers for accounts without alternative IDs.
+	aeadEnabledValue := platform.NewEnvFlag("v2ray.vmess.aead.enabled").GetValue(func() string { return defaultFlagValue })
+	if aeadEnabledValue == "true" {
+		aeadEnabled = true
+	}
 }
@@ -11,6 +11,7 @@ import (
 	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/common/serial"
 	"v2ray.com/core/common/task"
+	"v2ray.com/core/proxy/vmess/aead"
 )
 
 const (
@@ -31,6 +32,8 @@ type TimedUserValidator struct {
 	hasher   protocol.IDHash
 	baseTime protocol.Timestamp
 	task     *task.Periodic
+
+	aeadDecoderHolder *aead.AuthIDDecoderHolder
 }
 
 type indexTimePair struct {
@@ -45,6 +48,8 @@ func NewTimedUserValidator(hasher protocol.IDHash) *TimedUserValidator {
 		userHash: make(map[[16]byte]indexTimePair, 1024),
 		hasher:   hasher,
 		baseTime: protocol.Timestamp(time.Now().Unix() - cacheDurationSec*2),
+
+		aeadDecoderHolder: aead.NewAuthIDDecoderHolder(),
 	}
 	tuv.task = &task.Periodic{
 		Interval: updateInterval,
@@ -124,6 +129,9 @@ func (v *TimedUserValidator) Add(u *protocol.MemoryUser) error {
 	v.users = append(v.users, uu)
 	v.generateNewHashes(protocol.Timestamp(nowSec), uu)
 
+	account := uu.user.Account.(*MemoryAccount)
+	v.aeadDecoderHolder.AddUser(account.ID.CmdKey(), uu)
+
 	return nil
 }
 
@@ -142,6 +150,22 @@ func (v *TimedUserValidator) Get(userHash []byte) (*protocol.MemoryUser, protoco
 	return nil, 0, false
 }
 
+// GetAEAD returns the user of the auth ID of an AEAD header. The error is
+// aead.ErrReplay if the auth ID has been used.
+func (v *TimedUserValidator) GetAEAD(authID []byte) (*protocol.MemoryUser, bool, error) {
+	defer v.RUnlock()
+	v.RLock()
+
+	var fixedSizeAuthID [16]byte
+	copy(fixedSizeAuthID[:], authID)
+	ticket, err := v.aeadDecoderHolder.Match(fixedSizeAuthID)
+	if err != nil {
+		return nil, false, err
+	}
+	user := ticket.(*user).user
+	return &user, true, nil
+}
+
 func (v *TimedUserValidator) Remove(email string) bool {
 	v.Lock()
 	defer v.Unlock()
@@ -159,6 +183,9 @@ func (v *TimedUserValidator) Remove(email string) bool {
 	}
 	ulen := len(v.users)
 
+	account := v.users[idx].user.Account.(*MemoryAccount)
+	v.aeadDecoderHolder.RemoveUser(account.ID.CmdKey())
+
 	v.users[idx] = v.users[ulen-1]
 	v.users[ulen-1] = nil
 	v.users = v.users[:ulen-1]
@@ -1,17 +1,28 @@
 package scenarios
 
 import (
+	"context"
 	"crypto/rand"
+	"encoding/base64"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 
 	"v2ray.com/core"
+	"v2ray.com/core/app/commander"
 	"v2ray.com/core/app/log"
+	"v2ray.com/core/app/policy"
 	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/app/proxyman/command"
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/app/stats"
+	statscmd "v2ray.com/core/app/stats/command"
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
 	"v2ray.com/core/common/errors"
 	clog "v2ray.com/core/common/log"
 	"v2ray.com/core/common/net"
@@ -826,3 +837,453 @@ func TestShadowsocksNone(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestShadowsocksMultiUser(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: xor,
+	}
+	tcpDest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	udpServer := udp.Server{
+		MsgProcessor: xor,
+	}
+	udpDest, err := udpServer.Start()
+	common.Must(err)
+	defer udpServer.Close()
+
+	cmdPort := tcp.PickPort()
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&commander.Config{
+				Tag: "api",
+				Service: []*serial.TypedMessage{
+					serial.ToTypedMessage(&command.Config{}),
+					serial.ToTypedMessage(&statscmd.Config{}),
+				},
+			}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						InboundTag: []string{"api"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "api",
+						},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&policy.Config{
+				Level: map[uint32]*policy.Policy{
+					0: {
+						Stats: &policy.Policy_Stats{
+							UserUplink:   true,
+							UserDownlink: true,
+						},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				Tag: "shadowsocks",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(serverPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&shadowsocks.ServerConfig{
+					Users: []*protocol.User{
+						{
+							Email: "love@v2ray.com",
+							Account: serial.ToTypedMessage(&shadowsocks.Account{
+								Password:   "password-1",
+								CipherType: shadowsocks.CipherType_AES_128_GCM,
+							}),
+						},
+						{
+							Email: "peace@v2ray.com",
+							Account: serial.ToTypedMessage(&shadowsocks.Account{
+								Password:   "password-2",
+								CipherType: shadowsocks.CipherType_CHACHA20_POLY1305,
+							}),
+						},
+					},
+					Network: []net.Network{net.Network_TCP, net.Network_UDP},
+				}),
+			},
+			{
+				Tag: "api",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(cmdPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address: net.NewIPOrDomain(tcpDest.Address),
+					Port:    uint32(tcpDest.Port),
+					NetworkList: &net.NetworkList{
+						Network: []net.Network{net.Network_TCP},
+					},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{}),
+				ProxySettings:  serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	clientConfig := func(account *shadowsocks.Account, port net.Port) *core.Config {
+		return &core.Config{
+			Inbound: []*core.InboundHandlerConfig{
+				{
+					ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+						PortRange: net.SinglePortRange(port),
+						Listen:    net.NewIPOrDomain(net.LocalHostIP),
+					}),
+					ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+						Address: net.NewIPOrDomain(tcpDest.Address),
+						Port:    uint32(tcpDest.Port),
+						NetworkList: &net.NetworkList{
+							Network: []net.Network{net.Network_TCP},
+						},
+					}),
+				},
+			},
+			Outbound: []*core.OutboundHandlerConfig{
+				{
+					ProxySettings: serial.ToTypedMessage(&shadowsocks.ClientConfig{
+						Server: []*protocol.ServerEndpoint{
+							{
+								Address: net.NewIPOrDomain(net.LocalHostIP),
+								Port:    uint32(serverPort),
+								User: []*protocol.User{
+									{
+										Account: serial.ToTypedMessage(account),
+									},
+								},
+							},
+						},
+					}),
+				},
+			},
+		}
+	}
+
+	newAccount := &shadowsocks.Account{
+		Password:   "password-3",
+		CipherType: shadowsocks.CipherType_AES_256_GCM,
+	}
+	clientPort := tcp.PickPort()
+	newClientPort := tcp.PickPort()
+	servers, err := InitializeServerConfigs(serverConfig, clientConfig(&shadowsocks.Account{
+		Password:   "password-2",
+		CipherType: shadowsocks.CipherType_CHACHA20_POLY1305,
+	}, clientPort), clientConfig(newAccount, newClientPort))
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	// The second user is found by its key, and again from the cache.
+	for i := 0; i < 2; i++ {
+		if err := testTCPConn(clientPort, 1024, time.Second*5)(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	cmdConn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", cmdPort), grpc.WithInsecure(), grpc.WithBlock())
+	common.Must(err)
+	defer cmdConn.Close()
+
+	// TCP traffic is counted for the second user only.
+	sClient := statscmd.NewStatsServiceClient(cmdConn)
+	for _, name := range []string{"uplink", "downlink"} {
+		sresp, err := sClient.GetStats(context.Background(), &statscmd.GetStatsRequest{
+			Name: "user>>>peace@v2ray.com>>>traffic>>>" + name,
+		})
+		if err != nil || sresp.Stat.Value == 0 {
+			t.Error("no ", name, " traffic of user: ", err)
+		}
+		sresp, err = sClient.GetStats(context.Background(), &statscmd.GetStatsRequest{
+			Name: "user>>>love@v2ray.com>>>traffic>>>" + name,
+		})
+		if err == nil && sresp.Stat.Value != 0 {
+			t.Error("unexpected ", name, " traffic of another user")
+		}
+	}
+
+	// The first user sends UDP packets.
+	account, err := (&shadowsocks.Account{
+		Password:   "password-1",
+		CipherType: shadowsocks.CipherType_AES_128_GCM,
+	}).AsAccount()
+	common.Must(err)
+	request := &protocol.RequestHeader{
+		Version: shadowsocks.Version,
+		User:    &protocol.MemoryUser{Account: account},
+		Command: protocol.RequestCommandUDP,
+		Address: udpDest.Address,
+		Port:    udpDest.Port,
+	}
+	payload := []byte("shadowsocks payload")
+	packet, err := shadowsocks.EncodeUDPPacket(request, payload, nil)
+	common.Must(err)
+	conn, err := net.Dial("udp", "127.0.0.1:"+serverPort.String())
+	common.Must(err)
+	common.Must2(conn.Write(packet.Bytes()))
+	packet.Release()
+	common.Must(conn.SetReadDeadline(time.Now().Add(time.Second * 5)))
+	response := buf.New()
+	if _, err := response.ReadFrom(conn); err != nil {
+		t.Fatal("failed to read UDP response: ", err)
+	}
+	_, data, err := shadowsocks.DecodeUDPPacket(request.User, response)
+	common.Must(err)
+	if r := cmp.Diff(data.Bytes(), xor(payload)); r != "" {
+		t.Error(r)
+	}
+	response.Release()
+	conn.Close()
+
+	// Users are added and removed at runtime.
+	if err := testTCPConn(newClientPort, 1024, time.Second*5)(); err == nil {
+		t.Error("expect error from an unknown user")
+	}
+
+	hsClient := command.NewHandlerServiceClient(cmdConn)
+	common.Must2(hsClient.AlterInbound(context.Background(), &command.AlterInboundRequest{
+		Tag: "shadowsocks",
+		Operation: serial.ToTypedMessage(&command.AddUserOperation{
+			User: &protocol.User{
+				Email:   "new@v2ray.com",
+				Account: serial.ToTypedMessage(newAccount),
+			},
+		}),
+	}))
+	if err := testTCPConn(newClientPort, 1024, time.Second*5)(); err != nil {
+		t.Error(err)
+	}
+
+	removeUser := &command.AlterInboundRequest{
+		Tag:       "shadowsocks",
+		Operation: serial.ToTypedMessage(&command.RemoveUserOperation{Email: "new@v2ray.com"}),
+	}
+	common.Must2(hsClient.AlterInbound(context.Background(), removeUser))
+	if _, err := hsClient.AlterInbound(context.Background(), removeUser); err == nil {
+		t.Error("expect error when removing a removed user")
+	}
+	if err := testTCPConn(newClientPort, 1024, time.Second*5)(); err == nil {
+		t.Error("expect error from a removed user")
+	}
+
+	// Stream ciphers can't be told apart by their keys.
+	if _, err := hsClient.AlterInbound(context.Background(), &command.AlterInboundRequest{
+		Tag: "shadowsocks",
+		Operation: serial.ToTypedMessage(&command.AddUserOperation{
+			User: &protocol.User{
+				Account: serial.ToTypedMessage(&shadowsocks.Account{
+					Password:   "password-4",
+					CipherType: shadowsocks.CipherType_AES_128_CFB,
+				}),
+			},
+		}),
+	}); err == nil {
+		t.Error("expect error when adding a user of a stream cipher")
+	}
+}
+
+func TestShadowsocks2022(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: xor,
+	}
+	tcpDest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	udpServer := udp.Server{
+		MsgProcessor: xor,
+	}
+	udpDest, err := udpServer.Start()
+	common.Must(err)
+	defer udpServer.Close()
+
+	newKey := func(size int) string {
+		key := make([]byte, size)
+		common.Must2(rand.Read(key))
+		return base64.StdEncoding.EncodeToString(key)
+	}
+	clientAccount := &shadowsocks.Account{
+		Password:   newKey(32),
+		CipherType: shadowsocks.CipherType_CHACHA20_POLY1305_2022,
+	}
+
+	cmdPort := tcp.PickPort()
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&commander.Config{
+				Tag: "api",
+				Service: []*serial.TypedMessage{
+					serial.ToTypedMessage(&statscmd.Config{}),
+				},
+			}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						InboundTag: []string{"api"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "api",
+						},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&policy.Config{
+				Level: map[uint32]*policy.Policy{
+					0: {
+						Stats: &policy.Policy_Stats{
+							UserUplink: true,
+						},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(serverPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&shadowsocks.ServerConfig{
+					Users: []*protocol.User{
+						{
+							Email: "love@v2ray.com",
+							Account: serial.ToTypedMessage(&shadowsocks.Account{
+								Password:   newKey(16),
+								CipherType: shadowsocks.CipherType_AES_128_GCM_2022,
+							}),
+						},
+						{
+							Email:   "peace@v2ray.com",
+							Account: serial.ToTypedMessage(clientAccount),
+						},
+					},
+					Network: []net.Network{net.Network_TCP, net.Network_UDP},
+				}),
+			},
+			{
+				Tag: "api",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(cmdPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address: net.NewIPOrDomain(tcpDest.Address),
+					Port:    uint32(tcpDest.Port),
+					NetworkList: &net.NetworkList{
+						Network: []net.Network{net.Network_TCP},
+					},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{}),
+				ProxySettings:  serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	clientPort := tcp.PickPort()
+	clientConfig := &core.Config{
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(clientPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address: net.NewIPOrDomain(tcpDest.Address),
+					Port:    uint32(tcpDest.Port),
+					NetworkList: &net.NetworkList{
+						Network: []net.Network{net.Network_TCP},
+					},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&shadowsocks.ClientConfig{
+					Server: []*protocol.ServerEndpoint{
+						{
+							Address: net.NewIPOrDomain(net.LocalHostIP),
+							Port:    uint32(serverPort),
+							User: []*protocol.User{
+								{
+									Account: serial.ToTypedMessage(clientAccount),
+								},
+							},
+						},
+					},
+				}),
+			},
+		},
+	}
+
+	servers, err := InitializeServerConfigs(serverConfig, clientConfig)
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	var errg errgroup.Group
+	for i := 0; i < 10; i++ {
+		errg.Go(testTCPConn(clientPort, 10240*1024, time.Second*20))
+	}
+	if err := errg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The client sends UDP packets in a session.
+	account, err := clientAccount.AsAccount()
+	common.Must(err)
+	udpSession, err := shadowsocks.NewUDPSession(account.(*shadowsocks.MemoryAccount), false)
+	common.Must(err)
+	conn, err := net.Dial("udp", "127.0.0.1:"+serverPort.String())
+	common.Must(err)
+	defer conn.Close()
+	payload := []byte("shadowsocks 2022 payload")
+	for i := 0; i < 2; i++ {
+		packet, err := udpSession.EncodePacket(payload, udpDest)
+		common.Must(err)
+		common.Must2(conn.Write(packet.Bytes()))
+		packet.Release()
+
+		common.Must(conn.SetReadDeadline(time.Now().Add(time.Second * 5)))
+		response := buf.New()
+		if _, err := response.ReadFrom(conn); err != nil {
+			t.Fatal("failed to read UDP response: ", err)
+		}
+		source, err := udpSession.DecodePacket(response)
+		common.Must(err)
+		if source != udpDest {
+			t.Error("unexpected UDP source: ", source)
+		}
+		if r := cmp.Diff(response.Bytes(), xor(payload)); r != "" {
+			t.Error(r)
+		}
+		response.Release()
+	}
+
+	cmdConn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", cmdPort), grpc.WithInsecure(), grpc.WithBlock())
+	common.Must(err)
+	defer cmdConn.Close()
+
+	sClient := statscmd.NewStatsServiceClient(cmdConn)
+	sresp, err := sClient.GetStats(context.Background(), &statscmd.GetStatsRequest{
+		Name: "user>>>peace@v2ray.com>>>traffic>>>uplink",
+	})
+	if err != nil || sresp.Stat.Value == 0 {
+		t.Error("no uplink traffic of user: ", err)
+	}
+}
@@ -0,0 +1,225 @@
+package scenarios
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/commander"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/app/proxyman/command"
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/dokodemo"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/proxy/trojan"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/testing/servers/udp"
+)
+
+func TestTrojan(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: xor,
+	}
+	tcpDest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	udpServer := udp.Server{
+		MsgProcessor: xor,
+	}
+	udpDest, err := udpServer.Start()
+	common.Must(err)
+	defer udpServer.Close()
+
+	// The fallback echoes what it receives, like a web server answering a probe.
+	fallbackServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte { return b },
+	}
+	fallbackDest, err := fallbackServer.Start()
+	common.Must(err)
+	defer fallbackServer.Close()
+
+	cmdPort := tcp.PickPort()
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&commander.Config{
+				Tag: "api",
+				Service: []*serial.TypedMessage{
+					serial.ToTypedMessage(&command.Config{}),
+				},
+			}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						InboundTag: []string{"api"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "api",
+						},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				Tag: "trojan",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(serverPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&trojan.ServerConfig{
+					User: []*protocol.User{
+						{
+							Email:   "love@v2ray.com",
+							Account: serial.ToTypedMessage(&trojan.Account{Password: "password"}),
+						},
+					},
+					Fallback: &trojan.Fallback{
+						Address: net.NewIPOrDomain(fallbackDest.Address),
+						Port:    uint32(fallbackDest.Port),
+					},
+				}),
+			},
+			{
+				Tag: "api",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(cmdPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(tcpDest.Address),
+					Port:     uint32(tcpDest.Port),
+					Networks: []net.Network{net.Network_TCP},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{}),
+				ProxySettings:  serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	clientConfig := func(password string, port net.Port) *core.Config {
+		return &core.Config{
+			Inbound: []*core.InboundHandlerConfig{
+				{
+					ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+						PortRange: net.SinglePortRange(port),
+						Listen:    net.NewIPOrDomain(net.LocalHostIP),
+					}),
+					ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+						Address:  net.NewIPOrDomain(tcpDest.Address),
+						Port:     uint32(tcpDest.Port),
+						Networks: []net.Network{net.Network_TCP},
+					}),
+				},
+			},
+			Outbound: []*core.OutboundHandlerConfig{
+				{
+					ProxySettings: serial.ToTypedMessage(&trojan.ClientConfig{
+						Server: []*protocol.ServerEndpoint{
+							{
+								Address: net.NewIPOrDomain(net.LocalHostIP),
+								Port:    uint32(serverPort),
+								User: []*protocol.User{
+									{
+										Account: serial.ToTypedMessage(&trojan.Account{Password: password}),
+									},
+								},
+							},
+						},
+					}),
+				},
+			},
+		}
+	}
+
+	clientPort := tcp.PickPort()
+	newClientPort := tcp.PickPort()
+	servers, err := InitializeServerConfigs(serverConfig, clientConfig("password", clientPort), clientConfig("new-password", newClientPort))
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	if err := testTCPConn(clientPort, 1024, time.Second*5)(); err != nil {
+		t.Error(err)
+	}
+
+	// UDP packets are carried in the TCP connection to the server.
+	conn, err := net.Dial("tcp", "127.0.0.1:"+serverPort.String())
+	common.Must(err)
+	account, err := (&trojan.Account{Password: "password"}).AsAccount()
+	common.Must(err)
+	payload := []byte("trojan payload")
+	writer := &trojan.PacketWriter{Writer: conn, Target: udpDest}
+	common.Must(trojan.WriteHeader(conn, account.(*trojan.MemoryAccount), udpDest))
+	common.Must(writer.WriteMultiBuffer(buf.MergeBytes(nil, payload)))
+	common.Must(conn.SetReadDeadline(time.Now().Add(time.Second * 5)))
+	reader := &trojan.PacketReader{Reader: conn}
+	b, from, err := reader.ReadPacketFrom()
+	if err != nil {
+		t.Fatal("failed to read UDP response: ", err)
+	}
+	if r := cmp.Diff(b.Bytes(), xor(payload)); r != "" {
+		t.Error(r)
+	}
+	if from != udpDest {
+		t.Error("unexpected UDP source: ", from)
+	}
+	b.Release()
+	conn.Close()
+
+	// A request that doesn't speak Trojan goes to the fallback.
+	conn, err = net.Dial("tcp", "127.0.0.1:"+serverPort.String())
+	common.Must(err)
+	probe := []byte("GET / HTTP/1.1\r\nHost: v2ray.com\r\n\r\n")
+	common.Must2(conn.Write(probe))
+	response, err := readFrom2(conn, time.Second*5, len(probe))
+	conn.Close()
+	if err != nil || string(response) != string(probe) {
+		t.Error("unexpected fallback response: ", string(response), err)
+	}
+
+	// Users are added and removed at runtime.
+	if err := testTCPConn(newClientPort, 1024, time.Second*5)(); err == nil {
+		t.Error("expect error from an unknown user")
+	}
+
+	cmdConn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", cmdPort), grpc.WithInsecure(), grpc.WithBlock())
+	common.Must(err)
+	defer cmdConn.Close()
+
+	hsClient := command.NewHandlerServiceClient(cmdConn)
+	common.Must2(hsClient.AlterInbound(context.Background(), &command.AlterInboundRequest{
+		Tag: "trojan",
+		Operation: serial.ToTypedMessage(&command.AddUserOperation{
+			User: &protocol.User{
+				Email:   "new@v2ray.com",
+				Account: serial.ToTypedMessage(&trojan.Account{Password: "new-password"}),
+			},
+		}),
+	}))
+	if err := testTCPConn(newClientPort, 1024, time.Second*5)(); err != nil {
+		t.Error(err)
+	}
+
+	removeUser := &command.AlterInboundRequest{
+		Tag:       "trojan",
+		Operation: serial.ToTypedMessage(&command.RemoveUserOperation{Email: "new@v2ray.com"}),
+	}
+	common.Must2(hsClient.AlterInbound(context.Background(), removeUser))
+	if _, err := hsClient.AlterInbound(context.Background(), removeUser); err == nil {
+		t.Error("expect error when removing a removed user")
+	}
+}
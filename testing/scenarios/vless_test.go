@@ -0,0 +1,266 @@
+package scenarios
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+
+	"v2ray.com/core"
+	"v2ray.com/core/app/commander"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/app/proxyman/command"
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/app/stats"
+	statscmd "v2ray.com/core/app/stats/command"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/common/uuid"
+	"v2ray.com/core/proxy/dokodemo"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/proxy/vless"
+	"v2ray.com/core/proxy/vless/encoding"
+	"v2ray.com/core/proxy/vless/inbound"
+	"v2ray.com/core/proxy/vless/outbound"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/testing/servers/udp"
+)
+
+func TestVLess(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: xor,
+	}
+	tcpDest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	udpServer := udp.Server{
+		MsgProcessor: xor,
+	}
+	udpDest, err := udpServer.Start()
+	common.Must(err)
+	defer udpServer.Close()
+
+	// The fallback echoes what it receives, like a web server answering a probe.
+	fallbackServer := tcp.Server{
+		MsgProcessor: func(b []byte) []byte { return b },
+	}
+	fallbackDest, err := fallbackServer.Start()
+	common.Must(err)
+	defer fallbackServer.Close()
+
+	userID := uuid.New()
+	cmdPort := tcp.PickPort()
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&commander.Config{
+				Tag: "api",
+				Service: []*serial.TypedMessage{
+					serial.ToTypedMessage(&command.Config{}),
+					serial.ToTypedMessage(&statscmd.Config{}),
+				},
+			}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{
+					{
+						InboundTag: []string{"api"},
+						TargetTag: &router.RoutingRule_Tag{
+							Tag: "api",
+						},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&policy.Config{
+				Level: map[uint32]*policy.Policy{
+					0: {
+						Stats: &policy.Policy_Stats{
+							UserUplink:   true,
+							UserDownlink: true,
+						},
+					},
+				},
+			}),
+		},
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				Tag: "vless",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(serverPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&inbound.Config{
+					User: []*protocol.User{
+						{
+							Email:   "love@v2ray.com",
+							Account: serial.ToTypedMessage(&vless.Account{Id: userID.String()}),
+						},
+					},
+					Decryption: vless.None,
+					Fallback: &inbound.Fallback{
+						Address: net.NewIPOrDomain(fallbackDest.Address),
+						Port:    uint32(fallbackDest.Port),
+					},
+				}),
+			},
+			{
+				Tag: "api",
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(cmdPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+					Address:  net.NewIPOrDomain(tcpDest.Address),
+					Port:     uint32(tcpDest.Port),
+					Networks: []net.Network{net.Network_TCP},
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{}),
+				ProxySettings:  serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	clientConfig := func(id uuid.UUID, port net.Port) *core.Config {
+		return &core.Config{
+			Inbound: []*core.InboundHandlerConfig{
+				{
+					ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+						PortRange: net.SinglePortRange(port),
+						Listen:    net.NewIPOrDomain(net.LocalHostIP),
+					}),
+					ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+						Address:  net.NewIPOrDomain(tcpDest.Address),
+						Port:     uint32(tcpDest.Port),
+						Networks: []net.Network{net.Network_TCP},
+					}),
+				},
+			},
+			Outbound: []*core.OutboundHandlerConfig{
+				{
+					ProxySettings: serial.ToTypedMessage(&outbound.Config{
+						Vnext: []*protocol.ServerEndpoint{
+							{
+								Address: net.NewIPOrDomain(net.LocalHostIP),
+								Port:    uint32(serverPort),
+								User: []*protocol.User{
+									{
+										Account: serial.ToTypedMessage(&vless.Account{
+											Id:         id.String(),
+											Encryption: vless.None,
+										}),
+									},
+								},
+							},
+						},
+					}),
+				},
+			},
+		}
+	}
+
+	newID := uuid.New()
+	clientPort := tcp.PickPort()
+	newClientPort := tcp.PickPort()
+	servers, err := InitializeServerConfigs(serverConfig, clientConfig(userID, clientPort), clientConfig(newID, newClientPort))
+	common.Must(err)
+	defer CloseAllServers(servers)
+
+	if err := testTCPConn(clientPort, 1024, time.Second*5)(); err != nil {
+		t.Error(err)
+	}
+
+	// UDP packets are carried in the TCP connection to the server, with their lengths.
+	account, err := (&vless.Account{Id: userID.String()}).AsAccount()
+	common.Must(err)
+	request := &protocol.RequestHeader{
+		Version: encoding.Version,
+		User:    &protocol.MemoryUser{Account: account},
+		Command: protocol.RequestCommandUDP,
+		Address: udpDest.Address,
+		Port:    udpDest.Port,
+	}
+	conn, err := net.Dial("tcp", "127.0.0.1:"+serverPort.String())
+	common.Must(err)
+	payload := []byte("vless payload")
+	common.Must(encoding.EncodeRequestHeader(conn, request, nil))
+	common.Must(encoding.EncodeBodyAddons(conn, request, nil).WriteMultiBuffer(buf.MergeBytes(nil, payload)))
+	common.Must(conn.SetReadDeadline(time.Now().Add(time.Second * 5)))
+	reader := &buf.BufferedReader{Reader: buf.NewReader(conn)}
+	addons, err := encoding.DecodeResponseHeader(reader, request)
+	common.Must(err)
+	mb, err := encoding.DecodeBodyAddons(reader, request, addons).ReadMultiBuffer()
+	if err != nil {
+		t.Fatal("failed to read UDP response: ", err)
+	}
+	if r := cmp.Diff([]byte(mb.String()), xor(payload)); r != "" {
+		t.Error(r)
+	}
+	buf.ReleaseMulti(mb)
+	conn.Close()
+
+	cmdConn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", cmdPort), grpc.WithInsecure(), grpc.WithBlock())
+	common.Must(err)
+	defer cmdConn.Close()
+
+	// Traffic is counted for the user.
+	sClient := statscmd.NewStatsServiceClient(cmdConn)
+	for _, name := range []string{"uplink", "downlink"} {
+		sresp, err := sClient.GetStats(context.Background(), &statscmd.GetStatsRequest{
+			Name: "user>>>love@v2ray.com>>>traffic>>>" + name,
+		})
+		if err != nil || sresp.Stat.Value == 0 {
+			t.Error("no ", name, " traffic of user: ", err)
+		}
+	}
+
+	// A request without a known ID goes to the fallback.
+	conn, err = net.Dial("tcp", "127.0.0.1:"+serverPort.String())
+	common.Must(err)
+	probe := []byte("GET / HTTP/1.1\r\nHost: v2ray.com\r\n\r\n")
+	common.Must2(conn.Write(probe))
+	response, err := readFrom2(conn, time.Second*5, len(probe))
+	conn.Close()
+	if err != nil || string(response) != string(probe) {
+		t.Error("unexpected fallback response: ", string(response), err)
+	}
+
+	// Users are added and removed at runtime.
+	if err := testTCPConn(newClientPort, 1024, time.Second*5)(); err == nil {
+		t.Error("expect error from an unknown user")
+	}
+
+	hsClient := command.NewHandlerServiceClient(cmdConn)
+	common.Must2(hsClient.AlterInbound(context.Background(), &command.AlterInboundRequest{
+		Tag: "vless",
+		Operation: serial.ToTypedMessage(&command.AddUserOperation{
+			User: &protocol.User{
+				Email:   "new@v2ray.com",
+				Account: serial.ToTypedMessage(&vless.Account{Id: newID.String()}),
+			},
+		}),
+	}))
+	if err := testTCPConn(newClientPort, 1024, time.Second*5)(); err != nil {
+		t.Error(err)
+	}
+
+	removeUser := &command.AlterInboundRequest{
+		Tag:       "vless",
+		Operation: serial.ToTypedMessage(&command.RemoveUserOperation{Email: "new@v2ray.com"}),
+	}
+	common.Must2(hsClient.AlterInbound(context.Background(), removeUser))
+	if _, err := hsClient.AlterInbound(context.Background(), removeUser); err == nil {
+		t.Error("expect error when removing a removed user")
+	}
+}
@@ -1175,3 +1175,114 @@ func TestVMessGCMMuxUDP(t *testing.T) {
 		time.Sleep(time.Second)
 	}
 }
+
+func TestVMessAEADHeaderOnly(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: xor,
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	userID := protocol.NewID(uuid.New())
+	serverPort := tcp.PickPort()
+	serverConfig := &core.Config{
+		Inbound: []*core.InboundHandlerConfig{
+			{
+				ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+					PortRange: net.SinglePortRange(serverPort),
+					Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				}),
+				ProxySettings: serial.ToTypedMessage(&inbound.Config{
+					User: []*protocol.User{
+						{
+							Account: serial.ToTypedMessage(&vmess.Account{
+								Id:      userID.String(),
+								AlterId: 4,
+							}),
+						},
+					},
+					AeadHeaderOnly: true,
+				}),
+			},
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	clientConfig := func(port net.Port, alterID uint32) *core.Config {
+		return &core.Config{
+			Inbound: []*core.InboundHandlerConfig{
+				{
+					ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+						PortRange: net.SinglePortRange(port),
+						Listen:    net.NewIPOrDomain(net.LocalHostIP),
+					}),
+					ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+						Address: net.NewIPOrDomain(dest.Address),
+						Port:    uint32(dest.Port),
+						NetworkList: &net.NetworkList{
+							Network: []net.Network{net.Network_TCP},
+						},
+					}),
+				},
+			},
+			Outbound: []*core.OutboundHandlerConfig{
+				{
+					ProxySettings: serial.ToTypedMessage(&outbound.Config{
+						Receiver: []*protocol.ServerEndpoint{
+							{
+								Address: net.NewIPOrDomain(net.LocalHostIP),
+								Port:    uint32(serverPort),
+								User: []*protocol.User{
+									{
+										Account: serial.ToTypedMessage(&vmess.Account{
+											Id:      userID.String(),
+											AlterId: alterID,
+											SecuritySettings: &protocol.SecurityConfig{
+												Type: protocol.SecurityType_AES128_GCM,
+											},
+										}),
+									},
+								},
+							},
+						},
+					}),
+				},
+			},
+		}
+	}
+
+	// Clients send legacy headers unless AEAD is enabled.
+	legacyPort := tcp.PickPort()
+	legacyAlterIDPort := tcp.PickPort()
+	servers, err := InitializeServerConfigs(serverConfig, clientConfig(legacyPort, 0), clientConfig(legacyAlterIDPort, 4))
+	if err != nil {
+		t.Fatal("Failed to initialize all servers: ", err.Error())
+	}
+	defer CloseAllServers(servers)
+
+	common.Must(os.Setenv("V2RAY_VMESS_AEAD_ENABLED", "true"))
+	defer os.Unsetenv("V2RAY_VMESS_AEAD_ENABLED")
+
+	// Accounts with alternative IDs keep sending legacy headers.
+	aeadPort := tcp.PickPort()
+	aeadAlterIDPort := tcp.PickPort()
+	aeadServers, err := InitializeServerConfigs(clientConfig(aeadPort, 0), clientConfig(aeadAlterIDPort, 4))
+	if err != nil {
+		t.Fatal("Failed to initialize all servers: ", err.Error())
+	}
+	defer CloseAllServers(aeadServers)
+
+	if err := testTCPConn(aeadPort, 1024, time.Second*5)(); err != nil {
+		t.Error("AEAD client is refused: ", err)
+	}
+	for _, port := range []net.Port{legacyPort, legacyAlterIDPort, aeadAlterIDPort} {
+		if err := testTCPConn(port, 1024, time.Second*2)(); err == nil {
+			t.Error("legacy client is accepted on port ", port)
+		}
+	}
+}
@@ -10,6 +10,23 @@ type Connection interface {
 	net.Conn
 }
 
+// RawConnection is implemented by connections that wrap another connection, such as TLS and WebSocket.
+type RawConnection interface {
+	// RawConn returns the wrapped connection.
+	RawConn() net.Conn
+}
+
+// UnwrapRawConn returns the innermost connection that conn wraps, or conn itself if it doesn't wrap any.
+func UnwrapRawConn(conn net.Conn) net.Conn {
+	for {
+		c, ok := conn.(RawConnection)
+		if !ok {
+			return conn
+		}
+		conn = c.RawConn()
+	}
+}
+
 type StatCouterConnection struct {
 	Connection
 	Uplink   stats.Counter
@@ -25,6 +42,11 @@ func (c *StatCouterConnection) Read(b []byte) (int, error) {
 	return nBytes, err
 }
 
+// RawConn implements RawConnection.
+func (c *StatCouterConnection) RawConn() net.Conn {
+	return c.Connection
+}
+
 func (c *StatCouterConnection) Write(b []byte) (int, error) {
 	nBytes, err := c.Connection.Write(b)
 	if c.Downlink != nil {
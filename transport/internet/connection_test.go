@@ -0,0 +1,26 @@
+package internet_test
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+
+	. "v2ray.com/core/transport/internet"
+	v2tls "v2ray.com/core/transport/internet/tls"
+)
+
+func TestUnwrapRawConn(t *testing.T) {
+	raw, peer := net.Pipe()
+	defer raw.Close()
+	defer peer.Close()
+
+	conn := &StatCouterConnection{
+		Connection: v2tls.Server(raw, &tls.Config{}),
+	}
+	if c := UnwrapRawConn(conn); c != raw {
+		t.Error("expect the raw connection, but got ", c)
+	}
+	if c := UnwrapRawConn(raw); c != raw {
+		t.Error("expect the connection itself, but got ", c)
+	}
+}
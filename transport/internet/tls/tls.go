@@ -19,6 +19,12 @@ var (
 
 type conn struct {
 	*tls.Conn
+	raw net.Conn
+}
+
+// RawConn implements internet.RawConnection.
+func (c *conn) RawConn() net.Conn {
+	return c.raw
 }
 
 func (c *conn) WriteMultiBuffer(mb buf.MultiBuffer) error {
@@ -42,7 +48,7 @@ func (c *conn) HandshakeAddress() net.Address {
 // Client initiates a TLS client handshake on the given connection.
 func Client(c net.Conn, config *tls.Config) net.Conn {
 	tlsConn := tls.Client(c, config)
-	return &conn{Conn: tlsConn}
+	return &conn{Conn: tlsConn, raw: c}
 }
 
 func copyConfig(c *tls.Config) *utls.Config {
@@ -63,5 +69,5 @@ func UClient(c net.Conn, config *tls.Config) net.Conn {
 // Server initiates a TLS server handshake on the given connection.
 func Server(c net.Conn, config *tls.Config) net.Conn {
 	tlsConn := tls.Server(c, config)
-	return &conn{Conn: tlsConn}
+	return &conn{Conn: tlsConn, raw: c}
 }
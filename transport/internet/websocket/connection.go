@@ -112,3 +112,8 @@ func (c *connection) SetReadDeadline(t time.Time) error {
 func (c *connection) SetWriteDeadline(t time.Time) error {
 	return c.conn.SetWriteDeadline(t)
 }
+
+// RawConn implements internet.RawConnection.
+func (c *connection) RawConn() net.Conn {
+	return c.conn.UnderlyingConn()
+}
@@ -12,9 +12,11 @@ import (
 	"v2ray.com/core/features"
 	"v2ray.com/core/features/dns"
 	"v2ray.com/core/features/dns/localdns"
+	"v2ray.com/core/features/health"
 	"v2ray.com/core/features/inbound"
 	"v2ray.com/core/features/outbound"
 	"v2ray.com/core/features/policy"
+	"v2ray.com/core/features/record"
 	"v2ray.com/core/features/routing"
 	"v2ray.com/core/features/stats"
 )
@@ -187,9 +189,12 @@ func New(config *Config) (*Instance, error) {
 		Instance features.Feature
 	}{
 		{dns.ClientType(), localdns.New()},
+		{dns.FakeDNSEngineType(), dns.NoopFakeDNSEngine{}},
 		{policy.ManagerType(), policy.DefaultManager{}},
 		{routing.RouterType(), routing.DefaultRouter{}},
 		{stats.ManagerType(), stats.NoopManager{}},
+		{health.RegistryType(), health.NoopRegistry{}},
+		{record.RecorderType(), record.NoopRecorder{}},
 	}
 
 	for _, f := range essentialFeatures {